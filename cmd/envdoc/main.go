@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/config"
+)
+
+// envdoc prints the environment variables a service reads, e.g.
+//
+//	go run ./cmd/envdoc -service gateway
+func main() {
+	service := flag.String("service", "svedprint", "service to document (gateway, svedprint, svedprint-admin, svedprint-print)")
+	flag.Parse()
+
+	cfg := &config.Config{ServiceName: *service}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION")
+	for _, doc := range cfg.Describe() {
+		required := ""
+		if doc.Required {
+			required = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", doc.Name, doc.Type, doc.Default, required, doc.Description)
+	}
+	w.Flush()
+}
@@ -0,0 +1,9 @@
+drop table if exists grade_descriptor;
+
+alter table subject
+	drop column pass_threshold,
+	drop column max_grade,
+	drop column min_grade,
+	drop column grading_scheme;
+
+drop type if exists grading_scheme;
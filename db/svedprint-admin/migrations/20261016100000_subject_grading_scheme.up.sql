@@ -0,0 +1,15 @@
+create type grading_scheme as enum ('numeric', 'descriptive');
+
+alter table subject
+	add column grading_scheme grading_scheme not null default 'numeric',
+	add column min_grade int not null default 1,
+	add column max_grade int not null default 5,
+	add column pass_threshold int not null default 2;
+
+create table grade_descriptor (
+	uuid uuid primary key,
+	subject_uuid uuid not null references subject (uuid) on delete cascade,
+	descriptor text not null,
+	passing bool not null default true,
+	constraint uq_grade_descriptor unique (subject_uuid, descriptor)
+);
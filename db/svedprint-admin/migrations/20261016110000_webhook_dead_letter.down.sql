@@ -0,0 +1 @@
+drop table if exists webhook_dead_letter;
@@ -0,0 +1,10 @@
+create table webhook_dead_letter (
+	uuid uuid primary key,
+	event_id text not null,
+	event_type text not null,
+	endpoint text not null,
+	payload jsonb not null,
+	attempts int not null,
+	last_error text not null,
+	created_at timestamptz not null default now()
+);
@@ -0,0 +1,7 @@
+drop trigger if exists trg_subject_metadata on subject;
+drop function if exists touch_entity_metadata();
+
+alter table subject
+	drop column if exists version,
+	drop column if exists updated_at,
+	drop column if exists created_at;
@@ -0,0 +1,20 @@
+alter table subject
+	add column created_at timestamptz not null default now(),
+	add column updated_at timestamptz not null default now(),
+	add column version bigint not null default 1;
+
+-- Keeps updated_at and version current on every effective update
+create function touch_entity_metadata() returns trigger as $$
+begin
+	if row(new.*) is not distinct from row(old.*) then
+		return new;
+	end if;
+	new.updated_at = now();
+	new.version = old.version + 1;
+	return new;
+end;
+$$ language plpgsql;
+
+create trigger trg_subject_metadata
+	before update on subject
+	for each row execute function touch_entity_metadata();
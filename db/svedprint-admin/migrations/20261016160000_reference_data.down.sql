@@ -0,0 +1,2 @@
+drop table if exists subject_template;
+drop table if exists grading_scale;
@@ -0,0 +1,16 @@
+-- Reference data new schools start from; filled by the seed files on first run
+create table grading_scale (
+	name text primary key,
+	grading_scheme grading_scheme not null,
+	min_grade int not null,
+	max_grade int not null,
+	pass_threshold int not null
+);
+
+create table subject_template (
+	short_name text not null,
+	full_name text not null,
+	academic_level academic_level not null,
+	grading_scale text not null references grading_scale (name),
+	primary key (short_name, academic_level)
+);
@@ -0,0 +1,52 @@
+-- name: GetSubjectByUuid :one
+select * from subject
+where uuid = @subject_uuid;
+
+-- name: UpdateSubjectGrading :one
+update subject
+set
+    grading_scheme = @grading_scheme,
+    min_grade = @min_grade,
+    max_grade = @max_grade,
+    pass_threshold = @pass_threshold,
+    -- Descriptor-only changes leave the row untouched; still count them as an update
+    updated_at = now()
+where uuid = @subject_uuid
+returning *;
+
+-- name: ListGradeDescriptorsBySubject :many
+select * from grade_descriptor
+where subject_uuid = @subject_uuid
+order by descriptor;
+
+-- name: DeleteGradeDescriptorsBySubject :exec
+delete from grade_descriptor
+where subject_uuid = @subject_uuid;
+
+-- name: InsertGradeDescriptor :one
+insert into grade_descriptor (
+    uuid,
+    subject_uuid,
+    descriptor,
+    passing
+) values (
+    gen_random_uuid(),
+    @subject_uuid,
+    @descriptor,
+    @passing
+) returning *;
+
+-- name: GetClassSubjectEnrollment :one
+-- A class takes the subjects of its default package for the class's academic year
+select
+    sc.uuid as class_uuid,
+    exists (
+        select 1
+        from subject_package sp
+        join subject_package_subjects sps on sps.subject_package_uuid = sp.uuid
+        where sp.uuid = sc.default_subject_package_uuid
+            and sp.academic_year_uuid = sc.academic_year_uuid
+            and sps.subject_uuid = @subject_uuid
+    )::boolean as enrolled
+from school_class sc
+where sc.uuid = @class_uuid;
@@ -0,0 +1,18 @@
+-- name: InsertWebhookDeadLetter :exec
+insert into webhook_dead_letter (
+    uuid,
+    event_id,
+    event_type,
+    endpoint,
+    payload,
+    attempts,
+    last_error
+) values (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
@@ -0,0 +1 @@
+alter table student add column deleted_at timestamptz;
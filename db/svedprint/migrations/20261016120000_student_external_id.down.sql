@@ -0,0 +1,3 @@
+alter table student
+	drop constraint if exists uq_student_external_id,
+	drop column if exists external_id;
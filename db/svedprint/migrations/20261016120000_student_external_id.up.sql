@@ -0,0 +1,3 @@
+alter table student
+	add column external_id text,
+	add constraint uq_student_external_id unique (external_id);
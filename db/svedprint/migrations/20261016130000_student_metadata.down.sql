@@ -0,0 +1,7 @@
+drop trigger if exists trg_student_metadata on student;
+drop function if exists touch_entity_metadata();
+
+alter table student
+	drop column if exists version,
+	drop column if exists updated_at,
+	drop column if exists created_at;
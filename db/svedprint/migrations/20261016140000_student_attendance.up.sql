@@ -0,0 +1,11 @@
+create table student_attendance (
+	uuid uuid primary key,
+	student_uuid uuid not null references student (uuid),
+	academic_year_uuid uuid not null,
+	term smallint not null check (term in (1, 2)),
+	total_absences int not null check (total_absences >= 0),
+	justified_absences int not null check (justified_absences >= 0 and justified_absences <= total_absences),
+	updated_at timestamptz not null default now(),
+
+	constraint uq_student_attendance unique (student_uuid, academic_year_uuid, term)
+);
@@ -0,0 +1,21 @@
+create table certificate_issuance (
+	uuid uuid primary key,
+	registry_number text not null,
+	student_uuid uuid not null references student (uuid),
+	academic_year_uuid uuid not null,
+	certificate_type text not null,
+	version int not null default 1 check (version >= 1),
+	-- The issuance this one corrects; null for the original
+	supersedes_uuid uuid references certificate_issuance (uuid),
+	reason text,
+	issued_at timestamptz not null default now(),
+	superseded_at timestamptz,
+
+	constraint uq_certificate_issuance_registry_number unique (registry_number),
+	constraint uq_certificate_issuance_supersedes unique (supersedes_uuid)
+);
+
+-- Only one valid certificate per student, year and type at a time
+create unique index uq_certificate_issuance_current
+	on certificate_issuance (student_uuid, academic_year_uuid, certificate_type)
+	where superseded_at is null;
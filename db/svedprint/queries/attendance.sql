@@ -0,0 +1,26 @@
+-- name: ListStudentAttendance :many
+select * from student_attendance
+where student_uuid = @student_uuid
+order by academic_year_uuid, term;
+
+-- name: UpsertStudentAttendance :one
+insert into student_attendance (
+    uuid,
+    student_uuid,
+    academic_year_uuid,
+    term,
+    total_absences,
+    justified_absences
+) values (
+    gen_random_uuid(),
+    @student_uuid,
+    @academic_year_uuid,
+    @term,
+    @total_absences,
+    @justified_absences
+)
+on conflict (student_uuid, academic_year_uuid, term) do update set
+    total_absences = excluded.total_absences,
+    justified_absences = excluded.justified_absences,
+    updated_at = now()
+returning *;
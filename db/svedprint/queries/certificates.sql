@@ -0,0 +1,61 @@
+-- name: IssueCertificate :one
+insert into certificate_issuance (
+    uuid,
+    registry_number,
+    student_uuid,
+    academic_year_uuid,
+    certificate_type
+) values (
+    gen_random_uuid(),
+    @registry_number,
+    @student_uuid,
+    @academic_year_uuid,
+    @certificate_type
+)
+returning *;
+
+-- name: ReissueCertificate :one
+with superseded as (
+    update certificate_issuance
+    set superseded_at = now()
+    where registry_number = @previous_registry_number
+    and superseded_at is null
+    returning uuid, student_uuid, academic_year_uuid, certificate_type, version
+)
+insert into certificate_issuance (
+    uuid,
+    registry_number,
+    student_uuid,
+    academic_year_uuid,
+    certificate_type,
+    version,
+    supersedes_uuid,
+    reason
+)
+select
+    gen_random_uuid(),
+    @registry_number,
+    student_uuid,
+    academic_year_uuid,
+    certificate_type,
+    version + 1,
+    uuid,
+    @reason
+from superseded
+returning *;
+
+-- name: GetCertificateByRegistryNumber :one
+select * from certificate_issuance
+where registry_number = @registry_number;
+
+-- name: GetLatestCertificateVersion :one
+with recursive chain as (
+    select * from certificate_issuance
+    where certificate_issuance.registry_number = @registry_number
+    union all
+    select next.* from certificate_issuance next
+    join chain on next.supersedes_uuid = chain.uuid
+)
+select * from chain
+order by version desc
+limit 1;
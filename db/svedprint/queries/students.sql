@@ -1,3 +1,91 @@
 -- name: GetStudentByUuid :one
 select * from student
-where uuid = @student_uuid;
+where uuid = @student_uuid
+and deleted_at is null;
+
+-- name: SoftDeleteStudent :one
+update student
+set deleted_at = coalesce(deleted_at, now())
+where uuid = @student_uuid
+returning deleted_at;
+
+-- name: UpsertStudentByExternalId :one
+insert into student (
+    uuid,
+    external_id,
+    first_name,
+    middle_name,
+    last_name,
+    personal_number,
+    fathers_name,
+    mothers_name,
+    date_of_birth,
+    place_of_residence,
+    place_of_birth,
+    citizenship,
+    school_uuid
+) values (
+    gen_random_uuid(),
+    @external_id,
+    @first_name,
+    @middle_name,
+    @last_name,
+    @personal_number,
+    @fathers_name,
+    @mothers_name,
+    @date_of_birth,
+    @place_of_residence,
+    @place_of_birth,
+    @citizenship,
+    @school_uuid
+)
+on conflict (external_id) do update set
+    first_name = excluded.first_name,
+    middle_name = excluded.middle_name,
+    last_name = excluded.last_name,
+    personal_number = excluded.personal_number,
+    fathers_name = excluded.fathers_name,
+    mothers_name = excluded.mothers_name,
+    date_of_birth = excluded.date_of_birth,
+    place_of_residence = excluded.place_of_residence,
+    place_of_birth = excluded.place_of_birth,
+    citizenship = excluded.citizenship,
+    school_uuid = excluded.school_uuid,
+    deleted_at = null
+returning *, (xmax = 0) as inserted;
+
+-- name: ListStudentsBySchool :many
+select * from student
+where school_uuid = @school_uuid
+and deleted_at is null
+order by last_name, first_name, uuid;
+
+-- name: GetStudentSummaryByUuid :one
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where uuid = @student_uuid
+and deleted_at is null;
+
+-- name: ListStudentSummariesBySchool :many
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where school_uuid = @school_uuid
+and deleted_at is null
+order by last_name, first_name, uuid;
+
+-- name: ListStudentsBySchoolPage :many
+select * from student
+where school_uuid = @school_uuid
+and deleted_at is null
+order by last_name, first_name, uuid
+limit @limit_count offset @offset_count;
+
+-- name: ListStudentSummariesBySchoolPage :many
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where school_uuid = @school_uuid
+and deleted_at is null
+order by last_name, first_name, uuid
+limit @limit_count offset @offset_count;
+
+-- name: CountStudentsBySchool :one
+select count(*) from student
+where school_uuid = @school_uuid
+and deleted_at is null;
@@ -0,0 +1,49 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/keycloak"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// AuthHandler mediates token operations so frontends don't call Keycloak directly
+type AuthHandler struct {
+	tokens *keycloak.TokenClient
+}
+
+func NewAuthHandler(tokens *keycloak.TokenClient) *AuthHandler {
+	return &AuthHandler{tokens: tokens}
+}
+
+func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/refresh", h.Refresh)
+}
+
+// Refresh exchanges the client's refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	token, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, keycloak.ErrInvalidGrant) {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusUnauthorized, apierror.CodeInvalidRefreshToken, "refresh token is invalid or expired"))
+			return
+		}
+		log.Error().Err(err).Msg("Token refresh failed")
+		apierror.Respond(c, apierror.NewWithCode(http.StatusBadGateway, apierror.CodeIdentityUnavailable, "identity provider unavailable"))
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/keycloak"
+	"github.com/gin-gonic/gin"
+)
+
+// newStubTokenEndpoint serves Keycloak's token endpoint for the svedprint realm,
+// accepting only the refresh token "valid"
+func newStubTokenEndpoint(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/svedprint/protocol/openid-connect/token" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm: %v", err)
+		}
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("client_id") != "gateway" || r.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("unexpected token request form %v", r.PostForm)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("refresh_token") {
+		case "valid":
+			json.NewEncoder(w).Encode(keycloak.TokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 300, TokenType: "Bearer"})
+		case "down":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Token is not active"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthHandlerRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keycloakServer := newStubTokenEndpoint(t)
+	handler := NewAuthHandler(keycloak.NewTokenClient(keycloakServer.URL, "svedprint", "gateway", "secret"))
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/auth"))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{"valid refresh token", `{"refresh_token":"valid"}`, http.StatusOK, ""},
+		{"expired refresh token", `{"refresh_token":"expired"}`, http.StatusUnauthorized, apierror.CodeInvalidRefreshToken},
+		{"keycloak failure", `{"refresh_token":"down"}`, http.StatusBadGateway, apierror.CodeIdentityUnavailable},
+		{"missing refresh token", `{}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				var token keycloak.TokenResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &token); err != nil {
+					t.Fatalf("decode token: %v", err)
+				}
+				if token.AccessToken != "new-access" || token.RefreshToken != "new-refresh" {
+					t.Errorf("token = %+v", token)
+				}
+				return
+			}
+			var body apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+			if strings.Contains(w.Body.String(), "Token is not active") {
+				t.Error("Keycloak's error description leaked to the client")
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// authorize enforces the route's scope and audience requirements on an authenticated
+// request, responding 403 and returning false when the token falls short
+func (r *Route) authorize(c *gin.Context) bool {
+	if len(r.Scopes) == 0 && len(r.Audiences) == 0 {
+		return true
+	}
+
+	claims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeForbidden, "route requires an authenticated token"))
+		return false
+	}
+
+	var missing []string
+	for _, scope := range r.Scopes {
+		if !claims.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeMissingScope, "requires the "+strings.Join(missing, ", ")+" scope"))
+		return false
+	}
+
+	if len(r.Audiences) > 0 && !slices.ContainsFunc(r.Audiences, func(aud string) bool { return slices.Contains(claims.Audience, aud) }) {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeInvalidAudience, "token is not issued for this route"))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// scopedValidator accepts tokens naming their grants: "render" carries the
+// print:render scope for svedprint-print, "read" only print:read for svedprint-web
+type scopedValidator struct{}
+
+func (scopedValidator) ValidateToken(_ context.Context, token string) (*jwt.KeycloakClaims, error) {
+	claims := claimsFor("user-1", "school_a")
+	switch token {
+	case "render":
+		claims.Scope = "openid print:read print:render"
+		claims.Audience = []string{"svedprint-print"}
+	case "read":
+		claims.Scope = "openid print:read"
+		claims.Audience = []string{"svedprint-web"}
+	default:
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func TestProxyEnforcesScopesAndAudiences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	routes := []Route{
+		{Name: "render", Prefix: "/api/print/render", Upstream: "svedprint-print", Scopes: []string{"print:read", "print:render"}},
+		{Name: "print", Prefix: "/api/print", Upstream: "svedprint-print", Audiences: []string{"svedprint-print", "svedprint-admin"}},
+		{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint-print", Scopes: []string{"print:read"}},
+	}
+	upstreams := map[string][]string{"svedprint-print": {upstream.URL}}
+	proxy, err := NewProxy(routes, upstreams, middleware.Auth(scopedValidator{}), nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+	router := gin.New()
+	router.NoRoute(proxy.Handle)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{name: "every scope granted", path: "/api/print/render/certificate", token: "render", wantStatus: http.StatusOK},
+		{name: "scope missing", path: "/api/print/render/certificate", token: "read", wantStatus: http.StatusForbidden, wantCode: apierror.CodeMissingScope},
+		{name: "no token", path: "/api/print/render/certificate", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeMissingToken},
+		{name: "listed audience", path: "/api/print/status", token: "render", wantStatus: http.StatusOK},
+		{name: "other audience", path: "/api/print/status", token: "read", wantStatus: http.StatusForbidden, wantCode: apierror.CodeInvalidAudience},
+		{name: "single scope", path: "/api/svedprint/students", token: "read", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, gateway.URL+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var apiErr apierror.Error
+			if err := json.Unmarshal(body, &apiErr); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if apiErr.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", apiErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRouteRequirementsNeedAuth(t *testing.T) {
+	public := false
+	tests := []struct {
+		name  string
+		route Route
+	}{
+		{name: "scopes", route: Route{Name: "schools", Prefix: "/api/public", Upstream: "svedprint", AuthRequired: &public, Scopes: []string{"print:read"}}},
+		{name: "audiences", route: Route{Name: "schools", Prefix: "/api/public", Upstream: "svedprint", AuthRequired: &public, Audiences: []string{"svedprint-web"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.route.compile(); err == nil {
+				t.Error("compile() succeeded for a public route with requirements")
+			}
+		})
+	}
+}
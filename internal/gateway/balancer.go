@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HealthCheck enables health-aware balancing across a route's instances. Instances
+// are probed actively on Path and ejected passively after consecutive proxy
+// failures; an ejected instance is re-admitted once a probe succeeds.
+type HealthCheck struct {
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// MaxFailures is how many consecutive failed probes or proxied requests
+	// (transport errors or 5xx) eject an instance
+	MaxFailures int `yaml:"max_failures"`
+}
+
+func (hc *HealthCheck) applyDefaults() {
+	if hc.Path == "" {
+		hc.Path = "/health"
+	}
+	if hc.Interval <= 0 {
+		hc.Interval = 10 * time.Second
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = 2 * time.Second
+	}
+	if hc.MaxFailures <= 0 {
+		hc.MaxFailures = 3
+	}
+}
+
+// instanceHealth tracks whether one downstream instance may receive traffic
+type instanceHealth struct {
+	target   *url.URL
+	ejected  atomic.Bool
+	failures atomic.Int32
+}
+
+// balancer keeps the health of a route's instances up to date
+type balancer struct {
+	route     string
+	check     HealthCheck
+	instances []*instanceHealth
+	byHost    map[string]*instanceHealth
+	client    *http.Client
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newBalancer(route string, check HealthCheck, targets []*url.URL) *balancer {
+	check.applyDefaults()
+
+	b := &balancer{
+		route:  route,
+		check:  check,
+		byHost: make(map[string]*instanceHealth, len(targets)),
+		client: &http.Client{Timeout: check.Timeout},
+		stop:   make(chan struct{}),
+	}
+	for _, target := range targets {
+		inst := &instanceHealth{target: target}
+		b.instances = append(b.instances, inst)
+		b.byHost[target.Host] = inst
+	}
+	return b
+}
+
+// healthy reports whether the instance at idx may receive traffic
+func (b *balancer) healthy(idx int) bool {
+	return !b.instances[idx].ejected.Load()
+}
+
+// InstanceStatus is the health of one downstream instance as the gateway sees it
+type InstanceStatus struct {
+	URL                 string `json:"url"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+}
+
+// status snapshots the health of the instance at idx
+func (b *balancer) status(idx int) InstanceStatus {
+	inst := b.instances[idx]
+	return InstanceStatus{
+		URL:                 inst.target.String(),
+		Healthy:             !inst.ejected.Load(),
+		ConsecutiveFailures: int(inst.failures.Load()),
+	}
+}
+
+// start probes every instance on the configured interval until close is called
+func (b *balancer) start() {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(b.check.Interval)
+		defer ticker.Stop()
+
+		for {
+			b.probeAll()
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// close stops the active health checks
+func (b *balancer) close() {
+	close(b.stop)
+	b.wg.Wait()
+}
+
+func (b *balancer) probeAll() {
+	var wg sync.WaitGroup
+	for _, inst := range b.instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b.probe(inst) {
+				b.markSuccess(inst)
+			} else {
+				b.markFailure(inst)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *balancer) probe(inst *instanceHealth) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), b.check.Timeout)
+	defer cancel()
+
+	probeURL := *inst.target
+	probeURL.Path = singleJoiningSlash(inst.target.Path, b.check.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// observe records the outcome of a proxied request to host
+func (b *balancer) observe(host string, failed bool) {
+	inst, ok := b.byHost[host]
+	if !ok {
+		return
+	}
+	if failed {
+		b.markFailure(inst)
+	} else {
+		inst.failures.Store(0)
+	}
+}
+
+func (b *balancer) markFailure(inst *instanceHealth) {
+	if inst.failures.Add(1) >= int32(b.check.MaxFailures) && inst.ejected.CompareAndSwap(false, true) {
+		log.Warn().Str("route", b.route).Str("instance", inst.target.String()).Msg("Ejecting unhealthy instance")
+	}
+}
+
+// markSuccess re-admits an instance; only an active probe does this, so a flapping
+// instance is not re-admitted by the traffic it still receives
+func (b *balancer) markSuccess(inst *instanceHealth) {
+	inst.failures.Store(0)
+	if inst.ejected.CompareAndSwap(true, false) {
+		log.Info().Str("route", b.route).Str("instance", inst.target.String()).Msg("Re-admitting recovered instance")
+	}
+}
@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flakyInstance is a downstream instance that answers with its name while up and
+// fails every request, health probes included, while down
+type flakyInstance struct {
+	*httptest.Server
+	down atomic.Bool
+}
+
+func newFlakyInstance(t *testing.T, name string) *flakyInstance {
+	t.Helper()
+	inst := &flakyInstance{}
+	inst.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inst.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(inst.Close)
+	return inst
+}
+
+func TestBalancerActiveHealthChecks(t *testing.T) {
+	inst := newFlakyInstance(t, "a")
+	target, _ := url.Parse(inst.URL)
+	b := newBalancer("students", HealthCheck{MaxFailures: 2}, []*url.URL{target})
+
+	inst.down.Store(true)
+	b.probeAll()
+	if !b.healthy(0) {
+		t.Fatal("instance ejected after one failed probe, want MaxFailures")
+	}
+	b.probeAll()
+	if b.healthy(0) {
+		t.Fatal("instance still healthy after MaxFailures failed probes")
+	}
+	if s := b.status(0); s.Healthy || s.ConsecutiveFailures != 2 {
+		t.Errorf("status = %+v", s)
+	}
+
+	inst.down.Store(false)
+	b.probeAll()
+	if !b.healthy(0) {
+		t.Fatal("recovered instance not re-admitted by a successful probe")
+	}
+	if s := b.status(0); s.ConsecutiveFailures != 0 {
+		t.Errorf("failures not reset on recovery: %+v", s)
+	}
+}
+
+func TestBalancerPassiveEjection(t *testing.T) {
+	target, _ := url.Parse("http://10.0.0.1:8080")
+	b := newBalancer("students", HealthCheck{MaxFailures: 3}, []*url.URL{target})
+
+	b.observe(target.Host, true)
+	b.observe(target.Host, true)
+	b.observe(target.Host, false)
+	b.observe(target.Host, true)
+	if !b.healthy(0) {
+		t.Fatal("a success did not reset the consecutive failure count")
+	}
+	b.observe(target.Host, true)
+	b.observe(target.Host, true)
+	if b.healthy(0) {
+		t.Fatal("instance not ejected after MaxFailures consecutive failures")
+	}
+
+	// Traffic alone never re-admits an ejected instance; only a probe does
+	b.observe(target.Host, false)
+	if b.healthy(0) {
+		t.Error("proxied success re-admitted an ejected instance")
+	}
+	b.observe("10.0.0.2:8080", true) // unknown hosts are ignored
+}
+
+func TestProxyRoutesAroundEjectedInstance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newFlakyInstance(t, "a")
+	b := newFlakyInstance(t, "b")
+
+	routes := []Route{{
+		Name:        "students",
+		Prefix:      "/api/students",
+		Upstream:    "students",
+		StripPrefix: true,
+		HealthCheck: &HealthCheck{MaxFailures: 2, Interval: time.Hour},
+	}}
+	proxy, err := NewProxy(routes, map[string][]string{"students": {a.URL, b.URL}}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+	router := gin.New()
+	router.NoRoute(proxy.Handle)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	get := func() (int, string) {
+		resp, err := http.Get(gateway.URL + "/api/students")
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	served := func(requests int) map[string]int {
+		counts := map[string]int{}
+		for range requests {
+			status, body := get()
+			if status != http.StatusOK {
+				body = "failed"
+			}
+			counts[body]++
+		}
+		return counts
+	}
+
+	b.down.Store(true)
+	// Round-robin sends every other request to b until it is ejected
+	served(4)
+	if got := served(10); got["a"] != 10 {
+		t.Fatalf("after ejection requests were served %v, want all by a", got)
+	}
+
+	b.down.Store(false)
+	proxy.routes[0].balancer.probeAll()
+	if got := served(10); got["a"] == 0 || got["b"] == 0 {
+		t.Errorf("after recovery requests were served %v, want both instances", got)
+	}
+
+	statuses := proxy.Status()
+	if len(statuses) != 1 || !statuses[0].HealthChecked || len(statuses[0].Instances) != 2 {
+		t.Fatalf("Status = %+v", statuses)
+	}
+	for _, inst := range statuses[0].Instances {
+		if !inst.Healthy {
+			t.Errorf("instance %s reported unhealthy after recovery", inst.URL)
+		}
+	}
+}
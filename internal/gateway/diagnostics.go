@@ -0,0 +1,215 @@
+package gateway
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/cache"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// diagnosticsRole may read the diagnostics snapshot
+const diagnosticsRole = "admin"
+
+// errorWindow is how far back the recent error counts reach, in one minute buckets
+const errorWindow = 15
+
+type BuildInfo struct {
+	GoVersion    string `json:"go_version"`
+	Version      string `json:"version,omitempty"`
+	Revision     string `json:"revision,omitempty"`
+	RevisionTime string `json:"revision_time,omitempty"`
+	Modified     bool   `json:"modified,omitempty"`
+}
+
+type DatabasePoolStats struct {
+	MaxConns             int32   `json:"max_conns"`
+	TotalConns           int32   `json:"total_conns"`
+	IdleConns            int32   `json:"idle_conns"`
+	AcquiredConns        int32   `json:"acquired_conns"`
+	AcquireCount         int64   `json:"acquire_count"`
+	EmptyAcquireCount    int64   `json:"empty_acquire_count"`
+	CanceledAcquireCount int64   `json:"canceled_acquire_count"`
+	AcquireDurationMs    float64 `json:"acquire_duration_ms"`
+}
+
+type RedisPoolStats struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	StaleConns uint32 `json:"stale_conns"`
+	// LocalEntries is how many keys the per-instance LRU in front of Redis holds
+	LocalEntries int `json:"local_entries"`
+}
+
+type ErrorCounts struct {
+	WindowSeconds int   `json:"window_seconds"`
+	ClientErrors  int64 `json:"client_errors"`
+	ServerErrors  int64 `json:"server_errors"`
+}
+
+// DiagnosticsDTO is a point-in-time snapshot of the gateway for on-call triage
+type DiagnosticsDTO struct {
+	Build         BuildInfo          `json:"build"`
+	StartedAt     time.Time          `json:"started_at"`
+	UptimeSeconds int64              `json:"uptime_seconds"`
+	Database      *DatabasePoolStats `json:"database"`
+	Redis         *RedisPoolStats    `json:"redis"`
+	Upstreams     []RouteStatus      `json:"upstreams"`
+	Errors        ErrorCounts        `json:"errors"`
+}
+
+// Diagnostics aggregates the gateway's runtime state into a single admin-only endpoint
+type Diagnostics struct {
+	startedAt time.Time
+	pool      *pgxpool.Pool
+	redis     *redis.Client
+	local     *cache.Cache
+	proxy     *Proxy
+	errors    *errorCounter
+}
+
+func NewDiagnostics(pool *pgxpool.Pool, redis *redis.Client, local *cache.Cache, proxy *Proxy) *Diagnostics {
+	return &Diagnostics{
+		startedAt: time.Now(),
+		pool:      pool,
+		redis:     redis,
+		local:     local,
+		proxy:     proxy,
+		errors:    &errorCounter{},
+	}
+}
+
+// RegisterRoutes registers the diagnostics endpoint; rg must already require authentication
+func (d *Diagnostics) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/diagnostics", d.Get)
+}
+
+// Middleware counts 4xx and 5xx responses for the recent error counts
+func (d *Diagnostics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		d.errors.record(time.Now(), c.Writer.Status())
+	}
+}
+
+func (d *Diagnostics) Get(c *gin.Context) {
+	now := time.Now()
+	out := DiagnosticsDTO{
+		Build:         buildInfo(),
+		StartedAt:     d.startedAt.UTC(),
+		UptimeSeconds: int64(now.Sub(d.startedAt).Seconds()),
+		Errors:        d.errors.counts(now),
+	}
+
+	if d.pool != nil {
+		stat := d.pool.Stat()
+		out.Database = &DatabasePoolStats{
+			MaxConns:             stat.MaxConns(),
+			TotalConns:           stat.TotalConns(),
+			IdleConns:            stat.IdleConns(),
+			AcquiredConns:        stat.AcquiredConns(),
+			AcquireCount:         stat.AcquireCount(),
+			EmptyAcquireCount:    stat.EmptyAcquireCount(),
+			CanceledAcquireCount: stat.CanceledAcquireCount(),
+			AcquireDurationMs:    float64(stat.AcquireDuration()) / float64(time.Millisecond),
+		}
+	}
+
+	if d.redis != nil {
+		stats := d.redis.PoolStats()
+		out.Redis = &RedisPoolStats{
+			Hits:       stats.Hits,
+			Misses:     stats.Misses,
+			Timeouts:   stats.Timeouts,
+			TotalConns: stats.TotalConns,
+			IdleConns:  stats.IdleConns,
+			StaleConns: stats.StaleConns,
+		}
+		if d.local != nil {
+			out.Redis.LocalEntries = d.local.Len()
+		}
+	}
+
+	if d.proxy != nil {
+		out.Upstreams = d.proxy.Status()
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// buildInfo reads the version control stamp the Go toolchain embeds in the binary
+func buildInfo() BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}
+	}
+
+	build := BuildInfo{GoVersion: info.GoVersion, Version: info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.RevisionTime = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// errorBucket holds the error counts of one minute
+type errorBucket struct {
+	minute int64
+	client int64
+	server int64
+}
+
+// errorCounter keeps per-minute error counts for the last errorWindow minutes
+type errorCounter struct {
+	mu      sync.Mutex
+	buckets [errorWindow]errorBucket
+}
+
+func (e *errorCounter) record(now time.Time, status int) {
+	if status < http.StatusBadRequest {
+		return
+	}
+
+	minute := now.Unix() / 60
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bucket := &e.buckets[minute%errorWindow]
+	if bucket.minute != minute {
+		*bucket = errorBucket{minute: minute}
+	}
+	if status >= http.StatusInternalServerError {
+		bucket.server++
+	} else {
+		bucket.client++
+	}
+}
+
+func (e *errorCounter) counts(now time.Time) ErrorCounts {
+	minute := now.Unix() / 60
+	counts := ErrorCounts{WindowSeconds: errorWindow * 60}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, bucket := range e.buckets {
+		if minute-bucket.minute < errorWindow {
+			counts.ClientErrors += bucket.client
+			counts.ServerErrors += bucket.server
+		}
+	}
+	return counts
+}
@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/cache"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// newDiagnosticsRouter serves the diagnostics endpoint as the gateway does, with the
+// given claims standing in for authentication
+func newDiagnosticsRouter(t *testing.T, claims *jwt.KeycloakClaims) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	client, err := redis.NewClient(miniredis.RunT(t).Addr(), "", 0, time.Minute)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	routes := []Route{{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint"}}
+	proxy, err := NewProxy(routes, map[string][]string{"svedprint": {"http://svedprint:8001"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+
+	diagnostics := NewDiagnostics(nil, client, cache.New(client, 10, time.Minute), proxy)
+	auth := func(c *gin.Context) {
+		if claims != nil {
+			c.Set(middleware.ClaimsKey, claims)
+		}
+	}
+
+	router := gin.New()
+	router.Use(diagnostics.Middleware())
+	diagnostics.RegisterRoutes(router.Group("/admin", auth, middleware.RequireRole(diagnosticsRole)))
+	router.GET("/broken", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+	return router
+}
+
+func withRealmRoles(claims *jwt.KeycloakClaims, roles ...any) *jwt.KeycloakClaims {
+	claims.RealmAccess = map[string]any{"roles": roles}
+	return claims
+}
+
+func TestDiagnosticsSnapshot(t *testing.T) {
+	router := newDiagnosticsRouter(t, withRealmRoles(claimsFor("admin-1", ""), "admin"))
+
+	for _, path := range []string{"/missing", "/missing", "/broken"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/diagnostics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &sections); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, section := range []string{"build", "started_at", "uptime_seconds", "database", "redis", "upstreams", "errors"} {
+		if _, ok := sections[section]; !ok {
+			t.Errorf("snapshot is missing %s: %s", section, w.Body)
+		}
+	}
+
+	var out DiagnosticsDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Build.GoVersion == "" {
+		t.Error("build has no Go version")
+	}
+	if out.Database != nil {
+		t.Errorf("database = %+v, want null without a pool", out.Database)
+	}
+	if out.Redis == nil {
+		t.Error("redis stats missing")
+	}
+	if len(out.Upstreams) != 1 || out.Upstreams[0].Name != "svedprint" || len(out.Upstreams[0].Instances) != 1 {
+		t.Errorf("upstreams = %+v, want the svedprint route with one instance", out.Upstreams)
+	}
+	if out.Errors.ClientErrors != 2 || out.Errors.ServerErrors != 1 {
+		t.Errorf("errors = %+v, want 2 client and 1 server", out.Errors)
+	}
+}
+
+func TestDiagnosticsRequiresAdminRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *jwt.KeycloakClaims
+	}{
+		{name: "unauthenticated"},
+		{name: "no roles", claims: claimsFor("teacher-1", "school_a")},
+		{name: "other role", claims: withRealmRoles(claimsFor("teacher-1", "school_a"), "teacher")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newDiagnosticsRouter(t, tt.claims)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/diagnostics", nil))
+			if w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", w.Code)
+			}
+		})
+	}
+}
+
+func TestErrorCounterWindow(t *testing.T) {
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	var counter errorCounter
+
+	counter.record(start, http.StatusOK)
+	counter.record(start, http.StatusNotFound)
+	counter.record(start.Add(5*time.Minute), http.StatusInternalServerError)
+
+	if got := counter.counts(start.Add(5 * time.Minute)); got.ClientErrors != 1 || got.ServerErrors != 1 {
+		t.Errorf("counts within the window = %+v, want 1 client and 1 server", got)
+	}
+	// The first minute has left the window; its bucket is reused by a later minute
+	later := start.Add(errorWindow * time.Minute)
+	counter.record(later, http.StatusBadRequest)
+	if got := counter.counts(later); got.ClientErrors != 1 || got.ServerErrors != 1 {
+		t.Errorf("counts after the window moved = %+v, want 1 client and 1 server", got)
+	}
+	if got := counter.counts(later.Add(time.Hour)); got.ClientErrors != 0 || got.ServerErrors != 0 {
+		t.Errorf("counts an hour later = %+v, want none", got)
+	}
+}
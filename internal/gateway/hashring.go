@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+)
+
+// virtualNodes is how many points each instance gets on the ring; more points
+// spread keys more evenly
+const virtualNodes = 128
+
+// hashRing maps keys to instances with consistent hashing, so adding or removing
+// an instance only moves the keys that belonged to it
+type hashRing struct {
+	points    []uint32
+	instances map[uint32]int
+}
+
+// newHashRing places each named instance on the ring; get returns indexes into names
+func newHashRing(names []string) *hashRing {
+	r := &hashRing{instances: make(map[uint32]int, len(names)*virtualNodes)}
+	for i, name := range names {
+		for v := 0; v < virtualNodes; v++ {
+			point := crc32.ChecksumIEEE([]byte(name + "#" + strconv.Itoa(v)))
+			if _, taken := r.instances[point]; taken {
+				continue
+			}
+			r.instances[point] = i
+			r.points = append(r.points, point)
+		}
+	}
+	slices.Sort(r.points)
+	return r
+}
+
+// getAvailable returns the owner of key, walking clockwise past instances that are
+// not available so only the keys of an ejected instance move. If none is available
+// the owner is returned anyway.
+func (r *hashRing) getAvailable(key string, available func(int) bool) int {
+	if len(r.points) == 0 {
+		return 0
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	start, _ := slices.BinarySearch(r.points, h)
+	for i := range r.points {
+		inst := r.instances[r.points[(start+i)%len(r.points)]]
+		if available(inst) {
+			return inst
+		}
+	}
+	return r.instances[r.points[start%len(r.points)]]
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ringKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("class-%d", i)
+	}
+	return keys
+}
+
+func allAvailable(int) bool { return true }
+
+func TestHashRingIsStable(t *testing.T) {
+	names := []string{"http://grades-0", "http://grades-1", "http://grades-2"}
+	ring, again := newHashRing(names), newHashRing(names)
+
+	counts := make([]int, len(names))
+	for _, key := range ringKeys(3000) {
+		inst := ring.getAvailable(key, allAvailable)
+		if inst != ring.getAvailable(key, allAvailable) || inst != again.getAvailable(key, allAvailable) {
+			t.Fatalf("%s routed inconsistently", key)
+		}
+		counts[inst]++
+	}
+	for i, count := range counts {
+		if count < 600 {
+			t.Errorf("instance %d owns only %d of 3000 keys", i, count)
+		}
+	}
+}
+
+func TestHashRingRebalancesMinimally(t *testing.T) {
+	before := []string{"http://grades-0", "http://grades-1", "http://grades-2"}
+	after := append(before[:3:3], "http://grades-3")
+	oldRing, newRing := newHashRing(before), newHashRing(after)
+
+	keys := ringKeys(4000)
+	moved := 0
+	for _, key := range keys {
+		oldInst := oldRing.getAvailable(key, allAvailable)
+		newInst := newRing.getAvailable(key, allAvailable)
+		if oldInst != newInst {
+			moved++
+			if newInst != 3 {
+				t.Fatalf("%s moved from %d to %d, want only moves to the new instance", key, oldInst, newInst)
+			}
+		}
+	}
+	// Roughly a quarter of the keys belong to the new instance
+	if moved < len(keys)/8 || moved > len(keys)/2 {
+		t.Errorf("%d of %d keys moved after adding a fourth instance", moved, len(keys))
+	}
+}
+
+func TestHashRingSkipsUnavailableInstance(t *testing.T) {
+	ring := newHashRing([]string{"http://grades-0", "http://grades-1", "http://grades-2"})
+	withoutOne := func(i int) bool { return i != 1 }
+
+	for _, key := range ringKeys(1000) {
+		owner := ring.getAvailable(key, allAvailable)
+		got := ring.getAvailable(key, withoutOne)
+		if got == 1 {
+			t.Fatalf("%s routed to an unavailable instance", key)
+		}
+		if owner != 1 && got != owner {
+			t.Fatalf("%s moved from %d to %d although its instance is available", key, owner, got)
+		}
+	}
+
+	none := func(int) bool { return false }
+	if got := ring.getAvailable("class-1", none); got != ring.getAvailable("class-1", allAvailable) {
+		t.Error("with no instance available the owner should be returned")
+	}
+}
+
+func TestProxyStickyRoutesByClass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var instances []string
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("instance-%d", i)
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		}))
+		defer upstream.Close()
+		instances = append(instances, upstream.URL)
+	}
+
+	routes := []Route{{
+		Name:        "grades",
+		Prefix:      "/api/grades",
+		Upstream:    "grades",
+		StripPrefix: true,
+		Sticky:      &Sticky{PathRegex: `^/api/grades/classes/([^/]+)`},
+	}}
+	proxy, err := NewProxy(routes, map[string][]string{"grades": instances}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+	router := gin.New()
+	router.NoRoute(proxy.Handle)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	get := func(path string) string {
+		resp, err := http.Get(gateway.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	seen := map[string]bool{}
+	for class := 0; class < 20; class++ {
+		first := get(fmt.Sprintf("/api/grades/classes/%d/students", class))
+		for i := 0; i < 3; i++ {
+			if got := get(fmt.Sprintf("/api/grades/classes/%d/grades?term=%d", class, i)); got != first {
+				t.Fatalf("class %d went to %s and then %s", class, first, got)
+			}
+		}
+		seen[first] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("20 classes all went to %v", seen)
+	}
+}
@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// setIdentity passes the caller's user ID and tenant downstream in X-User-ID and
+// X-Tenant-ID, taken only from the validated token. Values sent by the client are always
+// dropped, so internal services can trust both headers for scoping and logging.
+func setIdentity(c *gin.Context) {
+	c.Request.Header.Del(middleware.UserIDHeader)
+	c.Request.Header.Del(tenant.Header)
+
+	claims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		return
+	}
+	if claims.Subject != "" {
+		c.Request.Header.Set(middleware.UserIDHeader, claims.Subject)
+	}
+	if claims.Tenant == "" {
+		return
+	}
+	if err := tenant.Validate(claims.Tenant); err != nil {
+		log.Warn().Err(err).Str("user_id", claims.Subject).Msg("Ignoring invalid tenant claim")
+		return
+	}
+	c.Request.Header.Set(tenant.Header, claims.Tenant)
+}
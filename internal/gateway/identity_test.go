@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSetIdentityReplacesClientHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		claims     *jwt.KeycloakClaims
+		wantUser   string
+		wantTenant string
+	}{
+		{name: "anonymous"},
+		{name: "claims", claims: claimsFor("user-1", "school_a"), wantUser: "user-1", wantTenant: "school_a"},
+		{name: "no tenant claim", claims: claimsFor("user-1", ""), wantUser: "user-1"},
+		{name: "invalid tenant claim", claims: claimsFor("user-1", "School A"), wantUser: "user-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/students", nil)
+			c.Request.Header.Set(middleware.UserIDHeader, "forged-user")
+			c.Request.Header.Set(tenant.Header, "forged_school")
+			if tt.claims != nil {
+				c.Set(middleware.ClaimsKey, tt.claims)
+			}
+
+			setIdentity(c)
+
+			if got := c.Request.Header.Get(middleware.UserIDHeader); got != tt.wantUser {
+				t.Errorf("%s = %q, want %q", middleware.UserIDHeader, got, tt.wantUser)
+			}
+			if got := c.Request.Header.Get(tenant.Header); got != tt.wantTenant {
+				t.Errorf("%s = %q, want %q", tenant.Header, got, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func claimsFor(subject, school string) *jwt.KeycloakClaims {
+	claims := &jwt.KeycloakClaims{Tenant: school}
+	claims.Subject = subject
+	return claims
+}
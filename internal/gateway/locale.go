@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"github.com/PegasusMKD/svedprint-go/pkg/i18n"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// setUserLocale passes the user's locale downstream in X-User-Locale, taken from the
+// token's locale claim or else the Accept-Language header. A value sent by the client
+// is replaced, and the header is dropped when no supported locale was found.
+func setUserLocale(c *gin.Context) {
+	var candidates []string
+	if claims, ok := middleware.ClaimsFromContext(c); ok && claims.Locale != "" {
+		candidates = append(candidates, claims.Locale)
+	}
+	candidates = append(candidates, i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language"))...)
+
+	c.Request.Header.Del(i18n.Header)
+	if locale, ok := i18n.Match(candidates...); ok {
+		c.Request.Header.Set(i18n.Header, locale)
+	}
+}
@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/certificate"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/transcript"
+	"github.com/PegasusMKD/svedprint-go/pkg/i18n"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func withLocale(claims *jwt.KeycloakClaims, locale string) *jwt.KeycloakClaims {
+	claims.Locale = locale
+	return claims
+}
+
+func TestSetUserLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		claims         *jwt.KeycloakClaims
+		acceptLanguage string
+		want           string
+	}{
+		{name: "locale claim", claims: withLocale(claimsFor("user-1", "school_a"), "sq"), acceptLanguage: "en", want: i18n.Albanian},
+		{name: "claim with region", claims: withLocale(claimsFor("user-1", "school_a"), "mk-MK"), want: i18n.Macedonian},
+		{name: "no locale claim", claims: claimsFor("user-1", "school_a"), acceptLanguage: "de, en;q=0.8", want: i18n.English},
+		{name: "unsupported claim", claims: withLocale(claimsFor("user-1", "school_a"), "de"), acceptLanguage: "sq", want: i18n.Albanian},
+		{name: "anonymous", acceptLanguage: "en-GB", want: i18n.English},
+		{name: "nothing supported", acceptLanguage: "de"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/print/render/transcript", nil)
+			c.Request.Header.Set(i18n.Header, "forged")
+			if tt.acceptLanguage != "" {
+				c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.claims != nil {
+				c.Set(middleware.ClaimsKey, tt.claims)
+			}
+
+			setUserLocale(c)
+
+			if got := c.Request.Header.Get(i18n.Header); got != tt.want {
+				t.Errorf("%s = %q, want %q", i18n.Header, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLocaleReachesPrintService renders a transcript through the gateway and checks the
+// print service labels it in the user's language
+func TestLocaleReachesPrintService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	printService := gin.New()
+	transcript.NewTranscriptHandler().RegisterRoutes(printService.Group("/render"))
+	upstream := httptest.NewServer(printService)
+	defer upstream.Close()
+
+	grade := 5
+	body, err := json.Marshal(transcript.Request{
+		School:  certificate.School{Name: "OOU Goce Delchev", DirectorName: "Marija Petrova", City: "Skopje"},
+		Student: certificate.Student{FirstName: "Ana", LastName: "Stojanova", FathersName: "Petar", DateOfBirth: "2012-03-04", PlaceOfBirth: "Skopje"},
+		Years: []transcript.Year{{AcademicYear: "2024/2025", Subjects: []certificate.Subject{
+			{Name: "Mathematics", Grading: certificate.Grading{Scheme: certificate.SchemeNumeric, MinGrade: 1, MaxGrade: 5}, Grade: &grade},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		locale         string
+		acceptLanguage string
+		wantLocale     string
+		wantTitle      string
+	}{
+		{name: "locale claim", locale: "sq", acceptLanguage: "en", wantLocale: i18n.Albanian, wantTitle: "Vërtetim për suksesin e arritur"},
+		{name: "accept language", acceptLanguage: "en-US, mk;q=0.5", wantLocale: i18n.English, wantTitle: "Transcript of records"},
+		{name: "default", acceptLanguage: "de", wantLocale: i18n.Macedonian, wantTitle: "Уверение за постигнат успех"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := func(c *gin.Context) {
+				c.Set(middleware.ClaimsKey, withLocale(claimsFor("teacher-1", "school_a"), tt.locale))
+			}
+			routes := []Route{{Name: "svedprint-print", Prefix: "/api/print", Upstream: "svedprint-print", StripPrefix: true}}
+			gateway := newTestGateway(t, routes, upstream.URL, auth)
+
+			req, _ := http.NewRequest(http.MethodPost, gateway.URL+"/api/print/render/transcript", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("POST: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d", resp.StatusCode)
+			}
+
+			var out transcript.Transcript
+			if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if out.Locale != tt.wantLocale || out.Labels.Title != tt.wantTitle {
+				t.Errorf("transcript in %q titled %q, want %q titled %q", out.Locale, out.Labels.Title, tt.wantLocale, tt.wantTitle)
+			}
+		})
+	}
+}
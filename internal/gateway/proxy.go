@@ -0,0 +1,274 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"sync/atomic"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// proxyRoute is a compiled route bound to its reverse proxy
+type proxyRoute struct {
+	Route
+	targets  []*url.URL
+	ring     *hashRing
+	next     atomic.Uint64
+	proxy    *httputil.ReverseProxy
+	shadow   *shadower
+	balancer *balancer
+}
+
+// Proxy forwards gateway requests to downstream services based on the route table
+type Proxy struct {
+	routes  []*proxyRoute
+	auth    gin.HandlerFunc
+	renders *RenderLimiter
+}
+
+// NewProxy compiles the route table. upstreams maps upstream names to the base URLs
+// of their instances, which requests are balanced across like a route's Instances.
+// auth is applied to every route that requires authentication; it must abort the
+// request to reject it. A nil auth leaves all routes open. A nil transport uses
+// http.DefaultTransport.
+func NewProxy(routes []Route, upstreams map[string][]string, auth gin.HandlerFunc, transport http.RoundTripper) (*Proxy, error) {
+	p := &Proxy{auth: auth}
+
+	for _, route := range routes {
+		if err := route.compile(); err != nil {
+			return nil, err
+		}
+
+		rawURLs := route.Instances
+		if len(rawURLs) == 0 {
+			var ok bool
+			rawURLs, ok = upstreams[route.Upstream]
+			if !ok || len(rawURLs) == 0 {
+				return nil, fmt.Errorf("route %q: unknown upstream %q", route.Name, route.Upstream)
+			}
+		}
+
+		pr := &proxyRoute{Route: route}
+		for _, rawURL := range rawURLs {
+			target, err := url.Parse(rawURL)
+			if err != nil {
+				return nil, fmt.Errorf("route %q: invalid upstream URL %q: %w", route.Name, rawURL, err)
+			}
+			pr.targets = append(pr.targets, target)
+		}
+		if route.Sticky != nil {
+			pr.ring = newHashRing(rawURLs)
+		}
+		if route.Shadow != "" {
+			target, err := url.Parse(route.Shadow)
+			if err != nil {
+				return nil, fmt.Errorf("route %q: invalid shadow URL %q: %w", route.Name, route.Shadow, err)
+			}
+			pr.shadow = newShadower(route.Name, target)
+		}
+
+		pr.proxy = &httputil.ReverseProxy{
+			Director:     pr.director,
+			ErrorHandler: pr.errorHandler,
+			Transport:    transport,
+		}
+		if route.Streaming {
+			pr.proxy.FlushInterval = -1
+		}
+		if route.HealthCheck != nil {
+			pr.balancer = newBalancer(route.Name, *route.HealthCheck, pr.targets)
+			pr.proxy.ModifyResponse = pr.observeResponse
+		}
+		p.routes = append(p.routes, pr)
+	}
+
+	// Longest prefix wins
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		return len(p.routes[i].Prefix) > len(p.routes[j].Prefix)
+	})
+
+	return p, nil
+}
+
+// LimitRenders applies limiter to render requests on routes marked Render
+func (p *Proxy) LimitRenders(limiter *RenderLimiter) {
+	p.renders = limiter
+}
+
+// StartHealthChecks begins probing the instances of routes with a health check
+func (p *Proxy) StartHealthChecks() {
+	for _, route := range p.routes {
+		if route.balancer != nil {
+			route.balancer.start()
+		}
+	}
+}
+
+// Close stops the background health checks
+func (p *Proxy) Close(ctx context.Context) error {
+	for _, route := range p.routes {
+		if route.balancer != nil {
+			route.balancer.close()
+		}
+	}
+	return nil
+}
+
+// RouteStatus reports the instances of a route and whether they are health checked
+type RouteStatus struct {
+	Name          string           `json:"name"`
+	HealthChecked bool             `json:"health_checked"`
+	Instances     []InstanceStatus `json:"instances"`
+}
+
+// Status snapshots the health of every route's instances; instances of routes without
+// a health check are always reported healthy
+func (p *Proxy) Status() []RouteStatus {
+	statuses := make([]RouteStatus, 0, len(p.routes))
+	for _, route := range p.routes {
+		status := RouteStatus{Name: route.Name, HealthChecked: route.balancer != nil}
+		for idx, target := range route.targets {
+			if route.balancer != nil {
+				status.Instances = append(status.Instances, route.balancer.status(idx))
+			} else {
+				status.Instances = append(status.Instances, InstanceStatus{URL: target.String(), Healthy: true})
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Handle proxies the request to the matching route, or responds 404 if none match
+func (p *Proxy) Handle(c *gin.Context) {
+	route := p.match(c.Request.URL.Path)
+	if route == nil {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusNotFound, apierror.CodeRouteNotFound, "no route for path"))
+		return
+	}
+
+	if p.auth != nil && route.requiresAuth() {
+		p.auth(c)
+		if c.IsAborted() {
+			return
+		}
+		if !route.authorize(c) {
+			return
+		}
+	}
+	if route.Render && p.renders != nil && isRender(c.Request.Method) && !p.renders.allow(c) {
+		return
+	}
+	if !route.validateBody(c) {
+		return
+	}
+	setUserLocale(c)
+	setIdentity(c)
+
+	if route.Streaming {
+		ctx, cancel := middleware.WithoutTimeout(c)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+	}
+
+	if route.shadow != nil {
+		route.shadow.mirror(c.Request, route.rewritePath(c.Request.URL.Path))
+	}
+
+	route.proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+func (p *Proxy) match(path string) *proxyRoute {
+	for _, route := range p.routes {
+		if route.matches(path) {
+			return route
+		}
+	}
+	return nil
+}
+
+// pick chooses the downstream instance for a request: by consistent hash of the
+// sticky key when configured, otherwise round-robin. Ejected instances are skipped
+// while any healthy one remains.
+func (pr *proxyRoute) pick(req *http.Request) *url.URL {
+	if len(pr.targets) == 1 {
+		return pr.targets[0]
+	}
+
+	if pr.ring != nil {
+		if key := pr.Sticky.key(req.URL.Path, req.Header.Get); key != "" {
+			return pr.targets[pr.ring.getAvailable(key, pr.available)]
+		}
+	}
+
+	n := uint64(len(pr.targets))
+	start := pr.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if idx := int((start + i) % n); pr.available(idx) {
+			return pr.targets[idx]
+		}
+	}
+	return pr.targets[start%n]
+}
+
+// available reports whether the instance at idx may receive traffic
+func (pr *proxyRoute) available(idx int) bool {
+	return pr.balancer == nil || pr.balancer.healthy(idx)
+}
+
+// observeResponse feeds upstream 5xx responses into passive health checking
+func (pr *proxyRoute) observeResponse(resp *http.Response) error {
+	pr.balancer.observe(resp.Request.URL.Host, resp.StatusCode >= http.StatusInternalServerError)
+	return nil
+}
+
+// director rewrites the outgoing request to target the downstream service
+func (pr *proxyRoute) director(req *http.Request) {
+	target := pr.pick(req)
+	path := pr.rewritePath(req.URL.Path)
+
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	req.URL.Path = singleJoiningSlash(target.Path, path)
+	req.URL.RawPath = ""
+	req.Host = target.Host
+
+	// Downstreams inherit whatever is left of the client's deadline
+	middleware.PropagateBudget(req)
+
+	if _, ok := req.Header["User-Agent"]; !ok {
+		// Explicitly disable the default Go User-Agent
+		req.Header.Set("User-Agent", "")
+	}
+}
+
+func (pr *proxyRoute) errorHandler(w http.ResponseWriter, req *http.Request, err error) {
+	if pr.balancer != nil && !errors.Is(err, context.Canceled) {
+		pr.balancer.observe(req.URL.Host, true)
+	}
+	log.Error().Err(err).Str("route", pr.Name).Str("path", req.URL.Path).Msg("Upstream request failed")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+	_ = json.NewEncoder(w).Encode(apierror.NewWithCode(http.StatusBadGateway, apierror.CodeUpstreamUnavailable, "upstream unavailable"))
+}
+
+func singleJoiningSlash(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case a[len(a)-1] == '/' && b[0] == '/':
+		return a + b[1:]
+	case a[len(a)-1] != '/' && b[0] != '/':
+		return a + "/" + b
+	}
+	return a + b
+}
@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestGateway serves routes through a Proxy behind the given middleware, with every
+// upstream pointing at upstream
+func newTestGateway(t *testing.T, routes []Route, upstream string, use ...gin.HandlerFunc) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	upstreams := map[string][]string{}
+	for _, route := range routes {
+		upstreams[route.Upstream] = []string{upstream}
+	}
+	proxy, err := NewProxy(routes, upstreams, nil, nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(use...)
+	router.NoRoute(proxy.Handle)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxyPropagatesRemainingBudget(t *testing.T) {
+	budgets := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budgets <- r.Header.Get(middleware.RequestTimeoutHeader)
+	}))
+	defer upstream.Close()
+
+	routes := []Route{{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true}}
+	delay := func(c *gin.Context) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	gateway := newTestGateway(t, routes, upstream.URL, middleware.Timeout(10*time.Second), delay)
+
+	tests := []struct {
+		name   string
+		client string
+		max    int64
+	}{
+		{name: "gateway timeout", max: 10_000 - 100},
+		{name: "client budget", client: "2000", max: 2000 - 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api/svedprint/students", nil)
+			if tt.client != "" {
+				req.Header.Set(middleware.RequestTimeoutHeader, tt.client)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			resp.Body.Close()
+
+			budget, err := strconv.ParseInt(<-budgets, 10, 64)
+			if err != nil {
+				t.Fatalf("downstream budget: %v", err)
+			}
+			if budget <= 0 || budget > tt.max {
+				t.Errorf("downstream budget = %dms, want at most %dms", budget, tt.max)
+			}
+		})
+	}
+}
+
+// stubValidator accepts the token "valid" as user-1 of school_a
+type stubValidator struct{}
+
+func (stubValidator) ValidateToken(_ context.Context, token string) (*jwt.KeycloakClaims, error) {
+	if token != "valid" {
+		return nil, errors.New("invalid token")
+	}
+	return claimsFor("user-1", "school_a"), nil
+}
+
+func TestProxyAuthRequiredPerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(middleware.UserIDHeader)))
+	}))
+	defer upstream.Close()
+
+	public := false
+	routes := []Route{
+		{Name: "school-info", Prefix: "/api/public", Upstream: "svedprint", StripPrefix: true, AuthRequired: &public},
+		{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true},
+	}
+	proxy, err := NewProxy(routes, map[string][]string{"svedprint": {upstream.URL}}, middleware.Auth(stubValidator{}), nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+
+	// Global middleware such as logging and limiting must still see public requests
+	var seen atomic.Int32
+	router := gin.New()
+	router.Use(func(c *gin.Context) { seen.Add(1) })
+	router.NoRoute(proxy.Handle)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "public without token", path: "/api/public/schools", wantStatus: http.StatusOK},
+		{name: "public ignores an invalid token", path: "/api/public/schools", token: "forged", wantStatus: http.StatusOK},
+		{name: "protected without token", path: "/api/svedprint/students", wantStatus: http.StatusUnauthorized},
+		{name: "protected with invalid token", path: "/api/svedprint/students", token: "forged", wantStatus: http.StatusUnauthorized},
+		{name: "protected with valid token", path: "/api/svedprint/students", token: "valid", wantStatus: http.StatusOK, wantUser: "user-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, gateway.URL+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate challenge")
+			}
+			if tt.wantStatus == http.StatusOK && string(body) != tt.wantUser {
+				t.Errorf("upstream saw user %q, want %q", body, tt.wantUser)
+			}
+		})
+	}
+	if got := seen.Load(); got != int32(len(tests)) {
+		t.Errorf("global middleware ran for %d of %d requests", got, len(tests))
+	}
+}
+
+func TestProxyStreamingRouteOutlivesRequestTimeout(t *testing.T) {
+	// The upstream streams one row every 50ms for well past the gateway's timeout
+	const rows = 10
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		for i := 0; i < rows; i++ {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+			io.WriteString(w, "row\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	routes := DefaultRoutes()
+	gateway := newTestGateway(t, routes, upstream.URL, middleware.Timeout(150*time.Millisecond))
+
+	tests := []struct {
+		name     string
+		path     string
+		complete bool
+	}{
+		{name: "export", path: "/api/svedprint/students/export", complete: true},
+		{name: "regular route", path: "/api/svedprint/students", complete: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(gateway.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			complete := err == nil && len(body) == rows*len("row\n")
+			if complete != tt.complete {
+				t.Errorf("read %d bytes (err %v), want complete = %v", len(body), err, tt.complete)
+			}
+		})
+	}
+}
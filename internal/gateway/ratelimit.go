@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RenderLimiter rate limits document rendering, which costs far more than reads, per
+// user and per school. Counts are kept in Redis so every gateway replica shares them.
+type RenderLimiter struct {
+	client    *redis.Client
+	perUser   int
+	perSchool int
+	window    time.Duration
+}
+
+// NewRenderLimiter admits perUser renders per user and perSchool per school in each
+// sliding window; a non-positive limit disables that check
+func NewRenderLimiter(client *redis.Client, perUser, perSchool int, window time.Duration) *RenderLimiter {
+	return &RenderLimiter{client: client, perUser: perUser, perSchool: perSchool, window: window}
+}
+
+// isRender reports whether a request to a render route renders a document. Reads such
+// as downloading a finished job are not limited.
+func isRender(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// allow checks the render limits, responding 429 and returning false when the user or
+// their school is over a limit. The school comes from the validated token, never from
+// a header; single-tenant tokens carry none, so those renders are limited per user
+// only. Redis errors admit the request; limiting is not worth failing renders over.
+func (l *RenderLimiter) allow(c *gin.Context) bool {
+	claims, _ := middleware.ClaimsFromContext(c)
+
+	user := c.ClientIP()
+	if claims != nil && claims.Subject != "" {
+		user = claims.Subject
+	}
+	if !l.check(c, "render:user:"+user, l.perUser, "too many render requests, retry later") {
+		return false
+	}
+	if claims == nil || tenant.Validate(claims.Tenant) != nil {
+		return true
+	}
+	return l.check(c, "render:school:"+claims.Tenant, l.perSchool, "too many render requests for this school, retry later")
+}
+
+func (l *RenderLimiter) check(c *gin.Context, key string, limit int, message string) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	allowed, _, err := l.client.AllowN(c.Request.Context(), key, limit, l.window)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Render rate limit unavailable, admitting request")
+		return true
+	}
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(l.window.Round(time.Second)/time.Second)))
+		apierror.Respond(c, apierror.NewWithCode(http.StatusTooManyRequests, apierror.CodeRateLimited, message))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestLimiter(t *testing.T, perUser, perSchool int) *RenderLimiter {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client, err := redis.NewClient(server.Addr(), "", 0, time.Minute)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return NewRenderLimiter(client, perUser, perSchool, time.Minute)
+}
+
+// render runs one render request through the limiter and returns its status
+func render(l *RenderLimiter, claims *jwt.KeycloakClaims, header string) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/print/certificates", nil)
+	if header != "" {
+		c.Request.Header.Set(tenant.Header, header)
+	}
+	if claims != nil {
+		c.Set(middleware.ClaimsKey, claims)
+	}
+	if !l.allow(c) {
+		return w.Code
+	}
+	return http.StatusOK
+}
+
+func TestRenderLimiterLimitsRendersWithoutSchool(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := newTestLimiter(t, 1, 1)
+
+	// Single-tenant tokens carry no school; the per-user limit still applies
+	if got := render(l, claimsFor("user-1", ""), "school_a"); got != http.StatusOK {
+		t.Errorf("first render without a school claim = %d, want 200", got)
+	}
+	if got := render(l, claimsFor("user-1", ""), "school_a"); got != http.StatusTooManyRequests {
+		t.Errorf("second render without a school claim = %d, want 429", got)
+	}
+	if got := render(l, claimsFor("user-2", ""), ""); got != http.StatusOK {
+		t.Errorf("render by another user without a school claim = %d, want 200", got)
+	}
+
+	// Without a token the caller is limited by address
+	if got := render(l, nil, ""); got != http.StatusOK {
+		t.Errorf("first anonymous render = %d, want 200", got)
+	}
+	if got := render(l, nil, ""); got != http.StatusTooManyRequests {
+		t.Errorf("second anonymous render = %d, want 429", got)
+	}
+}
+
+func TestRenderLimiterDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := newTestLimiter(t, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		if got := render(l, nil, ""); got != http.StatusOK {
+			t.Errorf("anonymous render %d = %d, want 200", i, got)
+		}
+		if got := render(l, claimsFor("user-1", ""), ""); got != http.StatusOK {
+			t.Errorf("render %d without a school claim = %d, want 200", i, got)
+		}
+		if got := render(l, claimsFor("user-1", "school_a"), ""); got != http.StatusOK {
+			t.Errorf("render %d in school_a = %d, want 200", i, got)
+		}
+	}
+}
+
+func TestRenderLimiterLimitsPerUserAndSchool(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := newTestLimiter(t, 2, 3)
+
+	steps := []struct {
+		claims *jwt.KeycloakClaims
+		header string
+		want   int
+	}{
+		{claimsFor("user-1", "school_a"), "", http.StatusOK},
+		{claimsFor("user-1", "school_a"), "", http.StatusOK},
+		{claimsFor("user-1", "school_a"), "", http.StatusTooManyRequests},
+		{claimsFor("user-2", "school_a"), "", http.StatusOK},
+		// The school budget is spent; a forged header must not reach another bucket
+		{claimsFor("user-3", "school_a"), "school_b", http.StatusTooManyRequests},
+		{claimsFor("user-3", "school_b"), "", http.StatusOK},
+	}
+	for i, step := range steps {
+		if got := render(l, step.claims, step.header); got != step.want {
+			t.Errorf("step %d: %s in %s = %d, want %d", i, step.claims.Subject, step.claims.Tenant, got, step.want)
+		}
+	}
+}
+
+func TestProxyLimitsRendersIndependentlyOfReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	routes := []Route{
+		{Name: "svedprint-print", Prefix: "/api/print", Upstream: "svedprint-print", StripPrefix: true, Render: true},
+		{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true},
+	}
+	proxy, err := NewProxy(routes, map[string][]string{"svedprint-print": {upstream.URL}, "svedprint": {upstream.URL}}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+	proxy.LimitRenders(newTestLimiter(t, 1, 10))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(middleware.ClaimsKey, claimsFor("user-1", "school_a")) })
+	router.NoRoute(proxy.Handle)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	steps := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/api/print/render/certificate", http.StatusOK},
+		{http.MethodPost, "/api/print/render/transcript", http.StatusTooManyRequests},
+		// Reads are not renders, on the render route or any other
+		{http.MethodGet, "/api/print/render/downloads/job-1", http.StatusOK},
+		{http.MethodGet, "/api/svedprint/students", http.StatusOK},
+		{http.MethodPost, "/api/svedprint/students", http.StatusOK},
+		{http.MethodGet, "/api/svedprint/students", http.StatusOK},
+	}
+	for i, step := range steps {
+		req, _ := http.NewRequest(step.method, gateway.URL+step.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != step.want {
+			t.Errorf("step %d: %s %s = %d, want %d", i, step.method, step.path, resp.StatusCode, step.want)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && resp.Header.Get("Retry-After") != "60" {
+			t.Errorf("step %d: Retry-After = %q, want 60", i, resp.Header.Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package gateway
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Route maps an incoming path prefix to a downstream service
+type Route struct {
+	Name        string        `yaml:"name"`
+	Prefix      string        `yaml:"prefix"`
+	Upstream    string        `yaml:"upstream"`
+	StripPrefix bool          `yaml:"strip_prefix"`
+	Rewrites    []RewriteRule `yaml:"rewrites"`
+	// Instances lists individual downstream instances, overriding the upstream URL.
+	// Requests are spread round-robin unless Sticky is set.
+	Instances []string `yaml:"instances"`
+	Sticky    *Sticky  `yaml:"sticky"`
+	// Shadow mirrors every request to this base URL, e.g. a new downstream version
+	// under test. Its responses are discarded; the client only sees the primary.
+	Shadow string `yaml:"shadow"`
+	// HealthCheck routes only to instances passing active and passive health checks
+	HealthCheck *HealthCheck `yaml:"health_check"`
+	// AuthRequired rejects requests without a valid bearer token. It defaults to
+	// true; set it to false for public endpoints such as school info.
+	AuthRequired *bool `yaml:"auth_required"`
+	// Scopes must all be granted to the token, e.g. "print:render"
+	Scopes []string `yaml:"scopes"`
+	// Audiences, when set, admits only tokens issued for at least one of them
+	Audiences []string `yaml:"audiences"`
+	// Render marks a route whose non-GET requests render documents, e.g. certificates;
+	// they count against the render rate limit rather than going unlimited like reads
+	Render bool `yaml:"render"`
+	// Streaming marks a route whose responses stream for as long as the client keeps
+	// reading, e.g. exports; its requests are exempt from REQUEST_TIMEOUT
+	Streaming bool `yaml:"streaming"`
+	// Validate checks JSON request bodies against schemas before they are forwarded.
+	// The first matching entry applies.
+	Validate []BodyValidation `yaml:"validate"`
+}
+
+// Sticky pins requests sharing a key (e.g. a class ID) to one instance, for
+// downstreams that keep in-memory state. Exactly one of PathRegex or Header must
+// be set; PathRegex takes the key from its first capture group.
+type Sticky struct {
+	PathRegex string `yaml:"path_regex"`
+	Header    string `yaml:"header"`
+
+	re *regexp.Regexp
+}
+
+// RewriteRule rewrites the incoming path before it is forwarded. Exactly one of
+// Prefix or Regex must be set. Prefix replaces a leading path prefix, Regex
+// replaces the first match (Replace may reference capture groups like $1).
+type RewriteRule struct {
+	Prefix  string `yaml:"prefix"`
+	Regex   string `yaml:"regex"`
+	Replace string `yaml:"replace"`
+
+	re *regexp.Regexp
+}
+
+type routeFile struct {
+	Routes []Route `yaml:"routes"`
+}
+
+// DefaultRoutes returns the route table used when no route file is configured
+func DefaultRoutes() []Route {
+	return []Route{
+		{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true},
+		{
+			Name: "svedprint-export", Prefix: "/api/svedprint/students/export", Upstream: "svedprint", Streaming: true,
+			Rewrites: []RewriteRule{{Prefix: "/api/svedprint", Replace: ""}},
+		},
+		{Name: "svedprint-admin", Prefix: "/api/admin", Upstream: "svedprint-admin", StripPrefix: true},
+		{Name: "svedprint-print", Prefix: "/api/print", Upstream: "svedprint-print", StripPrefix: true, Render: true},
+	}
+}
+
+// LoadRoutes reads the route table from a YAML route file
+func LoadRoutes(path string) ([]Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route file %s: %w", path, err)
+	}
+
+	var file routeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse route file %s: %w", path, err)
+	}
+
+	if len(file.Routes) == 0 {
+		return nil, fmt.Errorf("route file %s does not define any routes", path)
+	}
+
+	// Schema files are relative to the route file, so the two can be shipped together
+	for _, route := range file.Routes {
+		for i := range route.Validate {
+			if schemaFile := route.Validate[i].SchemaFile; schemaFile != "" && !filepath.IsAbs(schemaFile) {
+				route.Validate[i].SchemaFile = filepath.Join(filepath.Dir(path), schemaFile)
+			}
+		}
+	}
+
+	return file.Routes, nil
+}
+
+// ParseRoutes parses a route table given inline in the config file's routes section.
+// Relative schema files are resolved against the working directory.
+func ParseRoutes(data []byte) ([]Route, error) {
+	var routes []Route
+	if err := yaml.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse routes in config file: %w", err)
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("routes in config file do not define any routes")
+	}
+	return routes, nil
+}
+
+// compile validates the route and prepares its rewrite rules
+func (r *Route) compile() error {
+	if r.Prefix == "" || !strings.HasPrefix(r.Prefix, "/") {
+		return fmt.Errorf("route %q: prefix must start with '/'", r.Name)
+	}
+	if r.Upstream == "" {
+		return fmt.Errorf("route %q: upstream is required", r.Name)
+	}
+
+	if r.Sticky != nil {
+		switch {
+		case r.Sticky.PathRegex != "" && r.Sticky.Header != "":
+			return fmt.Errorf("route %q: sticky sets both path_regex and header", r.Name)
+		case r.Sticky.PathRegex != "":
+			re, err := regexp.Compile(r.Sticky.PathRegex)
+			if err != nil {
+				return fmt.Errorf("route %q: sticky has invalid path_regex: %w", r.Name, err)
+			}
+			if re.NumSubexp() < 1 {
+				return fmt.Errorf("route %q: sticky path_regex needs a capture group", r.Name)
+			}
+			r.Sticky.re = re
+		case r.Sticky.Header == "":
+			return fmt.Errorf("route %q: sticky must set path_regex or header", r.Name)
+		}
+	}
+
+	for i := range r.Rewrites {
+		rule := &r.Rewrites[i]
+		switch {
+		case rule.Prefix != "" && rule.Regex != "":
+			return fmt.Errorf("route %q: rewrite %d sets both prefix and regex", r.Name, i)
+		case rule.Regex != "":
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("route %q: rewrite %d has invalid regex: %w", r.Name, i, err)
+			}
+			rule.re = re
+		case rule.Prefix == "":
+			return fmt.Errorf("route %q: rewrite %d must set prefix or regex", r.Name, i)
+		}
+	}
+
+	if !r.requiresAuth() && (len(r.Scopes) > 0 || len(r.Audiences) > 0) {
+		return fmt.Errorf("route %q: scopes and audiences need auth_required", r.Name)
+	}
+
+	for i := range r.Validate {
+		if err := r.Validate[i].compile(); err != nil {
+			return fmt.Errorf("route %q: validate %d: %w", r.Name, i, err)
+		}
+	}
+
+	return nil
+}
+
+// requiresAuth reports whether requests on the route must carry a valid token
+func (r *Route) requiresAuth() bool {
+	return r.AuthRequired == nil || *r.AuthRequired
+}
+
+// matches reports whether path falls under the route prefix on a segment boundary
+func (r *Route) matches(path string) bool {
+	if !strings.HasPrefix(path, r.Prefix) {
+		return false
+	}
+	return len(path) == len(r.Prefix) || strings.HasSuffix(r.Prefix, "/") || path[len(r.Prefix)] == '/'
+}
+
+// rewritePath computes the downstream path. The first matching rewrite rule wins;
+// if none match, the route prefix is stripped when StripPrefix is set.
+func (r *Route) rewritePath(path string) string {
+	for _, rule := range r.Rewrites {
+		if rewritten, ok := rule.apply(path); ok {
+			return ensureLeadingSlash(rewritten)
+		}
+	}
+
+	if r.StripPrefix {
+		return ensureLeadingSlash(strings.TrimPrefix(path, r.Prefix))
+	}
+
+	return path
+}
+
+func (rule RewriteRule) apply(path string) (string, bool) {
+	if rule.re != nil {
+		loc := rule.re.FindStringSubmatchIndex(path)
+		if loc == nil {
+			return "", false
+		}
+		replaced := rule.re.ExpandString(nil, rule.Replace, path, loc)
+		return path[:loc[0]] + string(replaced) + path[loc[1]:], true
+	}
+
+	if !strings.HasPrefix(path, rule.Prefix) {
+		return "", false
+	}
+	return rule.Replace + strings.TrimPrefix(path, rule.Prefix), true
+}
+
+// key extracts the sticky key from the incoming request path or headers
+func (s *Sticky) key(path string, header func(string) string) string {
+	if s.re != nil {
+		if m := s.re.FindStringSubmatch(path); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+	return header(s.Header)
+}
+
+func ensureLeadingSlash(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/config"
+)
+
+func TestParseRoutesFromConfigFile(t *testing.T) {
+	cfg, err := config.LoadFromFile("gateway", "../../config/config.example.yaml")
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	routes, err := ParseRoutes(cfg.GatewayRoutes)
+	if err != nil {
+		t.Fatalf("ParseRoutes: %v", err)
+	}
+	want := []string{"svedprint", "svedprint-export", "svedprint-admin", "svedprint-print"}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(routes), len(want))
+	}
+	for i, route := range routes {
+		if route.Name != want[i] {
+			t.Errorf("route %d = %q, want %q", i, route.Name, want[i])
+		}
+	}
+	if !routes[1].Streaming {
+		t.Error("streaming flag of the export route was not parsed")
+	}
+	if !routes[3].Render {
+		t.Error("render flag of the print route was not parsed")
+	}
+}
+
+func TestLoadRoutesExample(t *testing.T) {
+	routes, err := LoadRoutes("../../config/routes.example.yaml")
+	if err != nil {
+		t.Fatalf("LoadRoutes: %v", err)
+	}
+	for i := range routes {
+		if err := routes[i].compile(); err != nil {
+			t.Errorf("route %q: %v", routes[i].Name, err)
+		}
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		route Route
+		path  string
+		want  string
+	}{
+		{
+			name:  "prefix replace keeps the remaining path",
+			route: Route{Prefix: "/api/admin", Rewrites: []RewriteRule{{Prefix: "/api/admin", Replace: "/v1"}}},
+			path:  "/api/admin/schools/42/classes",
+			want:  "/v1/schools/42/classes",
+		},
+		{
+			name:  "regex with capture group",
+			route: Route{Prefix: "/api/admin", Rewrites: []RewriteRule{{Regex: `^/api/admin/schools/([^/]+)`, Replace: "/v2/school/$1"}}},
+			path:  "/api/admin/schools/42/classes",
+			want:  "/v2/school/42/classes",
+		},
+		{
+			name: "first matching rule wins",
+			route: Route{Prefix: "/api/admin", Rewrites: []RewriteRule{
+				{Prefix: "/api/admin/reports", Replace: "/reporting"},
+				{Prefix: "/api/admin", Replace: "/v1"},
+			}},
+			path: "/api/admin/reports/yearly",
+			want: "/reporting/yearly",
+		},
+		{
+			name:  "no match falls back to strip prefix",
+			route: Route{Prefix: "/api/admin", StripPrefix: true, Rewrites: []RewriteRule{{Regex: `^/api/admin/legacy`, Replace: "/old"}}},
+			path:  "/api/admin/schools",
+			want:  "/schools",
+		},
+		{
+			name:  "replacement to empty keeps a leading slash",
+			route: Route{Prefix: "/api/admin", Rewrites: []RewriteRule{{Prefix: "/api/admin/", Replace: ""}}},
+			path:  "/api/admin/schools",
+			want:  "/schools",
+		},
+		{
+			name:  "no rules and no strip forwards unchanged",
+			route: Route{Prefix: "/api/admin"},
+			path:  "/api/admin/schools",
+			want:  "/api/admin/schools",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.route.Name, tt.route.Upstream = "admin", "svedprint-admin"
+			if err := tt.route.compile(); err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if got := tt.route.rewritePath(tt.path); got != tt.want {
+				t.Errorf("rewritePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteRuleValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RewriteRule
+	}{
+		{"both prefix and regex", RewriteRule{Prefix: "/a", Regex: "^/a"}},
+		{"neither prefix nor regex", RewriteRule{Replace: "/b"}},
+		{"invalid regex", RewriteRule{Regex: "("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := Route{Name: "admin", Prefix: "/api/admin", Upstream: "svedprint-admin", Rewrites: []RewriteRule{tt.rule}}
+			if err := route.compile(); err == nil {
+				t.Error("compile() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestProxyRewritesPathAndKeepsQuery(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+
+	routes := []Route{{
+		Name:     "svedprint-admin",
+		Prefix:   "/api/admin",
+		Upstream: "svedprint-admin",
+		Rewrites: []RewriteRule{{Prefix: "/api/admin", Replace: "/v1"}},
+	}}
+	gateway := newTestGateway(t, routes, upstream.URL)
+
+	resp, err := http.Get(gateway.URL + "/api/admin/schools/42?year=2025&sort=name")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if want := "/v1/schools/42?year=2025&sort=name"; string(got) != want {
+		t.Errorf("upstream saw %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+package gateway
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// maxSchemaErrors bounds how many problems are reported for one request body
+const maxSchemaErrors = 20
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// jsonSchema is the subset of JSON Schema the gateway enforces: type, enum, const,
+// properties, required, additionalProperties, items, string length, pattern and
+// format (date, date-time, uuid), numeric bounds and array length. Schemas using any
+// other keyword, such as $ref or the combinators, are rejected rather than enforced
+// partially; annotations like title and description are allowed.
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                any                    `json:"const"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Format               string                 `json:"format"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+
+	pattern      *regexp.Regexp
+	noAdditional bool
+	additional   *jsonSchema
+}
+
+// schemaKeywords are the keywords a schema may use: the enforced ones, then annotations
+var schemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true, "properties": true, "required": true,
+	"additionalProperties": true, "items": true, "minLength": true, "maxLength": true,
+	"pattern": true, "format": true, "minimum": true, "maximum": true, "minItems": true,
+	"maxItems": true,
+
+	"title": true, "description": true, "default": true, "examples": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "$schema": true, "$id": true, "$comment": true,
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"]
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// parseSchema decodes and compiles a JSON Schema document
+func parseSchema(data []byte) (*jsonSchema, error) {
+	if err := checkKeywords(data, "#"); err != nil {
+		return nil, err
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// checkKeywords rejects keywords outside schemaKeywords in the schema at location and
+// in its subschemas
+func checkKeywords(data json.RawMessage, location string) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		// Not an object, e.g. additionalProperties: false; the decoder reports bad types
+		return nil
+	}
+
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !schemaKeywords[name] {
+			return fmt.Errorf("invalid JSON schema: unsupported keyword %q at %s", name, location)
+		}
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(keywords["properties"], &properties); err == nil {
+		names = names[:0]
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := checkKeywords(properties[name], location+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range []string{"items", "additionalProperties"} {
+		if sub, ok := keywords[name]; ok {
+			if err := checkKeywords(sub, location+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compile prepares patterns and additionalProperties for the schema and its children
+func (s *jsonSchema) compile() error {
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("invalid JSON schema: unknown type %q", t)
+		}
+	}
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid JSON schema: pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			s.noAdditional = !allowed
+		} else {
+			var additional jsonSchema
+			if err := json.Unmarshal(s.AdditionalProperties, &additional); err != nil {
+				return fmt.Errorf("invalid JSON schema: additionalProperties must be a boolean or a schema")
+			}
+			s.additional = &additional
+		}
+	}
+
+	children := make([]*jsonSchema, 0, len(s.Properties)+2)
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	children = append(children, s.Items, s.additional)
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks a decoded JSON value and returns every problem found, named with
+// the same field paths the services use (e.g. grades[0].value). Problems with the
+// body as a whole are reported on "body".
+func (s *jsonSchema) validate(value any) []apierror.FieldError {
+	v := &schemaValidator{}
+	v.check(s, "", value)
+	return v.problems
+}
+
+type schemaValidator struct {
+	problems []apierror.FieldError
+}
+
+func (v *schemaValidator) add(field, rule, message string) {
+	if field == "" {
+		field = "body"
+	}
+	if len(v.problems) < maxSchemaErrors {
+		v.problems = append(v.problems, apierror.Field(field, rule, message))
+	}
+}
+
+// propertyField names a property of the object at field
+func propertyField(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func (v *schemaValidator) check(s *jsonSchema, field string, value any) {
+	if len(v.problems) >= maxSchemaErrors {
+		return
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		v.add(field, "type", fmt.Sprintf("must be of type %s", strings.Join(s.Type, " or ")))
+		return
+	}
+	if s.Enum != nil && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, value) }) {
+		v.add(field, "enum", fmt.Sprintf("must be one of %v", s.Enum))
+	}
+	if s.Const != nil && !jsonEqual(s.Const, value) {
+		v.add(field, "const", fmt.Sprintf("must be %v", s.Const))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.checkObject(s, field, value)
+	case []any:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			v.add(field, "minItems", fmt.Sprintf("must have at least %d items", *s.MinItems))
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			v.add(field, "maxItems", fmt.Sprintf("must have at most %d items", *s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				v.check(s.Items, fmt.Sprintf("%s[%d]", cmp.Or(field, "body"), i), item)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.MinLength != nil && length < *s.MinLength {
+			v.add(field, "minLength", fmt.Sprintf("must be at least %d characters", *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			v.add(field, "maxLength", fmt.Sprintf("must be at most %d characters", *s.MaxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			v.add(field, "pattern", fmt.Sprintf("must match %s", s.Pattern))
+		}
+		if s.Format != "" && !matchesFormat(s.Format, value) {
+			v.add(field, "format", fmt.Sprintf("must be a valid %s", s.Format))
+		}
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			v.add(field, "minimum", fmt.Sprintf("must be at least %v", *s.Minimum))
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			v.add(field, "maximum", fmt.Sprintf("must be at most %v", *s.Maximum))
+		}
+	}
+}
+
+func (v *schemaValidator) checkObject(s *jsonSchema, field string, object map[string]any) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.add(propertyField(field, name), "required", "is required")
+		}
+	}
+
+	// Sorted so the reported problems are stable between requests
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := s.Properties[name]; ok {
+			v.check(property, propertyField(field, name), object[name])
+			continue
+		}
+		switch {
+		case s.noAdditional:
+			v.add(propertyField(field, name), "additionalProperties", "is not allowed")
+		case s.additional != nil:
+			v.check(s.additional, propertyField(field, name), object[name])
+		}
+	}
+}
+
+// hasType reports whether a value decoded by encoding/json is of the JSON Schema type
+func hasType(value any, t string) bool {
+	switch value := value.(type) {
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && value == math.Trunc(value))
+	case bool:
+		return t == "boolean"
+	case nil:
+		return t == "null"
+	}
+	return false
+}
+
+// jsonEqual compares two values decoded by encoding/json
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	// Unknown formats are annotations only
+	return true
+}
@@ -1,22 +1,39 @@
 package gateway
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/PegasusMKD/svedprint-go/internal/gateway/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/cache"
 	"github.com/PegasusMKD/svedprint-go/pkg/config"
 	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/health"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/keycloak"
+	"github.com/PegasusMKD/svedprint-go/pkg/lifecycle"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
 )
 
 type GinServer struct {
-	addr   string
-	engine *gin.Engine
+	addr      string
+	engine    *gin.Engine
+	lifecycle *lifecycle.Lifecycle
 }
 
 func (gs *GinServer) Run() {
-	gs.engine.Run(gs.addr)
+	srv := &http.Server{Addr: gs.addr, Handler: gs.engine}
+	if err := gs.lifecycle.Run(srv); err != nil {
+		log.Fatal().Err(err).Msg("Server stopped unexpectedly")
+	}
 }
 
 func NewServer() *GinServer {
@@ -28,32 +45,181 @@ func NewServer() *GinServer {
 
 	cfg, err := config.Load("gateway")
 	if err != nil {
-		panic("Failed loading config for gateway!")
+		log.Fatal().Err(err).Msg("Failed loading config for gateway")
 	}
+	log.Info().Str("config", cfg.Redacted()).Msg("Loaded gateway configuration")
+
+	lc := lifecycle.New(cfg.ShutdownTimeout)
+	_, pool := setupSqlc(cfg, lc)
+
+	router := gin.New()
+
+	redisClient, localCache := setupRedis(cfg, lc)
+	sessions := NewSessionStore(redisClient, cfg.SessionTTL)
+	auth := authenticate(setupValidator(cfg, lc, redisClient, sessions), sessions)
+	transport := NewTransport(TransportConfig{
+		ConnMaxLifetime:    cfg.GatewayConnMaxLifetime,
+		DNSRefreshInterval: cfg.GatewayDNSRefreshInterval,
+	})
+	transport.Start()
+	lc.OnShutdown("proxy-transport", transport.Close)
 
-	setupSqlc(cfg)
+	proxy, err := setupProxy(cfg, auth, transport)
+	if err != nil {
+		panic(fmt.Sprintf("Failed setting up gateway proxy: %v", err))
+	}
+	proxy.LimitRenders(NewRenderLimiter(redisClient, cfg.RenderRateLimitPerUser, cfg.RenderRateLimitPerSchool, cfg.RenderRateLimitWindow))
+	proxy.StartHealthChecks()
+	lc.OnShutdown("health-checks", proxy.Close)
 
-	router := gin.Default()
+	diagnostics := NewDiagnostics(pool, redisClient, localCache, proxy)
 
-	setupMiddleware(router)
-	setupRoutes(router)
+	setupMiddleware(router, cfg, diagnostics)
+	setupHealth(router, lc, redisClient)
+	setupRoutes(router, cfg, proxy, auth, sessions, diagnostics)
 
-	return &GinServer{engine: router, addr: addr}
+	return &GinServer{engine: router, addr: addr, lifecycle: lc}
 }
 
-func setupSqlc(cfg *config.Config) *sqlc.Queries {
+func setupSqlc(cfg *config.Config, lc *lifecycle.Lifecycle) (*sqlc.Queries, *pgxpool.Pool) {
 	dbConfig := database.GetConfig(cfg.DatabaseURL, cfg.DatabaseMaxConns, cfg.DatabaseMaxIdleConns, cfg.DatabaseConnLifetime)
+	dbConfig.ExpectedReplicas = cfg.DatabaseReplicas
+	dbConfig.StrictConnectionLimit = cfg.DatabaseStrictLimit
+	dbConfig.CategoryTimeouts = database.NewCategoryTimeouts(cfg.DatabaseInteractiveTimeout, cfg.DatabaseReportingTimeout, cfg.DatabaseAcquireTimeout)
 	migrationPath := fmt.Sprintf("db/%s/migrations", cfg.ServiceName)
-	database.RunMigrations(dbConfig.URL, migrationPath)
-	return sqlc.New(database.SetupDatabasePool(dbConfig))
+
+	pool, err := database.OpenPool(context.Background(), dbConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create connection pool")
+	}
+
+	// Checked after the listener is up so liveness probes answer during startup
+	lc.OnStart("database", func(ctx context.Context) error {
+		return database.Verify(ctx, pool, dbConfig)
+	})
+	lc.OnStart("migrations", func(ctx context.Context) error {
+		return database.RunMigrations(dbConfig.URL, migrationPath)
+	})
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		return database.CloseWithTimeout(pool, cfg.DatabaseCloseTimeout)
+	})
+
+	return sqlc.New(database.NewQueryDB(pool, cfg.DatabaseQueryTimeout)), pool
+}
+
+func setupHealth(router *gin.Engine, lc *lifecycle.Lifecycle, redisClient *redis.Client) {
+	gate := health.NewGate()
+	lc.OnReady(gate.MarkReady)
+	gate.AddCheck("redis", redisClient.Health)
+
+	router.Use(gate.Middleware())
+	gate.RegisterRoutes(router)
+}
+
+func setupMiddleware(router *gin.Engine, cfg *config.Config, diagnostics *Diagnostics) {
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID(requestid.MustNew(cfg.RequestIDFormat)))
+	router.Use(middleware.AccessLog(cfg.AccessLogFormat, os.Stdout))
+	router.Use(diagnostics.Middleware())
+	router.Use(middleware.CORS(strings.Split(cfg.CORSAllowedOrigins, ","), cfg.CORSMaxAge))
+	router.Use(middleware.Compress(cfg.GatewayCompressMinSize))
+	router.Use(middleware.PrettyJSON(cfg.PrettyJSON))
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
+	router.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxQueuedRequests))
 }
 
-func setupMiddleware(router *gin.Engine) {
-	router.Use(gin.Logger())
+// setupRedis connects to Redis and fronts it with the per-instance LRU used for
+// cache-aside lookups
+func setupRedis(cfg *config.Config, lc *lifecycle.Lifecycle) (*redis.Client, *cache.Cache) {
+	client, err := redis.NewClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.RedisTTL,
+		redis.WithReadOnlyOnOOM(cfg.RedisOOMCooldown), redis.WithCompression(cfg.RedisCompressThreshold), redis.WithStampedeProtection(),
+		redis.WithRetry(cfg.RedisRetries, cfg.RedisRetryBaseDelay), redis.WithKeyPrefix(cfg.RedisKeyPrefix))
+	if err != nil {
+		panic(fmt.Sprintf("Failed connecting to Redis: %v", err))
+	}
+	lc.OnShutdown("redis", func(ctx context.Context) error {
+		return client.Close()
+	})
+
+	// Local copies of keys Redis expires or evicts are dropped right away
+	local := cache.New(client, cfg.LocalCacheSize, cfg.LocalCacheTTL)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	local.WatchRemoteEvictions(watchCtx)
+	lc.OnShutdown("redis-evictions", func(context.Context) error {
+		stopWatch()
+		return nil
+	})
+	return client, local
+}
+
+// setupValidator verifies tokens against the Keycloak JWKS, loading the signing keys
+// before the gateway reports ready and refreshing them in the background. Tokens of
+// revoked sessions and individually revoked tokens are rejected.
+func setupValidator(cfg *config.Config, lc *lifecycle.Lifecycle, redisClient *redis.Client, sessions *SessionStore) *jwt.Validator {
+	validator := jwt.NewValidator(cfg.KeycloakJWKSURL, cfg.KeycloakRealm, cfg.KeycloakClientID,
+		jwt.WithFetchTimeout(cfg.KeycloakJWKSFetchTimeout),
+		jwt.WithValidMethods(strings.Split(cfg.KeycloakJWTAlgorithms, ",")...),
+		jwt.WithAudiences(strings.Split(cfg.KeycloakAudiences, ",")...),
+		jwt.WithLeeway(cfg.KeycloakTokenLeeway),
+		jwt.WithSessionRevocationChecker(sessions, cfg.TokenRevocationFailClosed),
+		jwt.WithRevocationChecker(redis.NewTokenBlocklist(redisClient), cfg.TokenRevocationFailClosed))
+	lc.OnStart("jwks", validator.Warmup)
+
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	validator.StartBackgroundRefresh(refreshCtx, cfg.KeycloakJWKSRefresh)
+	lc.OnShutdown("jwks-refresh", func(context.Context) error {
+		stopRefresh()
+		return nil
+	})
+	return validator
+}
+
+// authenticate validates the bearer token and records the caller's session
+func authenticate(validator *jwt.Validator, sessions *SessionStore) gin.HandlerFunc {
+	auth := middleware.Auth(validator)
+	return func(c *gin.Context) {
+		auth(c)
+		if !c.IsAborted() {
+			sessions.Track(c)
+		}
+	}
+}
+
+func setupProxy(cfg *config.Config, auth gin.HandlerFunc, transport http.RoundTripper) (*Proxy, error) {
+	routes := DefaultRoutes()
+	switch {
+	case cfg.GatewayRoutesFile != "":
+		loaded, err := LoadRoutes(cfg.GatewayRoutesFile)
+		if err != nil {
+			return nil, err
+		}
+		routes = loaded
+	case len(cfg.GatewayRoutes) > 0:
+		parsed, err := ParseRoutes(cfg.GatewayRoutes)
+		if err != nil {
+			return nil, err
+		}
+		routes = parsed
+	}
+
+	upstreams := map[string][]string{
+		"svedprint":       config.ServiceInstances(cfg.SvedprintServiceURLs, cfg.SvedprintServiceURL),
+		"svedprint-admin": config.ServiceInstances(cfg.SvedprintAdminServiceURLs, cfg.SvedprintAdminServiceURL),
+		"svedprint-print": config.ServiceInstances(cfg.SvedprintPrintServiceURLs, cfg.SvedprintPrintServiceURL),
+	}
+
+	return NewProxy(routes, upstreams, auth, transport)
 }
 
-func setupRoutes(router *gin.Engine) {
+func setupRoutes(router *gin.Engine, cfg *config.Config, proxy *Proxy, auth gin.HandlerFunc, sessions *SessionStore, diagnostics *Diagnostics) {
 	router.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{"status": "healthy"})
 	})
+
+	tokens := keycloak.NewTokenClient(cfg.KeycloakURL, cfg.KeycloakRealm, cfg.KeycloakClientID, cfg.KeycloakClientSecret)
+	NewAuthHandler(tokens).RegisterRoutes(router.Group("/auth"))
+	NewSessionHandler(sessions).RegisterRoutes(router.Group("/auth", auth))
+	diagnostics.RegisterRoutes(router.Group("/admin", auth, middleware.RequireRole(diagnosticsRole)))
+
+	router.NoRoute(proxy.Handle)
 }
@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/cache"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sessionKeyPrefix = "session:"
+	revokedKeyPrefix = "session_revoked:"
+	userSessionsTag  = "user_sessions:"
+
+	// trackedSessionsSize bounds the local memory of sessions already recorded, which
+	// keeps Redis off the path of every authenticated request
+	trackedSessionsSize = 10000
+)
+
+// Session is an active login session of a user, keyed by the Keycloak session ID
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionStore records sessions in Redis when they are first seen by the gateway and
+// revokes them by deleting the record and denying their tokens
+type SessionStore struct {
+	redis   *redis.Client
+	ttl     time.Duration
+	tracked *cache.LRU
+}
+
+// NewSessionStore keeps session records and revocations for ttl, which should cover
+// the longest Keycloak session
+func NewSessionStore(client *redis.Client, ttl time.Duration) *SessionStore {
+	return &SessionStore{
+		redis:   client,
+		ttl:     ttl,
+		tracked: cache.NewLRU(trackedSessionsSize, ttl),
+	}
+}
+
+// Track records the session of an authenticated request the first time it is seen.
+// Failures are only logged; session listing is not worth failing a request over.
+func (s *SessionStore) Track(c *gin.Context) {
+	claims, ok := middleware.ClaimsFromContext(c)
+	if !ok || claims.SessionID == "" {
+		return
+	}
+	if _, seen := s.tracked.Get(claims.SessionID); seen {
+		return
+	}
+
+	ctx := c.Request.Context()
+	first, err := s.redis.ClaimOnce(ctx, sessionKeyPrefix+claims.SessionID, s.ttl)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to track session")
+		return
+	}
+	if first {
+		session := Session{
+			ID:        claims.SessionID,
+			UserID:    claims.GetUserID(),
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			CreatedAt: time.Now().UTC(),
+		}
+		err := s.redis.SetWithTags(ctx, sessionKeyPrefix+session.ID, session, s.ttl, userSessionsTag+session.UserID)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to store session")
+			return
+		}
+	}
+	s.tracked.Set(claims.SessionID, nil)
+}
+
+// List returns the active sessions of a user, newest first
+func (s *SessionStore) List(ctx context.Context, userID string) ([]Session, error) {
+	keys, err := s.redis.TagMembers(ctx, userSessionsTag+userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(keys))
+	for _, key := range keys {
+		var session Session
+		if err := s.redis.Get(ctx, key, &session); err != nil {
+			if errors.Is(err, redis.ErrCacheMiss) {
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+// Get returns a single session
+func (s *SessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	if err := s.redis.Get(ctx, sessionKeyPrefix+id, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Revoke deletes the session and denies every token issued for it until the session
+// would have expired anyway
+func (s *SessionStore) Revoke(ctx context.Context, id string) error {
+	if err := s.redis.SetWithTTL(ctx, revokedKeyPrefix+id, time.Now().Unix(), s.ttl); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if err := s.redis.Delete(ctx, sessionKeyPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	s.tracked.Delete(id)
+	return nil
+}
+
+// IsRevoked reports whether the session was revoked, making the store the validator's
+// session jwt.RevocationChecker
+func (s *SessionStore) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	revoked, err := s.redis.Exists(ctx, revokedKeyPrefix+sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session revocation: %w", err)
+	}
+	return revoked, nil
+}
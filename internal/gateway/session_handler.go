@@ -0,0 +1,116 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// sessionAdminRole may list and revoke the sessions of any user
+const sessionAdminRole = "admin"
+
+type SessionDTO struct {
+	Session
+	Current bool `json:"current"`
+}
+
+// SessionHandler lets users see where they are logged in and log out sessions
+type SessionHandler struct {
+	sessions *SessionStore
+}
+
+func NewSessionHandler(sessions *SessionStore) *SessionHandler {
+	return &SessionHandler{sessions: sessions}
+}
+
+// RegisterRoutes registers the session endpoints; rg must already require authentication
+func (h *SessionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/sessions", h.List)
+	rg.DELETE("/sessions", h.RevokeAll)
+	rg.DELETE("/sessions/:id", h.Revoke)
+}
+
+// List returns the caller's sessions. Admins may pass user_id to see another user's.
+func (h *SessionHandler) List(c *gin.Context) {
+	claims, _ := middleware.ClaimsFromContext(c)
+
+	userID := claims.GetUserID()
+	if requested := c.Query("user_id"); requested != "" && requested != userID {
+		if !claims.HasRealmRole(sessionAdminRole) {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeForbidden, "only admins may list other users' sessions"))
+			return
+		}
+		userID = requested
+	}
+
+	sessions, err := h.sessions.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list sessions")
+		apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeSessionsUnavailable, "sessions are unavailable"))
+		return
+	}
+
+	dtos := make([]SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		dtos = append(dtos, SessionDTO{Session: session, Current: session.ID == claims.SessionID})
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// Revoke logs out one session of the caller, or of anyone for admins
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	claims, _ := middleware.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+
+	session, err := h.sessions.Get(ctx, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheMiss) {
+			apierror.Respond(c, apierror.WithCode(apierror.ErrNotFound, apierror.CodeSessionNotFound))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to load session")
+		apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeSessionsUnavailable, "sessions are unavailable"))
+		return
+	}
+	// Other users' sessions are reported missing so their IDs can't be probed
+	if session.UserID != claims.GetUserID() && !claims.HasRealmRole(sessionAdminRole) {
+		apierror.Respond(c, apierror.WithCode(apierror.ErrNotFound, apierror.CodeSessionNotFound))
+		return
+	}
+
+	if err := h.sessions.Revoke(ctx, session.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to revoke session")
+		apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeSessionsUnavailable, "sessions are unavailable"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAll logs the caller out everywhere, including the current session
+func (h *SessionHandler) RevokeAll(c *gin.Context) {
+	claims, _ := middleware.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+
+	sessions, err := h.sessions.List(ctx, claims.GetUserID())
+	if err == nil {
+		for _, session := range sessions {
+			if err = h.sessions.Revoke(ctx, session.ID); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil && claims.SessionID != "" {
+		err = h.sessions.Revoke(ctx, claims.SessionID)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke sessions")
+		apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeSessionsUnavailable, "sessions are unavailable"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestSessionStore(t *testing.T) *SessionStore {
+	t.Helper()
+	client, err := redis.NewClient(miniredis.RunT(t).Addr(), "", 0, time.Minute)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return NewSessionStore(client, time.Hour)
+}
+
+// sessionClaims are the claims of a token issued for session sid of user
+func sessionClaims(user, sid string, roles ...string) *jwt.KeycloakClaims {
+	claims := claimsFor(user, "school_a")
+	claims.SessionID = sid
+	if len(roles) > 0 {
+		granted := make([]interface{}, len(roles))
+		for i, role := range roles {
+			granted[i] = role
+		}
+		claims.RealmAccess = map[string]interface{}{"roles": granted}
+	}
+	return claims
+}
+
+// newSessionRouter authenticates each request with the claims given to the returned
+// send function and tracks its session, like the gateway does
+func newSessionRouter(store *SessionStore) func(claims *jwt.KeycloakClaims, method, path, userAgent string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	var current *jwt.KeycloakClaims
+	router := gin.New()
+	auth := router.Group("/auth", func(c *gin.Context) {
+		c.Set(middleware.ClaimsKey, current)
+		store.Track(c)
+	})
+	NewSessionHandler(store).RegisterRoutes(auth)
+
+	return func(claims *jwt.KeycloakClaims, method, path, userAgent string) *httptest.ResponseRecorder {
+		current = claims
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func listSessions(t *testing.T, w *httptest.ResponseRecorder) []SessionDTO {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /auth/sessions = %d: %s", w.Code, w.Body)
+	}
+	var sessions []SessionDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &sessions); err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	store := newTestSessionStore(t)
+	send := newSessionRouter(store)
+	laptop := sessionClaims("user-1", "sid-laptop")
+	phone := sessionClaims("user-1", "sid-phone")
+
+	send(laptop, http.MethodGet, "/auth/sessions", "Firefox")
+	time.Sleep(10 * time.Millisecond) // distinct creation times for ordering
+	sessions := listSessions(t, send(phone, http.MethodGet, "/auth/sessions", "Mobile Safari"))
+
+	if len(sessions) != 2 {
+		t.Fatalf("listed %d sessions, want 2: %+v", len(sessions), sessions)
+	}
+	newest, oldest := sessions[0], sessions[1]
+	if newest.ID != "sid-phone" || newest.UserAgent != "Mobile Safari" || !newest.Current {
+		t.Errorf("newest session = %+v, want the current phone session", newest)
+	}
+	if oldest.ID != "sid-laptop" || oldest.UserAgent != "Firefox" || oldest.Current || oldest.IP == "" || oldest.CreatedAt.IsZero() {
+		t.Errorf("oldest session = %+v, want the laptop session", oldest)
+	}
+
+	if w := send(phone, http.MethodDelete, "/auth/sessions/sid-laptop", "Mobile Safari"); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE = %d: %s", w.Code, w.Body)
+	}
+	sessions = listSessions(t, send(phone, http.MethodGet, "/auth/sessions", "Mobile Safari"))
+	if len(sessions) != 1 || sessions[0].ID != "sid-phone" {
+		t.Errorf("after revoking the laptop: %+v", sessions)
+	}
+
+	ctx := context.Background()
+	if revoked, err := store.IsRevoked(ctx, laptop.SessionID); err != nil || !revoked {
+		t.Error("tokens of the revoked session are still accepted")
+	}
+	if revoked, err := store.IsRevoked(ctx, phone.SessionID); err != nil || revoked {
+		t.Error("tokens of the remaining session are denied")
+	}
+	if w := send(phone, http.MethodDelete, "/auth/sessions/sid-laptop", "Mobile Safari"); w.Code != http.StatusNotFound {
+		t.Errorf("revoking a revoked session = %d, want 404", w.Code)
+	}
+}
+
+func TestSessionsBelongToTheirUser(t *testing.T) {
+	store := newTestSessionStore(t)
+	send := newSessionRouter(store)
+	ana := sessionClaims("user-1", "sid-ana")
+	marko := sessionClaims("user-2", "sid-marko")
+	admin := sessionClaims("admin-1", "sid-admin", sessionAdminRole)
+
+	send(ana, http.MethodGet, "/auth/sessions", "Firefox")
+	send(marko, http.MethodGet, "/auth/sessions", "Chrome")
+
+	if sessions := listSessions(t, send(marko, http.MethodGet, "/auth/sessions", "Chrome")); len(sessions) != 1 || sessions[0].ID != "sid-marko" {
+		t.Errorf("user-2 listed %+v", sessions)
+	}
+	if w := send(marko, http.MethodGet, "/auth/sessions?user_id=user-1", "Chrome"); w.Code != http.StatusForbidden {
+		t.Errorf("listing another user's sessions = %d, want 403", w.Code)
+	} else {
+		var body apierror.Error
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != apierror.CodeForbidden {
+			t.Errorf("listing another user's sessions = %s, want code %s", w.Body, apierror.CodeForbidden)
+		}
+	}
+	if w := send(marko, http.MethodDelete, "/auth/sessions/sid-ana", "Chrome"); w.Code != http.StatusNotFound {
+		t.Errorf("revoking another user's session = %d, want 404", w.Code)
+	}
+
+	sessions := listSessions(t, send(admin, http.MethodGet, "/auth/sessions?user_id=user-1", "curl"))
+	if len(sessions) != 1 || sessions[0].ID != "sid-ana" {
+		t.Errorf("admin listed %+v for user-1", sessions)
+	}
+	if w := send(admin, http.MethodDelete, "/auth/sessions/sid-ana", "curl"); w.Code != http.StatusNoContent {
+		t.Errorf("admin revoking a session = %d, want 204", w.Code)
+	}
+}
+
+func TestSessionsRevokeAll(t *testing.T) {
+	store := newTestSessionStore(t)
+	send := newSessionRouter(store)
+	laptop := sessionClaims("user-1", "sid-laptop")
+	phone := sessionClaims("user-1", "sid-phone")
+	other := sessionClaims("user-2", "sid-other")
+
+	for _, claims := range []*jwt.KeycloakClaims{laptop, phone, other} {
+		send(claims, http.MethodGet, "/auth/sessions", "Firefox")
+	}
+	if w := send(phone, http.MethodDelete, "/auth/sessions", "Firefox"); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /auth/sessions = %d: %s", w.Code, w.Body)
+	}
+
+	ctx := context.Background()
+	for _, claims := range []*jwt.KeycloakClaims{laptop, phone} {
+		if revoked, err := store.IsRevoked(ctx, claims.SessionID); err != nil || !revoked {
+			t.Errorf("session %s survived logging out everywhere", claims.SessionID)
+		}
+	}
+	if revoked, err := store.IsRevoked(ctx, other.SessionID); err != nil || revoked {
+		t.Error("another user's session was revoked")
+	}
+	sessions, err := store.List(ctx, "user-1")
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("List after logging out everywhere = %+v, %v", sessions, err)
+	}
+}
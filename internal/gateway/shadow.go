@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ShadowHeader marks mirrored requests so shadow backends can tell them apart
+	ShadowHeader = "X-Shadow-Request"
+	// maxShadowBody is the largest body mirrored; bigger requests are not shadowed
+	maxShadowBody = 1 << 20
+	// maxShadowInFlight caps concurrent mirrored requests; extra copies are dropped
+	maxShadowInFlight = 64
+	// shadowTimeout bounds a mirrored request independently of the client's request
+	shadowTimeout = 10 * time.Second
+)
+
+// shadower copies requests to a shadow backend in the background. Responses are
+// discarded and failures only logged at debug level, so the client never sees them.
+type shadower struct {
+	route    string
+	target   *url.URL
+	client   *http.Client
+	inFlight chan struct{}
+}
+
+func newShadower(route string, target *url.URL) *shadower {
+	return &shadower{
+		route:    route,
+		target:   target,
+		client:   &http.Client{Timeout: shadowTimeout},
+		inFlight: make(chan struct{}, maxShadowInFlight),
+	}
+}
+
+// mirror clones req (including its body, which is restored for the primary) and
+// sends the copy to the shadow backend at path
+func (s *shadower) mirror(req *http.Request, path string) {
+	if req.ContentLength > maxShadowBody {
+		return
+	}
+
+	body, ok := cloneBody(req)
+	if !ok {
+		return
+	}
+
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		log.Debug().Str("route", s.route).Msg("Shadow backend saturated, dropping mirrored request")
+		return
+	}
+
+	shadowURL := *s.target
+	shadowURL.Path = singleJoiningSlash(s.target.Path, path)
+	shadowURL.RawPath = ""
+	shadowURL.RawQuery = req.URL.RawQuery
+
+	header := req.Header.Clone()
+	header.Set(ShadowHeader, "true")
+	method := req.Method
+
+	go func() {
+		defer func() { <-s.inFlight }()
+
+		// Detached from the client request so the copy outlives the primary response
+		ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+		defer cancel()
+
+		shadowReq, err := http.NewRequestWithContext(ctx, method, shadowURL.String(), bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		shadowReq.Header = header
+
+		resp, err := s.client.Do(shadowReq)
+		if err != nil {
+			log.Debug().Err(err).Str("route", s.route).Msg("Shadow request failed")
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// cloneBody reads the request body so it can be sent twice, putting an equivalent
+// body back on req. It reports false when the body exceeds maxShadowBody, in which
+// case req still carries the complete original body.
+func cloneBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxShadowBody+1))
+	if err != nil {
+		req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return nil, false
+	}
+
+	if len(body) > maxShadowBody {
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return nil, false
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
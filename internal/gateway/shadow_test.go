@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mirroredRequest struct {
+	method, path, query, body, shadowHeader string
+}
+
+// recordingBackend answers every request with status and reply and reports what it
+// received on the returned channel
+func recordingBackend(t *testing.T, status int, reply string) (*httptest.Server, <-chan mirroredRequest) {
+	t.Helper()
+	received := make(chan mirroredRequest, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- mirroredRequest{r.Method, r.URL.Path, r.URL.RawQuery, string(body), r.Header.Get(ShadowHeader)}
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestProxyMirrorsToShadow(t *testing.T) {
+	primary, primaryReceived := recordingBackend(t, http.StatusCreated, "primary")
+	shadow, shadowReceived := recordingBackend(t, http.StatusInternalServerError, "shadow")
+
+	routes := []Route{{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true, Shadow: shadow.URL}}
+	gateway := newTestGateway(t, routes, primary.URL)
+
+	const body = `{"first_name":"Ana"}`
+	resp, err := http.Post(gateway.URL+"/api/svedprint/students?school=1", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// The client only ever sees the primary's response, even though the shadow fails
+	if resp.StatusCode != http.StatusCreated || string(got) != "primary" {
+		t.Errorf("client got %d %q, want the primary's 201", resp.StatusCode, got)
+	}
+
+	want := mirroredRequest{http.MethodPost, "/students", "school=1", body, ""}
+	if req := <-primaryReceived; req != want {
+		t.Errorf("primary received %+v, want %+v", req, want)
+	}
+	want.shadowHeader = "true"
+	select {
+	case req := <-shadowReceived:
+		if req != want {
+			t.Errorf("shadow received %+v, want %+v", req, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow never received the mirrored request")
+	}
+}
+
+func TestProxyIgnoresUnreachableShadow(t *testing.T) {
+	primary, _ := recordingBackend(t, http.StatusOK, "primary")
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	routes := []Route{{Name: "svedprint", Prefix: "/api/svedprint", Upstream: "svedprint", StripPrefix: true, Shadow: unreachable.URL}}
+	gateway := newTestGateway(t, routes, primary.URL)
+
+	resp, err := http.Get(gateway.URL + "/api/svedprint/students")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(got) != "primary" {
+		t.Errorf("client got %d %q, want the primary's 200", resp.StatusCode, got)
+	}
+}
+
+func TestCloneBody(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), maxShadowBody+1)
+
+	tests := []struct {
+		name   string
+		body   []byte
+		wantOK bool
+	}{
+		{"small body", []byte(`{"grade":5}`), true},
+		{"body at the limit", large[:maxShadowBody], true},
+		{"body over the limit", large, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			clone, ok := cloneBody(req)
+			if ok != tt.wantOK {
+				t.Fatalf("cloneBody ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !bytes.Equal(clone, tt.body) {
+				t.Errorf("clone has %d bytes, want %d", len(clone), len(tt.body))
+			}
+			// The primary must still read the complete original body
+			restored, err := io.ReadAll(req.Body)
+			if err != nil || !bytes.Equal(restored, tt.body) {
+				t.Errorf("restored body has %d bytes (err %v), want %d", len(restored), err, len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TransportConfig controls how long connections to downstream instances are reused.
+// Pods get new IPs when they are rescheduled, so connections must be recycled for
+// the gateway to notice a service now resolves elsewhere.
+type TransportConfig struct {
+	// ConnMaxLifetime is how long a connection is reused; an older connection serves
+	// one last request with Connection: close. 0 reuses connections indefinitely.
+	ConnMaxLifetime time.Duration
+	// DNSRefreshInterval drops idle connections on this interval, so the next request
+	// dials (and resolves the host) again. 0 disables the refresh.
+	DNSRefreshInterval time.Duration
+}
+
+// Transport is the proxy's round tripper, recycling connections that outlived
+// ConnMaxLifetime and periodically dropping idle ones
+type Transport struct {
+	base *http.Transport
+	cfg  TransportConfig
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTransport clones the default transport, dialing connections that remember their age
+func NewTransport(cfg TransportConfig) *Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &agedConn{Conn: conn, created: time.Now()}, nil
+	}
+
+	return &Transport{base: base, cfg: cfg, stop: make(chan struct{})}
+}
+
+// RoundTrip sends req, asking for the connection to be closed afterwards if it is
+// past its lifetime
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cfg.ConnMaxLifetime <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// The trace fires when the connection is picked, before the request is written,
+	// so Close still turns into a Connection: close header on this request
+	out := req.Clone(req.Context())
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if conn, ok := info.Conn.(*agedConn); ok && time.Since(conn.created) >= t.cfg.ConnMaxLifetime {
+				out.Close = true
+			}
+		},
+	}
+	out = out.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return t.base.RoundTrip(out)
+}
+
+// Start begins dropping idle connections every DNSRefreshInterval until Close
+func (t *Transport) Start() {
+	if t.cfg.DNSRefreshInterval <= 0 {
+		return
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.cfg.DNSRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.base.CloseIdleConnections()
+			}
+		}
+	}()
+}
+
+// Close stops the refresh loop and closes idle connections
+func (t *Transport) Close(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.stop) })
+	t.wg.Wait()
+	t.base.CloseIdleConnections()
+	return nil
+}
+
+// CloseIdleConnections lets http.Client and ReverseProxy callers drop idle connections
+func (t *Transport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// agedConn records when a downstream connection was dialed
+type agedConn struct {
+	net.Conn
+	created time.Time
+}
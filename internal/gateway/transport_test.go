@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// movingBackend is a downstream service whose host name resolves to one of two
+// instances, like a Kubernetes service whose pod was rescheduled. The old instance
+// keeps serving the connections it already has.
+type movingBackend struct {
+	old, moved *httptest.Server
+	addr       atomic.Value
+}
+
+const movingHost = "svedprint.internal:80"
+
+func newMovingBackend(t *testing.T) *movingBackend {
+	t.Helper()
+	serve := func(name string) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+	b := &movingBackend{old: serve("old"), moved: serve("moved")}
+	b.addr.Store(b.old.Listener.Addr().String())
+	return b
+}
+
+// resolveThrough makes tr dial movingHost at the backend's current address
+func (b *movingBackend) resolveThrough(tr *Transport) {
+	dial := tr.base.DialContext
+	tr.base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == movingHost {
+			addr = b.addr.Load().(string)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+func (b *movingBackend) move() {
+	b.addr.Store(b.moved.Listener.Addr().String())
+}
+
+func getHealth(t *testing.T, client *http.Client) string {
+	t.Helper()
+	resp, err := client.Get("http://" + movingHost + "/health")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// reachesMoved reports whether a request reaches the moved instance within a few tries
+func reachesMoved(t *testing.T, client *http.Client) bool {
+	t.Helper()
+	for range 3 {
+		if getHealth(t, client) == "moved" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTransportConnMaxLifetimeFollowsMovedBackend(t *testing.T) {
+	backend := newMovingBackend(t)
+	tr := NewTransport(TransportConfig{ConnMaxLifetime: 100 * time.Millisecond})
+	defer tr.Close(context.Background())
+	backend.resolveThrough(tr)
+	client := &http.Client{Transport: tr}
+
+	if got := getHealth(t, client); got != "old" {
+		t.Fatalf("first request reached %q", got)
+	}
+	backend.move()
+	if got := getHealth(t, client); got != "old" {
+		t.Fatalf("young connection was not reused, reached %q", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if !reachesMoved(t, client) {
+		t.Error("proxy kept using the old instance after the connection's lifetime")
+	}
+}
+
+func TestTransportDNSRefreshFollowsMovedBackend(t *testing.T) {
+	backend := newMovingBackend(t)
+	tr := NewTransport(TransportConfig{DNSRefreshInterval: 50 * time.Millisecond})
+	tr.Start()
+	defer tr.Close(context.Background())
+	backend.resolveThrough(tr)
+	client := &http.Client{Transport: tr}
+
+	if got := getHealth(t, client); got != "old" {
+		t.Fatalf("first request reached %q", got)
+	}
+	backend.move()
+
+	time.Sleep(150 * time.Millisecond)
+	if got := getHealth(t, client); got != "moved" {
+		t.Errorf("request after a DNS refresh reached %q, want the moved instance", got)
+	}
+}
+
+func TestTransportWithoutRecyclingStaysOnOldBackend(t *testing.T) {
+	backend := newMovingBackend(t)
+	tr := NewTransport(TransportConfig{})
+	defer tr.Close(context.Background())
+	backend.resolveThrough(tr)
+	client := &http.Client{Transport: tr}
+
+	getHealth(t, client)
+	backend.move()
+	time.Sleep(150 * time.Millisecond)
+	if reachesMoved(t, client) {
+		t.Error("connection was recycled without a lifetime or DNS refresh configured")
+	}
+}
@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+// maxValidatedBody is the largest request body checked against a schema; bigger
+// bodies on validated paths are rejected
+const maxValidatedBody = 1 << 20
+
+// defaultValidatedMethods are checked when a body validation lists no methods
+var defaultValidatedMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
+// BodyValidation rejects JSON request bodies that don't match a JSON Schema with 400
+// at the gateway, before they reach the downstream. It applies to requests whose
+// incoming path matches PathRegex (every path of the route when empty) and whose
+// method is listed in Methods (POST, PUT and PATCH by default). Exactly one of
+// Schema, an inline schema, or SchemaFile, a JSON or YAML file resolved relative
+// to the route file, must be set.
+type BodyValidation struct {
+	Methods    []string       `yaml:"methods"`
+	PathRegex  string         `yaml:"path_regex"`
+	Schema     map[string]any `yaml:"schema"`
+	SchemaFile string         `yaml:"schema_file"`
+
+	re     *regexp.Regexp
+	schema *jsonSchema
+}
+
+// compile loads the schema and prepares the path regex
+func (bv *BodyValidation) compile() error {
+	if bv.PathRegex != "" {
+		re, err := regexp.Compile(bv.PathRegex)
+		if err != nil {
+			return fmt.Errorf("invalid path_regex: %w", err)
+		}
+		bv.re = re
+	}
+	if len(bv.Methods) == 0 {
+		bv.Methods = slices.Clone(defaultValidatedMethods)
+	}
+	for i, method := range bv.Methods {
+		bv.Methods[i] = strings.ToUpper(method)
+	}
+
+	var data []byte
+	switch {
+	case bv.Schema != nil && bv.SchemaFile != "":
+		return errors.New("sets both schema and schema_file")
+	case bv.SchemaFile != "":
+		raw, err := os.ReadFile(bv.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+		// YAML is a superset of JSON, so both are normalized through the YAML decoder
+		var schema map[string]any
+		if err := yaml.Unmarshal(raw, &schema); err != nil {
+			return fmt.Errorf("failed to parse schema file %s: %w", bv.SchemaFile, err)
+		}
+		if data, err = json.Marshal(schema); err != nil {
+			return fmt.Errorf("failed to parse schema file %s: %w", bv.SchemaFile, err)
+		}
+	case bv.Schema != nil:
+		var err error
+		if data, err = json.Marshal(bv.Schema); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+	default:
+		return errors.New("must set schema or schema_file")
+	}
+
+	schema, err := parseSchema(data)
+	if err != nil {
+		return err
+	}
+	bv.schema = schema
+	return nil
+}
+
+// applies reports whether the validation covers the request
+func (bv *BodyValidation) applies(req *http.Request) bool {
+	if !slices.Contains(bv.Methods, req.Method) {
+		return false
+	}
+	return bv.re == nil || bv.re.MatchString(req.URL.Path)
+}
+
+// validateBody checks the request body against the first body validation of the route
+// that covers the request. It responds and returns false if the body is rejected;
+// otherwise the body is left intact for the proxy.
+func (pr *proxyRoute) validateBody(c *gin.Context) bool {
+	idx := slices.IndexFunc(pr.Validate, func(bv BodyValidation) bool { return bv.applies(c.Request) })
+	if idx < 0 {
+		return true
+	}
+	schema := pr.Validate[idx].schema
+
+	if c.Request.ContentLength > maxValidatedBody {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "request body is too large"))
+		return false
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBody+1))
+		c.Request.Body.Close()
+		if err != nil {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusBadRequest, apierror.CodeMalformedRequest, "failed to read request body"))
+			return false
+		}
+		if len(body) > maxValidatedBody {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "request body is too large"))
+			return false
+		}
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusBadRequest, apierror.CodeMalformedRequest, "request body must be a single JSON document"))
+		return false
+	}
+
+	if problems := schema.validate(value); len(problems) > 0 {
+		apiErr := apierror.NewWithCode(http.StatusBadRequest, apierror.CodeSchemaViolation, "request body does not match the route schema")
+		apiErr.Fields = problems
+		apierror.Respond(c, apiErr)
+		return false
+	}
+	return true
+}
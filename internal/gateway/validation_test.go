@@ -0,0 +1,245 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// gradeSchema is the body of a single grade, as the admin service accepts it
+var gradeSchema = map[string]any{
+	"type":                 "object",
+	"required":             []any{"student_uuid", "value"},
+	"additionalProperties": false,
+	"properties": map[string]any{
+		"student_uuid": map[string]any{"type": "string", "format": "uuid"},
+		"value":        map[string]any{"type": "integer", "minimum": 1, "maximum": 5},
+		"note":         map[string]any{"type": []any{"string", "null"}, "maxLength": 10},
+	},
+}
+
+func TestProxyValidatesRequestBody(t *testing.T) {
+	var forwarded []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = append(forwarded, string(body))
+	}))
+	defer upstream.Close()
+
+	routes := []Route{{
+		Name: "svedprint-admin", Prefix: "/api/admin", Upstream: "svedprint-admin", StripPrefix: true,
+		Validate: []BodyValidation{{PathRegex: `^/api/admin/grades$`, Schema: gradeSchema}},
+	}}
+	gateway := newTestGateway(t, routes, upstream.URL)
+
+	const valid = `{"student_uuid":"0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80","value":4,"note":null}`
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   apierror.Code
+		wantFields []string
+	}{
+		{name: "valid body", method: http.MethodPost, path: "/api/admin/grades", body: valid, wantStatus: http.StatusOK},
+		{name: "schema violations", method: http.MethodPost, path: "/api/admin/grades", body: `{"value":7,"note":"far too long","school":"x"}`,
+			wantStatus: http.StatusBadRequest, wantCode: apierror.CodeSchemaViolation, wantFields: []string{"student_uuid", "note", "school", "value"}},
+		{name: "wrong type", method: http.MethodPut, path: "/api/admin/grades", body: `[]`,
+			wantStatus: http.StatusBadRequest, wantCode: apierror.CodeSchemaViolation, wantFields: []string{"body"}},
+		{name: "malformed JSON", method: http.MethodPatch, path: "/api/admin/grades", body: `{"value":`,
+			wantStatus: http.StatusBadRequest, wantCode: apierror.CodeMalformedRequest},
+		{name: "trailing document", method: http.MethodPost, path: "/api/admin/grades", body: valid + valid,
+			wantStatus: http.StatusBadRequest, wantCode: apierror.CodeMalformedRequest},
+		{name: "too large", method: http.MethodPost, path: "/api/admin/grades", body: `"` + strings.Repeat("a", maxValidatedBody) + `"`,
+			wantStatus: http.StatusRequestEntityTooLarge, wantCode: apierror.CodePayloadTooLarge},
+		{name: "method not validated", method: http.MethodDelete, path: "/api/admin/grades", body: `not json`, wantStatus: http.StatusOK},
+		{name: "path not validated", method: http.MethodPost, path: "/api/admin/grades/batch", body: `not json`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded = nil
+			req, _ := http.NewRequest(tt.method, gateway.URL+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.method, err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				// The downstream gets the body exactly as sent
+				if len(forwarded) != 1 || forwarded[0] != tt.body {
+					t.Errorf("forwarded bodies = %q, want the original body", forwarded)
+				}
+				return
+			}
+			if len(forwarded) != 0 {
+				t.Errorf("rejected body reached the downstream: %q", forwarded)
+			}
+
+			var body apierror.Error
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+			fields := make([]string, len(body.Fields))
+			for i, f := range body.Fields {
+				fields[i] = f.Field
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"class": {"enum": ["VI-1", "VI-2"]},
+			"date": {"type": "string", "format": "date"},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+			"grades": {
+				"type": "array",
+				"minItems": 1,
+				"items": {"type": "object", "properties": {"value": {"type": "integer"}}}
+			},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseSchema: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want []apierror.FieldError
+	}{
+		{name: "valid", body: `{"class":"VI-2","date":"2026-10-01","code":"MAT","grades":[{"value":5}],"labels":{"en":"Maths"}}`},
+		{name: "enum", body: `{"class":"IX-1"}`, want: []apierror.FieldError{{Field: "class", Rule: "enum"}}},
+		{name: "format", body: `{"date":"01.10.2026"}`, want: []apierror.FieldError{{Field: "date", Rule: "format"}}},
+		{name: "pattern", body: `{"code":"mat"}`, want: []apierror.FieldError{{Field: "code", Rule: "pattern"}}},
+		{name: "array items", body: `{"grades":[{"value":5},{"value":4.5}]}`, want: []apierror.FieldError{{Field: "grades[1].value", Rule: "type"}}},
+		{name: "min items", body: `{"grades":[]}`, want: []apierror.FieldError{{Field: "grades", Rule: "minItems"}}},
+		{name: "additional properties schema", body: `{"labels":{"en":"Maths","mk":1}}`, want: []apierror.FieldError{{Field: "labels.mk", Rule: "type"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(tt.body), &value); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			got := schema.validate(value)
+			if len(got) != len(tt.want) {
+				t.Fatalf("problems = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Field != want.Field || got[i].Rule != want.Rule || got[i].Message == "" {
+					t.Errorf("problems[%d] = %+v, want %s (%s)", i, got[i], want.Field, want.Rule)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaValidateCapsProblems(t *testing.T) {
+	schema, err := parseSchema([]byte(`{"type": "array", "items": {"type": "string"}}`))
+	if err != nil {
+		t.Fatalf("parseSchema: %v", err)
+	}
+	items := make([]any, 3*maxSchemaErrors)
+	if got := schema.validate(items); len(got) != maxSchemaErrors {
+		t.Errorf("%d problems, want %d", len(got), maxSchemaErrors)
+	}
+}
+
+func TestBodyValidationCompile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "grade.yaml"), []byte("type: object\nrequired: [value]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		bv      BodyValidation
+		wantErr string
+	}{
+		{name: "inline schema", bv: BodyValidation{Schema: gradeSchema}},
+		{name: "schema file", bv: BodyValidation{SchemaFile: filepath.Join(dir, "grade.yaml")}},
+		{name: "no schema", bv: BodyValidation{}, wantErr: "must set schema or schema_file"},
+		{name: "both schemas", bv: BodyValidation{Schema: gradeSchema, SchemaFile: filepath.Join(dir, "grade.yaml")}, wantErr: "sets both"},
+		{name: "missing schema file", bv: BodyValidation{SchemaFile: filepath.Join(dir, "missing.yaml")}, wantErr: "failed to read schema file"},
+		{name: "invalid path regex", bv: BodyValidation{PathRegex: "(", Schema: gradeSchema}, wantErr: "invalid path_regex"},
+		{name: "unknown type", bv: BodyValidation{Schema: map[string]any{"type": "date"}}, wantErr: `unknown type "date"`},
+		{name: "$ref", bv: BodyValidation{Schema: map[string]any{"properties": map[string]any{"grade": map[string]any{"$ref": "#/$defs/grade"}}}}, wantErr: `unsupported keyword "$ref" at #/properties/grade`},
+		{name: "combinator", bv: BodyValidation{Schema: map[string]any{"items": map[string]any{"oneOf": []any{}}}}, wantErr: `unsupported keyword "oneOf" at #/items`},
+		{name: "annotations", bv: BodyValidation{Schema: map[string]any{"title": "Grade", "description": "A grade entry", "type": "object"}}},
+		{name: "invalid pattern", bv: BodyValidation{Schema: map[string]any{"properties": map[string]any{"code": map[string]any{"pattern": "("}}}}, wantErr: "pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.bv.compile()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("compile: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRoutesResolvesSchemaFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "schemas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "schemas", "grade.json"), []byte(`{"type": "object"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routeFile := filepath.Join(dir, "routes.yaml")
+	err := os.WriteFile(routeFile, []byte(`routes:
+  - name: svedprint-admin
+    prefix: /api/admin
+    upstream: svedprint-admin
+    validate:
+      - methods: [post]
+        schema_file: schemas/grade.json
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, err := LoadRoutes(routeFile)
+	if err != nil {
+		t.Fatalf("LoadRoutes: %v", err)
+	}
+	bv := &routes[0].Validate[0]
+	if want := filepath.Join(dir, "schemas", "grade.json"); bv.SchemaFile != want {
+		t.Errorf("schema file = %s, want %s", bv.SchemaFile, want)
+	}
+	if err := bv.compile(); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if len(bv.Methods) != 1 || bv.Methods[0] != http.MethodPost {
+		t.Errorf("methods = %v, want [POST]", bv.Methods)
+	}
+}
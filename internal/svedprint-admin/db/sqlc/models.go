@@ -55,6 +55,48 @@ func (ns NullAcademicLevel) Value() (driver.Value, error) {
 	return string(ns.AcademicLevel), nil
 }
 
+type GradingScheme string
+
+const (
+	GradingSchemeNumeric     GradingScheme = "numeric"
+	GradingSchemeDescriptive GradingScheme = "descriptive"
+)
+
+func (e *GradingScheme) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GradingScheme(s)
+	case string:
+		*e = GradingScheme(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GradingScheme: %T", src)
+	}
+	return nil
+}
+
+type NullGradingScheme struct {
+	GradingScheme GradingScheme
+	Valid         bool // Valid is true if GradingScheme is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGradingScheme) Scan(value interface{}) error {
+	if value == nil {
+		ns.GradingScheme, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GradingScheme.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGradingScheme) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.GradingScheme), nil
+}
+
 type MigrationStatus string
 
 const (
@@ -248,6 +290,13 @@ type DataMigration struct {
 	RecordsFailed    pgtype.Int4
 }
 
+type GradeDescriptor struct {
+	Uuid        pgtype.UUID
+	SubjectUuid pgtype.UUID
+	Descriptor  string
+	Passing     bool
+}
+
 type School struct {
 	Uuid           pgtype.UUID
 	SchoolName     string
@@ -286,6 +335,13 @@ type Subject struct {
 	FullName      pgtype.Text
 	AcademicLevel AcademicLevel
 	SchoolUuid    pgtype.UUID
+	GradingScheme GradingScheme
+	MinGrade      int32
+	MaxGrade      int32
+	PassThreshold int32
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	Version       int64
 }
 
 type SubjectPackage struct {
@@ -312,3 +368,14 @@ type Teacher struct {
 	Password     string
 	PrintAllowed bool
 }
+
+type WebhookDeadLetter struct {
+	Uuid      pgtype.UUID
+	EventID   string
+	EventType string
+	Endpoint  string
+	Payload   []byte
+	Attempts  int32
+	LastError string
+	CreatedAt pgtype.Timestamptz
+}
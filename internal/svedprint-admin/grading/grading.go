@@ -0,0 +1,147 @@
+package grading
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// Scheme determines how grades for a subject are expressed
+type Scheme string
+
+const (
+	SchemeNumeric     Scheme = "numeric"
+	SchemeDescriptive Scheme = "descriptive"
+)
+
+// Descriptor is an allowed grade for a descriptively graded subject (e.g. "completed")
+type Descriptor struct {
+	Value   string
+	Passing bool
+}
+
+// SubjectGrading is the grading configuration of a single subject
+type SubjectGrading struct {
+	SubjectUUID   string
+	Scheme        Scheme
+	MinGrade      int
+	MaxGrade      int
+	PassThreshold int
+	Descriptors   []Descriptor
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Version       int64
+}
+
+// Grade is a grade entry for a subject; exactly one of Numeric or Descriptor is set
+// depending on the subject's scheme
+type Grade struct {
+	Numeric    *int
+	Descriptor string
+}
+
+// ValidateConfig checks that the grading configuration itself is consistent
+func (g *SubjectGrading) ValidateConfig() error {
+	var fields []apierror.FieldError
+
+	switch g.Scheme {
+	case SchemeNumeric:
+		if g.MinGrade > g.MaxGrade {
+			fields = append(fields, apierror.Field("min_grade", "lte_max", "must not exceed max_grade"))
+		}
+		if g.PassThreshold < g.MinGrade || g.PassThreshold > g.MaxGrade {
+			fields = append(fields, apierror.Field("pass_threshold", "range",
+				fmt.Sprintf("must be between %d and %d", g.MinGrade, g.MaxGrade)))
+		}
+		if len(g.Descriptors) > 0 {
+			fields = append(fields, apierror.Field("descriptors", "numeric_scheme", "must be empty for numeric subjects"))
+		}
+	case SchemeDescriptive:
+		if len(g.Descriptors) == 0 {
+			fields = append(fields, apierror.Field("descriptors", "required", "descriptive subjects need at least one descriptor"))
+		}
+		seen := make(map[string]bool, len(g.Descriptors))
+		for _, d := range g.Descriptors {
+			if d.Value == "" {
+				fields = append(fields, apierror.Field("descriptors", "required", "descriptor value must not be empty"))
+				continue
+			}
+			if seen[d.Value] {
+				fields = append(fields, apierror.Field("descriptors", "unique", fmt.Sprintf("descriptor %q is duplicated", d.Value)))
+			}
+			seen[d.Value] = true
+		}
+	default:
+		fields = append(fields, apierror.Field("scheme", "oneof", "must be one of [numeric descriptive]"))
+	}
+
+	if len(fields) > 0 {
+		return apierror.NewValidationError(fields...)
+	}
+	return nil
+}
+
+// Validate checks a grade against the subject's scheme
+func (g *SubjectGrading) Validate(grade Grade) error {
+	switch g.Scheme {
+	case SchemeNumeric:
+		if grade.Descriptor != "" {
+			return apierror.WithCode(apierror.NewValidationError(apierror.Field("grade", "numeric_scheme", "subject is graded numerically")),
+				apierror.CodeGradeSchemeMismatch)
+		}
+		if grade.Numeric == nil {
+			return apierror.WithCode(apierror.NewValidationError(apierror.Field("grade", "required", "is required")),
+				apierror.CodeGradeRequired)
+		}
+		if *grade.Numeric < g.MinGrade || *grade.Numeric > g.MaxGrade {
+			return apierror.WithCode(apierror.NewValidationError(apierror.Field("grade", "range",
+				fmt.Sprintf("must be between %d and %d", g.MinGrade, g.MaxGrade))),
+				apierror.CodeGradeOutOfRange)
+		}
+	case SchemeDescriptive:
+		if grade.Numeric != nil {
+			return apierror.WithCode(apierror.NewValidationError(apierror.Field("grade", "descriptive_scheme", "subject is graded descriptively")),
+				apierror.CodeGradeSchemeMismatch)
+		}
+		if _, ok := g.descriptor(grade.Descriptor); !ok {
+			return apierror.WithCode(apierror.NewValidationError(apierror.Field("grade", "descriptor",
+				fmt.Sprintf("%q is not a descriptor for this subject", grade.Descriptor))),
+				apierror.CodeUnknownGradeDescriptor)
+		}
+	default:
+		return fmt.Errorf("subject %s has unknown grading scheme %q", g.SubjectUUID, g.Scheme)
+	}
+
+	return nil
+}
+
+// Passed reports whether a valid grade meets the subject's pass criteria
+func (g *SubjectGrading) Passed(grade Grade) bool {
+	if g.Scheme == SchemeNumeric {
+		return grade.Numeric != nil && *grade.Numeric >= g.PassThreshold
+	}
+	d, ok := g.descriptor(grade.Descriptor)
+	return ok && d.Passing
+}
+
+// Format renders a grade the way it should appear on a printed certificate
+func (g *SubjectGrading) Format(grade Grade) string {
+	if g.Scheme == SchemeNumeric {
+		if grade.Numeric == nil {
+			return ""
+		}
+		return strconv.Itoa(*grade.Numeric)
+	}
+	return grade.Descriptor
+}
+
+func (g *SubjectGrading) descriptor(value string) (Descriptor, bool) {
+	for _, d := range g.Descriptors {
+		if d.Value == value {
+			return d, true
+		}
+	}
+	return Descriptor{}, false
+}
@@ -0,0 +1,63 @@
+package grading
+
+import (
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+	"github.com/PegasusMKD/svedprint-go/pkg/numeric"
+)
+
+type DescriptorDTO struct {
+	Value   string `json:"value" binding:"required"`
+	Passing bool   `json:"passing"`
+}
+
+type SubjectGradingDTO struct {
+	dto.Base
+	SubjectUUID   string          `json:"subject_uuid"`
+	Scheme        string          `json:"scheme"`
+	MinGrade      int             `json:"min_grade,omitempty"`
+	MaxGrade      int             `json:"max_grade,omitempty"`
+	PassThreshold int             `json:"pass_threshold,omitempty"`
+	Descriptors   []DescriptorDTO `json:"descriptors,omitempty"`
+}
+
+type UpdateSubjectGradingRequest struct {
+	Scheme        string          `json:"scheme" binding:"required,oneof=numeric descriptive"`
+	MinGrade      int             `json:"min_grade"`
+	MaxGrade      int             `json:"max_grade"`
+	PassThreshold int             `json:"pass_threshold"`
+	Descriptors   []DescriptorDTO `json:"descriptors" binding:"dive"`
+}
+
+type ValidateGradeRequest struct {
+	// ClassUUID names the class the grade is entered for; inside a batch it may be
+	// left out to use the batch's class
+	ClassUUID  string         `json:"class_uuid" binding:"required"`
+	Grade      *numeric.Grade `json:"grade"`
+	Descriptor string         `json:"descriptor"`
+}
+
+type ValidateGradeResponse struct {
+	Valid     bool   `json:"valid"`
+	Passed    bool   `json:"passed"`
+	Formatted string `json:"formatted"`
+}
+
+type ValidateGradeBatchRequest struct {
+	// ClassUUID names the class the batch's grades are entered for, unless an entry
+	// names its own
+	ClassUUID string                 `json:"class_uuid" binding:"required"`
+	Grades    []ValidateGradeRequest `json:"grades" binding:"required,min=1"`
+}
+
+type GradeResultDTO struct {
+	Valid     bool          `json:"valid"`
+	Passed    bool          `json:"passed"`
+	Formatted string        `json:"formatted,omitempty"`
+	Code      apierror.Code `json:"code,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type ValidateGradeBatchResponse struct {
+	Results []GradeResultDTO `json:"results"`
+}
@@ -0,0 +1,175 @@
+package grading
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+	"github.com/PegasusMKD/svedprint-go/pkg/patch"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type GradingHandler struct {
+	service       *GradingService
+	maxBatchItems int
+}
+
+func NewGradingHandler(service *GradingService, maxBatchItems int) *GradingHandler {
+	return &GradingHandler{service: service, maxBatchItems: maxBatchItems}
+}
+
+// RegisterRoutes registers the grading endpoints under a subject router group
+func (h *GradingHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:uuid/grading", h.GetSubjectGrading)
+	rg.PUT("/:uuid/grading", h.UpdateSubjectGrading)
+	rg.PATCH("/:uuid/grading", h.PatchSubjectGrading)
+	rg.POST("/:uuid/grading/validate", h.ValidateGrade)
+	rg.POST("/:uuid/grading/validate/batch", h.ValidateGradeBatch)
+}
+
+func (h *GradingHandler) GetSubjectGrading(c *gin.Context) {
+	grading, err := h.service.GetSubjectGrading(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	out := SubjectGradingToDTO(grading)
+	dto.SetLastModified(c, out.Base)
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *GradingHandler) UpdateSubjectGrading(c *gin.Context) {
+	var req UpdateSubjectGradingRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	grading, err := h.service.UpdateSubjectGrading(c.Request.Context(), UpdateRequestToSubjectGrading(c.Param("uuid"), &req))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	out := SubjectGradingToDTO(grading)
+	dto.SetLastModified(c, out.Base)
+	c.JSON(http.StatusOK, out)
+}
+
+// PatchSubjectGrading accepts either a JSON Patch or a JSON Merge Patch against the
+// PUT representation, validating the patched document before it is stored
+func (h *GradingHandler) PatchSubjectGrading(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	current, err := h.service.GetSubjectGrading(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	doc, err := json.Marshal(SubjectGradingToUpdateRequest(current))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	patched, err := patch.Apply(c.GetHeader("Content-Type"), doc, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, patch.ErrUnsupportedMediaType):
+			apierror.Respond(c, apierror.New(http.StatusUnsupportedMediaType, err.Error()))
+		case errors.Is(err, patch.ErrInvalidPatch):
+			apierror.Respond(c, apierror.NewWithCode(http.StatusUnprocessableEntity, apierror.CodeInvalidPatch, err.Error()))
+		default:
+			apierror.Respond(c, err)
+		}
+		return
+	}
+
+	var req UpdateSubjectGradingRequest
+	if err := json.Unmarshal(patched, &req); err != nil {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusUnprocessableEntity, apierror.CodeInvalidPatch, "patched document does not match the grading schema"))
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	grading, err := h.service.UpdateSubjectGrading(c.Request.Context(), UpdateRequestToSubjectGrading(c.Param("uuid"), &req))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	out := SubjectGradingToDTO(grading)
+	dto.SetLastModified(c, out.Base)
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *GradingHandler) ValidateGrade(c *gin.Context) {
+	var req ValidateGradeRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	grade := ValidateRequestToGrade(&req)
+	grading, passed, err := h.service.ValidateGrade(c.Request.Context(), req.ClassUUID, c.Param("uuid"), grade)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ValidateGradeResponse{Valid: true, Passed: passed, Formatted: grading.Format(grade)})
+}
+
+// ValidateGradeBatch checks many grade entries against one subject, reporting a result
+// per entry in request order. Entries for a class the subject is not taught in fail.
+// Batches over the configured size are rejected with 413 before the subject is loaded.
+func (h *GradingHandler) ValidateGradeBatch(c *gin.Context) {
+	var req ValidateGradeBatchRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+	if h.maxBatchItems > 0 && len(req.Grades) > h.maxBatchItems {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusRequestEntityTooLarge, apierror.CodeBatchTooLarge,
+			fmt.Sprintf("batch of %d grades exceeds the limit of %d", len(req.Grades), h.maxBatchItems)))
+		return
+	}
+
+	grading, err := h.service.GetSubjectGrading(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	// Entries default to the batch's class; each class is checked once
+	classChecks := make(map[string]error)
+	results := make([]GradeResultDTO, len(req.Grades))
+	for i := range req.Grades {
+		classUUID := cmp.Or(req.Grades[i].ClassUUID, req.ClassUUID)
+		classErr, checked := classChecks[classUUID]
+		if !checked {
+			classErr = h.service.CheckClassSubject(c.Request.Context(), classUUID, c.Param("uuid"))
+			classChecks[classUUID] = classErr
+		}
+		if classErr != nil {
+			results[i] = GradeResultDTO{Code: apierror.Map(classErr).Code, Error: classErr.Error()}
+			continue
+		}
+
+		grade := ValidateRequestToGrade(&req.Grades[i])
+		if err := grading.Validate(grade); err != nil {
+			results[i] = GradeResultDTO{Code: apierror.Map(err).Code, Error: err.Error()}
+			continue
+		}
+		results[i] = GradeResultDTO{Valid: true, Passed: grading.Passed(grade), Formatted: grading.Format(grade)}
+	}
+	c.JSON(http.StatusOK, ValidateGradeBatchResponse{Results: results})
+}
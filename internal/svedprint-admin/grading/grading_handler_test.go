@@ -0,0 +1,346 @@
+package grading
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const testSubjectUUID = "8d2c7e1a-4b5f-4c3d-9e8f-1a2b3c4d5e6f"
+
+var (
+	testCreatedAt = time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+	testUpdatedAt = time.Date(2026, 10, 2, 13, 30, 15, 0, time.UTC)
+)
+
+// gradingDB serves a numerically graded subject (1 to 5, passing from 2) and counts
+// how often it was loaded
+type gradingDB struct {
+	subjectLoads int
+}
+
+func (db *gradingDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	db.subjectLoads++
+	return rowFunc(func(dest ...any) error {
+		*dest[5].(*sqlc.GradingScheme) = sqlc.GradingSchemeNumeric
+		*dest[6].(*int32) = 1
+		*dest[7].(*int32) = 5
+		*dest[8].(*int32) = 2
+		*dest[9].(*pgtype.Timestamptz) = pgtype.Timestamptz{Time: testCreatedAt, Valid: true}
+		*dest[10].(*pgtype.Timestamptz) = pgtype.Timestamptz{Time: testUpdatedAt, Valid: true}
+		*dest[11].(*int64) = 4
+		return nil
+	})
+}
+
+func (db *gradingDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return emptyRows{}, nil
+}
+
+func (db *gradingDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	panic("unexpected Exec")
+}
+
+func (db *gradingDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	panic("unexpected CopyFrom")
+}
+
+type rowFunc func(dest ...any) error
+
+func (f rowFunc) Scan(dest ...any) error { return f(dest...) }
+
+type emptyRows struct{ pgx.Rows }
+
+func (emptyRows) Next() bool { return false }
+func (emptyRows) Err() error { return nil }
+func (emptyRows) Close()     {}
+
+func TestGetSubjectGradingMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := NewGradingService(NewGradingRepository(nil, sqlc.New(&gradingDB{})), nil)
+	router := gin.New()
+	NewGradingHandler(service, 0).RegisterRoutes(router.Group("/subjects"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/subjects/"+testSubjectUUID+"/grading", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got, want := rec.Header().Get("Last-Modified"), testUpdatedAt.Format(http.TimeFormat); got != want {
+		t.Errorf("Last-Modified = %q, want %q", got, want)
+	}
+
+	var out SubjectGradingDTO
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !out.CreatedAt.Equal(testCreatedAt) || !out.UpdatedAt.Equal(testUpdatedAt) || out.Version != 4 {
+		t.Errorf("metadata = %+v, want created %v, updated %v, version 4", out.Base, testCreatedAt, testUpdatedAt)
+	}
+}
+
+// gradeBatch builds a batch of n passing grades
+func gradeBatch(n int) string {
+	grades := make([]string, n)
+	for i := range grades {
+		grades[i] = fmt.Sprintf(`{"grade":%d}`, 2+i%4)
+	}
+	return fmt.Sprintf(`{"class_uuid":%q,"grades":[%s]}`, testClassUUID, strings.Join(grades, ","))
+}
+
+func TestValidateGradeBatchLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const limit = 3
+
+	tests := []struct {
+		name        string
+		body        string
+		maxItems    int
+		wantStatus  int
+		wantCode    string
+		wantResults int
+	}{
+		{name: "under the limit", body: gradeBatch(limit - 1), maxItems: limit, wantStatus: http.StatusOK, wantResults: limit - 1},
+		{name: "at the limit", body: gradeBatch(limit), maxItems: limit, wantStatus: http.StatusOK, wantResults: limit},
+		{name: "over the limit", body: gradeBatch(limit + 1), maxItems: limit, wantStatus: http.StatusRequestEntityTooLarge, wantCode: "BATCH_TOO_LARGE"},
+		{name: "no limit", body: gradeBatch(limit + 1), maxItems: 0, wantStatus: http.StatusOK, wantResults: limit + 1},
+		{name: "empty batch", body: fmt.Sprintf(`{"class_uuid":%q,"grades":[]}`, testClassUUID), maxItems: limit, wantStatus: http.StatusUnprocessableEntity},
+		{name: "no class", body: `{"grades":[{"grade":3}]}`, maxItems: limit, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &enrollmentDB{taught: map[string]bool{testClassUUID: true}}
+			service := NewGradingService(NewGradingRepository(nil, sqlc.New(db)), nil)
+			router := gin.New()
+			NewGradingHandler(service, tt.maxItems).RegisterRoutes(router.Group("/subjects"))
+
+			req := httptest.NewRequest(http.MethodPost, "/subjects/"+testSubjectUUID+"/grading/validate/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if db.subjectLoads != 0 {
+					t.Errorf("rejected batch loaded the subject %d times", db.subjectLoads)
+				}
+				if tt.wantCode != "" && !strings.Contains(rec.Body.String(), tt.wantCode) {
+					t.Errorf("body = %s, want code %s", rec.Body, tt.wantCode)
+				}
+				return
+			}
+
+			var resp ValidateGradeBatchResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Results) != tt.wantResults {
+				t.Fatalf("%d results, want %d", len(resp.Results), tt.wantResults)
+			}
+			for i, result := range resp.Results {
+				if !result.Valid || !result.Passed {
+					t.Errorf("result %d = %+v, want a valid passing grade", i, result)
+				}
+			}
+		})
+	}
+}
+
+const (
+	testClassUUID  = "3e4f5a6b-7c8d-4e9f-8a1b-2c3d4e5f6a7b"
+	otherClassUUID = "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	unknownClass   = "0f1e2d3c-4b5a-4968-8776-655443322110"
+)
+
+// enrollmentDB is a gradingDB whose classes take the subject as listed in taught;
+// classes missing from it do not exist
+type enrollmentDB struct {
+	gradingDB
+	taught  map[string]bool
+	checked []string
+}
+
+func (db *enrollmentDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if !strings.Contains(sql, "GetClassSubjectEnrollment") {
+		return db.gradingDB.QueryRow(ctx, sql, args...)
+	}
+	class := args[1].(pgtype.UUID)
+	db.checked = append(db.checked, class.String())
+	return rowFunc(func(dest ...any) error {
+		taught, ok := db.taught[class.String()]
+		if !ok {
+			return pgx.ErrNoRows
+		}
+		*dest[0].(*pgtype.UUID) = class
+		*dest[1].(*bool) = taught
+		return nil
+	})
+}
+
+func newEnrollmentRouter(db *enrollmentDB) *gin.Engine {
+	service := NewGradingService(NewGradingRepository(nil, sqlc.New(db)), nil)
+	router := gin.New()
+	NewGradingHandler(service, 0).RegisterRoutes(router.Group("/subjects"))
+	return router
+}
+
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestValidateGradeClassSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		class       string
+		wantStatus  int
+		wantField   string
+		wantChecked bool
+	}{
+		{name: "enrolled subject", class: testClassUUID, wantStatus: http.StatusOK, wantChecked: true},
+		{name: "subject not taught in the class", class: otherClassUUID, wantStatus: http.StatusUnprocessableEntity, wantField: "subject_uuid", wantChecked: true},
+		{name: "unknown class", class: unknownClass, wantStatus: http.StatusNotFound, wantChecked: true},
+		{name: "malformed class", class: "class-1", wantStatus: http.StatusBadRequest},
+		{name: "no class", class: "", wantStatus: http.StatusUnprocessableEntity, wantField: "ClassUUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &enrollmentDB{taught: map[string]bool{testClassUUID: true, otherClassUUID: false}}
+			router := newEnrollmentRouter(db)
+
+			rec := postJSON(router, "/subjects/"+testSubjectUUID+"/grading/validate",
+				fmt.Sprintf(`{"class_uuid":%q,"grade":4}`, tt.class))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if checked := len(db.checked) > 0; checked != tt.wantChecked {
+				t.Errorf("enrollment checked = %v, want %v", checked, tt.wantChecked)
+			}
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				body := rec.Body.String()
+				if !strings.Contains(body, `"`+tt.wantField+`"`) {
+					t.Errorf("body = %s, want a %s error", body, tt.wantField)
+				}
+				if db.subjectLoads != 0 {
+					t.Errorf("rejected entry loaded the subject %d times", db.subjectLoads)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGradeBatchClassSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &enrollmentDB{taught: map[string]bool{testClassUUID: true, otherClassUUID: false}}
+	router := newEnrollmentRouter(db)
+
+	// Entries default to the batch's class and may name their own
+	body := fmt.Sprintf(`{"class_uuid":%q,"grades":[{"grade":3},{"grade":4,"class_uuid":%q},{"grade":5}]}`,
+		testClassUUID, otherClassUUID)
+	rec := postJSON(router, "/subjects/"+testSubjectUUID+"/grading/validate/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp ValidateGradeBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("%d results, want 3", len(resp.Results))
+	}
+	for _, i := range []int{0, 2} {
+		if !resp.Results[i].Valid || resp.Results[i].Error != "" {
+			t.Errorf("result %d = %+v, want a valid grade", i, resp.Results[i])
+		}
+	}
+	if resp.Results[1].Valid || resp.Results[1].Error == "" {
+		t.Errorf("result 1 = %+v, want the class mismatch reported", resp.Results[1])
+	}
+	if len(db.checked) != 2 {
+		t.Errorf("enrollment checked %d times (%v), want once per class", len(db.checked), db.checked)
+	}
+}
+
+func TestValidateGradeErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{name: "out of range", body: fmt.Sprintf(`{"class_uuid":%q,"grade":7}`, testClassUUID), wantStatus: http.StatusUnprocessableEntity, wantCode: apierror.CodeGradeOutOfRange},
+		{name: "missing grade", body: fmt.Sprintf(`{"class_uuid":%q}`, testClassUUID), wantStatus: http.StatusUnprocessableEntity, wantCode: apierror.CodeGradeRequired},
+		{name: "descriptor on a numeric subject", body: fmt.Sprintf(`{"class_uuid":%q,"descriptor":"excellent"}`, testClassUUID), wantStatus: http.StatusUnprocessableEntity, wantCode: apierror.CodeGradeSchemeMismatch},
+		{name: "subject not in class", body: fmt.Sprintf(`{"class_uuid":%q,"grade":4}`, otherClassUUID), wantStatus: http.StatusUnprocessableEntity, wantCode: apierror.CodeSubjectNotInClass},
+		{name: "unknown class", body: fmt.Sprintf(`{"class_uuid":%q,"grade":4}`, unknownClass), wantStatus: http.StatusNotFound, wantCode: apierror.CodeClassNotFound},
+		{name: "malformed class", body: `{"class_uuid":"class-1","grade":4}`, wantStatus: http.StatusBadRequest, wantCode: apierror.CodeInvalidInput},
+		{name: "missing class", body: `{"grade":4}`, wantStatus: http.StatusUnprocessableEntity, wantCode: apierror.CodeValidationFailed},
+		{name: "malformed body", body: `{"grade":`, wantStatus: http.StatusBadRequest, wantCode: apierror.CodeMalformedRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newEnrollmentRouter(&enrollmentDB{taught: map[string]bool{testClassUUID: true, otherClassUUID: false}})
+
+			rec := postJSON(router, "/subjects/"+testSubjectUUID+"/grading/validate", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body apierror.Error
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateGradeBatchResultCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newEnrollmentRouter(&enrollmentDB{taught: map[string]bool{testClassUUID: true, otherClassUUID: false}})
+
+	body := fmt.Sprintf(`{"class_uuid":%q,"grades":[{"grade":4},{"grade":0},{"descriptor":"good"},{"grade":3,"class_uuid":%q}]}`,
+		testClassUUID, otherClassUUID)
+	rec := postJSON(router, "/subjects/"+testSubjectUUID+"/grading/validate/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp ValidateGradeBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []apierror.Code{"", apierror.CodeGradeOutOfRange, apierror.CodeGradeSchemeMismatch, apierror.CodeSubjectNotInClass}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("%d results, want %d", len(resp.Results), len(want))
+	}
+	for i, code := range want {
+		if resp.Results[i].Code != code {
+			t.Errorf("result %d code = %q, want %q", i, resp.Results[i].Code, code)
+		}
+	}
+}
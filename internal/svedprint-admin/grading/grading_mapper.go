@@ -0,0 +1,62 @@
+package grading
+
+import "github.com/PegasusMKD/svedprint-go/pkg/dto"
+
+func SubjectGradingToDTO(g *SubjectGrading) *SubjectGradingDTO {
+	out := &SubjectGradingDTO{
+		Base:        dto.NewBase(g.CreatedAt, g.UpdatedAt, g.Version),
+		SubjectUUID: g.SubjectUUID,
+		Scheme:      string(g.Scheme),
+	}
+	if g.Scheme == SchemeNumeric {
+		out.MinGrade = g.MinGrade
+		out.MaxGrade = g.MaxGrade
+		out.PassThreshold = g.PassThreshold
+	}
+	for _, d := range g.Descriptors {
+		out.Descriptors = append(out.Descriptors, DescriptorDTO{Value: d.Value, Passing: d.Passing})
+	}
+	return out
+}
+
+func UpdateRequestToSubjectGrading(subjectUUID string, req *UpdateSubjectGradingRequest) *SubjectGrading {
+	g := &SubjectGrading{
+		SubjectUUID:   subjectUUID,
+		Scheme:        Scheme(req.Scheme),
+		MinGrade:      req.MinGrade,
+		MaxGrade:      req.MaxGrade,
+		PassThreshold: req.PassThreshold,
+	}
+	if g.Scheme == SchemeDescriptive {
+		// Numeric bounds are meaningless for descriptive subjects; keep the column defaults
+		g.MinGrade, g.MaxGrade, g.PassThreshold = 1, 5, 2
+	}
+	for _, d := range req.Descriptors {
+		g.Descriptors = append(g.Descriptors, Descriptor{Value: d.Value, Passing: d.Passing})
+	}
+	return g
+}
+
+// SubjectGradingToUpdateRequest is the document PATCH requests are applied to
+func SubjectGradingToUpdateRequest(g *SubjectGrading) *UpdateSubjectGradingRequest {
+	req := &UpdateSubjectGradingRequest{
+		Scheme:        string(g.Scheme),
+		MinGrade:      g.MinGrade,
+		MaxGrade:      g.MaxGrade,
+		PassThreshold: g.PassThreshold,
+		Descriptors:   []DescriptorDTO{},
+	}
+	for _, d := range g.Descriptors {
+		req.Descriptors = append(req.Descriptors, DescriptorDTO{Value: d.Value, Passing: d.Passing})
+	}
+	return req
+}
+
+func ValidateRequestToGrade(req *ValidateGradeRequest) Grade {
+	grade := Grade{Descriptor: req.Descriptor}
+	if req.Grade != nil {
+		numeric := int(*req.Grade)
+		grade.Numeric = &numeric
+	}
+	return grade
+}
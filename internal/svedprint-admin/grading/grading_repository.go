@@ -0,0 +1,150 @@
+package grading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type GradingRepository struct {
+	db      database.TxBeginner
+	queries *sqlc.Queries
+}
+
+func NewGradingRepository(db database.TxBeginner, queries *sqlc.Queries) *GradingRepository {
+	return &GradingRepository{db: db, queries: queries}
+}
+
+// GetBySubject loads the grading configuration of a subject
+func (r *GradingRepository) GetBySubject(ctx context.Context, subjectUUID string) (*SubjectGrading, error) {
+	pgUUID, err := parseSubjectUUID(subjectUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := r.queries.GetSubjectByUuid(ctx, pgUUID)
+	if err != nil {
+		return nil, subjectError(subjectUUID, err)
+	}
+
+	descriptors, err := r.queries.ListGradeDescriptorsBySubject(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grade descriptors: %w", err)
+	}
+
+	return fromSQLC(subject, descriptors), nil
+}
+
+// Update replaces the grading configuration of a subject, including its descriptors
+func (r *GradingRepository) Update(ctx context.Context, grading *SubjectGrading) (*SubjectGrading, error) {
+	pgUUID, err := parseSubjectUUID(grading.SubjectUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+
+	subject, err := qtx.UpdateSubjectGrading(ctx, sqlc.UpdateSubjectGradingParams{
+		GradingScheme: sqlc.GradingScheme(grading.Scheme),
+		MinGrade:      int32(grading.MinGrade),
+		MaxGrade:      int32(grading.MaxGrade),
+		PassThreshold: int32(grading.PassThreshold),
+		SubjectUuid:   pgUUID,
+	})
+	if err != nil {
+		return nil, subjectError(grading.SubjectUUID, err)
+	}
+
+	if err := qtx.DeleteGradeDescriptorsBySubject(ctx, pgUUID); err != nil {
+		return nil, fmt.Errorf("failed to clear grade descriptors: %w", err)
+	}
+
+	descriptors := make([]sqlc.GradeDescriptor, 0, len(grading.Descriptors))
+	for _, d := range grading.Descriptors {
+		row, err := qtx.InsertGradeDescriptor(ctx, sqlc.InsertGradeDescriptorParams{
+			SubjectUuid: pgUUID,
+			Descriptor:  d.Value,
+			Passing:     d.Passing,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert grade descriptor: %w", err)
+		}
+		descriptors = append(descriptors, row)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit grading update: %w", err)
+	}
+
+	return fromSQLC(subject, descriptors), nil
+}
+
+// TaughtInClass reports whether a subject is part of the class's subject package for
+// the class's academic year
+func (r *GradingRepository) TaughtInClass(ctx context.Context, classUUID, subjectUUID string) (bool, error) {
+	pgSubject, err := parseSubjectUUID(subjectUUID)
+	if err != nil {
+		return false, err
+	}
+	pgClass, err := utility.ParseUUID(classUUID)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	row, err := r.queries.GetClassSubjectEnrollment(ctx, sqlc.GetClassSubjectEnrollmentParams{
+		SubjectUuid: pgSubject,
+		ClassUuid:   pgClass,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, apierror.WithCode(fmt.Errorf("class %s: %w", classUUID, apierror.ErrNotFound), apierror.CodeClassNotFound)
+		}
+		return false, fmt.Errorf("failed to check class subjects: %w", err)
+	}
+	return row.Enrolled, nil
+}
+
+func parseSubjectUUID(subjectUUID string) (pgtype.UUID, error) {
+	pgUUID, err := utility.ParseUUID(subjectUUID)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+	return pgUUID, nil
+}
+
+func subjectError(subjectUUID string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apierror.WithCode(fmt.Errorf("subject %s: %w", subjectUUID, apierror.ErrNotFound), apierror.CodeSubjectNotFound)
+	}
+	return fmt.Errorf("failed to load subject: %w", err)
+}
+
+func fromSQLC(subject sqlc.Subject, descriptors []sqlc.GradeDescriptor) *SubjectGrading {
+	grading := &SubjectGrading{
+		SubjectUUID:   subject.Uuid.String(),
+		Scheme:        Scheme(subject.GradingScheme),
+		MinGrade:      int(subject.MinGrade),
+		MaxGrade:      int(subject.MaxGrade),
+		PassThreshold: int(subject.PassThreshold),
+		CreatedAt:     subject.CreatedAt.Time,
+		UpdatedAt:     subject.UpdatedAt.Time,
+		Version:       subject.Version,
+	}
+	for _, d := range descriptors {
+		grading.Descriptors = append(grading.Descriptors, Descriptor{Value: d.Descriptor, Passing: d.Passing})
+	}
+	return grading
+}
@@ -0,0 +1,77 @@
+package grading
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/webhook"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// EventGradingUpdated is published after a subject's grading configuration changes
+const EventGradingUpdated = "subject.grading.updated"
+
+type GradingService struct {
+	repo      *GradingRepository
+	publisher webhook.Publisher
+}
+
+func NewGradingService(repo *GradingRepository, publisher webhook.Publisher) *GradingService {
+	return &GradingService{repo: repo, publisher: publisher}
+}
+
+func (s *GradingService) GetSubjectGrading(ctx context.Context, subjectUUID string) (*SubjectGrading, error) {
+	return s.repo.GetBySubject(ctx, subjectUUID)
+}
+
+// UpdateSubjectGrading validates and stores a subject's grading configuration
+func (s *GradingService) UpdateSubjectGrading(ctx context.Context, grading *SubjectGrading) (*SubjectGrading, error) {
+	if err := grading.ValidateConfig(); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.Update(ctx, grading)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, EventGradingUpdated, SubjectGradingToDTO(updated))
+	return updated, nil
+}
+
+// ValidateGrade checks a grade entry against the subject's grading scheme and reports
+// whether it is a passing grade. Entries for a class must be for one of its subjects.
+func (s *GradingService) ValidateGrade(ctx context.Context, classUUID, subjectUUID string, grade Grade) (*SubjectGrading, bool, error) {
+	if err := s.CheckClassSubject(ctx, classUUID, subjectUUID); err != nil {
+		return nil, false, err
+	}
+
+	grading, err := s.repo.GetBySubject(ctx, subjectUUID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := grading.Validate(grade); err != nil {
+		return nil, false, err
+	}
+
+	return grading, grading.Passed(grade), nil
+}
+
+// CheckClassSubject rejects grade entries for a subject the class does not take in its
+// academic year. Every entry must name its class.
+func (s *GradingService) CheckClassSubject(ctx context.Context, classUUID, subjectUUID string) error {
+	if classUUID == "" {
+		return apierror.NewValidationError(apierror.Field("class_uuid", "required", "class_uuid is required"))
+	}
+
+	taught, err := s.repo.TaughtInClass(ctx, classUUID, subjectUUID)
+	if err != nil {
+		return err
+	}
+	if !taught {
+		return apierror.WithCode(apierror.NewValidationError(apierror.Field("subject_uuid", "class_subject",
+			fmt.Sprintf("subject %s is not taught in class %s this academic year", subjectUUID, classUUID))),
+			apierror.CodeSubjectNotInClass)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+insert into grading_scale (name, grading_scheme, min_grade, max_grade, pass_threshold)
+values
+	('default', 'numeric', 1, 5, 2),
+	('descriptive', 'descriptive', 1, 5, 2)
+on conflict do nothing;
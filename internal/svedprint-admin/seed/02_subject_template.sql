@@ -0,0 +1,47 @@
+insert into subject_template (short_name, full_name, academic_level, grading_scale)
+values
+	('МЈ', 'Македонски јазик и литература', 'first_year', 'default'),
+	('АЈ', 'Англиски јазик', 'first_year', 'default'),
+	('ВСЈ', 'Втор странски јазик', 'first_year', 'default'),
+	('МАТ', 'Математика', 'first_year', 'default'),
+	('ФИЗ', 'Физика', 'first_year', 'default'),
+	('ХЕМ', 'Хемија', 'first_year', 'default'),
+	('БИО', 'Биологија', 'first_year', 'default'),
+	('ИСТ', 'Историја', 'first_year', 'default'),
+	('ГЕО', 'Географија', 'first_year', 'default'),
+	('ИНФ', 'Информатика', 'first_year', 'default'),
+	('ФЗО', 'Физичко и здравствено образование', 'first_year', 'default'),
+	('МЈ', 'Македонски јазик и литература', 'second_year', 'default'),
+	('АЈ', 'Англиски јазик', 'second_year', 'default'),
+	('ВСЈ', 'Втор странски јазик', 'second_year', 'default'),
+	('МАТ', 'Математика', 'second_year', 'default'),
+	('ФИЗ', 'Физика', 'second_year', 'default'),
+	('ХЕМ', 'Хемија', 'second_year', 'default'),
+	('БИО', 'Биологија', 'second_year', 'default'),
+	('ИСТ', 'Историја', 'second_year', 'default'),
+	('ГЕО', 'Географија', 'second_year', 'default'),
+	('ИНФ', 'Информатика', 'second_year', 'default'),
+	('ФЗО', 'Физичко и здравствено образование', 'second_year', 'default'),
+	('МЈ', 'Македонски јазик и литература', 'junior_year', 'default'),
+	('АЈ', 'Англиски јазик', 'junior_year', 'default'),
+	('ВСЈ', 'Втор странски јазик', 'junior_year', 'default'),
+	('МАТ', 'Математика', 'junior_year', 'default'),
+	('ФИЗ', 'Физика', 'junior_year', 'default'),
+	('ХЕМ', 'Хемија', 'junior_year', 'default'),
+	('БИО', 'Биологија', 'junior_year', 'default'),
+	('ИСТ', 'Историја', 'junior_year', 'default'),
+	('ГЕО', 'Географија', 'junior_year', 'default'),
+	('ИНФ', 'Информатика', 'junior_year', 'default'),
+	('ФЗО', 'Физичко и здравствено образование', 'junior_year', 'default'),
+	('МЈ', 'Македонски јазик и литература', 'senior_year', 'default'),
+	('АЈ', 'Англиски јазик', 'senior_year', 'default'),
+	('ВСЈ', 'Втор странски јазик', 'senior_year', 'default'),
+	('МАТ', 'Математика', 'senior_year', 'default'),
+	('ФИЗ', 'Физика', 'senior_year', 'default'),
+	('ХЕМ', 'Хемија', 'senior_year', 'default'),
+	('БИО', 'Биологија', 'senior_year', 'default'),
+	('ИСТ', 'Историја', 'senior_year', 'default'),
+	('ГЕО', 'Географија', 'senior_year', 'default'),
+	('ИНФ', 'Информатика', 'senior_year', 'default'),
+	('ФЗО', 'Физичко и здравствено образование', 'senior_year', 'default')
+on conflict do nothing;
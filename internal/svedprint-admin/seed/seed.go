@@ -0,0 +1,10 @@
+// Package seed holds the reference data the admin database is populated with on
+// first run
+package seed
+
+import "embed"
+
+// Files are the seed scripts, one per table, run by database.Seed
+//
+//go:embed *.sql
+var Files embed.FS
@@ -1,22 +1,39 @@
 package svedprintadmin
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/grading"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/seed"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/webhook"
 	"github.com/PegasusMKD/svedprint-go/pkg/config"
 	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/health"
+	"github.com/PegasusMKD/svedprint-go/pkg/lifecycle"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type GinServer struct {
-	addr   string
-	engine *gin.Engine
+	addr      string
+	engine    *gin.Engine
+	lifecycle *lifecycle.Lifecycle
 }
 
 func (gs *GinServer) Run() {
-	gs.engine.Run(gs.addr)
+	srv := &http.Server{Addr: gs.addr, Handler: gs.engine}
+	if err := gs.lifecycle.Run(srv); err != nil {
+		log.Fatal().Err(err).Msg("Server stopped unexpectedly")
+	}
 }
 
 func NewServer() *GinServer {
@@ -26,34 +43,127 @@ func NewServer() *GinServer {
 	}
 	addr := fmt.Sprintf(":%s", port)
 
-	cfg, err := config.Load("svedprint")
+	cfg, err := config.Load("svedprint-admin")
 	if err != nil {
-		panic("Failed loading config for svedprint!")
+		log.Fatal().Err(err).Msg("Failed loading config for svedprint-admin")
 	}
 
-	setupSqlc(cfg)
+	return newServer(addr, cfg)
+}
+
+// newServer wires the service from cfg; the database, migrations and seed data are
+// brought up by the lifecycle's start hooks
+func newServer(addr string, cfg *config.Config) *GinServer {
+	lc := lifecycle.New(cfg.ShutdownTimeout)
+	db, queries := setupSqlc(cfg, lc)
+	dispatcher := setupWebhooks(cfg, queries, lc)
 
-	router := gin.Default()
+	router := gin.New()
 
-	setupMiddleware(router)
-	setupRoutes(router)
+	setupMiddleware(router, cfg)
+	setupHealth(router, lc)
+	setupRoutes(router, cfg, db, queries, dispatcher)
 
-	return &GinServer{engine: router, addr: addr}
+	return &GinServer{engine: router, addr: addr, lifecycle: lc}
 }
 
-func setupSqlc(cfg *config.Config) *sqlc.Queries {
+func setupSqlc(cfg *config.Config, lc *lifecycle.Lifecycle) (*database.TenantDB, *sqlc.Queries) {
 	dbConfig := database.GetConfig(cfg.DatabaseURL, cfg.DatabaseMaxConns, cfg.DatabaseMaxIdleConns, cfg.DatabaseConnLifetime)
+	dbConfig.ExpectedReplicas = cfg.DatabaseReplicas
+	dbConfig.StrictConnectionLimit = cfg.DatabaseStrictLimit
+	dbConfig.CategoryTimeouts = database.NewCategoryTimeouts(cfg.DatabaseInteractiveTimeout, cfg.DatabaseReportingTimeout, cfg.DatabaseAcquireTimeout)
 	migrationPath := fmt.Sprintf("db/%s/migrations", cfg.ServiceName)
-	database.RunMigrations(dbConfig.URL, migrationPath)
-	return sqlc.New(database.SetupDatabasePool(dbConfig))
+
+	pool, err := database.OpenPool(context.Background(), dbConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create connection pool")
+	}
+
+	// Checked after the listener is up so liveness probes answer during startup
+	lc.OnStart("database", func(ctx context.Context) error {
+		return database.Verify(ctx, pool, dbConfig)
+	})
+	lc.OnStart("migrations", func(ctx context.Context) error {
+		return database.RunMigrations(dbConfig.URL, migrationPath)
+	})
+	if cfg.DatabaseSeed {
+		var seedFiles fs.FS = seed.Files
+		if cfg.DatabaseSeedDir != "" {
+			seedFiles = os.DirFS(cfg.DatabaseSeedDir)
+		}
+		lc.OnStart("seed", func(ctx context.Context) error {
+			return database.Seed(ctx, pool, seedFiles)
+		})
+	}
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		return database.CloseWithTimeout(pool, cfg.DatabaseCloseTimeout)
+	})
+
+	db := database.NewTenantDB(pool)
+	return db, sqlc.New(database.NewQueryDB(db, cfg.DatabaseQueryTimeout))
 }
 
-func setupMiddleware(router *gin.Engine) {
-	router.Use(gin.Logger())
+func setupWebhooks(cfg *config.Config, queries *sqlc.Queries, lc *lifecycle.Lifecycle) *webhook.Dispatcher {
+	var endpoints []string
+	for _, endpoint := range strings.Split(cfg.WebhookEndpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	var signer webhook.Signer = webhook.NewHMACSigner(cfg.WebhookSecret)
+	if cfg.WebhookSigningKeyFile != "" {
+		rsaSigner, err := webhook.NewRSASignerFromFile(cfg.WebhookSigningKeyFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed loading webhook signing key")
+		}
+		signer = rsaSigner
+	}
+
+	dispatcher := webhook.NewDispatcher(endpoints, signer, webhook.DefaultRetryPolicy, queries)
+	if len(endpoints) > 0 {
+		// Shared claims keep replicas from notifying receivers twice
+		client, err := redis.NewClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.RedisTTL,
+			redis.WithReadOnlyOnOOM(cfg.RedisOOMCooldown), redis.WithCompression(cfg.RedisCompressThreshold),
+			redis.WithRetry(cfg.RedisRetries, cfg.RedisRetryBaseDelay), redis.WithKeyPrefix(cfg.RedisKeyPrefix))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed connecting to Redis for webhook deduplication")
+		}
+		lc.OnShutdown("redis", func(ctx context.Context) error {
+			return client.Close()
+		})
+		dispatcher.WithDeduplication(client, webhook.DefaultDedupTTL)
+	}
+	// Registered after the database hook so it runs first and can still dead-letter
+	lc.OnShutdown("webhooks", dispatcher.Close)
+
+	return dispatcher
 }
 
-func setupRoutes(router *gin.Engine) {
+func setupHealth(router *gin.Engine, lc *lifecycle.Lifecycle) {
+	gate := health.NewGate()
+	lc.OnReady(gate.MarkReady)
+
+	router.Use(gate.Middleware())
+	gate.RegisterRoutes(router)
+}
+
+func setupMiddleware(router *gin.Engine, cfg *config.Config) {
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID(requestid.MustNew(cfg.RequestIDFormat)))
+	router.Use(middleware.AccessLog(cfg.AccessLogFormat, os.Stdout))
+	router.Use(middleware.PrettyJSON(cfg.PrettyJSON))
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
+	router.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxQueuedRequests))
+	router.Use(middleware.Tenant())
+	router.Use(middleware.RequestLogger())
+}
+
+func setupRoutes(router *gin.Engine, cfg *config.Config, db *database.TenantDB, queries *sqlc.Queries, publisher webhook.Publisher) {
 	router.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{"status": "healthy"})
 	})
+
+	gradingHandler := grading.NewGradingHandler(grading.NewGradingService(grading.NewGradingRepository(db, queries), publisher), cfg.MaxBatchItems)
+	gradingHandler.RegisterRoutes(router.Group("/subjects"))
 }
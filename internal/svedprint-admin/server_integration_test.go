@@ -0,0 +1,84 @@
+package svedprintadmin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/config"
+	"github.com/PegasusMKD/svedprint-go/pkg/health"
+	"github.com/jackc/pgx/v5"
+)
+
+// TestServerBootsOnEmptyDatabase needs a server in TEST_DATABASE_URL; it creates and
+// drops its own database, so the admin migrations and seed data start from nothing
+func TestServerBootsOnEmptyDatabase(t *testing.T) {
+	adminURL := os.Getenv("TEST_DATABASE_URL")
+	if adminURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, adminURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close(ctx)
+	name := fmt.Sprintf("admin_boot_test_%d", time.Now().UnixNano())
+	ident := pgx.Identifier{name}.Sanitize()
+	if _, err := conn.Exec(ctx, "create database "+ident); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { conn.Exec(context.Background(), "drop database if exists "+ident+" with (force)") })
+
+	dbURL, err := url.Parse(adminURL)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	dbURL.Path = "/" + name
+
+	// Migrations are found relative to the working directory, as in the image
+	t.Chdir("../..")
+	t.Setenv("DATABASE_URL", dbURL.String())
+	t.Setenv("DATABASE_MAX_CONNS", "4")
+	t.Setenv("DATABASE_MAX_IDLE_CONNS", "0")
+	cfg, err := config.LoadFromFile("svedprint-admin", "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// A second boot finds the schema migrated and the reference data in place
+	for boot := 0; boot < 2; boot++ {
+		gs := newServer(":0", cfg)
+		if err := gs.lifecycle.Start(ctx); err != nil {
+			t.Fatalf("boot %d: start: %v", boot, err)
+		}
+
+		w := httptest.NewRecorder()
+		gs.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("boot %d: %s = %d, want 200", boot, health.ReadyPath, w.Code)
+		}
+		gs.lifecycle.Shutdown()
+	}
+
+	db, err := pgx.Connect(ctx, dbURL.String())
+	if err != nil {
+		t.Fatalf("connect to %s: %v", name, err)
+	}
+	defer db.Close(ctx)
+	for _, table := range []string{"grading_scale", "subject_template"} {
+		var rows int
+		if err := db.QueryRow(ctx, "select count(*) from "+table).Scan(&rows); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if rows == 0 {
+			t.Errorf("%s was not seeded", table)
+		}
+	}
+}
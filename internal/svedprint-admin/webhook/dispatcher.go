@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SignatureHeader = "X-Svedprint-Signature"
+	TimestampHeader = "X-Svedprint-Timestamp"
+	EventHeader     = "X-Svedprint-Event"
+)
+
+// DefaultRetryPolicy spreads delivery attempts over roughly half a minute
+var DefaultRetryPolicy = retry.Policy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 15 * time.Second}
+
+// Event is the payload POSTed to webhook endpoints
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Publisher is implemented by anything that can emit admin events
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data any)
+}
+
+// Deduplicator lets exactly one replica claim a delivery
+type Deduplicator interface {
+	ClaimOnce(ctx context.Context, id string, ttl time.Duration) (bool, error)
+}
+
+// DefaultDedupTTL is how long a delivery claim is remembered
+const DefaultDedupTTL = 24 * time.Hour
+
+// statusError is a non-2xx response from an endpoint
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("endpoint responded with status %d", e.status)
+}
+
+// Dispatcher delivers signed events to the configured endpoints in the background,
+// retrying transient failures and dead-lettering deliveries that never succeed
+type Dispatcher struct {
+	endpoints  []string
+	signer     Signer
+	policy     retry.Policy
+	queries    *sqlc.Queries
+	httpClient *http.Client
+	dedup      Deduplicator
+	dedupTTL   time.Duration
+	wg         sync.WaitGroup
+}
+
+func NewDispatcher(endpoints []string, signer Signer, policy retry.Policy, queries *sqlc.Queries) *Dispatcher {
+	return &Dispatcher{
+		endpoints: endpoints,
+		signer:    signer,
+		policy:    policy,
+		queries:   queries,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// WithDeduplication makes deliveries at-most-once across replicas: a (event ID,
+// endpoint) pair is only delivered by the replica that claims it first
+func (d *Dispatcher) WithDeduplication(dedup Deduplicator, ttl time.Duration) *Dispatcher {
+	d.dedup = dedup
+	d.dedupTTL = ttl
+	return d
+}
+
+// Publish delivers a new event to every endpoint without blocking the caller
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data any) {
+	d.PublishEvent(ctx, Event{ID: newEventID(), Type: eventType, OccurredAt: time.Now().UTC(), Data: data})
+}
+
+// PublishEvent delivers an event with a caller-chosen ID. Replicas that observe the
+// same change should publish it under the same ID so deduplication can apply.
+func (d *Dispatcher) PublishEvent(ctx context.Context, event Event) {
+	if len(d.endpoints) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("event_type", event.Type).Msg("Failed to marshal webhook event")
+		return
+	}
+
+	// Deliveries outlive the request that triggered them
+	deliveryCtx := context.WithoutCancel(ctx)
+	for _, endpoint := range d.endpoints {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(deliveryCtx, endpoint, event, body)
+		}()
+	}
+}
+
+// Close waits for in-flight deliveries, giving up when ctx is done
+func (d *Dispatcher) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries still in flight: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, endpoint string, event Event, body []byte) {
+	if d.dedup != nil {
+		claimed, err := d.dedup.ClaimOnce(ctx, "webhook:"+event.ID+":"+endpoint, d.dedupTTL)
+		if err != nil {
+			// Without a claim we can't rule out a duplicate, so don't deliver
+			log.Error().Err(err).Str("event_id", event.ID).Str("endpoint", endpoint).Msg("Failed to claim webhook delivery")
+			return
+		}
+		if !claimed {
+			log.Debug().Str("event_id", event.ID).Str("endpoint", endpoint).Msg("Webhook already delivered by another replica")
+			return
+		}
+	}
+
+	attempts := 0
+	err := retry.Do(ctx, d.policy, isRetryable, func() error {
+		attempts++
+		return d.send(ctx, endpoint, event.Type, body)
+	})
+	if err == nil {
+		return
+	}
+
+	log.Error().Err(err).Str("event_id", event.ID).Str("endpoint", endpoint).Int("attempts", attempts).
+		Msg("Webhook delivery failed, dead-lettering")
+
+	dlErr := d.queries.InsertWebhookDeadLetter(ctx, sqlc.InsertWebhookDeadLetterParams{
+		EventID:   event.ID,
+		EventType: event.Type,
+		Endpoint:  endpoint,
+		Payload:   body,
+		Attempts:  int32(attempts),
+		LastError: err.Error(),
+	})
+	if dlErr != nil {
+		log.Error().Err(dlErr).Str("event_id", event.ID).Msg("Failed to store webhook dead letter")
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint, eventType string, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature, err := d.signer.Sign([]byte(timestamp + "." + string(body)))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, d.signer.Algorithm()+"="+signature)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{status: resp.StatusCode}
+	}
+	return nil
+}
+
+// isRetryable retries transport failures, throttling and server errors; other
+// client errors mean the receiver rejected the event and retrying won't help
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500
+	}
+	return true
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
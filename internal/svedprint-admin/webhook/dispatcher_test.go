@@ -0,0 +1,243 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-admin/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var testPolicy = retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+// deadLetterDB records the arguments of every dead letter insert
+type deadLetterDB struct {
+	mu      sync.Mutex
+	letters [][]any
+}
+
+func (db *deadLetterDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.letters = append(db.letters, args)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (db *deadLetterDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	panic("unexpected Query")
+}
+
+func (db *deadLetterDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	panic("unexpected QueryRow")
+}
+
+func publishAndWait(t *testing.T, d *Dispatcher, eventType string, data any) {
+	t.Helper()
+	d.Publish(context.Background(), eventType, data)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestDispatcherSignsWithHMAC(t *testing.T) {
+	const secret = "shared-secret"
+	var verified atomic.Bool
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get(TimestampHeader) + "." + string(body)))
+		want := "hmac-sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		if got := r.Header.Get(SignatureHeader); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if got := r.Header.Get(EventHeader); got != "school.updated" {
+			t.Errorf("event header = %q", got)
+		}
+		verified.Store(true)
+	}))
+	defer endpoint.Close()
+
+	db := &deadLetterDB{}
+	d := NewDispatcher([]string{endpoint.URL}, NewHMACSigner(secret), testPolicy, sqlc.New(db))
+	publishAndWait(t, d, "school.updated", map[string]string{"uuid": "42"})
+
+	if !verified.Load() {
+		t.Fatal("endpoint never received the event")
+	}
+	if len(db.letters) != 0 {
+		t.Errorf("successful delivery was dead-lettered: %v", db.letters)
+	}
+}
+
+func TestDispatcherSignsWithRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "webhook.pem")
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, pemKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewRSASignerFromFile(path)
+	if err != nil {
+		t.Fatalf("NewRSASignerFromFile: %v", err)
+	}
+
+	var verified atomic.Bool
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		algorithm, encoded, _ := strings.Cut(r.Header.Get(SignatureHeader), "=")
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if algorithm != "rsa-sha256" || err != nil {
+			t.Errorf("signature header = %q", r.Header.Get(SignatureHeader))
+			return
+		}
+		digest := sha256.Sum256([]byte(r.Header.Get(TimestampHeader) + "." + string(body)))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			t.Errorf("signature does not verify: %v", err)
+		}
+		verified.Store(true)
+	}))
+	defer endpoint.Close()
+
+	d := NewDispatcher([]string{endpoint.URL}, signer, testPolicy, sqlc.New(&deadLetterDB{}))
+	publishAndWait(t, d, "school.created", map[string]string{"uuid": "42"})
+
+	if !verified.Load() {
+		t.Fatal("endpoint never received the event")
+	}
+}
+
+func TestDispatcherRetriesThenDeadLetters(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAttempts int32
+	}{
+		{"server error retries", http.StatusServiceUnavailable, 3},
+		{"throttling retries", http.StatusTooManyRequests, 3},
+		{"rejection does not retry", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer endpoint.Close()
+
+			db := &deadLetterDB{}
+			d := NewDispatcher([]string{endpoint.URL}, NewHMACSigner("secret"), testPolicy, sqlc.New(db))
+			publishAndWait(t, d, "school.updated", map[string]string{"uuid": "42"})
+
+			if got := calls.Load(); got != tt.wantAttempts {
+				t.Errorf("endpoint called %d times, want %d", got, tt.wantAttempts)
+			}
+			if len(db.letters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(db.letters))
+			}
+			// event_id, event_type, endpoint, payload, attempts, last_error
+			letter := db.letters[0]
+			if letter[1] != "school.updated" || letter[2] != endpoint.URL || letter[4] != tt.wantAttempts {
+				t.Errorf("dead letter = %v", letter)
+			}
+		})
+	}
+}
+
+func TestDispatcherRecoversAfterTransientFailure(t *testing.T) {
+	var calls atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer endpoint.Close()
+
+	db := &deadLetterDB{}
+	d := NewDispatcher([]string{endpoint.URL}, NewHMACSigner("secret"), testPolicy, sqlc.New(db))
+	publishAndWait(t, d, "school.updated", nil)
+
+	if calls.Load() != 2 || len(db.letters) != 0 {
+		t.Errorf("%d calls and %d dead letters, want 2 and 0", calls.Load(), len(db.letters))
+	}
+}
+
+func TestDeduplicationDeliversOnceAcrossReplicas(t *testing.T) {
+	var deliveries atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries.Add(1)
+	}))
+	defer endpoint.Close()
+
+	server := miniredis.RunT(t)
+	var replicas []*Dispatcher
+	for i := 0; i < 2; i++ {
+		cache, err := redis.NewClient(server.Addr(), "", 0, time.Minute)
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		defer cache.Close()
+		d := NewDispatcher([]string{endpoint.URL}, NewHMACSigner("secret"), testPolicy, sqlc.New(&deadLetterDB{}))
+		replicas = append(replicas, d.WithDeduplication(cache, DefaultDedupTTL))
+	}
+
+	// Both replicas observe the same change and publish it under the same ID
+	event := Event{ID: "school-42-updated", Type: "school.updated", OccurredAt: time.Now().UTC()}
+	var wg sync.WaitGroup
+	for _, d := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.PublishEvent(context.Background(), event)
+		}()
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, d := range replicas {
+		if err := d.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+	if got := deliveries.Load(); got != 1 {
+		t.Errorf("event delivered %d times, want once", got)
+	}
+
+	// A new event is delivered again
+	replicas[0].PublishEvent(context.Background(), Event{ID: "school-42-updated-2", Type: "school.updated"})
+	if err := replicas[0].Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := deliveries.Load(); got != 2 {
+		t.Errorf("second event: %d deliveries in total, want 2", got)
+	}
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Signer signs the string "<timestamp>.<body>" so receivers can verify both the
+// payload and its freshness
+type Signer interface {
+	// Algorithm is sent alongside the signature, e.g. "hmac-sha256"
+	Algorithm() string
+	Sign(message []byte) (string, error)
+}
+
+// HMACSigner signs payloads with a secret shared with the receiver
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret)}
+}
+
+func (s *HMACSigner) Algorithm() string {
+	return "hmac-sha256"
+}
+
+func (s *HMACSigner) Sign(message []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// RSASigner signs payloads with a private key; receivers verify with the public key
+type RSASigner struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSASignerFromFile loads a PEM encoded PKCS#1 or PKCS#8 RSA private key
+func NewRSASignerFromFile(path string) (*RSASigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook signing key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("webhook signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return &RSASigner{key: key}, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("webhook signing key is not an RSA key")
+	}
+	return &RSASigner{key: key}, nil
+}
+
+func (s *RSASigner) Algorithm() string {
+	return "rsa-sha256"
+}
+
+func (s *RSASigner) Sign(message []byte) (string, error) {
+	digest := sha256.Sum256(message)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign webhook payload: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
@@ -0,0 +1,189 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// RenderFunc renders document i of a batch
+type RenderFunc func(ctx context.Context, i int) ([]byte, error)
+
+// EmitFunc consumes a rendered document, e.g. by writing it into an archive. It is
+// called from a single goroutine, in completion order.
+type EmitFunc func(i int, doc []byte) error
+
+// Pool renders batches of documents concurrently. Two limits are shared by every
+// batch running on the pool: how many documents render at once, which bounds CPU, and
+// how many are in flight, rendering or rendered but not yet emitted, which bounds the
+// memory held by PDFs. Both can be changed while batches run.
+type Pool struct {
+	rendering *gauge
+	inFlight  *gauge
+}
+
+// DefaultWorkers renders one document per available CPU
+func DefaultWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// NewPool creates a pool rendering up to workers documents at once with at most
+// maxInFlight held in memory. A non-positive workers uses DefaultWorkers and a
+// non-positive maxInFlight twice the workers.
+func NewPool(workers, maxInFlight int) *Pool {
+	p := &Pool{rendering: newGauge(), inFlight: newGauge()}
+	p.SetLimits(workers, maxInFlight)
+	return p
+}
+
+// SetLimits changes the pool's limits, with the same defaults as NewPool. Lowering a
+// limit doesn't interrupt documents already rendering; it takes effect as they finish.
+func (p *Pool) SetLimits(workers, maxInFlight int) {
+	if workers <= 0 {
+		workers = DefaultWorkers()
+	}
+	if maxInFlight <= 0 {
+		maxInFlight = 2 * workers
+	}
+	p.rendering.setLimit(workers)
+	p.inFlight.setLimit(maxInFlight)
+}
+
+// Limits returns the pool's current worker and in-flight limits
+func (p *Pool) Limits() (workers, maxInFlight int) {
+	return p.rendering.getLimit(), p.inFlight.getLimit()
+}
+
+type result struct {
+	index int
+	doc   []byte
+	err   error
+}
+
+// Run renders documents 0 to n-1 of a batch and hands each to emit. The first render
+// or emit error cancels the rest of the batch and is returned once every started
+// render has stopped.
+func (p *Pool) Run(ctx context.Context, n int, render RenderFunc, emit EmitFunc) error {
+	if n <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan result, n)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		// Documents never emitted still hold their in-flight slot
+		for len(results) > 0 {
+			<-results
+			p.inFlight.release()
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			// Held until the document is emitted, so rendering waits while emit lags
+			if err := p.inFlight.acquire(ctx); err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- p.render(ctx, i, render)
+			}()
+		}
+	}()
+
+	for range n {
+		var res result
+		select {
+		case res = <-results:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if res.err != nil {
+			p.inFlight.release()
+			return fmt.Errorf("failed to render document %d: %w", res.index, res.err)
+		}
+
+		err := emit(res.index, res.doc)
+		p.inFlight.release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render renders one document once a worker slot is free
+func (p *Pool) render(ctx context.Context, i int, render RenderFunc) result {
+	if err := p.rendering.acquire(ctx); err != nil {
+		return result{index: i, err: err}
+	}
+	defer p.rendering.release()
+
+	doc, err := render(ctx, i)
+	return result{index: i, doc: doc, err: err}
+}
+
+// gauge is a counting semaphore whose limit can change while it is held
+type gauge struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+	// changed is closed and replaced whenever capacity may have freed up
+	changed chan struct{}
+}
+
+func newGauge() *gauge {
+	return &gauge{changed: make(chan struct{})}
+}
+
+func (g *gauge) acquire(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		if g.used < g.limit {
+			g.used++
+			g.mu.Unlock()
+			return nil
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (g *gauge) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.used--
+	g.notify()
+}
+
+func (g *gauge) setLimit(limit int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limit = limit
+	g.notify()
+}
+
+func (g *gauge) getLimit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limit
+}
+
+// notify wakes every waiter; callers hold mu
+func (g *gauge) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
@@ -0,0 +1,211 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// peak tracks how many of something are held at once
+type peak struct {
+	mu       sync.Mutex
+	current  int
+	greatest int
+}
+
+func (p *peak) enter() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current++
+	p.greatest = max(p.greatest, p.current)
+}
+
+func (p *peak) leave() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current--
+}
+
+func (p *peak) max() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.greatest
+}
+
+func TestPoolDefaults(t *testing.T) {
+	workers, maxInFlight := NewPool(0, 0).Limits()
+	if want := runtime.GOMAXPROCS(0); workers != want || maxInFlight != 2*want {
+		t.Errorf("limits = %d, %d, want %d, %d", workers, maxInFlight, want, 2*want)
+	}
+	if workers, maxInFlight := NewPool(3, -1).Limits(); workers != 3 || maxInFlight != 6 {
+		t.Errorf("limits = %d, %d, want 3, 6", workers, maxInFlight)
+	}
+}
+
+func TestPoolRespectsConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     int
+		maxInFlight int
+		docs        int
+	}{
+		{name: "more documents than workers", workers: 3, docs: 25},
+		{name: "one worker", workers: 1, docs: 5},
+		{name: "in-flight below workers", workers: 4, maxInFlight: 2, docs: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewPool(tt.workers, tt.maxInFlight)
+			workers, maxInFlight := pool.Limits()
+			var rendering, inFlight peak
+			emitted := make([]int, tt.docs)
+
+			render := func(ctx context.Context, i int) ([]byte, error) {
+				inFlight.enter()
+				rendering.enter()
+				defer rendering.leave()
+				time.Sleep(5 * time.Millisecond)
+				return []byte(fmt.Sprintf("doc-%d", i)), nil
+			}
+			emit := func(i int, doc []byte) error {
+				defer inFlight.leave()
+				if string(doc) != fmt.Sprintf("doc-%d", i) {
+					t.Errorf("document %d = %q", i, doc)
+				}
+				emitted[i]++
+				return nil
+			}
+			if err := pool.Run(context.Background(), tt.docs, render, emit); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			for i, n := range emitted {
+				if n != 1 {
+					t.Errorf("document %d emitted %d times, want once", i, n)
+				}
+			}
+			if got, want := rendering.max(), min(workers, maxInFlight); got != want {
+				t.Errorf("%d documents rendered at once, want %d", got, want)
+			}
+			if got := inFlight.max(); got > maxInFlight {
+				t.Errorf("%d documents in flight, want at most %d", got, maxInFlight)
+			}
+		})
+	}
+}
+
+func TestPoolInFlightWaitsForEmit(t *testing.T) {
+	pool := NewPool(4, 2)
+	var rendered atomic.Int32
+	unblock := make(chan struct{})
+
+	render := func(ctx context.Context, i int) ([]byte, error) {
+		rendered.Add(1)
+		return nil, nil
+	}
+	emit := func(i int, doc []byte) error {
+		<-unblock
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(context.Background(), 10, render, emit) }()
+
+	// With the first emit stuck, only the two in-flight documents can render
+	time.Sleep(50 * time.Millisecond)
+	if got := rendered.Load(); got != 2 {
+		t.Errorf("%d documents rendered while emit was blocked, want 2", got)
+	}
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := rendered.Load(); got != 10 {
+		t.Errorf("%d documents rendered, want 10", got)
+	}
+}
+
+func TestPoolSetLimitsWhileRunning(t *testing.T) {
+	pool := NewPool(1, 10)
+	var rendering peak
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	render := func(ctx context.Context, i int) ([]byte, error) {
+		rendering.enter()
+		defer rendering.leave()
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(context.Background(), 6, render, func(int, []byte) error { return nil })
+	}()
+
+	<-started
+	pool.SetLimits(3, 10)
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("raising the limit did not start more renders")
+		}
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := rendering.max(); got != 3 {
+		t.Errorf("%d documents rendered at once, want 3", got)
+	}
+}
+
+func TestPoolStopsOnError(t *testing.T) {
+	errRender := errors.New("template failed")
+	tests := []struct {
+		name    string
+		render  RenderFunc
+		emit    EmitFunc
+		wantErr error
+	}{
+		{
+			name: "render error",
+			render: func(ctx context.Context, i int) ([]byte, error) {
+				if i == 3 {
+					return nil, errRender
+				}
+				return nil, nil
+			},
+			emit:    func(int, []byte) error { return nil },
+			wantErr: errRender,
+		},
+		{
+			name:    "emit error",
+			render:  func(ctx context.Context, i int) ([]byte, error) { return nil, nil },
+			emit:    func(int, []byte) error { return errRender },
+			wantErr: errRender,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewPool(2, 4)
+			if err := pool.Run(context.Background(), 50, tt.render, tt.emit); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run = %v, want %v", err, tt.wantErr)
+			}
+
+			// Every slot is handed back, so the pool still runs full batches
+			var emitted atomic.Int32
+			err := pool.Run(context.Background(), 8,
+				func(ctx context.Context, i int) ([]byte, error) { return nil, nil },
+				func(int, []byte) error { emitted.Add(1); return nil })
+			if err != nil || emitted.Load() != 8 {
+				t.Errorf("next batch = %v with %d emitted, want all 8", err, emitted.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package certificate
+
+import "strconv"
+
+// Certificate is the data needed to render a student's certificate
+type Certificate struct {
+	Type         string    `json:"type"`
+	AcademicYear string    `json:"academic_year"`
+	School       School    `json:"school"`
+	Student      Student   `json:"student"`
+	Subjects     []Subject `json:"subjects"`
+	// Attendance is the absence summary for the year, printed when present
+	Attendance *Attendance `json:"attendance,omitempty"`
+}
+
+type School struct {
+	Name           string `json:"name"`
+	DirectorName   string `json:"director_name"`
+	BusinessNumber string `json:"business_number"`
+	City           string `json:"city"`
+}
+
+type Student struct {
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	FathersName    string `json:"fathers_name"`
+	PersonalNumber string `json:"personal_number"`
+	DateOfBirth    string `json:"date_of_birth"`
+	PlaceOfBirth   string `json:"place_of_birth"`
+}
+
+// Subject is a graded subject together with the grading rules it was graded under
+type Subject struct {
+	Name    string  `json:"name"`
+	Grading Grading `json:"grading"`
+	// Grade is set for numerically graded subjects, Descriptor for descriptive ones
+	Grade      *int   `json:"grade,omitempty"`
+	Descriptor string `json:"descriptor,omitempty"`
+}
+
+// Grading schemes a subject can be graded under
+const (
+	SchemeNumeric     = "numeric"
+	SchemeDescriptive = "descriptive"
+)
+
+// GradeText is the grade as printed: the number for numerically graded subjects and
+// the descriptor, e.g. "passed", for descriptive ones. It is empty for a subject
+// without a grade in its scheme.
+func (s Subject) GradeText() string {
+	switch s.Grading.Scheme {
+	case SchemeNumeric:
+		if s.Grade != nil {
+			return strconv.Itoa(*s.Grade)
+		}
+	case SchemeDescriptive:
+		return s.Descriptor
+	}
+	return ""
+}
+
+// Attendance is the number of absent classes, split by whether they were justified
+type Attendance struct {
+	Justified   int `json:"justified"`
+	Unjustified int `json:"unjustified"`
+}
+
+type Grading struct {
+	Scheme      string   `json:"scheme"`
+	MinGrade    int      `json:"min_grade,omitempty"`
+	MaxGrade    int      `json:"max_grade,omitempty"`
+	Descriptors []string `json:"descriptors,omitempty"`
+}
+
+// Certificate types the renderer knows templates for
+const (
+	TypeTestimony = "testimony"
+	TypeDiploma   = "diploma"
+)
+
+// Problem is a single reason a certificate cannot be rendered
+type Problem struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,261 @@
+package certificate
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/batch"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/download"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/packet"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRenderRequestSize bounds a multipart render request: the certificate data plus a
+// cover and an appendix of at most packet.MaxAttachmentSize each
+const maxRenderRequestSize = 2*packet.MaxAttachmentSize + 1<<20
+
+type ValidateResponse struct {
+	Valid    bool      `json:"valid"`
+	Problems []Problem `json:"problems"`
+}
+
+// BatchRequest lists the certificates rendered into one archive, e.g. a whole class
+type BatchRequest struct {
+	Certificates []Certificate `json:"certificates" binding:"required,min=1"`
+}
+
+type CertificateHandler struct {
+	merger        packet.Merger
+	publisher     download.Publisher
+	pool          *batch.Pool
+	maxBatchItems int
+}
+
+// NewCertificateHandler merges certificate packets with merger, renders batches of up
+// to maxBatchItems certificates on pool and hands rendered documents out through
+// publisher
+func NewCertificateHandler(merger packet.Merger, publisher download.Publisher, pool *batch.Pool, maxBatchItems int) *CertificateHandler {
+	return &CertificateHandler{merger: merger, publisher: publisher, pool: pool, maxBatchItems: maxBatchItems}
+}
+
+func (h *CertificateHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/validate", h.Validate)
+	rg.POST("/certificate", h.Render)
+	rg.POST("/certificate/batch", h.RenderBatch)
+}
+
+// Validate reports every problem that would make rendering the certificate fail,
+// without rendering it
+func (h *CertificateHandler) Validate(c *gin.Context) {
+	var cert Certificate
+	if !apierror.BindJSON(c, &cert) {
+		return
+	}
+
+	problems := Validate(&cert)
+	if problems == nil {
+		problems = []Problem{}
+	}
+	c.JSON(http.StatusOK, ValidateResponse{Valid: len(problems) == 0, Problems: problems})
+}
+
+// Render renders a certificate as PDF in the user's locale and responds with a signed,
+// expiring link to it. The certificate is sent as a JSON body, or as the "certificate"
+// field of a multipart form that may also carry "cover" and "appendix" PDFs to print
+// before and after it.
+func (h *CertificateHandler) Render(c *gin.Context) {
+	var cert Certificate
+	var p packet.Packet
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := bindPacketForm(c, &cert, &p); err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+	} else if !apierror.BindJSON(c, &cert) {
+		return
+	}
+
+	if problems := Validate(&cert); len(problems) > 0 {
+		apierror.Respond(c, problemsError(problems))
+		return
+	}
+
+	body, err := Render(&cert, i18n.FromRequest(c.Request))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	p.Body = body
+
+	doc, err := packet.Assemble(c.Request.Context(), h.merger, &p)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	link, err := h.publisher.Publish(c.Request.Context(), &download.Artifact{
+		Filename:    cert.Type + ".pdf",
+		ContentType: "application/pdf",
+		Data:        doc,
+	})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// RenderBatch renders every certificate of the batch on the shared render pool into a
+// zip archive and responds with a signed, expiring link to it. Nothing is rendered
+// unless every certificate is valid.
+func (h *CertificateHandler) RenderBatch(c *gin.Context) {
+	var req BatchRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+	if h.maxBatchItems > 0 && len(req.Certificates) > h.maxBatchItems {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusRequestEntityTooLarge, apierror.CodeBatchTooLarge,
+			fmt.Sprintf("batch of %d certificates exceeds the limit of %d", len(req.Certificates), h.maxBatchItems)))
+		return
+	}
+
+	var problems []Problem
+	for i := range req.Certificates {
+		for _, p := range Validate(&req.Certificates[i]) {
+			p.Field = fmt.Sprintf("certificates[%d].%s", i, p.Field)
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) > 0 {
+		apierror.Respond(c, problemsError(problems))
+		return
+	}
+
+	archive, size, err := h.renderArchive(c.Request.Context(), req.Certificates, i18n.FromRequest(c.Request))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	link, err := h.publisher.Publish(c.Request.Context(), &download.Artifact{
+		Filename:    "certificates.zip",
+		ContentType: "application/zip",
+		Body:        archive,
+		Size:        size,
+	})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// renderArchive renders the certificates on the shared pool into a zip written to a
+// temporary file, so a large batch never sits in memory whole. It returns the file,
+// positioned at its start, and its size; the caller removes it.
+func (h *CertificateHandler) renderArchive(ctx context.Context, certs []Certificate, locale string) (*os.File, int64, error) {
+	archive, err := os.CreateTemp("", "svedprint-batch-*.zip")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create batch archive: %w", err)
+	}
+
+	zw := zip.NewWriter(archive)
+	err = h.pool.Run(ctx, len(certs),
+		func(_ context.Context, i int) ([]byte, error) {
+			return Render(&certs[i], locale)
+		},
+		func(i int, doc []byte) error {
+			w, err := zw.Create(batchEntryName(i, &certs[i]))
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(doc)
+			return err
+		})
+	if err == nil {
+		err = zw.Close()
+	}
+	var size int64
+	if err == nil {
+		size, err = archive.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		_, err = archive.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		archive.Close()
+		os.Remove(archive.Name())
+		return nil, 0, err
+	}
+	return archive, size, nil
+}
+
+// batchEntryName numbers archive entries in request order, e.g. 001-Stojanova-Ana.pdf,
+// since the pool emits documents in completion order
+func batchEntryName(i int, cert *Certificate) string {
+	name := strings.NewReplacer("/", "-", "\\", "-").Replace(cert.Student.LastName + "-" + cert.Student.FirstName)
+	return fmt.Sprintf("%03d-%s.pdf", i+1, name)
+}
+
+// bindPacketForm reads the certificate and the optional attachments of a multipart render request
+func bindPacketForm(c *gin.Context, cert *Certificate, p *packet.Packet) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRenderRequestSize)
+	if err := c.Request.ParseMultipartForm(maxRenderRequestSize); err != nil {
+		return fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	data := c.Request.FormValue("certificate")
+	if data == "" {
+		return apierror.NewValidationError(apierror.Field("certificate", "required", "is required"))
+	}
+	if err := json.Unmarshal([]byte(data), cert); err != nil {
+		return fmt.Errorf("%w: certificate: %v", apierror.ErrInvalidInput, err)
+	}
+
+	var err error
+	if p.Cover, err = readAttachment(c, "cover"); err != nil {
+		return err
+	}
+	if p.Appendix, err = readAttachment(c, "appendix"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readAttachment returns the contents of an uploaded file, or nil if none was sent.
+// Anything past MaxAttachmentSize is cut off; Packet.Validate reports the oversize.
+func readAttachment(c *gin.Context, field string) ([]byte, error) {
+	header, err := c.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", apierror.ErrInvalidInput, field, err)
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s upload: %w", field, err)
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, packet.MaxAttachmentSize+1))
+}
+
+func problemsError(problems []Problem) error {
+	fields := make([]apierror.FieldError, 0, len(problems))
+	for _, p := range problems {
+		fields = append(fields, apierror.Field(p.Field, p.Rule, p.Message))
+	}
+	return apierror.NewValidationError(fields...)
+}
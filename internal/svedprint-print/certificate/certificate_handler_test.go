@@ -0,0 +1,476 @@
+package certificate
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/batch"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/download"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/objectstore"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/packet"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func postValidate(t *testing.T, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	return post(t, "/render/validate", "application/json", body)
+}
+
+const testMaxBatchItems = 10
+
+// newRenderRouter serves certificates and their downloads under /render as the print
+// service does
+func newRenderRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	store, err := download.NewDirStore(t.TempDir(), 64<<20)
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	downloads := download.NewDownloads(store, download.NewSigner([]byte("download-secret")), 15*time.Minute)
+	render := router.Group("/render")
+	NewCertificateHandler(packet.NewPDFMerger(), downloads, batch.NewPool(2, 0), testMaxBatchItems).RegisterRoutes(render)
+	download.NewDownloadHandler(downloads).RegisterRoutes(render)
+	return router
+}
+
+func post(t *testing.T, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	newRenderRouter(t).ServeHTTP(w, req)
+	return w
+}
+
+// renderAndDownload renders a certificate and follows the returned link to the PDF
+func renderAndDownload(t *testing.T, contentType string, body []byte) []byte {
+	t.Helper()
+	return renderAndDownloadFrom(t, "/render/certificate", contentType, body, "application/pdf")
+}
+
+// renderAndDownloadFrom posts a render request to path and follows the returned link
+// to a document of wantType
+func renderAndDownloadFrom(t *testing.T, path, contentType string, body []byte, wantType string) []byte {
+	t.Helper()
+	router := newRenderRouter(t)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("render status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var link download.Link
+	if err := json.Unmarshal(w.Body.Bytes(), &link); err != nil {
+		t.Fatalf("decode link: %v", err)
+	}
+	if link.JobID == "" || !strings.HasPrefix(link.URL, "/render/jobs/"+link.JobID+"/download?token=") {
+		t.Fatalf("link = %+v, want a signed download URL for the job", link)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link.URL, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("download status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != wantType {
+		t.Errorf("Content-Type = %q, want %s", ct, wantType)
+	}
+	return w.Body.Bytes()
+}
+
+// labelledPDF builds an attachment with one page per label, each page printing its label
+func labelledPDF(t *testing.T, labels ...string) []byte {
+	t.Helper()
+	pdf := fpdf.New(fpdf.OrientationPortrait, fpdf.UnitMillimeter, fpdf.PageSizeA4, "")
+	pdf.SetFont("Helvetica", "", 12)
+	for _, label := range labels {
+		pdf.AddPage()
+		pdf.Text(20, 20, label)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		t.Fatalf("building test PDF: %v", err)
+	}
+	return out.Bytes()
+}
+
+var showText = regexp.MustCompile(`\((.*?)\) Tj`)
+
+// pageTexts parses doc and returns the text shown on each of its pages, in order
+func pageTexts(t *testing.T, doc []byte) []string {
+	t.Helper()
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadAndValidate(bytes.NewReader(doc), conf)
+	if err != nil {
+		t.Fatalf("parsing rendered PDF: %v", err)
+	}
+
+	texts := make([]string, 0, ctx.PageCount)
+	for page := 1; page <= ctx.PageCount; page++ {
+		r, err := pdfcpu.ExtractPageContent(ctx, page)
+		if err != nil {
+			t.Fatalf("reading page %d: %v", page, err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading page %d: %v", page, err)
+		}
+		var shown []string
+		for _, m := range showText.FindAllSubmatch(content, -1) {
+			shown = append(shown, string(m[1]))
+		}
+		texts = append(texts, strings.Join(shown, " "))
+	}
+	return texts
+}
+
+// packetForm builds a multipart render request from a certificate and named attachments
+func packetForm(t *testing.T, cert *Certificate, files map[string][]byte) (string, []byte) {
+	t.Helper()
+	data, err := json.Marshal(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("certificate", string(data)); err != nil {
+		t.Fatal(err)
+	}
+	for field, content := range files {
+		part, err := form.CreateFormFile(field, field+".pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return form.FormDataContentType(), body.Bytes()
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(c *Certificate)
+		wantFields []string
+	}{
+		{name: "complete", modify: func(*Certificate) {}},
+		{
+			name: "incomplete",
+			modify: func(c *Certificate) {
+				c.AcademicYear = ""
+				c.School.DirectorName = ""
+				c.Student.FathersName = ""
+				c.Subjects = nil
+			},
+			wantFields: []string{"academic_year", "school.director_name", "student.fathers_name", "subjects"},
+		},
+		{
+			name: "invalid",
+			modify: func(c *Certificate) {
+				c.Type = "report"
+				c.Student.DateOfBirth = "04.03.2012"
+				c.Subjects = append(c.Subjects, c.Subjects[0])
+				c.Attendance = &Attendance{Justified: -1}
+			},
+			wantFields: []string{"type", "student.date_of_birth", "subjects[2].name", "attendance.justified"},
+		},
+		{
+			name:       "diploma needs a business number",
+			modify:     func(c *Certificate) { c.Type = TypeDiploma },
+			wantFields: []string{"school.business_number"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := mixedCertificate()
+			tt.modify(cert)
+			body, err := json.Marshal(cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := postValidate(t, body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+			}
+			var resp ValidateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Valid != (len(tt.wantFields) == 0) || resp.Problems == nil {
+				t.Errorf("valid = %v with problems %v", resp.Valid, resp.Problems)
+			}
+			if len(resp.Problems) != len(tt.wantFields) {
+				t.Fatalf("problems = %+v, want fields %v", resp.Problems, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if resp.Problems[i].Field != field {
+					t.Errorf("problems[%d] = %s, want %s", i, resp.Problems[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateEndpointRejectsMalformedJSON(t *testing.T) {
+	if w := postValidate(t, []byte(`{"type":`)); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestRenderEndpointRendersJSONCertificate(t *testing.T) {
+	body, err := json.Marshal(mixedCertificate())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := renderAndDownload(t, "application/json", body)
+	if pages, err := packet.PageCount(doc); err != nil || pages != 1 {
+		t.Errorf("PageCount = %d, %v, want 1", pages, err)
+	}
+}
+
+func TestRenderEndpointAssemblesPacket(t *testing.T) {
+	contentType, body := packetForm(t, mixedCertificate(), map[string][]byte{
+		"cover":    labelledPDF(t, "cover-1", "cover-2"),
+		"appendix": labelledPDF(t, "law-1"),
+	})
+
+	texts := pageTexts(t, renderAndDownload(t, contentType, body))
+	if len(texts) != 4 {
+		t.Fatalf("pages = %d, want 4", len(texts))
+	}
+	if texts[0] != "cover-1" || texts[1] != "cover-2" || texts[3] != "law-1" {
+		t.Errorf("pages = %q, want the cover first and the appendix last", texts)
+	}
+	if strings.Contains(texts[2], "cover") || strings.Contains(texts[2], "law") {
+		t.Errorf("page 3 = %q, want the certificate", texts[2])
+	}
+}
+
+func TestRenderEndpointRejectsInvalidInput(t *testing.T) {
+	incomplete := mixedCertificate()
+	incomplete.Student.FirstName = ""
+
+	tests := []struct {
+		name      string
+		cert      *Certificate
+		files     map[string][]byte
+		wantField string
+	}{
+		{"invalid certificate", incomplete, nil, "student.first_name"},
+		{"cover is not a pdf", mixedCertificate(), map[string][]byte{"cover": []byte("%PDF-1.7\n%%EOF\n")}, "cover"},
+		{"truncated appendix", mixedCertificate(), map[string][]byte{"appendix": labelledPDF(t, "law-1")[:200]}, "appendix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := packetForm(t, tt.cert, tt.files)
+			w := post(t, "/render/certificate", contentType, body)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422: %s", w.Code, w.Body)
+			}
+
+			var resp apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Fields) != 1 || resp.Fields[0].Field != tt.wantField {
+				t.Errorf("fields = %+v, want %s", resp.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+// classBatch returns a batch of n valid certificates for distinct students
+func classBatch(n int) BatchRequest {
+	req := BatchRequest{}
+	for i := range n {
+		cert := mixedCertificate()
+		cert.Student.FirstName = fmt.Sprintf("Student%d", i+1)
+		req.Certificates = append(req.Certificates, *cert)
+	}
+	return req
+}
+
+func TestRenderBatchEndpoint(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	// More certificates than the pool has workers
+	body, err := json.Marshal(classBatch(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := renderAndDownloadFrom(t, "/render/certificate/batch", "application/json", body, "application/zip")
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		doc, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		if pages, err := packet.PageCount(doc); err != nil || pages != 1 {
+			t.Errorf("%s: PageCount = %d, %v, want 1", f.Name, pages, err)
+		}
+	}
+	for i := range 5 {
+		if name := fmt.Sprintf("%03d-Stojanova-Student%d.pdf", i+1, i+1); !names[name] {
+			t.Errorf("archive is missing %s, has %v", name, names)
+		}
+	}
+	if len(names) != 5 {
+		t.Errorf("archive has %d entries, want 5", len(names))
+	}
+	// The archive is built in a temporary file, removed once it is published
+	if leftover, _ := filepath.Glob(filepath.Join(tmp, "svedprint-batch-*")); len(leftover) != 0 {
+		t.Errorf("batch archives left behind: %v", leftover)
+	}
+}
+
+func TestRenderBatchEndpointRejectsBadBatches(t *testing.T) {
+	invalid := classBatch(3)
+	invalid.Certificates[1].Student.FirstName = ""
+
+	tests := []struct {
+		name       string
+		req        BatchRequest
+		wantStatus int
+		wantField  string
+	}{
+		{name: "empty", req: BatchRequest{}, wantStatus: http.StatusUnprocessableEntity, wantField: "Certificates"},
+		{name: "too large", req: classBatch(testMaxBatchItems + 1), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "invalid certificate", req: invalid, wantStatus: http.StatusUnprocessableEntity, wantField: "certificates[1].student.first_name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := post(t, "/render/certificate/batch", "application/json", body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantField == "" {
+				return
+			}
+
+			var resp apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Fields) != 1 || resp.Fields[0].Field != tt.wantField {
+				t.Errorf("fields = %+v, want %s", resp.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRenderEndpointsExportToObjectStorage(t *testing.T) {
+	var mu sync.Mutex
+	uploads := map[string][]byte{}
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploads[r.URL.Path] = data
+		mu.Unlock()
+	}))
+	defer bucket.Close()
+
+	store, err := objectstore.NewS3(objectstore.Config{Endpoint: bucket.URL, Bucket: "school-42", AccessKey: "minio", SecretKey: "minio-secret"})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	publisher := objectstore.NewPublisher(store, "certificates/", time.Hour)
+	NewCertificateHandler(packet.NewPDFMerger(), publisher, batch.NewPool(2, 0), testMaxBatchItems).RegisterRoutes(router.Group("/render"))
+
+	single, err := json.Marshal(mixedCertificate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchBody, err := json.Marshal(classBatch(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     []byte
+		filename string
+	}{
+		{name: "single", path: "/render/certificate", body: single, filename: "testimony.pdf"},
+		{name: "batch", path: "/render/certificate/batch", body: batchBody, filename: "certificates.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+			}
+
+			var link download.Link
+			if err := json.Unmarshal(w.Body.Bytes(), &link); err != nil {
+				t.Fatalf("decode link: %v", err)
+			}
+			if link.Key != "certificates/"+link.JobID+"/"+tt.filename {
+				t.Errorf("key = %q, want certificates/<job>/%s", link.Key, tt.filename)
+			}
+			if !strings.HasPrefix(link.URL, bucket.URL+"/school-42/"+link.Key+"?") {
+				t.Errorf("url = %q, want a presigned URL for the object", link.URL)
+			}
+
+			mu.Lock()
+			data, ok := uploads["/school-42/"+link.Key]
+			mu.Unlock()
+			if !ok || len(data) == 0 {
+				t.Errorf("object %s was not uploaded", link.Key)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// fontFamily is embedded so Cyrillic and Albanian texts render without system fonts
+const fontFamily = "Go"
+
+// Render lays out a validated certificate as a single A4 page PDF, labelled in locale
+func Render(c *Certificate, locale string) ([]byte, error) {
+	l := labelsFor(locale)
+
+	pdf := fpdf.New(fpdf.OrientationPortrait, fpdf.UnitMillimeter, fpdf.PageSizeA4, "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.SetTitle(title(c, l), true)
+	pdf.AddPage()
+
+	pdf.SetFont(fontFamily, "B", 20)
+	pdf.CellFormat(0, 14, title(c, l), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont(fontFamily, "", 11)
+	field := func(label, value string) {
+		pdf.CellFormat(55, 7, label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 7, value, "", 1, "L", false, 0, "")
+	}
+	field(l.School, fmt.Sprintf("%s, %s", c.School.Name, c.School.City))
+	field(l.AcademicYear, c.AcademicYear)
+	field(l.Student, c.Student.FirstName+" "+c.Student.LastName)
+	field(l.FathersName, c.Student.FathersName)
+	field(l.Born, fmt.Sprintf("%s, %s", c.Student.DateOfBirth, c.Student.PlaceOfBirth))
+	if c.Student.PersonalNumber != "" {
+		field(l.PersonalNumber, c.Student.PersonalNumber)
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.CellFormat(130, 8, l.Subject, "1", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 8, l.Grade, "1", 1, "C", false, 0, "")
+	pdf.SetFont(fontFamily, "", 11)
+	for _, subject := range c.Subjects {
+		pdf.CellFormat(130, 8, subject.Name, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 8, subject.GradeText(), "1", 1, "C", false, 0, "")
+	}
+
+	if c.Attendance != nil {
+		pdf.Ln(6)
+		field(l.Justified, strconv.Itoa(c.Attendance.Justified))
+		field(l.Unjustified, strconv.Itoa(c.Attendance.Unjustified))
+	}
+
+	pdf.Ln(20)
+	pdf.CellFormat(0, 7, l.Director, "", 1, "R", false, 0, "")
+	pdf.CellFormat(0, 7, c.School.DirectorName, "", 1, "R", false, 0, "")
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, fmt.Errorf("failed to render certificate: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func title(c *Certificate, l Labels) string {
+	if c.Type == TypeDiploma {
+		return l.Diploma
+	}
+	return l.Testimony
+}
@@ -0,0 +1,109 @@
+package certificate
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Validate checks a certificate against the required-field and grading rules and
+// returns every problem found; an empty result means it can be rendered
+func Validate(c *Certificate) []Problem {
+	v := &validator{}
+
+	switch c.Type {
+	case TypeTestimony, TypeDiploma:
+	case "":
+		v.add("type", "required", "is required")
+	default:
+		v.add("type", "oneof", fmt.Sprintf("must be one of [%s %s]", TypeTestimony, TypeDiploma))
+	}
+	v.required("academic_year", c.AcademicYear)
+
+	v.required("school.name", c.School.Name)
+	v.required("school.director_name", c.School.DirectorName)
+	v.required("school.city", c.School.City)
+	if c.Type == TypeDiploma {
+		v.required("school.business_number", c.School.BusinessNumber)
+	}
+
+	v.required("student.first_name", c.Student.FirstName)
+	v.required("student.last_name", c.Student.LastName)
+	v.required("student.fathers_name", c.Student.FathersName)
+	v.required("student.place_of_birth", c.Student.PlaceOfBirth)
+	if v.required("student.date_of_birth", c.Student.DateOfBirth) {
+		if _, err := time.Parse(time.DateOnly, c.Student.DateOfBirth); err != nil {
+			v.add("student.date_of_birth", "date", "must be a date in YYYY-MM-DD format")
+		}
+	}
+
+	if len(c.Subjects) == 0 {
+		v.add("subjects", "required", "at least one graded subject is required")
+	}
+	seen := make(map[string]bool, len(c.Subjects))
+	for i, subject := range c.Subjects {
+		prefix := fmt.Sprintf("subjects[%d]", i)
+		if v.required(prefix+".name", subject.Name) {
+			if seen[subject.Name] {
+				v.add(prefix+".name", "unique", fmt.Sprintf("subject %q is listed more than once", subject.Name))
+			}
+			seen[subject.Name] = true
+		}
+		v.grade(prefix, subject)
+	}
+
+	if c.Attendance != nil {
+		if c.Attendance.Justified < 0 {
+			v.add("attendance.justified", "gte", "must not be negative")
+		}
+		if c.Attendance.Unjustified < 0 {
+			v.add("attendance.unjustified", "gte", "must not be negative")
+		}
+	}
+
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(field, rule, message string) {
+	v.problems = append(v.problems, Problem{Field: field, Rule: rule, Message: message})
+}
+
+// required records a problem for an empty value and reports whether it was present
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.add(field, "required", "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) grade(prefix string, s Subject) {
+	switch s.Grading.Scheme {
+	case SchemeNumeric:
+		if s.Descriptor != "" {
+			v.add(prefix+".descriptor", "numeric_scheme", "subject is graded numerically")
+		}
+		if s.Grade == nil {
+			v.add(prefix+".grade", "required", "is required")
+			return
+		}
+		if *s.Grade < s.Grading.MinGrade || *s.Grade > s.Grading.MaxGrade {
+			v.add(prefix+".grade", "range", fmt.Sprintf("must be between %d and %d", s.Grading.MinGrade, s.Grading.MaxGrade))
+		}
+	case SchemeDescriptive:
+		if s.Grade != nil {
+			v.add(prefix+".grade", "descriptive_scheme", "subject is graded descriptively")
+		}
+		if v.required(prefix+".descriptor", s.Descriptor) && !slices.Contains(s.Grading.Descriptors, s.Descriptor) {
+			v.add(prefix+".descriptor", "descriptor", fmt.Sprintf("%q is not a descriptor for this subject", s.Descriptor))
+		}
+	case "":
+		v.add(prefix+".grading.scheme", "required", "is required")
+	default:
+		v.add(prefix+".grading.scheme", "oneof", "must be one of [numeric descriptive]")
+	}
+}
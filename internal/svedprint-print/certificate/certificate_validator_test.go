@@ -0,0 +1,127 @@
+package certificate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+var (
+	numericGrading     = Grading{Scheme: SchemeNumeric, MinGrade: 1, MaxGrade: 5}
+	descriptiveGrading = Grading{Scheme: SchemeDescriptive, Descriptors: []string{"passed", "failed"}}
+)
+
+// mixedCertificate has a numerically and a descriptively graded subject
+func mixedCertificate() *Certificate {
+	return &Certificate{
+		Type:         TypeTestimony,
+		AcademicYear: "2024/2025",
+		School:       School{Name: "OOU Goce Delchev", DirectorName: "Marija Petrova", City: "Skopje"},
+		Student: Student{
+			FirstName:    "Ana",
+			LastName:     "Stojanova",
+			FathersName:  "Petar",
+			DateOfBirth:  "2012-03-04",
+			PlaceOfBirth: "Skopje",
+		},
+		Subjects: []Subject{
+			{Name: "Mathematics", Grading: numericGrading, Grade: intPtr(5)},
+			{Name: "Physical education", Grading: descriptiveGrading, Descriptor: "passed"},
+		},
+	}
+}
+
+func TestValidateMixedSchemes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Certificate)
+		want   []Problem
+	}{
+		{name: "valid", modify: func(*Certificate) {}},
+		{
+			name:   "numeric grade out of range",
+			modify: func(c *Certificate) { c.Subjects[0].Grade = intPtr(6) },
+			want:   []Problem{{Field: "subjects[0].grade", Rule: "range", Message: "must be between 1 and 5"}},
+		},
+		{
+			name:   "descriptor on a numeric subject",
+			modify: func(c *Certificate) { c.Subjects[0].Grade, c.Subjects[0].Descriptor = nil, "passed" },
+			want: []Problem{
+				{Field: "subjects[0].descriptor", Rule: "numeric_scheme", Message: "subject is graded numerically"},
+				{Field: "subjects[0].grade", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:   "number on a descriptive subject",
+			modify: func(c *Certificate) { c.Subjects[1].Grade, c.Subjects[1].Descriptor = intPtr(5), "" },
+			want: []Problem{
+				{Field: "subjects[1].grade", Rule: "descriptive_scheme", Message: "subject is graded descriptively"},
+				{Field: "subjects[1].descriptor", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:   "attendance",
+			modify: func(c *Certificate) { c.Attendance = &Attendance{Justified: 10, Unjustified: 2} },
+		},
+		{
+			name:   "negative attendance",
+			modify: func(c *Certificate) { c.Attendance = &Attendance{Justified: -1, Unjustified: -2} },
+			want: []Problem{
+				{Field: "attendance.justified", Rule: "gte", Message: "must not be negative"},
+				{Field: "attendance.unjustified", Rule: "gte", Message: "must not be negative"},
+			},
+		},
+		{
+			name:   "unknown descriptor",
+			modify: func(c *Certificate) { c.Subjects[1].Descriptor = "excellent" },
+			want:   []Problem{{Field: "subjects[1].descriptor", Rule: "descriptor", Message: `"excellent" is not a descriptor for this subject`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := mixedCertificate()
+			tt.modify(c)
+			if got := Validate(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeTextFollowsSubjectScheme(t *testing.T) {
+	c := mixedCertificate()
+	want := []string{"5", "passed"}
+	for i, subject := range c.Subjects {
+		if got := subject.GradeText(); got != want[i] {
+			t.Errorf("%s: GradeText() = %q, want %q", subject.Name, got, want[i])
+		}
+	}
+
+	ungraded := Subject{Name: "Art", Grading: numericGrading}
+	if got := ungraded.GradeText(); got != "" {
+		t.Errorf("ungraded subject: GradeText() = %q, want empty", got)
+	}
+}
+
+func TestCertificateAttendanceIsPrintedWhenPresent(t *testing.T) {
+	c := mixedCertificate()
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"attendance"`) {
+		t.Errorf("certificate without attendance rendered %s", data)
+	}
+
+	c.Attendance = &Attendance{Justified: 10, Unjustified: 2}
+	data, err = json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"attendance":{"justified":10,"unjustified":2}`) {
+		t.Errorf("certificate rendered %s, want the absence counts", data)
+	}
+}
@@ -0,0 +1,76 @@
+package certificate
+
+import "github.com/PegasusMKD/svedprint-go/pkg/i18n"
+
+// Labels are the fixed texts printed on a certificate, in the certificate's locale
+type Labels struct {
+	Testimony      string
+	Diploma        string
+	School         string
+	Student        string
+	FathersName    string
+	PersonalNumber string
+	Born           string
+	AcademicYear   string
+	Subject        string
+	Grade          string
+	Justified      string
+	Unjustified    string
+	Director       string
+}
+
+var labels = map[string]Labels{
+	i18n.Macedonian: {
+		Testimony:      "Свидетелство",
+		Diploma:        "Диплома",
+		School:         "Училиште",
+		Student:        "Ученик",
+		FathersName:    "Име на таткото",
+		PersonalNumber: "ЕМБГ",
+		Born:           "Роден/а",
+		AcademicYear:   "Учебна година",
+		Subject:        "Предмет",
+		Grade:          "Оценка",
+		Justified:      "Оправдани изостаноци",
+		Unjustified:    "Неоправдани изостаноци",
+		Director:       "Директор",
+	},
+	i18n.Albanian: {
+		Testimony:      "Dëftesë",
+		Diploma:        "Diplomë",
+		School:         "Shkolla",
+		Student:        "Nxënësi",
+		FathersName:    "Emri i babait",
+		PersonalNumber: "NUAQ",
+		Born:           "I/E lindur",
+		AcademicYear:   "Viti shkollor",
+		Subject:        "Lënda",
+		Grade:          "Nota",
+		Justified:      "Mungesa të arsyetuara",
+		Unjustified:    "Mungesa të paarsyetuara",
+		Director:       "Drejtori",
+	},
+	i18n.English: {
+		Testimony:      "Certificate of achievement",
+		Diploma:        "Diploma",
+		School:         "School",
+		Student:        "Student",
+		FathersName:    "Father's name",
+		PersonalNumber: "Personal number",
+		Born:           "Born",
+		AcademicYear:   "School year",
+		Subject:        "Subject",
+		Grade:          "Grade",
+		Justified:      "Justified absences",
+		Unjustified:    "Unjustified absences",
+		Director:       "Director",
+	},
+}
+
+// labelsFor returns the labels of a locale, falling back to the default locale
+func labelsFor(locale string) Labels {
+	if l, ok := labels[locale]; ok {
+		return l
+	}
+	return labels[i18n.Default]
+}
@@ -0,0 +1,297 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrLinkInvalid is returned for a token that is malformed or not signed for the job
+	ErrLinkInvalid = errors.New("invalid download link")
+	// ErrLinkExpired is returned for a correctly signed token past its expiry
+	ErrLinkExpired = errors.New("download link expired")
+	// ErrArtifactNotFound is returned for a job whose artifact was never stored or has expired
+	ErrArtifactNotFound = errors.New("artifact not found")
+	// ErrStoreFull is returned when storing an artifact would exceed the store's size limit
+	ErrStoreFull = fmt.Errorf("%w: download store is full", apierror.ErrUnavailable)
+)
+
+// Artifact is a rendered document kept for download. Its contents are Data or, for
+// documents too large to hold in memory, Size bytes read from Body.
+type Artifact struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Body        io.Reader
+	Size        int64
+}
+
+// Contents returns a reader over the artifact's contents and their length
+func (a *Artifact) Contents() (io.Reader, int64) {
+	if a.Body != nil {
+		return a.Body, a.Size
+	}
+	return bytes.NewReader(a.Data), int64(len(a.Data))
+}
+
+// Store keeps rendered artifacts for a limited time
+type Store interface {
+	Put(ctx context.Context, id string, artifact *Artifact, ttl time.Duration) error
+	// Get returns ErrArtifactNotFound once the artifact has expired. The caller closes
+	// the artifact's Body if it is an io.Closer.
+	Get(ctx context.Context, id string) (*Artifact, error)
+}
+
+// Signer creates and checks download tokens: an expiry and an HMAC-SHA256 over the
+// job ID and that expiry, so neither can be changed without the secret
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Sign returns a token granting download of the job until expires
+func (s *Signer) Sign(id string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + s.mac(id, exp)
+}
+
+// Verify checks that token was signed for the job and has not expired at now
+func (s *Signer) Verify(id, token string, now time.Time) error {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrLinkInvalid
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrLinkInvalid
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.mac(id, exp))) {
+		return ErrLinkInvalid
+	}
+	// Checked after the signature, so an expired answer is only given for genuine links
+	if now.Unix() >= expires {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *Signer) mac(id, exp string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id))
+	mac.Write([]byte{0})
+	mac.Write([]byte(exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Link is a signed, time-limited download URL for a stored artifact
+type Link struct {
+	JobID     string    `json:"job_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Key is the object's key when the artifact was exported to object storage
+	Key string `json:"key,omitempty"`
+}
+
+// Publisher stores a rendered artifact and returns a link to it. Downloads serves
+// artifacts from the print service itself; objectstore.Publisher exports them.
+type Publisher interface {
+	Publish(ctx context.Context, artifact *Artifact) (*Link, error)
+}
+
+// Downloads stores rendered artifacts and hands out signed links to them
+type Downloads struct {
+	store  Store
+	signer *Signer
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewDownloads keeps artifacts and their links valid for ttl
+func NewDownloads(store Store, signer *Signer, ttl time.Duration) *Downloads {
+	return &Downloads{store: store, signer: signer, ttl: ttl, now: time.Now}
+}
+
+// Publish stores an artifact under a random job ID and returns its download link. The
+// URL is relative to the print service.
+func (d *Downloads) Publish(ctx context.Context, artifact *Artifact) (*Link, error) {
+	id, err := NewJobID()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.store.Put(ctx, id, artifact, d.ttl); err != nil {
+		return nil, fmt.Errorf("failed to store artifact: %w", err)
+	}
+
+	expires := d.now().Add(d.ttl)
+	token := d.signer.Sign(id, expires)
+	return &Link{
+		JobID:     id,
+		URL:       fmt.Sprintf("/render/jobs/%s/download?token=%s", id, url.QueryEscape(token)),
+		ExpiresAt: expires.UTC().Truncate(time.Second),
+	}, nil
+}
+
+// Open verifies the token and returns the job's artifact
+func (d *Downloads) Open(ctx context.Context, id, token string) (*Artifact, error) {
+	if err := d.signer.Verify(id, token, d.now()); err != nil {
+		return nil, err
+	}
+	return d.store.Get(ctx, id)
+}
+
+// NewJobID returns 128 random bits, hex encoded
+func NewJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DirStore is a Store keeping artifacts as files in a directory of their own, so
+// rendered documents don't pile up in memory. Artifacts are lost on restart and are
+// only downloadable from the instance that rendered them. Expired files are removed on
+// every Put and Get, and together the files never exceed the store's byte limit.
+type DirStore struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]dirEntry
+	size    int64
+	now     func() time.Time
+}
+
+type dirEntry struct {
+	filename    string
+	contentType string
+	size        int64
+	expires     time.Time
+}
+
+// NewDirStore keeps up to maxBytes of artifacts in a new directory under parent, the
+// system temp directory when empty. Close removes the directory.
+func NewDirStore(parent string, maxBytes int64) (*DirStore, error) {
+	dir, err := os.MkdirTemp(parent, "svedprint-downloads-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	return &DirStore{dir: dir, maxBytes: maxBytes, entries: make(map[string]dirEntry), now: time.Now}, nil
+}
+
+// Put copies the artifact to its own file. It fails with ErrStoreFull if the artifact
+// doesn't fit next to those that have not expired yet.
+func (s *DirStore) Put(_ context.Context, id string, artifact *Artifact, ttl time.Duration) error {
+	contents, size := artifact.Contents()
+
+	s.mu.Lock()
+	s.sweep()
+	if s.size+size > s.maxBytes {
+		s.mu.Unlock()
+		return ErrStoreFull
+	}
+	// Reserved before writing, so concurrent Puts can't exceed the limit together
+	s.size += size
+	s.mu.Unlock()
+
+	if err := writeFile(s.path(id), contents, size); err != nil {
+		os.Remove(s.path(id))
+		s.mu.Lock()
+		s.size -= size
+		s.mu.Unlock()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = dirEntry{
+		filename:    artifact.Filename,
+		contentType: artifact.ContentType,
+		size:        size,
+		expires:     s.now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *DirStore) Get(_ context.Context, id string) (*Artifact, error) {
+	s.mu.Lock()
+	s.sweep()
+	entry, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrArtifactNotFound
+	}
+
+	// An open file stays readable even if a concurrent sweep removes it
+	file, err := os.Open(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return &Artifact{Filename: entry.filename, ContentType: entry.contentType, Body: file, Size: entry.size}, nil
+}
+
+// Close removes the directory and every artifact in it
+func (s *DirStore) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// sweep removes expired artifacts; callers hold s.mu
+func (s *DirStore) sweep() {
+	now := s.now()
+	for id, entry := range s.entries {
+		if now.Before(entry.expires) {
+			continue
+		}
+		if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Warn().Err(err).Str("job_id", id).Msg("Failed to remove expired artifact")
+		}
+		delete(s.entries, id)
+		s.size -= entry.size
+	}
+}
+
+// writeFile copies exactly size bytes from r to a new file at path
+func writeFile(path string, r io.Reader, size int64) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(file, io.LimitReader(r, size))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != size {
+		err = fmt.Errorf("wrote %d of %d bytes", n, size)
+	}
+	return err
+}
+
+// path locates an artifact's file. Only IDs from Downloads.Publish reach it, so they
+// are plain hex.
+func (s *DirStore) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
@@ -0,0 +1,53 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+type DownloadHandler struct {
+	downloads *Downloads
+}
+
+func NewDownloadHandler(downloads *Downloads) *DownloadHandler {
+	return &DownloadHandler{downloads: downloads}
+}
+
+func (h *DownloadHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/jobs/:id/download", h.Download)
+}
+
+// Download serves a rendered artifact to the holder of a signed link. A tampered
+// token is 403 DOWNLOAD_LINK_INVALID and an expired one 410 DOWNLOAD_LINK_EXPIRED.
+func (h *DownloadHandler) Download(c *gin.Context) {
+	artifact, err := h.downloads.Open(c.Request.Context(), c.Param("id"), c.Query("token"))
+	switch {
+	case errors.Is(err, ErrLinkInvalid):
+		apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeDownloadLinkInvalid, "download link is invalid"))
+		return
+	case errors.Is(err, ErrLinkExpired):
+		apierror.Respond(c, apierror.NewWithCode(http.StatusGone, apierror.CodeDownloadLinkExpired, "download link has expired"))
+		return
+	case errors.Is(err, ErrArtifactNotFound):
+		apierror.Respond(c, apierror.NewWithCode(http.StatusNotFound, apierror.CodeNotFound, "document is no longer available"))
+		return
+	case err != nil:
+		apierror.Respond(c, err)
+		return
+	}
+
+	if closer, ok := artifact.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	contents, size := artifact.Contents()
+	c.DataFromReader(http.StatusOK, size, artifact.ContentType, contents, map[string]string{
+		"Cache-Control":       "private, no-store",
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", artifact.Filename),
+	})
+}
@@ -0,0 +1,124 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// newDownloadRouter serves downloads under /render as the print service does; the
+// returned pointer sets the time both the links and the store see
+func newDownloadRouter(t *testing.T) (*gin.Engine, *Downloads, *time.Time) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	now := testNow
+	clock := func() time.Time { return now }
+	store, err := NewDirStore(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	store.now = clock
+	downloads := NewDownloads(store, NewSigner([]byte("download-secret")), 15*time.Minute)
+	downloads.now = clock
+
+	router := gin.New()
+	NewDownloadHandler(downloads).RegisterRoutes(router.Group("/render"))
+	return router, downloads, &now
+}
+
+func TestDownload(t *testing.T) {
+	router, downloads, now := newDownloadRouter(t)
+	pdf := []byte("%PDF-1.7 certificate")
+	link, err := downloads.Publish(context.Background(), &Artifact{Filename: "certificate.pdf", ContentType: "application/pdf", Data: pdf})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if !link.ExpiresAt.Equal(testNow.Add(15 * time.Minute)) {
+		t.Errorf("link expires at %s, want 15m from now", link.ExpiresAt)
+	}
+
+	u, err := url.Parse(link.URL)
+	if err != nil {
+		t.Fatalf("link URL %q: %v", link.URL, err)
+	}
+	token := u.Query().Get("token")
+	exp, sig, _ := strings.Cut(token, ".")
+	tampered := exp + "." + strings.Repeat("0", len(sig))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		after      time.Duration
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{name: "valid link", path: u.Path, token: token, wantStatus: http.StatusOK},
+		{name: "valid just before expiry", path: u.Path, token: token, after: 15*time.Minute - time.Second, wantStatus: http.StatusOK},
+		{name: "expired link", path: u.Path, token: token, after: 15 * time.Minute, wantStatus: http.StatusGone, wantCode: apierror.CodeDownloadLinkExpired},
+		{name: "tampered signature", path: u.Path, token: tampered, wantStatus: http.StatusForbidden, wantCode: apierror.CodeDownloadLinkInvalid},
+		{name: "tampered expiry", path: u.Path, token: "9999999999." + sig, after: time.Hour, wantStatus: http.StatusForbidden, wantCode: apierror.CodeDownloadLinkInvalid},
+		{name: "token of another job", path: "/render/jobs/0b8a3c1e4d8f4a7e9c553f1a2b6d7e80/download", token: token, wantStatus: http.StatusForbidden, wantCode: apierror.CodeDownloadLinkInvalid},
+		{name: "missing token", path: u.Path, wantStatus: http.StatusForbidden, wantCode: apierror.CodeDownloadLinkInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*now = testNow.Add(tt.after)
+			target := tt.path
+			if tt.token != "" {
+				target += "?token=" + url.QueryEscape(tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if w.Body.String() != string(pdf) {
+					t.Errorf("body = %q, want the artifact", w.Body)
+				}
+				if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+					t.Errorf("Content-Type = %q", got)
+				}
+				if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="certificate.pdf"` {
+					t.Errorf("Content-Disposition = %q", got)
+				}
+				if got := w.Header().Get("Cache-Control"); !strings.Contains(got, "no-store") {
+					t.Errorf("Cache-Control = %q, want no-store", got)
+				}
+				return
+			}
+
+			var body apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestDownloadArtifactGone(t *testing.T) {
+	router, downloads, _ := newDownloadRouter(t)
+
+	// A genuine link whose artifact was never stored, e.g. one rendered by another instance
+	const job = "5f0f4a4e8f4e4b7a9d360d6b1f0c2a11"
+	token := downloads.signer.Sign(job, testNow.Add(time.Minute))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/render/jobs/"+job+"/download?token="+url.QueryEscape(token), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
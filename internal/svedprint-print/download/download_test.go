@@ -0,0 +1,163 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner([]byte("download-secret"))
+	const job = "5f0f4a4e8f4e4b7a9d360d6b1f0c2a11"
+	token := signer.Sign(job, testNow.Add(time.Hour))
+	exp, sig, _ := strings.Cut(token, ".")
+
+	// flip changes the last hex digit of the signature
+	flip := func(sig string) string {
+		last := sig[len(sig)-1]
+		if last == '0' {
+			return sig[:len(sig)-1] + "1"
+		}
+		return sig[:len(sig)-1] + "0"
+	}
+
+	tests := []struct {
+		name    string
+		job     string
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", job: job, token: token, now: testNow},
+		{name: "valid until the last second", job: job, token: token, now: testNow.Add(time.Hour - time.Second)},
+		{name: "expired", job: job, token: token, now: testNow.Add(time.Hour), wantErr: ErrLinkExpired},
+		{name: "tampered signature", job: job, token: exp + "." + flip(sig), now: testNow, wantErr: ErrLinkInvalid},
+		{name: "extended expiry", job: job, token: "9999999999." + sig, now: testNow, wantErr: ErrLinkInvalid},
+		{name: "expired and extended", job: job, token: "9999999999." + sig, now: testNow.Add(2 * time.Hour), wantErr: ErrLinkInvalid},
+		{name: "another job", job: "0b8a3c1e4d8f4a7e9c553f1a2b6d7e80", token: token, now: testNow, wantErr: ErrLinkInvalid},
+		{name: "another secret", job: job, token: NewSigner([]byte("other-secret")).Sign(job, testNow.Add(time.Hour)), now: testNow, wantErr: ErrLinkInvalid},
+		{name: "no separator", job: job, token: sig, now: testNow, wantErr: ErrLinkInvalid},
+		{name: "non-numeric expiry", job: job, token: "soon." + sig, now: testNow, wantErr: ErrLinkInvalid},
+		{name: "empty", job: job, token: "", now: testNow, wantErr: ErrLinkInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.job, tt.token, tt.now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDirStoreExpires(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	store, err := NewDirStore(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	store.now = func() time.Time { return now }
+
+	artifact := &Artifact{Filename: "certificate.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.7")}
+	if err := store.Put(ctx, "job-1", artifact, time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, err := store.Get(ctx, "job-1"); err != nil || readArtifact(t, got) != "application/pdf certificate.pdf %PDF-1.7" {
+		t.Errorf("Get = %v, %v, want the artifact", got, err)
+	}
+	if _, err := store.Get(ctx, "job-2"); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("Get of an unknown job = %v, want ErrArtifactNotFound", err)
+	}
+
+	// Expired artifacts are removed by the next Get rather than kept on disk
+	now = now.Add(time.Minute)
+	if _, err := store.Get(ctx, "job-1"); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("Get after the TTL = %v, want ErrArtifactNotFound", err)
+	}
+	if _, err := os.Stat(store.path("job-1")); !errors.Is(err, fs.ErrNotExist) || len(store.entries) != 0 || store.size != 0 {
+		t.Errorf("after expiry: stat %v, %d entries of %d bytes, want nothing left", err, len(store.entries), store.size)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(store.dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Close left the directory: %v", err)
+	}
+}
+
+func TestDirStoreStreamsBodies(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+
+	zip := &Artifact{Filename: "certificates.zip", ContentType: "application/zip", Body: strings.NewReader("PK archive"), Size: 10}
+	if err := store.Put(ctx, "job-1", zip, time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, err := store.Get(ctx, "job-1"); err != nil || readArtifact(t, got) != "application/zip certificates.zip PK archive" {
+		t.Errorf("Get = %v, %v, want the streamed artifact", got, err)
+	}
+
+	// A body shorter than its size is not stored
+	short := &Artifact{Filename: "certificates.zip", Body: strings.NewReader("PK"), Size: 10}
+	if err := store.Put(ctx, "job-2", short, time.Minute); err == nil {
+		t.Error("Put of a truncated body succeeded")
+	}
+	if _, err := store.Get(ctx, "job-2"); !errors.Is(err, ErrArtifactNotFound) || store.size != 10 {
+		t.Errorf("after a failed Put: Get = %v, %d bytes stored, want only job-1's", err, store.size)
+	}
+}
+
+// readArtifact describes a stored artifact as "<content type> <filename> <contents>"
+func readArtifact(t *testing.T, artifact *Artifact) string {
+	t.Helper()
+	if closer, ok := artifact.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+	contents, size := artifact.Contents()
+	data, err := io.ReadAll(contents)
+	if err != nil || int64(len(data)) != size {
+		t.Fatalf("read %d of %d bytes: %v", len(data), size, err)
+	}
+	return artifact.ContentType + " " + artifact.Filename + " " + string(data)
+}
+
+func TestDirStoreLimitsSize(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	store, err := NewDirStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	store.now = func() time.Time { return now }
+
+	artifact := func(size int) *Artifact { return &Artifact{Filename: "batch.zip", Data: make([]byte, size)} }
+	if err := store.Put(ctx, "job-1", artifact(6), time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(ctx, "job-2", artifact(5), 2*time.Minute); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("Put past the limit = %v, want ErrStoreFull", err)
+	}
+	if err := store.Put(ctx, "job-2", artifact(4), 2*time.Minute); err != nil {
+		t.Errorf("Put up to the limit: %v", err)
+	}
+
+	// Space is given back as artifacts expire
+	now = now.Add(time.Minute)
+	if err := store.Put(ctx, "job-3", artifact(6), time.Minute); err != nil {
+		t.Errorf("Put after job-1 expired: %v", err)
+	}
+	if _, err := store.Get(ctx, "job-2"); err != nil {
+		t.Errorf("Get(job-2): %v", err)
+	}
+}
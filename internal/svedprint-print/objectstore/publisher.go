@@ -0,0 +1,58 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/download"
+)
+
+// Publisher exports rendered artifacts to object storage and links to them with
+// presigned URLs, so large batches go straight to a school's bucket instead of
+// through the print service. Objects outlive their links; the bucket's lifecycle
+// rules decide how long they are kept.
+type Publisher struct {
+	store  *S3
+	prefix string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewPublisher stores objects under prefix, e.g. "certificates/", with links valid
+// for ttl
+func NewPublisher(store *S3, prefix string, ttl time.Duration) *Publisher {
+	return &Publisher{store: store, prefix: prefix, ttl: min(ttl, MaxPresignTTL), now: time.Now}
+}
+
+// Publish uploads the artifact as <prefix><job id>/<filename> and returns its key and
+// a presigned download URL
+func (p *Publisher) Publish(ctx context.Context, artifact *download.Artifact) (*download.Link, error) {
+	id, err := download.NewJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base("/" + artifact.Filename)
+	if name == "/" {
+		name = "document"
+	}
+	key := p.prefix + id + "/" + name
+	contents, size := artifact.Contents()
+	if err := p.store.Put(ctx, key, artifact.ContentType, contents, size); err != nil {
+		return nil, fmt.Errorf("failed to export artifact: %w", err)
+	}
+
+	expires := p.now().Add(p.ttl)
+	url, err := p.store.PresignGet(ctx, key, p.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &download.Link{
+		JobID:     id,
+		URL:       url,
+		ExpiresAt: expires.UTC().Truncate(time.Second),
+		Key:       key,
+	}, nil
+}
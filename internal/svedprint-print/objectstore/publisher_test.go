@@ -0,0 +1,202 @@
+package objectstore
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/download"
+)
+
+// minioStub keeps uploaded objects in memory, accepting uploads signed for its bucket
+// and downloads through presigned URLs
+type minioStub struct {
+	*httptest.Server
+	mu      sync.Mutex
+	objects map[string]object
+	fail    bool
+}
+
+type object struct {
+	contentType string
+	data        []byte
+}
+
+func newMinioStub(t *testing.T) *minioStub {
+	t.Helper()
+	s := &minioStub{objects: map[string]object{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *minioStub) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		if s.fail {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>")
+			return
+		}
+		data, err := readPayload(r)
+		if err != nil || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") {
+			http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+			return
+		}
+		s.objects[r.URL.Path] = object{contentType: r.Header.Get("Content-Type"), data: data}
+	case http.MethodGet:
+		if r.URL.Query().Get("X-Amz-Signature") == "" {
+			http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+			return
+		}
+		obj, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// readPayload returns an upload's data, undoing the aws-chunked encoding of uploads
+// signed chunk by chunk, as the MinIO client does over plain HTTP
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	body := bufio.NewReader(r.Body)
+	for {
+		header, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2) // and the CRLF ending it
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func newTestPublisher(t *testing.T, stub *minioStub) *Publisher {
+	t.Helper()
+	store, err := NewS3(Config{Endpoint: stub.URL, Bucket: "certificates", AccessKey: "minio", SecretKey: "minio123"})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return NewPublisher(store, "school_a/", time.Hour)
+}
+
+func TestPublishUploadsAndLinks(t *testing.T) {
+	stub := newMinioStub(t)
+	publisher := newTestPublisher(t, stub)
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	publisher.now = func() time.Time { return now }
+
+	artifact := &download.Artifact{Filename: "certificate-ana.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.7 ana")}
+	link, err := publisher.Publish(context.Background(), artifact)
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if want := "school_a/" + link.JobID + "/certificate-ana.pdf"; link.JobID == "" || link.Key != want {
+		t.Errorf("key = %q, want %q", link.Key, want)
+	}
+	if !link.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expires at %s, want %s", link.ExpiresAt, now.Add(time.Hour))
+	}
+	obj, ok := stub.objects["/certificates/"+link.Key]
+	if !ok {
+		t.Fatalf("no object uploaded at %s", link.Key)
+	}
+	if obj.contentType != "application/pdf" || string(obj.data) != "%PDF-1.7 ana" {
+		t.Errorf("uploaded %s %q", obj.contentType, obj.data)
+	}
+
+	// The link downloads the object without credentials
+	resp, err := http.Get(link.URL)
+	if err != nil {
+		t.Fatalf("GET link: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "%PDF-1.7 ana" {
+		t.Errorf("link served %d %q", resp.StatusCode, body)
+	}
+	if !strings.Contains(link.URL, "X-Amz-Expires=3600") {
+		t.Errorf("link %s is not valid for an hour", link.URL)
+	}
+}
+
+func TestPublishSanitizesFilenames(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{filename: "transcript.pdf", want: "transcript.pdf"},
+		{filename: "../../other_school/transcript.pdf", want: "transcript.pdf"},
+		{filename: "", want: "document"},
+	}
+	stub := newMinioStub(t)
+	publisher := newTestPublisher(t, stub)
+	for _, tt := range tests {
+		link, err := publisher.Publish(context.Background(), &download.Artifact{Filename: tt.filename, ContentType: "application/pdf"})
+		if err != nil {
+			t.Fatalf("Publish(%q): %v", tt.filename, err)
+		}
+		if want := "school_a/" + link.JobID + "/" + tt.want; link.Key != want {
+			t.Errorf("Publish(%q) key = %q, want %q", tt.filename, link.Key, want)
+		}
+	}
+}
+
+func TestPublishUploadFailure(t *testing.T) {
+	stub := newMinioStub(t)
+	stub.fail = true
+	publisher := newTestPublisher(t, stub)
+
+	_, err := publisher.Publish(context.Background(), &download.Artifact{Filename: "certificate.pdf", ContentType: "application/pdf"})
+	if err == nil || !strings.Contains(err.Error(), "Access Denied") {
+		t.Errorf("Publish = %v, want the upload's AccessDenied", err)
+	}
+}
+
+func TestNewPublisherCapsLinkLifetime(t *testing.T) {
+	stub := newMinioStub(t)
+	store, err := NewS3(Config{Endpoint: stub.URL, Bucket: "certificates", AccessKey: "minio", SecretKey: "minio123"})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	publisher := NewPublisher(store, "", 30*24*time.Hour)
+
+	link, err := publisher.Publish(context.Background(), &download.Artifact{Filename: "certificate.pdf", ContentType: "application/pdf"})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if !strings.Contains(link.URL, "X-Amz-Expires=604800") {
+		t.Errorf("link %s, want one valid for the longest allowed week", link.URL)
+	}
+}
@@ -0,0 +1,89 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// MaxPresignTTL is the longest validity SigV4 allows for a presigned URL
+const MaxPresignTTL = 7 * 24 * time.Hour
+
+// Config locates a bucket on S3 or an S3-compatible server such as MinIO
+type Config struct {
+	// Endpoint is the server's base URL, e.g. https://s3.eu-central-1.amazonaws.com
+	// or http://minio:9000. Buckets are addressed path-style, which both accept.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 uploads objects and presigns downloads through the MinIO client, which signs
+// every request with AWS Signature Version 4
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3 creates a client for the configured bucket. An empty region means us-east-1,
+// which MinIO accepts by default; a known region also spares the client from asking
+// the server for the bucket's location.
+func NewS3(cfg Config) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid object storage endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("object storage bucket is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("object storage access key and secret key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid object storage configuration: %w", err)
+	}
+	return &S3{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads size bytes read from r as the object key
+func (s *S3) Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, strings.TrimPrefix(key, "/"), r, size,
+		minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// PresignGet returns a URL that downloads the object key without credentials until
+// ttl passes
+func (s *S3) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > MaxPresignTTL {
+		return "", fmt.Errorf("presigned URL lifetime must be between 1s and %s, got %s", MaxPresignTTL, ttl)
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, strings.TrimPrefix(key, "/"), ttl, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %s: %w", key, err)
+	}
+	return u.String(), nil
+}
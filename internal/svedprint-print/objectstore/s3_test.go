@@ -0,0 +1,71 @@
+package objectstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+var minioConfig = Config{Endpoint: "http://minio:9000/", Bucket: "certificates", AccessKey: "minio", SecretKey: "minio123"}
+
+func newMinioS3(t *testing.T) *S3 {
+	t.Helper()
+	s, err := NewS3(minioConfig)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s
+}
+
+func TestPresignGetBoundsLifetime(t *testing.T) {
+	s := newMinioS3(t)
+	for _, ttl := range []time.Duration{0, -time.Minute, MaxPresignTTL + time.Second} {
+		if _, err := s.PresignGet(context.Background(), "test.txt", ttl); err == nil {
+			t.Errorf("PresignGet with lifetime %s succeeded", ttl)
+		}
+	}
+	if _, err := s.PresignGet(context.Background(), "test.txt", MaxPresignTTL); err != nil {
+		t.Errorf("PresignGet with the longest lifetime: %v", err)
+	}
+}
+
+func TestPresignGetAddressesKeysPathStyle(t *testing.T) {
+	s := newMinioS3(t)
+	got, err := s.PresignGet(context.Background(), "/school a/Ана+1.pdf", time.Hour)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	if want := "http://minio:9000/certificates/school%20a/%D0%90%D0%BD%D0%B0%2B1.pdf?"; !strings.HasPrefix(got, want) {
+		t.Errorf("PresignGet = %s, want it to start with %s", got, want)
+	}
+	// An empty region signs for us-east-1, which MinIO accepts by default
+	for _, param := range []string{"X-Amz-Algorithm=AWS4-HMAC-SHA256", "%2Fus-east-1%2Fs3%2Faws4_request", "X-Amz-Expires=3600", "X-Amz-Signature="} {
+		if !strings.Contains(got, param) {
+			t.Errorf("PresignGet = %s, want %s", got, param)
+		}
+	}
+}
+
+func TestNewS3Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{name: "no endpoint", modify: func(c *Config) { c.Endpoint = "" }},
+		{name: "endpoint without scheme", modify: func(c *Config) { c.Endpoint = "minio:9000" }},
+		{name: "unsupported scheme", modify: func(c *Config) { c.Endpoint = "ftp://minio:9000" }},
+		{name: "no bucket", modify: func(c *Config) { c.Bucket = "" }},
+		{name: "no access key", modify: func(c *Config) { c.AccessKey = "" }},
+		{name: "no secret key", modify: func(c *Config) { c.SecretKey = "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := minioConfig
+			tt.modify(&cfg)
+			if _, err := NewS3(cfg); err == nil {
+				t.Error("NewS3 succeeded")
+			}
+		})
+	}
+}
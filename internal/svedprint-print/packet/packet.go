@@ -0,0 +1,95 @@
+package packet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// MaxAttachmentSize bounds uploaded cover and appendix PDFs
+const MaxAttachmentSize = 10 << 20
+
+// ErrNoMerger is returned when a packet needs merging but no PDF merger is configured
+var ErrNoMerger = errors.New("no PDF merger configured")
+
+// Merger concatenates PDF documents in order into a single document
+type Merger interface {
+	Merge(ctx context.Context, docs ...[]byte) ([]byte, error)
+}
+
+// Packet is an official certificate packet: an optional school-provided cover page,
+// the generated certificate pages and an optional legal appendix
+type Packet struct {
+	Cover    []byte
+	Body     []byte
+	Appendix []byte
+}
+
+// Validate checks that the uploaded attachments are PDF documents the merger can read
+func (p *Packet) Validate() error {
+	var fields []apierror.FieldError
+	if err := validatePDF(p.Cover); err != nil {
+		fields = append(fields, apierror.Field("cover", "pdf", err.Error()))
+	}
+	if err := validatePDF(p.Appendix); err != nil {
+		fields = append(fields, apierror.Field("appendix", "pdf", err.Error()))
+	}
+
+	if len(fields) > 0 {
+		return apierror.NewValidationError(fields...)
+	}
+	return nil
+}
+
+// Documents returns the packet's documents in print order, skipping missing attachments
+func (p *Packet) Documents() [][]byte {
+	docs := make([][]byte, 0, 3)
+	for _, doc := range [][]byte{p.Cover, p.Body, p.Appendix} {
+		if len(doc) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// Assemble validates the packet and merges cover, body and appendix into one PDF.
+// A packet without attachments is returned as-is.
+func Assemble(ctx context.Context, merger Merger, p *Packet) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	docs := p.Documents()
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+	if merger == nil {
+		return nil, ErrNoMerger
+	}
+
+	merged, err := merger.Merge(ctx, docs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge certificate packet: %w", err)
+	}
+	return merged, nil
+}
+
+// validatePDF parses an attachment and checks it has pages; an empty attachment is allowed
+func validatePDF(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) > MaxAttachmentSize {
+		return fmt.Errorf("must not exceed %d bytes", MaxAttachmentSize)
+	}
+	pages, err := PageCount(data)
+	if err != nil {
+		return errors.New("is not a readable PDF document")
+	}
+	if pages == 0 {
+		return errors.New("has no pages")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package packet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/go-pdf/fpdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// testPDF builds a document with one page per label, each page printing its label so
+// merge order can be read back
+func testPDF(t *testing.T, labels ...string) []byte {
+	t.Helper()
+	pdf := fpdf.New(fpdf.OrientationPortrait, fpdf.UnitMillimeter, fpdf.PageSizeA4, "")
+	pdf.SetFont("Helvetica", "", 12)
+	for _, label := range labels {
+		pdf.AddPage()
+		pdf.Text(20, 20, label)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		t.Fatalf("building test PDF: %v", err)
+	}
+	return out.Bytes()
+}
+
+var showText = regexp.MustCompile(`\((.*?)\) Tj`)
+
+// pageLabels parses doc and returns the label printed on each of its pages, in order
+func pageLabels(t *testing.T, doc []byte) []string {
+	t.Helper()
+	ctx, err := api.ReadAndValidate(bytes.NewReader(doc), newConfig())
+	if err != nil {
+		t.Fatalf("parsing merged PDF: %v", err)
+	}
+
+	labels := make([]string, 0, ctx.PageCount)
+	for page := 1; page <= ctx.PageCount; page++ {
+		r, err := pdfcpu.ExtractPageContent(ctx, page)
+		if err != nil {
+			t.Fatalf("reading page %d: %v", page, err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading page %d: %v", page, err)
+		}
+		var texts []string
+		for _, m := range showText.FindAllSubmatch(content, -1) {
+			texts = append(texts, string(m[1]))
+		}
+		labels = append(labels, strings.Join(texts, " "))
+	}
+	return labels
+}
+
+// countingMerger records how often the real merger is used
+type countingMerger struct {
+	PDFMerger
+	calls int
+}
+
+func (m *countingMerger) Merge(ctx context.Context, docs ...[]byte) ([]byte, error) {
+	m.calls++
+	return m.PDFMerger.Merge(ctx, docs...)
+}
+
+func TestAssemblePageOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet Packet
+		want   []string
+	}{
+		{
+			name:   "cover, body and appendix",
+			packet: Packet{Cover: testPDF(t, "cover"), Body: testPDF(t, "cert-1", "cert-2"), Appendix: testPDF(t, "law-1", "law-2", "law-3")},
+			want:   []string{"cover", "cert-1", "cert-2", "law-1", "law-2", "law-3"},
+		},
+		{
+			name:   "cover only",
+			packet: Packet{Cover: testPDF(t, "cover"), Body: testPDF(t, "cert-1")},
+			want:   []string{"cover", "cert-1"},
+		},
+		{
+			name:   "appendix only",
+			packet: Packet{Body: testPDF(t, "cert-1"), Appendix: testPDF(t, "law-1")},
+			want:   []string{"cert-1", "law-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := Assemble(context.Background(), NewPDFMerger(), &tt.packet)
+			if err != nil {
+				t.Fatalf("Assemble: %v", err)
+			}
+			pages, err := PageCount(merged)
+			if err != nil || pages != len(tt.want) {
+				t.Fatalf("PageCount = %d, %v, want %d", pages, err, len(tt.want))
+			}
+			got := pageLabels(t, merged)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssembleWithoutAttachmentsSkipsMerge(t *testing.T) {
+	body := testPDF(t, "cert-1")
+	merger := &countingMerger{}
+
+	got, err := Assemble(context.Background(), merger, &Packet{Body: body})
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if !bytes.Equal(got, body) || merger.calls != 0 {
+		t.Errorf("body was not returned as-is (%d merges)", merger.calls)
+	}
+
+	if _, err := Assemble(context.Background(), nil, &Packet{Body: body, Cover: testPDF(t, "cover")}); !errors.Is(err, ErrNoMerger) {
+		t.Errorf("Assemble without merger = %v, want ErrNoMerger", err)
+	}
+}
+
+func TestValidateRejectsBadAttachments(t *testing.T) {
+	tests := []struct {
+		name       string
+		packet     Packet
+		wantFields []string
+	}{
+		{"not a pdf", Packet{Cover: []byte("hello")}, []string{"cover"}},
+		{"pdf header only", Packet{Cover: []byte("%PDF-1.7\n%%EOF\n")}, []string{"cover"}},
+		{"truncated", Packet{Appendix: testPDF(t, "law-1")[:200]}, []string{"appendix"}},
+		{"too large", Packet{Cover: append(testPDF(t, "cover"), make([]byte, MaxAttachmentSize)...)}, []string{"cover"}},
+		{"both bad", Packet{Cover: []byte("x"), Appendix: []byte("y")}, []string{"cover", "appendix"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merger := &countingMerger{}
+			_, err := Assemble(context.Background(), merger, &tt.packet)
+
+			var validationErr *apierror.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Assemble = %v, want a validation error", err)
+			}
+			if len(validationErr.Fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %+v, want %v", validationErr.Fields, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if validationErr.Fields[i].Field != field {
+					t.Errorf("fields[%d] = %s, want %s", i, validationErr.Fields[i].Field, field)
+				}
+			}
+			if merger.calls != 0 {
+				t.Error("invalid packet was merged")
+			}
+		})
+	}
+}
@@ -1,19 +1,38 @@
 package svedprintprint
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/batch"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/certificate"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/download"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/objectstore"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/packet"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/transcript"
+	"github.com/PegasusMKD/svedprint-go/pkg/config"
+	"github.com/PegasusMKD/svedprint-go/pkg/health"
+	"github.com/PegasusMKD/svedprint-go/pkg/lifecycle"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type GinServer struct {
-	addr   string
-	engine *gin.Engine
+	addr      string
+	engine    *gin.Engine
+	lifecycle *lifecycle.Lifecycle
 }
 
 func (gs *GinServer) Run() {
-	gs.engine.Run(gs.addr)
+	srv := &http.Server{Addr: gs.addr, Handler: gs.engine}
+	if err := gs.lifecycle.Run(srv); err != nil {
+		log.Fatal().Err(err).Msg("Server stopped unexpectedly")
+	}
 }
 
 func NewServer() *GinServer {
@@ -23,20 +42,94 @@ func NewServer() *GinServer {
 	}
 	addr := fmt.Sprintf(":%s", port)
 
-	router := gin.Default()
+	cfg, err := config.Load("svedprint-print")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed loading config for svedprint-print")
+	}
+
+	lc := lifecycle.New(cfg.ShutdownTimeout)
+	router := gin.New()
+
+	setupMiddleware(router, cfg)
+	setupHealth(router, lc)
+	setupRoutes(router, cfg, lc)
+
+	return &GinServer{engine: router, addr: addr, lifecycle: lc}
+}
 
-	setupMiddleware(router)
-	setupRoutes(router)
+func setupHealth(router *gin.Engine, lc *lifecycle.Lifecycle) {
+	gate := health.NewGate()
+	lc.OnReady(gate.MarkReady)
 
-	return &GinServer{engine: router, addr: addr}
+	router.Use(gate.Middleware())
+	gate.RegisterRoutes(router)
 }
 
-func setupMiddleware(router *gin.Engine) {
-	router.Use(gin.Logger())
+func setupMiddleware(router *gin.Engine, cfg *config.Config) {
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID(requestid.MustNew(cfg.RequestIDFormat)))
+	router.Use(middleware.AccessLog(cfg.AccessLogFormat, os.Stdout))
+	router.Use(middleware.PrettyJSON(cfg.PrettyJSON))
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
+	router.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxQueuedRequests))
+	router.Use(middleware.RequestLogger())
 }
 
-func setupRoutes(router *gin.Engine) {
+func setupRoutes(router *gin.Engine, cfg *config.Config, lc *lifecycle.Lifecycle) {
 	router.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{"status": "healthy"})
 	})
+
+	downloads := setupDownloads(cfg, lc)
+	publisher := setupPublisher(cfg, downloads)
+	// One pool for the whole service, so concurrent batches share its limits
+	pool := batch.NewPool(cfg.RenderWorkers, cfg.RenderMaxInFlight)
+
+	render := router.Group("/render")
+	certificate.NewCertificateHandler(packet.NewPDFMerger(), publisher, pool, cfg.MaxBatchItems).RegisterRoutes(render)
+	transcript.NewTranscriptHandler().RegisterRoutes(render)
+	download.NewDownloadHandler(downloads).RegisterRoutes(render)
+}
+
+// setupDownloads keeps rendered documents in a temporary directory behind signed
+// links. Without DOWNLOAD_URL_SECRET a random secret is used, so links only work on
+// this instance until it restarts, which the per-instance directory implies anyway.
+func setupDownloads(cfg *config.Config, lc *lifecycle.Lifecycle) *download.Downloads {
+	secret := []byte(cfg.DownloadURLSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate download URL secret")
+		}
+		log.Warn().Msg("DOWNLOAD_URL_SECRET is not set, using a random secret for download links")
+	}
+
+	store, err := download.NewDirStore(cfg.DownloadDir, int64(cfg.DownloadMaxBytes))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up the download store")
+	}
+	lc.OnShutdown("downloads", func(context.Context) error {
+		return store.Close()
+	})
+	return download.NewDownloads(store, download.NewSigner(secret), cfg.DownloadURLTTL)
+}
+
+// setupPublisher chooses where rendered documents go: the local downloads by default,
+// or the configured bucket with the s3 storage backend
+func setupPublisher(cfg *config.Config, downloads *download.Downloads) download.Publisher {
+	if cfg.StorageBackend != "s3" {
+		return downloads
+	}
+
+	store, err := objectstore.NewS3(objectstore.Config{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid object storage configuration")
+	}
+	return objectstore.NewPublisher(store, cfg.S3Prefix, cfg.DownloadURLTTL)
 }
@@ -0,0 +1,64 @@
+package transcript
+
+import "github.com/PegasusMKD/svedprint-go/pkg/i18n"
+
+// Labels are the fixed texts printed on a transcript, in the transcript's locale
+type Labels struct {
+	Title        string `json:"title"`
+	School       string `json:"school"`
+	Student      string `json:"student"`
+	Subject      string `json:"subject"`
+	AcademicYear string `json:"academic_year"`
+	Class        string `json:"class"`
+	Grade        string `json:"grade"`
+	NotTaken     string `json:"not_taken"`
+	Justified    string `json:"justified_absences"`
+	Unjustified  string `json:"unjustified_absences"`
+}
+
+var labels = map[string]Labels{
+	i18n.Macedonian: {
+		Title:        "Уверение за постигнат успех",
+		School:       "Училиште",
+		Student:      "Ученик",
+		Subject:      "Предмет",
+		AcademicYear: "Учебна година",
+		Class:        "Паралелка",
+		Grade:        "Оценка",
+		NotTaken:     "не изучувал",
+		Justified:    "Оправдани изостаноци",
+		Unjustified:  "Неоправдани изостаноци",
+	},
+	i18n.Albanian: {
+		Title:        "Vërtetim për suksesin e arritur",
+		School:       "Shkolla",
+		Student:      "Nxënësi",
+		Subject:      "Lënda",
+		AcademicYear: "Viti shkollor",
+		Class:        "Paralelja",
+		Grade:        "Nota",
+		NotTaken:     "nuk e ka mësuar",
+		Justified:    "Mungesa të arsyetuara",
+		Unjustified:  "Mungesa të paarsyetuara",
+	},
+	i18n.English: {
+		Title:        "Transcript of records",
+		School:       "School",
+		Student:      "Student",
+		Subject:      "Subject",
+		AcademicYear: "School year",
+		Class:        "Class",
+		Grade:        "Grade",
+		NotTaken:     "not taken",
+		Justified:    "Justified absences",
+		Unjustified:  "Unjustified absences",
+	},
+}
+
+// labelsFor returns the labels of a locale, falling back to the default locale
+func labelsFor(locale string) Labels {
+	if l, ok := labels[locale]; ok {
+		return l
+	}
+	return labels[i18n.Default]
+}
@@ -0,0 +1,62 @@
+package transcript
+
+import (
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/certificate"
+)
+
+// Request is the data of every school year a student attended, in any order
+type Request struct {
+	School  certificate.School  `json:"school"`
+	Student certificate.Student `json:"student"`
+	Years   []Year              `json:"years"`
+}
+
+// Year is what a single year's certificate would hold
+type Year struct {
+	AcademicYear string                  `json:"academic_year"`
+	Class        string                  `json:"class,omitempty"`
+	Subjects     []certificate.Subject   `json:"subjects"`
+	Attendance   *certificate.Attendance `json:"attendance,omitempty"`
+}
+
+// Transcript is a cumulative, multi-year document laid out for rendering: one column
+// per school year in chronological order and one row per subject
+type Transcript struct {
+	School    certificate.School  `json:"school"`
+	Student   certificate.Student `json:"student"`
+	FirstYear string              `json:"first_year"`
+	LastYear  string              `json:"last_year"`
+	Years     []YearColumn        `json:"years"`
+	Subjects  []SubjectRow        `json:"subjects"`
+	// Attendance sums the absences of the years that recorded them
+	Attendance *certificate.Attendance `json:"attendance,omitempty"`
+	// Locale is the language the labels are in
+	Locale string `json:"locale"`
+	Labels Labels `json:"labels"`
+}
+
+// YearColumn heads the results of one school year
+type YearColumn struct {
+	AcademicYear string                  `json:"academic_year"`
+	Class        string                  `json:"class,omitempty"`
+	Attendance   *certificate.Attendance `json:"attendance,omitempty"`
+}
+
+// SubjectRow is a subject's results across the transcript's years. Results has one
+// entry per year column; years the subject was not taken are left empty.
+type SubjectRow struct {
+	Name      string   `json:"name"`
+	FirstYear string   `json:"first_year"`
+	LastYear  string   `json:"last_year"`
+	Results   []Result `json:"results"`
+}
+
+// Result is a subject's grade in one year. Text is the grade as printed, in the format
+// of the subject's grading scheme that year.
+type Result struct {
+	AcademicYear string `json:"academic_year"`
+	Taken        bool   `json:"taken"`
+	Grade        *int   `json:"grade,omitempty"`
+	Descriptor   string `json:"descriptor,omitempty"`
+	Text         string `json:"text,omitempty"`
+}
@@ -0,0 +1,153 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/certificate"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// academicYearPattern matches school years such as 2023/2024 or 2023-2024
+var academicYearPattern = regexp.MustCompile(`^(\d{4})\s*[/-]\s*(\d{4})$`)
+
+// Build validates every year against the certificate rules and lays the years out
+// chronologically, labelled in locale. A subject taken in several years becomes a
+// single row, so a subject dropped and picked up again keeps its earlier results.
+func Build(req *Request, locale string) (*Transcript, error) {
+	years, err := sortYears(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transcript{
+		School:    req.School,
+		Student:   req.Student,
+		FirstYear: years[0].AcademicYear,
+		LastYear:  years[len(years)-1].AcademicYear,
+		Years:     make([]YearColumn, 0, len(years)),
+		Locale:    locale,
+		Labels:    labelsFor(locale),
+	}
+
+	rows := make(map[string]*SubjectRow)
+	var order []string
+	for i, year := range years {
+		t.Years = append(t.Years, YearColumn{AcademicYear: year.AcademicYear, Class: year.Class, Attendance: year.Attendance})
+		if year.Attendance != nil {
+			if t.Attendance == nil {
+				t.Attendance = &certificate.Attendance{}
+			}
+			t.Attendance.Justified += year.Attendance.Justified
+			t.Attendance.Unjustified += year.Attendance.Unjustified
+		}
+
+		for _, subject := range year.Subjects {
+			row, ok := rows[subject.Name]
+			if !ok {
+				row = &SubjectRow{Name: subject.Name, FirstYear: year.AcademicYear, Results: make([]Result, len(years))}
+				for j, column := range years {
+					row.Results[j].AcademicYear = column.AcademicYear
+				}
+				rows[subject.Name] = row
+				order = append(order, subject.Name)
+			}
+			row.LastYear = year.AcademicYear
+			row.Results[i] = Result{AcademicYear: year.AcademicYear, Taken: true, Grade: subject.Grade, Descriptor: subject.Descriptor, Text: subject.GradeText()}
+		}
+	}
+
+	t.Subjects = make([]SubjectRow, 0, len(order))
+	for _, name := range order {
+		t.Subjects = append(t.Subjects, *rows[name])
+	}
+	return t, nil
+}
+
+// datedYear is a year together with the calendar year it starts in
+type datedYear struct {
+	Year
+	start int
+}
+
+// sortYears validates the request and returns its years oldest first. Each year must
+// span two consecutive calendar years and appear only once.
+func sortYears(req *Request) ([]Year, error) {
+	var fields []apierror.FieldError
+	if len(req.Years) == 0 {
+		fields = append(fields, apierror.Field("years", "required", "at least one school year is required"))
+	}
+
+	dated := make([]datedYear, 0, len(req.Years))
+	seen := make(map[int]bool, len(req.Years))
+	for i, year := range req.Years {
+		prefix := fmt.Sprintf("years[%d]", i)
+
+		cert := certificate.Certificate{
+			Type:         certificate.TypeTestimony,
+			AcademicYear: year.AcademicYear,
+			School:       req.School,
+			Student:      req.Student,
+			Subjects:     year.Subjects,
+			Attendance:   year.Attendance,
+		}
+		for _, problem := range certificate.Validate(&cert) {
+			field := problem.Field
+			if isSharedField(field) {
+				// School and student problems are the same for every year
+				if i > 0 {
+					continue
+				}
+			} else {
+				field = prefix + "." + field
+			}
+			fields = append(fields, apierror.Field(field, problem.Rule, problem.Message))
+		}
+
+		if year.AcademicYear == "" {
+			continue
+		}
+		start, ok := academicYearStart(year.AcademicYear)
+		if !ok {
+			fields = append(fields, apierror.Field(prefix+".academic_year", "academic_year", "must be two consecutive years, e.g. 2023/2024"))
+			continue
+		}
+		if seen[start] {
+			fields = append(fields, apierror.Field(prefix+".academic_year", "unique", fmt.Sprintf("school year %s is listed more than once", year.AcademicYear)))
+			continue
+		}
+		seen[start] = true
+		dated = append(dated, datedYear{Year: year, start: start})
+	}
+
+	if len(fields) > 0 {
+		return nil, apierror.NewValidationError(fields...)
+	}
+
+	slices.SortFunc(dated, func(a, b datedYear) int { return a.start - b.start })
+	years := make([]Year, len(dated))
+	for i, year := range dated {
+		years[i] = year.Year
+	}
+	return years, nil
+}
+
+// academicYearStart returns the calendar year a school year starts in
+func academicYearStart(academicYear string) (int, bool) {
+	match := academicYearPattern.FindStringSubmatch(academicYear)
+	if match == nil {
+		return 0, false
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	return start, end == start+1
+}
+
+// isSharedField reports whether a certificate field comes from the school or student,
+// which the transcript shares across years
+func isSharedField(field string) bool {
+	return strings.HasPrefix(field, "school.") || strings.HasPrefix(field, "student.")
+}
@@ -0,0 +1,162 @@
+package transcript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint-print/certificate"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/i18n"
+)
+
+func intPtr(v int) *int { return &v }
+
+var (
+	numeric     = certificate.Grading{Scheme: certificate.SchemeNumeric, MinGrade: 1, MaxGrade: 5}
+	descriptive = certificate.Grading{Scheme: certificate.SchemeDescriptive, Descriptors: []string{"passed", "failed"}}
+
+	testSchool  = certificate.School{Name: "OOU Goce Delchev", DirectorName: "Marija Petrova", City: "Skopje"}
+	testStudent = certificate.Student{FirstName: "Ana", LastName: "Stojanova", FathersName: "Petar", DateOfBirth: "2012-03-04", PlaceOfBirth: "Skopje"}
+)
+
+func TestBuildPrintsEachSubjectInItsScheme(t *testing.T) {
+	req := &Request{
+		School:  testSchool,
+		Student: testStudent,
+		Years: []Year{
+			{AcademicYear: "2023/2024", Subjects: []certificate.Subject{
+				{Name: "Mathematics", Grading: numeric, Grade: intPtr(4)},
+				{Name: "Physical education", Grading: descriptive, Descriptor: "passed"},
+			}},
+			{AcademicYear: "2024/2025", Subjects: []certificate.Subject{
+				{Name: "Mathematics", Grading: numeric, Grade: intPtr(5)},
+				// Graded numerically from this year on
+				{Name: "Physical education", Grading: numeric, Grade: intPtr(3)},
+			}},
+		},
+	}
+
+	tr, err := Build(req, i18n.English)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := map[string][]string{
+		"Mathematics":        {"4", "5"},
+		"Physical education": {"passed", "3"},
+	}
+	for _, row := range tr.Subjects {
+		for i, result := range row.Results {
+			if result.Text != want[row.Name][i] {
+				t.Errorf("%s in %s printed as %q, want %q", row.Name, result.AcademicYear, result.Text, want[row.Name][i])
+			}
+		}
+	}
+}
+
+func TestBuildOrdersYearsChronologically(t *testing.T) {
+	math := func(grade int) certificate.Subject {
+		return certificate.Subject{Name: "Mathematics", Grading: numeric, Grade: intPtr(grade)}
+	}
+	req := &Request{
+		School:  testSchool,
+		Student: testStudent,
+		// Sent newest first, with mixed separators
+		Years: []Year{
+			{AcademicYear: "2024-2025", Class: "VIII-2", Subjects: []certificate.Subject{
+				math(5),
+				{Name: "Chemistry", Grading: numeric, Grade: intPtr(4)},
+				{Name: "Informatics", Grading: numeric, Grade: intPtr(5)},
+			}, Attendance: &certificate.Attendance{Justified: 4, Unjustified: 1}},
+			{AcademicYear: "2022/2023", Class: "VI-2", Subjects: []certificate.Subject{
+				math(3),
+				{Name: "Informatics", Grading: numeric, Grade: intPtr(4)},
+			}, Attendance: &certificate.Attendance{Justified: 10}},
+			{AcademicYear: "2023/2024", Class: "VII-2", Subjects: []certificate.Subject{math(4)}},
+		},
+	}
+
+	tr, err := Build(req, i18n.English)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	wantYears := []string{"2022/2023", "2023/2024", "2024-2025"}
+	if len(tr.Years) != len(wantYears) {
+		t.Fatalf("%d year columns, want %d", len(tr.Years), len(wantYears))
+	}
+	for i, want := range wantYears {
+		if tr.Years[i].AcademicYear != want {
+			t.Errorf("years[%d] = %s, want %s", i, tr.Years[i].AcademicYear, want)
+		}
+	}
+	if tr.Years[0].Class != "VI-2" || tr.Years[2].Class != "VIII-2" {
+		t.Errorf("classes = %q, %q, want each year's own class", tr.Years[0].Class, tr.Years[2].Class)
+	}
+	if tr.FirstYear != "2022/2023" || tr.LastYear != "2024-2025" {
+		t.Errorf("span = %s to %s", tr.FirstYear, tr.LastYear)
+	}
+
+	// Subjects appear in the order they were first taken, each with one result per year
+	want := []struct {
+		name, first, last string
+		texts             []string
+	}{
+		{"Mathematics", "2022/2023", "2024-2025", []string{"3", "4", "5"}},
+		{"Informatics", "2022/2023", "2024-2025", []string{"4", "", "5"}},
+		{"Chemistry", "2024-2025", "2024-2025", []string{"", "", "4"}},
+	}
+	if len(tr.Subjects) != len(want) {
+		t.Fatalf("%d subject rows, want %d", len(tr.Subjects), len(want))
+	}
+	for i, w := range want {
+		row := tr.Subjects[i]
+		if row.Name != w.name || row.FirstYear != w.first || row.LastYear != w.last {
+			t.Errorf("subjects[%d] = %s %s to %s, want %s %s to %s", i, row.Name, row.FirstYear, row.LastYear, w.name, w.first, w.last)
+			continue
+		}
+		for j, result := range row.Results {
+			if result.AcademicYear != wantYears[j] || result.Taken != (w.texts[j] != "") || result.Text != w.texts[j] {
+				t.Errorf("%s results[%d] = %+v, want %q in %s", row.Name, j, result, w.texts[j], wantYears[j])
+			}
+		}
+	}
+
+	// The year without attendance is left out of the total
+	if tr.Attendance == nil || *tr.Attendance != (certificate.Attendance{Justified: 14, Unjustified: 1}) {
+		t.Errorf("attendance = %+v, want 14 justified and 1 unjustified", tr.Attendance)
+	}
+}
+
+func TestBuildRejectsInvalidYears(t *testing.T) {
+	year := func(academicYear string) Year {
+		return Year{AcademicYear: academicYear, Subjects: []certificate.Subject{
+			{Name: "Mathematics", Grading: numeric, Grade: intPtr(4)},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		years     []Year
+		wantField string
+		wantRule  string
+	}{
+		{name: "no years", years: nil, wantField: "years", wantRule: "required"},
+		{name: "not consecutive", years: []Year{year("2022/2024")}, wantField: "years[0].academic_year", wantRule: "academic_year"},
+		{name: "not a school year", years: []Year{year("2023")}, wantField: "years[0].academic_year", wantRule: "academic_year"},
+		{name: "listed twice", years: []Year{year("2023/2024"), year("2022/2023"), year("2023-2024")}, wantField: "years[2].academic_year", wantRule: "unique"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(&Request{School: testSchool, Student: testStudent, Years: tt.years}, i18n.English)
+
+			var verr *apierror.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Build error = %v, want a validation error", err)
+			}
+			if len(verr.Fields) != 1 || verr.Fields[0].Field != tt.wantField || verr.Fields[0].Rule != tt.wantRule {
+				t.Errorf("fields = %+v, want %s (%s)", verr.Fields, tt.wantField, tt.wantRule)
+			}
+		})
+	}
+}
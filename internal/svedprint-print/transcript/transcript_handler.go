@@ -0,0 +1,35 @@
+package transcript
+
+import (
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+type TranscriptHandler struct{}
+
+func NewTranscriptHandler() *TranscriptHandler {
+	return &TranscriptHandler{}
+}
+
+func (h *TranscriptHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/transcript", h.Build)
+}
+
+// Build lays out a cumulative transcript from the student's yearly results, labelled
+// in the user's locale as passed on by the gateway
+func (h *TranscriptHandler) Build(c *gin.Context) {
+	var req Request
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	transcript, err := Build(&req, i18n.FromRequest(c.Request))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, transcript)
+}
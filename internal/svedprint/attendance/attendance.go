@@ -0,0 +1,52 @@
+package attendance
+
+import (
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+// Terms of an academic year attendance is recorded for
+const (
+	FirstTerm  = 1
+	SecondTerm = 2
+)
+
+// Attendance is a student's absence count for one term of an academic year
+type Attendance struct {
+	StudentUUID       string
+	AcademicYearUUID  string
+	Term              int
+	TotalAbsences     int
+	JustifiedAbsences int
+	UpdatedAt         time.Time
+}
+
+// UnjustifiedAbsences is the part of the total absences that was not justified
+func (a *Attendance) UnjustifiedAbsences() int {
+	return a.TotalAbsences - a.JustifiedAbsences
+}
+
+// Validate checks the counts are non-negative and justified absences fit in the total
+func (a *Attendance) Validate() error {
+	var fields []apierror.FieldError
+
+	if a.Term != FirstTerm && a.Term != SecondTerm {
+		fields = append(fields, apierror.Field("term", "oneof", "must be one of [1 2]"))
+	}
+	if a.TotalAbsences < 0 {
+		fields = append(fields, apierror.Field("total_absences", "gte", "must not be negative"))
+	}
+	if a.JustifiedAbsences < 0 {
+		fields = append(fields, apierror.Field("justified_absences", "gte", "must not be negative"))
+	}
+	// A negative total is already reported; comparing against it would only add noise
+	if a.TotalAbsences >= 0 && a.JustifiedAbsences > a.TotalAbsences {
+		fields = append(fields, apierror.Field("justified_absences", "lte_total", "must not exceed total_absences"))
+	}
+
+	if len(fields) > 0 {
+		return apierror.NewValidationError(fields...)
+	}
+	return nil
+}
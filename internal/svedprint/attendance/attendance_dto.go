@@ -0,0 +1,16 @@
+package attendance
+
+type AttendanceDTO struct {
+	AcademicYearUUID    string `json:"academic_year_uuid"`
+	Term                int    `json:"term"`
+	TotalAbsences       int    `json:"total_absences"`
+	JustifiedAbsences   int    `json:"justified_absences"`
+	UnjustifiedAbsences int    `json:"unjustified_absences"`
+}
+
+type SetAttendanceRequest struct {
+	AcademicYearUUID  string `json:"academic_year_uuid" binding:"required,uuid"`
+	Term              int    `json:"term" binding:"required"`
+	TotalAbsences     int    `json:"total_absences"`
+	JustifiedAbsences int    `json:"justified_absences"`
+}
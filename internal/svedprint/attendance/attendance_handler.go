@@ -0,0 +1,51 @@
+package attendance
+
+import (
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+type AttendanceHandler struct {
+	service *AttendanceService
+}
+
+func NewAttendanceHandler(service *AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{service: service}
+}
+
+// RegisterRoutes registers the attendance endpoints under a student router group
+func (h *AttendanceHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:uuid/attendance", h.GetAttendance)
+	rg.PUT("/:uuid/attendance", h.SetAttendance)
+}
+
+func (h *AttendanceHandler) GetAttendance(c *gin.Context) {
+	records, err := h.service.GetStudentAttendance(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	dtos := make([]AttendanceDTO, 0, len(records))
+	for i := range records {
+		dtos = append(dtos, AttendanceToDTO(&records[i]))
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// SetAttendance records a student's absences for one term, replacing earlier counts
+func (h *AttendanceHandler) SetAttendance(c *gin.Context) {
+	var req SetAttendanceRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	stored, err := h.service.SetAttendance(c.Request.Context(), SetRequestToAttendance(c.Param("uuid"), &req))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AttendanceToDTO(stored))
+}
@@ -0,0 +1,21 @@
+package attendance
+
+func AttendanceToDTO(a *Attendance) AttendanceDTO {
+	return AttendanceDTO{
+		AcademicYearUUID:    a.AcademicYearUUID,
+		Term:                a.Term,
+		TotalAbsences:       a.TotalAbsences,
+		JustifiedAbsences:   a.JustifiedAbsences,
+		UnjustifiedAbsences: a.UnjustifiedAbsences(),
+	}
+}
+
+func SetRequestToAttendance(studentUUID string, req *SetAttendanceRequest) *Attendance {
+	return &Attendance{
+		StudentUUID:       studentUUID,
+		AcademicYearUUID:  req.AcademicYearUUID,
+		Term:              req.Term,
+		TotalAbsences:     req.TotalAbsences,
+		JustifiedAbsences: req.JustifiedAbsences,
+	}
+}
@@ -0,0 +1,83 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// foreignKeyViolation is the SQLSTATE returned when the referenced student does not exist
+const foreignKeyViolation = "23503"
+
+type AttendanceRepository struct {
+	queries *sqlc.Queries
+}
+
+func NewAttendanceRepository(queries *sqlc.Queries) *AttendanceRepository {
+	return &AttendanceRepository{queries: queries}
+}
+
+// ListByStudent returns every recorded term of a student
+func (r *AttendanceRepository) ListByStudent(ctx context.Context, studentUUID string) ([]Attendance, error) {
+	pgUUID, err := utility.ParseUUID(studentUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	rows, err := r.queries.ListStudentAttendance(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendance: %w", err)
+	}
+
+	records := make([]Attendance, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, fromSQLC(row))
+	}
+	return records, nil
+}
+
+// Upsert stores the attendance of a student for a term, replacing any earlier counts
+func (r *AttendanceRepository) Upsert(ctx context.Context, a *Attendance) (*Attendance, error) {
+	studentUUID, err := utility.ParseUUID(a.StudentUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+	yearUUID, err := utility.ParseUUID(a.AcademicYearUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	row, err := r.queries.UpsertStudentAttendance(ctx, sqlc.UpsertStudentAttendanceParams{
+		StudentUuid:       studentUUID,
+		AcademicYearUuid:  yearUUID,
+		Term:              int16(a.Term),
+		TotalAbsences:     int32(a.TotalAbsences),
+		JustifiedAbsences: int32(a.JustifiedAbsences),
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return nil, apierror.WithCode(fmt.Errorf("student %s: %w", a.StudentUUID, apierror.ErrNotFound), apierror.CodeStudentNotFound)
+		}
+		return nil, fmt.Errorf("failed to store attendance: %w", err)
+	}
+
+	stored := fromSQLC(row)
+	return &stored, nil
+}
+
+func fromSQLC(row sqlc.StudentAttendance) Attendance {
+	return Attendance{
+		StudentUUID:       row.StudentUuid.String(),
+		AcademicYearUUID:  row.AcademicYearUuid.String(),
+		Term:              int(row.Term),
+		TotalAbsences:     int(row.TotalAbsences),
+		JustifiedAbsences: int(row.JustifiedAbsences),
+		UpdatedAt:         row.UpdatedAt.Time,
+	}
+}
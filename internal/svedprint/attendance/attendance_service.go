@@ -0,0 +1,23 @@
+package attendance
+
+import "context"
+
+type AttendanceService struct {
+	repo *AttendanceRepository
+}
+
+func NewAttendanceService(repo *AttendanceRepository) *AttendanceService {
+	return &AttendanceService{repo: repo}
+}
+
+func (s *AttendanceService) GetStudentAttendance(ctx context.Context, studentUUID string) ([]Attendance, error) {
+	return s.repo.ListByStudent(ctx, studentUUID)
+}
+
+// SetAttendance validates and stores a student's absences for one term
+func (s *AttendanceService) SetAttendance(ctx context.Context, a *Attendance) (*Attendance, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Upsert(ctx, a)
+}
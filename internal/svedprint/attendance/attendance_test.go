@@ -0,0 +1,112 @@
+package attendance
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAttendanceValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Attendance
+		want []apierror.FieldError
+	}{
+		{name: "valid", a: Attendance{Term: FirstTerm, TotalAbsences: 12, JustifiedAbsences: 10}},
+		{name: "all justified", a: Attendance{Term: SecondTerm, TotalAbsences: 4, JustifiedAbsences: 4}},
+		{name: "no absences", a: Attendance{Term: FirstTerm}},
+		{
+			name: "justified over total",
+			a:    Attendance{Term: FirstTerm, TotalAbsences: 3, JustifiedAbsences: 5},
+			want: []apierror.FieldError{apierror.Field("justified_absences", "lte_total", "must not exceed total_absences")},
+		},
+		{
+			name: "negative counts",
+			a:    Attendance{Term: FirstTerm, TotalAbsences: -1, JustifiedAbsences: -2},
+			want: []apierror.FieldError{
+				apierror.Field("total_absences", "gte", "must not be negative"),
+				apierror.Field("justified_absences", "gte", "must not be negative"),
+			},
+		},
+		{
+			name: "negative total",
+			a:    Attendance{Term: FirstTerm, TotalAbsences: -1},
+			want: []apierror.FieldError{apierror.Field("total_absences", "gte", "must not be negative")},
+		},
+		{
+			name: "unknown term",
+			a:    Attendance{Term: 3},
+			want: []apierror.FieldError{apierror.Field("term", "oneof", "must be one of [1 2]")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.a.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var validationErr *apierror.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want a validation error", err)
+			}
+			if !reflect.DeepEqual(validationErr.Fields, tt.want) {
+				t.Errorf("fields = %+v, want %+v", validationErr.Fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttendanceToDTOSplitsAbsences(t *testing.T) {
+	got := AttendanceToDTO(&Attendance{AcademicYearUUID: "year-1", Term: SecondTerm, TotalAbsences: 12, JustifiedAbsences: 9})
+	want := AttendanceDTO{AcademicYearUUID: "year-1", Term: SecondTerm, TotalAbsences: 12, JustifiedAbsences: 9, UnjustifiedAbsences: 3}
+	if got != want {
+		t.Errorf("AttendanceToDTO = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetAttendanceRejectsInvalidCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Invalid counts are rejected before the repository is reached
+	router := gin.New()
+	NewAttendanceHandler(NewAttendanceService(nil)).RegisterRoutes(router.Group("/students"))
+
+	const year = "3c9e2b7a-5d1f-4e8a-b6c4-2f7d9a1e0b35"
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{"justified over total", `{"academic_year_uuid":"` + year + `","term":1,"total_absences":2,"justified_absences":3}`, "justified_absences"},
+		{"negative total", `{"academic_year_uuid":"` + year + `","term":2,"total_absences":-1}`, "total_absences"},
+		{"missing year", `{"term":1,"total_absences":2}`, "AcademicYearUUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/students/0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80/attendance", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422: %s", w.Code, w.Body)
+			}
+			var body apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Fields) != 1 || body.Fields[0].Field != tt.wantField {
+				t.Errorf("fields = %+v, want %s", body.Fields, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package certificate
+
+import "time"
+
+// Certificate types that can be issued
+const (
+	TypeTestimony = "testimony"
+	TypeDiploma   = "diploma"
+)
+
+// Issuance records a certificate handed out under a registry number. A correction
+// is a new issuance that supersedes the previous one and bumps the version.
+type Issuance struct {
+	UUID             string
+	RegistryNumber   string
+	StudentUUID      string
+	AcademicYearUUID string
+	Type             string
+	Version          int
+	// SupersedesUUID is the issuance this one corrects, empty for the original
+	SupersedesUUID string
+	Reason         string
+	IssuedAt       time.Time
+	SupersededAt   *time.Time
+}
+
+// Valid reports whether the issuance is the current version of its certificate
+func (i *Issuance) Valid() bool {
+	return i.SupersededAt == nil
+}
+
+// Verification is the outcome of looking up a registry number
+type Verification struct {
+	Requested *Issuance
+	// Latest is the newest version in the correction chain; it equals Requested
+	// when the certificate was never corrected
+	Latest *Issuance
+}
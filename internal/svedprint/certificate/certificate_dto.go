@@ -0,0 +1,36 @@
+package certificate
+
+import "time"
+
+type IssuanceDTO struct {
+	UUID             string     `json:"uuid"`
+	RegistryNumber   string     `json:"registry_number"`
+	StudentUUID      string     `json:"student_uuid"`
+	AcademicYearUUID string     `json:"academic_year_uuid"`
+	Type             string     `json:"type"`
+	Version          int        `json:"version"`
+	SupersedesUUID   string     `json:"supersedes_uuid,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+	IssuedAt         time.Time  `json:"issued_at"`
+	SupersededAt     *time.Time `json:"superseded_at,omitempty"`
+	Valid            bool       `json:"valid"`
+}
+
+type IssueCertificateRequest struct {
+	RegistryNumber   string `json:"registry_number" binding:"required,max=64"`
+	StudentUUID      string `json:"student_uuid" binding:"required,uuid"`
+	AcademicYearUUID string `json:"academic_year_uuid" binding:"required,uuid"`
+	Type             string `json:"type" binding:"required,oneof=testimony diploma"`
+}
+
+type ReissueCertificateRequest struct {
+	RegistryNumber string `json:"registry_number" binding:"required,max=64"`
+	Reason         string `json:"reason" binding:"required,max=500"`
+}
+
+// VerificationDTO answers whether a registry number is the valid certificate and,
+// if it was corrected, which registry number replaced it
+type VerificationDTO struct {
+	Certificate IssuanceDTO `json:"certificate"`
+	Latest      IssuanceDTO `json:"latest"`
+}
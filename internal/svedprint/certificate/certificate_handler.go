@@ -0,0 +1,65 @@
+package certificate
+
+import (
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+type CertificateHandler struct {
+	service *CertificateService
+}
+
+func NewCertificateHandler(service *CertificateService) *CertificateHandler {
+	return &CertificateHandler{service: service}
+}
+
+// RegisterRoutes registers the certificate issuance endpoints on the given router group
+func (h *CertificateHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Issue)
+	rg.POST("/:registry/reissue", h.Reissue)
+	rg.GET("/:registry/verify", h.Verify)
+}
+
+// Issue records a newly issued certificate; a student has at most one valid
+// certificate of a type per academic year
+func (h *CertificateHandler) Issue(c *gin.Context) {
+	var req IssueCertificateRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	issuance, err := h.service.Issue(c.Request.Context(), IssueRequestToIssuance(&req))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, IssuanceToDTO(issuance))
+}
+
+// Reissue issues a corrected certificate under a new registry number, superseding
+// the one in the path
+func (h *CertificateHandler) Reissue(c *gin.Context) {
+	var req ReissueCertificateRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	issuance, err := h.service.Reissue(c.Request.Context(), c.Param("registry"), req.RegistryNumber, req.Reason)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, IssuanceToDTO(issuance))
+}
+
+// Verify reports whether a registry number is valid and the latest valid version
+func (h *CertificateHandler) Verify(c *gin.Context) {
+	verification, err := h.service.Verify(c.Request.Context(), c.Param("registry"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, VerificationToDTO(verification))
+}
@@ -0,0 +1,33 @@
+package certificate
+
+func IssuanceToDTO(i *Issuance) IssuanceDTO {
+	return IssuanceDTO{
+		UUID:             i.UUID,
+		RegistryNumber:   i.RegistryNumber,
+		StudentUUID:      i.StudentUUID,
+		AcademicYearUUID: i.AcademicYearUUID,
+		Type:             i.Type,
+		Version:          i.Version,
+		SupersedesUUID:   i.SupersedesUUID,
+		Reason:           i.Reason,
+		IssuedAt:         i.IssuedAt,
+		SupersededAt:     i.SupersededAt,
+		Valid:            i.Valid(),
+	}
+}
+
+func IssueRequestToIssuance(req *IssueCertificateRequest) *Issuance {
+	return &Issuance{
+		RegistryNumber:   req.RegistryNumber,
+		StudentUUID:      req.StudentUUID,
+		AcademicYearUUID: req.AcademicYearUUID,
+		Type:             req.Type,
+	}
+}
+
+func VerificationToDTO(v *Verification) VerificationDTO {
+	return VerificationDTO{
+		Certificate: IssuanceToDTO(v.Requested),
+		Latest:      IssuanceToDTO(v.Latest),
+	}
+}
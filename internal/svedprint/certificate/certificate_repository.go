@@ -0,0 +1,131 @@
+package certificate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+type CertificateRepository struct {
+	queries *sqlc.Queries
+}
+
+func NewCertificateRepository(queries *sqlc.Queries) *CertificateRepository {
+	return &CertificateRepository{queries: queries}
+}
+
+// Issue records the original version of a certificate
+func (r *CertificateRepository) Issue(ctx context.Context, issuance *Issuance) (*Issuance, error) {
+	studentUUID, err := utility.ParseUUID(issuance.StudentUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+	yearUUID, err := utility.ParseUUID(issuance.AcademicYearUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	row, err := r.queries.IssueCertificate(ctx, sqlc.IssueCertificateParams{
+		RegistryNumber:   issuance.RegistryNumber,
+		StudentUuid:      studentUUID,
+		AcademicYearUuid: yearUUID,
+		CertificateType:  issuance.Type,
+	})
+	if err != nil {
+		return nil, mapWriteError(err, issuance.RegistryNumber)
+	}
+	return fromSQLC(row), nil
+}
+
+// Reissue supersedes the valid certificate under previous with a corrected version
+// under registryNumber, in a single statement
+func (r *CertificateRepository) Reissue(ctx context.Context, previous, registryNumber, reason string) (*Issuance, error) {
+	row, err := r.queries.ReissueCertificate(ctx, sqlc.ReissueCertificateParams{
+		PreviousRegistryNumber: previous,
+		RegistryNumber:         registryNumber,
+		Reason:                 utility.StringToText(reason),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Nothing was superseded: the certificate is unknown or already corrected
+		original, err := r.GetByRegistryNumber(ctx, previous)
+		if err != nil {
+			return nil, err
+		}
+		return nil, apierror.NewWithCode(http.StatusConflict, apierror.CodeCertificateSuperseded, fmt.Sprintf("certificate %s was already superseded", original.RegistryNumber))
+	}
+	if err != nil {
+		return nil, mapWriteError(err, registryNumber)
+	}
+	return fromSQLC(row), nil
+}
+
+func (r *CertificateRepository) GetByRegistryNumber(ctx context.Context, registryNumber string) (*Issuance, error) {
+	row, err := r.queries.GetCertificateByRegistryNumber(ctx, registryNumber)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.WithCode(fmt.Errorf("certificate %s: %w", registryNumber, apierror.ErrNotFound), apierror.CodeCertificateNotFound)
+		}
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	return fromSQLC(row), nil
+}
+
+// GetLatestVersion follows the correction chain forward from registryNumber
+func (r *CertificateRepository) GetLatestVersion(ctx context.Context, registryNumber string) (*Issuance, error) {
+	row, err := r.queries.GetLatestCertificateVersion(ctx, registryNumber)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.WithCode(fmt.Errorf("certificate %s: %w", registryNumber, apierror.ErrNotFound), apierror.CodeCertificateNotFound)
+		}
+		return nil, fmt.Errorf("failed to get latest certificate version: %w", err)
+	}
+	return fromSQLC(sqlc.CertificateIssuance(row)), nil
+}
+
+func mapWriteError(err error, registryNumber string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == foreignKeyViolation:
+			return apierror.WithCode(fmt.Errorf("student: %w", apierror.ErrNotFound), apierror.CodeStudentNotFound)
+		case pgErr.Code == uniqueViolation && pgErr.ConstraintName == "uq_certificate_issuance_registry_number":
+			return apierror.NewWithCode(http.StatusConflict, apierror.CodeRegistryNumberTaken, fmt.Sprintf("registry number %s is already in use", registryNumber))
+		case pgErr.Code == uniqueViolation:
+			return apierror.NewWithCode(http.StatusConflict, apierror.CodeCertificateIssued, "a valid certificate was already issued; re-issue it to correct it")
+		}
+	}
+	return fmt.Errorf("failed to store certificate: %w", err)
+}
+
+func fromSQLC(row sqlc.CertificateIssuance) *Issuance {
+	issuance := &Issuance{
+		UUID:             row.Uuid.String(),
+		RegistryNumber:   row.RegistryNumber,
+		StudentUUID:      row.StudentUuid.String(),
+		AcademicYearUUID: row.AcademicYearUuid.String(),
+		Type:             row.CertificateType,
+		Version:          int(row.Version),
+		Reason:           utility.TextToString(row.Reason),
+		IssuedAt:         row.IssuedAt.Time,
+	}
+	if row.SupersedesUuid.Valid {
+		issuance.SupersedesUUID = row.SupersedesUuid.String()
+	}
+	if row.SupersededAt.Valid {
+		supersededAt := row.SupersededAt.Time
+		issuance.SupersededAt = &supersededAt
+	}
+	return issuance
+}
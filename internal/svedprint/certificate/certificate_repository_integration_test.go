@@ -0,0 +1,132 @@
+package certificate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const testYearUUID = "3c9e2b7a-5d1f-4e8a-b6c4-2f7d9a1e0b35"
+
+// TestCertificateCorrectionChain needs a scratch database in TEST_DATABASE_URL; it
+// applies the svedprint migrations to it
+func TestCertificateCorrectionChain(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	if err := database.RunMigrations(url, "../../../db/svedprint/migrations"); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	var studentUUID string
+	err = pool.QueryRow(ctx, `insert into student (uuid, first_name, last_name, school_uuid)
+		values (gen_random_uuid(), 'Ana', 'Stojanova', gen_random_uuid()) returning uuid::text`).Scan(&studentUUID)
+	if err != nil {
+		t.Fatalf("insert student: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), "delete from certificate_issuance where student_uuid = $1", studentUUID)
+		pool.Exec(context.Background(), "delete from student where uuid = $1", studentUUID)
+	})
+
+	service := NewCertificateService(NewCertificateRepository(sqlc.New(pool)))
+	run := time.Now().UnixNano()
+	registry := func(n int) string { return fmt.Sprintf("T-%d-%d", run, n) }
+
+	original, err := service.Issue(ctx, &Issuance{
+		RegistryNumber:   registry(1),
+		StudentUUID:      studentUUID,
+		AcademicYearUUID: testYearUUID,
+		Type:             TypeTestimony,
+	})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if original.Version != 1 || original.SupersedesUUID != "" || !original.Valid() {
+		t.Errorf("original = %+v", original)
+	}
+
+	verification, err := service.Verify(ctx, registry(1))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if verification.Requested.UUID != original.UUID || verification.Latest.UUID != original.UUID {
+		t.Errorf("uncorrected certificate verified as %+v", verification)
+	}
+
+	// A second valid certificate of the same kind must be a correction instead
+	_, err = service.Issue(ctx, &Issuance{RegistryNumber: registry(9), StudentUUID: studentUUID, AcademicYearUUID: testYearUUID, Type: TypeTestimony})
+	assertStatus(t, "issuing a second certificate", err, http.StatusConflict)
+
+	first, err := service.Reissue(ctx, registry(1), registry(2), "grade in mathematics corrected")
+	if err != nil {
+		t.Fatalf("Reissue: %v", err)
+	}
+	if first.Version != 2 || first.SupersedesUUID != original.UUID || first.Reason != "grade in mathematics corrected" {
+		t.Errorf("first correction = %+v", first)
+	}
+	second, err := service.Reissue(ctx, registry(2), registry(3), "name spelling corrected")
+	if err != nil {
+		t.Fatalf("Reissue: %v", err)
+	}
+	if second.Version != 3 || second.SupersedesUUID != first.UUID {
+		t.Errorf("second correction = %+v", second)
+	}
+
+	for _, number := range []string{registry(1), registry(2), registry(3)} {
+		verification, err := service.Verify(ctx, number)
+		if err != nil {
+			t.Fatalf("Verify(%s): %v", number, err)
+		}
+		if verification.Latest.RegistryNumber != registry(3) || !verification.Latest.Valid() {
+			t.Errorf("Verify(%s) latest = %+v, want %s", number, verification.Latest, registry(3))
+		}
+		if wantValid := number == registry(3); verification.Requested.Valid() != wantValid {
+			t.Errorf("Verify(%s) valid = %v, want %v", number, verification.Requested.Valid(), wantValid)
+		}
+	}
+
+	_, err = service.Reissue(ctx, registry(1), registry(4), "again")
+	assertStatus(t, "correcting a superseded certificate", err, http.StatusConflict)
+	_, err = service.Reissue(ctx, registry(3), registry(1), "reused number")
+	assertStatus(t, "correcting under a used registry number", err, http.StatusConflict)
+	_, err = service.Verify(ctx, registry(9))
+	assertStatus(t, "verifying an unknown number", err, http.StatusNotFound)
+}
+
+func TestReissueNeedsNewRegistryNumber(t *testing.T) {
+	service := NewCertificateService(nil)
+	_, err := service.Reissue(context.Background(), "T-1", "T-1", "typo")
+	var validationErr *apierror.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Reissue under the same number = %v, want a validation error", err)
+	}
+}
+
+func assertStatus(t *testing.T, what string, err error, status int) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s succeeded, want %d", what, status)
+		return
+	}
+	if got := apierror.Map(err).Status; got != status {
+		t.Errorf("%s = %v (%d), want %d", what, err, got, status)
+	}
+}
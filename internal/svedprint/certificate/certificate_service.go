@@ -0,0 +1,45 @@
+package certificate
+
+import (
+	"context"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+)
+
+type CertificateService struct {
+	repo *CertificateRepository
+}
+
+func NewCertificateService(repo *CertificateRepository) *CertificateService {
+	return &CertificateService{repo: repo}
+}
+
+func (s *CertificateService) Issue(ctx context.Context, issuance *Issuance) (*Issuance, error) {
+	return s.repo.Issue(ctx, issuance)
+}
+
+// Reissue issues a corrected certificate that supersedes the one under previous
+func (s *CertificateService) Reissue(ctx context.Context, previous, registryNumber, reason string) (*Issuance, error) {
+	if previous == registryNumber {
+		return nil, apierror.NewValidationError(apierror.Field("registry_number", "ne_previous", "a corrected certificate needs a new registry number"))
+	}
+	return s.repo.Reissue(ctx, previous, registryNumber, reason)
+}
+
+// Verify reports the certificate under registryNumber together with the latest
+// valid version of it
+func (s *CertificateService) Verify(ctx context.Context, registryNumber string) (*Verification, error) {
+	requested, err := s.repo.GetByRegistryNumber(ctx, registryNumber)
+	if err != nil {
+		return nil, err
+	}
+	if requested.Valid() {
+		return &Verification{Requested: requested, Latest: requested}, nil
+	}
+
+	latest, err := s.repo.GetLatestVersion(ctx, registryNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &Verification{Requested: requested, Latest: latest}, nil
+}
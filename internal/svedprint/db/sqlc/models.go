@@ -226,6 +226,19 @@ func (ns NullYearSuccessType) Value() (driver.Value, error) {
 	return string(ns.YearSuccessType), nil
 }
 
+type CertificateIssuance struct {
+	Uuid             pgtype.UUID
+	RegistryNumber   string
+	StudentUuid      pgtype.UUID
+	AcademicYearUuid pgtype.UUID
+	CertificateType  string
+	Version          int32
+	SupersedesUuid   pgtype.UUID
+	Reason           pgtype.Text
+	IssuedAt         pgtype.Timestamptz
+	SupersededAt     pgtype.Timestamptz
+}
+
 type Student struct {
 	Uuid             pgtype.UUID
 	FirstName        pgtype.Text
@@ -239,6 +252,21 @@ type Student struct {
 	PlaceOfBirth     pgtype.Text
 	Citizenship      pgtype.Text
 	SchoolUuid       pgtype.UUID
+	DeletedAt        pgtype.Timestamptz
+	ExternalID       pgtype.Text
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int64
+}
+
+type StudentAttendance struct {
+	Uuid              pgtype.UUID
+	StudentUuid       pgtype.UUID
+	AcademicYearUuid  pgtype.UUID
+	Term              int16
+	TotalAbsences     int32
+	JustifiedAbsences int32
+	UpdatedAt         pgtype.Timestamptz
 }
 
 type StudentsYearlyDetail struct {
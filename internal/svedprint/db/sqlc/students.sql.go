@@ -12,8 +12,9 @@ import (
 )
 
 const getStudentByUuid = `-- name: GetStudentByUuid :one
-select uuid, first_name, middle_name, last_name, personal_number, fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth, citizenship, school_uuid from student
+select uuid, first_name, middle_name, last_name, personal_number, fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth, citizenship, school_uuid, deleted_at, external_id, created_at, updated_at, version from student
 where uuid = $1
+and deleted_at is null
 `
 
 func (q *Queries) GetStudentByUuid(ctx context.Context, studentUuid pgtype.UUID) (Student, error) {
@@ -32,6 +33,384 @@ func (q *Queries) GetStudentByUuid(ctx context.Context, studentUuid pgtype.UUID)
 		&i.PlaceOfBirth,
 		&i.Citizenship,
 		&i.SchoolUuid,
+		&i.DeletedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
+
+const softDeleteStudent = `-- name: SoftDeleteStudent :one
+update student
+set deleted_at = coalesce(deleted_at, now())
+where uuid = $1
+returning deleted_at
+`
+
+func (q *Queries) SoftDeleteStudent(ctx context.Context, studentUuid pgtype.UUID) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, softDeleteStudent, studentUuid)
+	var deleted_at pgtype.Timestamptz
+	err := row.Scan(&deleted_at)
+	return deleted_at, err
+}
+
+const upsertStudentByExternalId = `-- name: UpsertStudentByExternalId :one
+insert into student (
+    uuid,
+    external_id,
+    first_name,
+    middle_name,
+    last_name,
+    personal_number,
+    fathers_name,
+    mothers_name,
+    date_of_birth,
+    place_of_residence,
+    place_of_birth,
+    citizenship,
+    school_uuid
+) values (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
+)
+on conflict (external_id) do update set
+    first_name = excluded.first_name,
+    middle_name = excluded.middle_name,
+    last_name = excluded.last_name,
+    personal_number = excluded.personal_number,
+    fathers_name = excluded.fathers_name,
+    mothers_name = excluded.mothers_name,
+    date_of_birth = excluded.date_of_birth,
+    place_of_residence = excluded.place_of_residence,
+    place_of_birth = excluded.place_of_birth,
+    citizenship = excluded.citizenship,
+    school_uuid = excluded.school_uuid,
+    deleted_at = null
+returning uuid, first_name, middle_name, last_name, personal_number, fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth, citizenship, school_uuid, deleted_at, external_id, created_at, updated_at, version, (xmax = 0) as inserted
+`
+
+type UpsertStudentByExternalIdParams struct {
+	ExternalID       pgtype.Text
+	FirstName        pgtype.Text
+	MiddleName       pgtype.Text
+	LastName         pgtype.Text
+	PersonalNumber   pgtype.Text
+	FathersName      pgtype.Text
+	MothersName      pgtype.Text
+	DateOfBirth      pgtype.Date
+	PlaceOfResidence pgtype.Text
+	PlaceOfBirth     pgtype.Text
+	Citizenship      pgtype.Text
+	SchoolUuid       pgtype.UUID
+}
+
+type UpsertStudentByExternalIdRow struct {
+	Uuid             pgtype.UUID
+	FirstName        pgtype.Text
+	MiddleName       pgtype.Text
+	LastName         pgtype.Text
+	PersonalNumber   pgtype.Text
+	FathersName      pgtype.Text
+	MothersName      pgtype.Text
+	DateOfBirth      pgtype.Date
+	PlaceOfResidence pgtype.Text
+	PlaceOfBirth     pgtype.Text
+	Citizenship      pgtype.Text
+	SchoolUuid       pgtype.UUID
+	DeletedAt        pgtype.Timestamptz
+	ExternalID       pgtype.Text
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int64
+	Inserted         bool
+}
+
+func (q *Queries) UpsertStudentByExternalId(ctx context.Context, arg UpsertStudentByExternalIdParams) (UpsertStudentByExternalIdRow, error) {
+	row := q.db.QueryRow(ctx, upsertStudentByExternalId,
+		arg.ExternalID,
+		arg.FirstName,
+		arg.MiddleName,
+		arg.LastName,
+		arg.PersonalNumber,
+		arg.FathersName,
+		arg.MothersName,
+		arg.DateOfBirth,
+		arg.PlaceOfResidence,
+		arg.PlaceOfBirth,
+		arg.Citizenship,
+		arg.SchoolUuid,
+	)
+	var i UpsertStudentByExternalIdRow
+	err := row.Scan(
+		&i.Uuid,
+		&i.FirstName,
+		&i.MiddleName,
+		&i.LastName,
+		&i.PersonalNumber,
+		&i.FathersName,
+		&i.MothersName,
+		&i.DateOfBirth,
+		&i.PlaceOfResidence,
+		&i.PlaceOfBirth,
+		&i.Citizenship,
+		&i.SchoolUuid,
+		&i.DeletedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.Inserted,
+	)
+	return i, err
+}
+
+const listStudentsBySchool = `-- name: ListStudentsBySchool :many
+select uuid, first_name, middle_name, last_name, personal_number, fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth, citizenship, school_uuid, deleted_at, external_id, created_at, updated_at, version from student
+where school_uuid = $1
+and deleted_at is null
+order by last_name, first_name, uuid
+`
+
+func (q *Queries) ListStudentsBySchool(ctx context.Context, schoolUuid pgtype.UUID) ([]Student, error) {
+	rows, err := q.db.Query(ctx, listStudentsBySchool, schoolUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Student
+	for rows.Next() {
+		var i Student
+		if err := rows.Scan(
+			&i.Uuid,
+			&i.FirstName,
+			&i.MiddleName,
+			&i.LastName,
+			&i.PersonalNumber,
+			&i.FathersName,
+			&i.MothersName,
+			&i.DateOfBirth,
+			&i.PlaceOfResidence,
+			&i.PlaceOfBirth,
+			&i.Citizenship,
+			&i.SchoolUuid,
+			&i.DeletedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStudentsBySchoolPage = `-- name: ListStudentsBySchoolPage :many
+select uuid, first_name, middle_name, last_name, personal_number, fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth, citizenship, school_uuid, deleted_at, external_id, created_at, updated_at, version from student
+where school_uuid = $1
+and deleted_at is null
+order by last_name, first_name, uuid
+limit $2 offset $3
+`
+
+type ListStudentsBySchoolPageParams struct {
+	SchoolUuid  pgtype.UUID
+	LimitCount  int32
+	OffsetCount int32
+}
+
+func (q *Queries) ListStudentsBySchoolPage(ctx context.Context, arg ListStudentsBySchoolPageParams) ([]Student, error) {
+	rows, err := q.db.Query(ctx, listStudentsBySchoolPage, arg.SchoolUuid, arg.LimitCount, arg.OffsetCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Student
+	for rows.Next() {
+		var i Student
+		if err := rows.Scan(
+			&i.Uuid,
+			&i.FirstName,
+			&i.MiddleName,
+			&i.LastName,
+			&i.PersonalNumber,
+			&i.FathersName,
+			&i.MothersName,
+			&i.DateOfBirth,
+			&i.PlaceOfResidence,
+			&i.PlaceOfBirth,
+			&i.Citizenship,
+			&i.SchoolUuid,
+			&i.DeletedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStudentSummaryByUuid = `-- name: GetStudentSummaryByUuid :one
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where uuid = $1
+and deleted_at is null
+`
+
+type GetStudentSummaryByUuidRow struct {
+	Uuid       pgtype.UUID
+	ExternalID pgtype.Text
+	FirstName  pgtype.Text
+	LastName   pgtype.Text
+	SchoolUuid pgtype.UUID
+	UpdatedAt  pgtype.Timestamptz
+	Version    int64
+}
+
+func (q *Queries) GetStudentSummaryByUuid(ctx context.Context, studentUuid pgtype.UUID) (GetStudentSummaryByUuidRow, error) {
+	row := q.db.QueryRow(ctx, getStudentSummaryByUuid, studentUuid)
+	var i GetStudentSummaryByUuidRow
+	err := row.Scan(
+		&i.Uuid,
+		&i.ExternalID,
+		&i.FirstName,
+		&i.LastName,
+		&i.SchoolUuid,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const listStudentSummariesBySchool = `-- name: ListStudentSummariesBySchool :many
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where school_uuid = $1
+and deleted_at is null
+order by last_name, first_name, uuid
+`
+
+type ListStudentSummariesBySchoolRow struct {
+	Uuid       pgtype.UUID
+	ExternalID pgtype.Text
+	FirstName  pgtype.Text
+	LastName   pgtype.Text
+	SchoolUuid pgtype.UUID
+	UpdatedAt  pgtype.Timestamptz
+	Version    int64
+}
+
+func (q *Queries) ListStudentSummariesBySchool(ctx context.Context, schoolUuid pgtype.UUID) ([]ListStudentSummariesBySchoolRow, error) {
+	rows, err := q.db.Query(ctx, listStudentSummariesBySchool, schoolUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStudentSummariesBySchoolRow
+	for rows.Next() {
+		var i ListStudentSummariesBySchoolRow
+		if err := rows.Scan(
+			&i.Uuid,
+			&i.ExternalID,
+			&i.FirstName,
+			&i.LastName,
+			&i.SchoolUuid,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStudentSummariesBySchoolPage = `-- name: ListStudentSummariesBySchoolPage :many
+select uuid, external_id, first_name, last_name, school_uuid, updated_at, version from student
+where school_uuid = $1
+and deleted_at is null
+order by last_name, first_name, uuid
+limit $2 offset $3
+`
+
+type ListStudentSummariesBySchoolPageParams struct {
+	SchoolUuid  pgtype.UUID
+	LimitCount  int32
+	OffsetCount int32
+}
+
+type ListStudentSummariesBySchoolPageRow struct {
+	Uuid       pgtype.UUID
+	ExternalID pgtype.Text
+	FirstName  pgtype.Text
+	LastName   pgtype.Text
+	SchoolUuid pgtype.UUID
+	UpdatedAt  pgtype.Timestamptz
+	Version    int64
+}
+
+func (q *Queries) ListStudentSummariesBySchoolPage(ctx context.Context, arg ListStudentSummariesBySchoolPageParams) ([]ListStudentSummariesBySchoolPageRow, error) {
+	rows, err := q.db.Query(ctx, listStudentSummariesBySchoolPage, arg.SchoolUuid, arg.LimitCount, arg.OffsetCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStudentSummariesBySchoolPageRow
+	for rows.Next() {
+		var i ListStudentSummariesBySchoolPageRow
+		if err := rows.Scan(
+			&i.Uuid,
+			&i.ExternalID,
+			&i.FirstName,
+			&i.LastName,
+			&i.SchoolUuid,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countStudentsBySchool = `-- name: CountStudentsBySchool :one
+select count(*) from student
+where school_uuid = $1
+and deleted_at is null
+`
+
+func (q *Queries) CountStudentsBySchool(ctx context.Context, schoolUuid pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countStudentsBySchool, schoolUuid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
@@ -1,22 +1,37 @@
 package svedprint
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/attendance"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/certificate"
 	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/student"
 	"github.com/PegasusMKD/svedprint-go/pkg/config"
 	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+	"github.com/PegasusMKD/svedprint-go/pkg/health"
+	"github.com/PegasusMKD/svedprint-go/pkg/lifecycle"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type GinServer struct {
-	addr   string
-	engine *gin.Engine
+	addr      string
+	engine    *gin.Engine
+	lifecycle *lifecycle.Lifecycle
 }
 
 func (gs *GinServer) Run() {
-	gs.engine.Run(gs.addr)
+	srv := &http.Server{Addr: gs.addr, Handler: gs.engine}
+	if err := gs.lifecycle.Run(srv); err != nil {
+		log.Fatal().Err(err).Msg("Server stopped unexpectedly")
+	}
 }
 
 func NewServer() *GinServer {
@@ -28,32 +43,80 @@ func NewServer() *GinServer {
 
 	cfg, err := config.Load("svedprint")
 	if err != nil {
-		panic("Failed loading config for svedprint!")
+		log.Fatal().Err(err).Msg("Failed loading config for svedprint")
 	}
 
-	setupSqlc(cfg)
+	lc := lifecycle.New(cfg.ShutdownTimeout)
+	db, queries := setupSqlc(cfg, lc)
 
-	router := gin.Default()
+	router := gin.New()
 
-	setupMiddleware(router)
-	setupRoutes(router)
+	setupMiddleware(router, cfg)
+	setupHealth(router, lc)
+	setupRoutes(router, cfg, db, queries)
 
-	return &GinServer{engine: router, addr: addr}
+	return &GinServer{engine: router, addr: addr, lifecycle: lc}
 }
 
-func setupSqlc(cfg *config.Config) *sqlc.Queries {
+func setupSqlc(cfg *config.Config, lc *lifecycle.Lifecycle) (*database.TenantDB, *sqlc.Queries) {
 	dbConfig := database.GetConfig(cfg.DatabaseURL, cfg.DatabaseMaxConns, cfg.DatabaseMaxIdleConns, cfg.DatabaseConnLifetime)
+	dbConfig.ExpectedReplicas = cfg.DatabaseReplicas
+	dbConfig.StrictConnectionLimit = cfg.DatabaseStrictLimit
+	dbConfig.CategoryTimeouts = database.NewCategoryTimeouts(cfg.DatabaseInteractiveTimeout, cfg.DatabaseReportingTimeout, cfg.DatabaseAcquireTimeout)
 	migrationPath := fmt.Sprintf("db/%s/migrations", cfg.ServiceName)
-	database.RunMigrations(dbConfig.URL, migrationPath)
-	return sqlc.New(database.SetupDatabasePool(dbConfig))
+
+	pool, err := database.OpenPool(context.Background(), dbConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create connection pool")
+	}
+
+	// Checked after the listener is up so liveness probes answer during startup
+	lc.OnStart("database", func(ctx context.Context) error {
+		return database.Verify(ctx, pool, dbConfig)
+	})
+	lc.OnStart("migrations", func(ctx context.Context) error {
+		return database.RunMigrations(dbConfig.URL, migrationPath)
+	})
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		return database.CloseWithTimeout(pool, cfg.DatabaseCloseTimeout)
+	})
+
+	db := database.NewTenantDB(pool)
+	return db, sqlc.New(database.NewQueryDB(db, cfg.DatabaseQueryTimeout))
+}
+
+func setupHealth(router *gin.Engine, lc *lifecycle.Lifecycle) {
+	gate := health.NewGate()
+	lc.OnReady(gate.MarkReady)
+
+	router.Use(gate.Middleware())
+	gate.RegisterRoutes(router)
 }
 
-func setupMiddleware(router *gin.Engine) {
-	router.Use(gin.Logger())
+func setupMiddleware(router *gin.Engine, cfg *config.Config) {
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID(requestid.MustNew(cfg.RequestIDFormat)))
+	router.Use(middleware.AccessLog(cfg.AccessLogFormat, os.Stdout))
+	router.Use(middleware.PrettyJSON(cfg.PrettyJSON))
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
+	router.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxQueuedRequests))
+	router.Use(middleware.Tenant())
+	router.Use(middleware.RequestLogger())
 }
 
-func setupRoutes(router *gin.Engine) {
+func setupRoutes(router *gin.Engine, cfg *config.Config, db *database.TenantDB, queries *sqlc.Queries) {
 	router.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{"status": "healthy"})
 	})
+
+	timeouts := database.NewCategoryTimeouts(cfg.DatabaseInteractiveTimeout, cfg.DatabaseReportingTimeout, cfg.DatabaseAcquireTimeout)
+	studentHandler := student.NewStudentHandler(student.NewStudentService(student.NewStudentRepository(db, queries, timeouts)), dto.CountStrategy(cfg.StudentListCount), cfg.ExportIdleTimeout)
+	students := router.Group("/students")
+	studentHandler.RegisterRoutes(students)
+
+	attendanceHandler := attendance.NewAttendanceHandler(attendance.NewAttendanceService(attendance.NewAttendanceRepository(queries)))
+	attendanceHandler.RegisterRoutes(students)
+
+	certificateHandler := certificate.NewCertificateHandler(certificate.NewCertificateService(certificate.NewCertificateRepository(queries)))
+	certificateHandler.RegisterRoutes(router.Group("/certificates"))
 }
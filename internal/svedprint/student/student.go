@@ -0,0 +1,23 @@
+package student
+
+import "time"
+
+// Student is the domain representation of a student
+type Student struct {
+	UUID             string
+	ExternalID       string
+	FirstName        string
+	MiddleName       string
+	LastName         string
+	PersonalNumber   string
+	FathersName      string
+	MothersName      string
+	DateOfBirth      *time.Time
+	PlaceOfResidence string
+	PlaceOfBirth     string
+	Citizenship      string
+	SchoolUUID       string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Version          int64
+}
@@ -0,0 +1,60 @@
+package student
+
+import (
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+)
+
+type StudentDTO struct {
+	dto.Base
+	UUID             string `json:"uuid"`
+	ExternalID       string `json:"external_id,omitempty"`
+	FirstName        string `json:"first_name"`
+	MiddleName       string `json:"middle_name,omitempty"`
+	LastName         string `json:"last_name"`
+	PersonalNumber   string `json:"personal_number,omitempty"`
+	FathersName      string `json:"fathers_name,omitempty"`
+	MothersName      string `json:"mothers_name,omitempty"`
+	DateOfBirth      string `json:"date_of_birth,omitempty"`
+	PlaceOfResidence string `json:"place_of_residence,omitempty"`
+	PlaceOfBirth     string `json:"place_of_birth,omitempty"`
+	Citizenship      string `json:"citizenship,omitempty"`
+	SchoolUUID       string `json:"school_uuid"`
+}
+
+// StudentSummaryDTO is the view=summary projection: enough to list and link to a
+// student without the personal details
+type StudentSummaryDTO struct {
+	UUID       string    `json:"uuid"`
+	ExternalID string    `json:"external_id,omitempty"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	SchoolUUID string    `json:"school_uuid"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Version    int64     `json:"version"`
+}
+
+// UpsertStudentRequest is the full representation written by PUT /students/by-external-id/:extid
+type UpsertStudentRequest struct {
+	FirstName        string `json:"first_name" binding:"required"`
+	MiddleName       string `json:"middle_name"`
+	LastName         string `json:"last_name" binding:"required"`
+	PersonalNumber   string `json:"personal_number"`
+	FathersName      string `json:"fathers_name"`
+	MothersName      string `json:"mothers_name"`
+	DateOfBirth      string `json:"date_of_birth" binding:"omitempty,datetime=2006-01-02"`
+	PlaceOfResidence string `json:"place_of_residence"`
+	PlaceOfBirth     string `json:"place_of_birth"`
+	Citizenship      string `json:"citizenship"`
+	SchoolUUID       string `json:"school_uuid" binding:"required,uuid"`
+}
+
+// ImportResultDTO reports the outcome of a CSV import: valid lines are upserted by
+// external ID in one transaction, invalid cells are listed so they can be fixed and
+// the file re-imported
+type ImportResultDTO struct {
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []ImportError `json:"errors"`
+}
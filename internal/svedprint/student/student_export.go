@@ -0,0 +1,61 @@
+package student
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/export"
+)
+
+// StudentCSV writes students with the same columns the importer reads, one row at a
+// time so an export can stream straight from the query. With the ISO format the file
+// can be imported again unchanged.
+type StudentCSV struct {
+	cw     *csv.Writer
+	format export.Format
+	header []string
+}
+
+// NewStudentCSV writes the header row to w
+func NewStudentCSV(w io.Writer, format export.Format) (*StudentCSV, error) {
+	header := make([]string, 0, len(importColumns))
+	for _, col := range importColumns {
+		header = append(header, col.name)
+	}
+
+	sc := &StudentCSV{cw: format.NewCSVWriter(w), format: format, header: header}
+	if err := sc.cw.Write(header); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Write writes one student's row
+func (sc *StudentCSV) Write(s *Student) error {
+	values := map[string]string{
+		"external_id":        s.ExternalID,
+		"first_name":         s.FirstName,
+		"middle_name":        s.MiddleName,
+		"last_name":          s.LastName,
+		"personal_number":    s.PersonalNumber,
+		"fathers_name":       s.FathersName,
+		"mothers_name":       s.MothersName,
+		"date_of_birth":      sc.format.Date(s.DateOfBirth),
+		"place_of_residence": s.PlaceOfResidence,
+		"place_of_birth":     s.PlaceOfBirth,
+		"citizenship":        s.Citizenship,
+		"school_uuid":        s.SchoolUUID,
+	}
+
+	record := make([]string, 0, len(sc.header))
+	for _, name := range sc.header {
+		record = append(record, values[name])
+	}
+	return sc.cw.Write(record)
+}
+
+// Flush writes any buffered rows
+func (sc *StudentCSV) Flush() error {
+	sc.cw.Flush()
+	return sc.cw.Error()
+}
@@ -0,0 +1,111 @@
+package student
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/export"
+)
+
+func TestStudentCSVFormats(t *testing.T) {
+	born := time.Date(2010, time.April, 2, 0, 0, 0, 0, time.UTC)
+	student := &Student{
+		ExternalID:  "d-1",
+		FirstName:   "Ana",
+		LastName:    "Stojanova",
+		DateOfBirth: &born,
+		SchoolUUID:  testSchoolUUID,
+	}
+
+	tests := []struct {
+		format  export.Format
+		wantRow string
+	}{
+		{export.ISO, "d-1,Ana,,Stojanova,,,,2010-04-02,,,," + testSchoolUUID},
+		{export.Macedonian, "d-1;Ana;;Stojanova;;;;02.04.2010;;;;" + testSchoolUUID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sc, err := NewStudentCSV(&buf, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sc.Write(student); err != nil {
+				t.Fatal(err)
+			}
+			if err := sc.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("export has %d lines, want a header and one row", len(lines))
+			}
+			if lines[1] != tt.wantRow {
+				t.Errorf("row = %q, want %q", lines[1], tt.wantRow)
+			}
+		})
+	}
+}
+
+func TestStudentCSVISOReimports(t *testing.T) {
+	born := time.Date(2010, time.April, 2, 0, 0, 0, 0, time.UTC)
+	student := &Student{ExternalID: "d-1", FirstName: "Ana", LastName: "Stojanova", PlaceOfBirth: "Skopje, Centar",
+		DateOfBirth: &born, SchoolUUID: testSchoolUUID}
+
+	var buf bytes.Buffer
+	sc, err := NewStudentCSV(&buf, export.ISO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Write(student); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, importErrs, err := ParseStudentCSV(&buf)
+	if err != nil || len(importErrs) > 0 || len(rows) != 1 {
+		t.Fatalf("reimport = %d rows, %v, %v", len(rows), importErrs, err)
+	}
+	got := rows[0].Student
+	if got.ExternalID != student.ExternalID || got.PlaceOfBirth != student.PlaceOfBirth || !got.DateOfBirth.Equal(born) {
+		t.Errorf("reimported %+v, want %+v", got, student)
+	}
+}
+
+func TestExportStudentsLocale(t *testing.T) {
+	tests := []struct {
+		locale     string
+		wantStatus int
+		wantHeader string
+	}{
+		{"", http.StatusOK, "external_id,first_name,"},
+		{"mk", http.StatusOK, "external_id;first_name;"},
+		{"de", http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			server, _ := newExportServer(t, &streamDB{}, time.Second, time.Second)
+			resp, err := http.Get(server.URL + "/students/export?school_uuid=" + testSchoolUUID + "&locale=" + tt.locale)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.HasPrefix(string(body), tt.wantHeader) {
+				t.Errorf("export starts %q, want %q", body, tt.wantHeader)
+			}
+		})
+	}
+}
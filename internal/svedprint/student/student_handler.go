@@ -0,0 +1,207 @@
+package student
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+	"github.com/PegasusMKD/svedprint-go/pkg/export"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type StudentHandler struct {
+	service           *StudentService
+	listCount         dto.CountStrategy
+	exportIdleTimeout time.Duration
+}
+
+// NewStudentHandler creates the handler; listCount is how paged student lists report
+// their total and exportIdleTimeout how long an export waits on a client that stops
+// reading
+func NewStudentHandler(service *StudentService, listCount dto.CountStrategy, exportIdleTimeout time.Duration) *StudentHandler {
+	return &StudentHandler{service: service, listCount: listCount, exportIdleTimeout: exportIdleTimeout}
+}
+
+// RegisterRoutes registers the student endpoints on the given router group
+func (h *StudentHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.ListStudents)
+	rg.GET("/:uuid", h.GetStudent)
+	rg.DELETE("/:uuid", h.DeleteStudent)
+	rg.PUT("/by-external-id/:extid", h.UpsertStudent)
+	rg.POST("/import", h.ImportStudents)
+	rg.GET("/export", h.ExportStudents)
+}
+
+// GetStudent returns a student; view=summary returns only the identifying fields
+func (h *StudentHandler) GetStudent(c *gin.Context) {
+	view, err := dto.ParseView(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	student, err := h.service.GetStudentByUUID(c.Request.Context(), c.Param("uuid"), view)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	dto.SetLastModified(c, dto.Base{UpdatedAt: student.UpdatedAt})
+	c.JSON(http.StatusOK, StudentToView(student, view))
+}
+
+// ListStudents returns a school's students, in the summary or full view. With limit or
+// offset set it returns one page with has_more, and a total as the endpoint's count
+// strategy allows; otherwise it returns every student as a plain array.
+func (h *StudentHandler) ListStudents(c *gin.Context) {
+	view, err := dto.ParseView(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	schoolUUID := c.Query("school_uuid")
+	if schoolUUID == "" {
+		apierror.Respond(c, apierror.NewValidationError(apierror.Field("school_uuid", "required", "is required")))
+		return
+	}
+	pageReq, paged, err := dto.ParsePage(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	if paged {
+		page, err := h.service.ListSchoolStudentsPage(c.Request.Context(), schoolUUID, view, pageReq, h.listCount)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.MapPage(page, func(student *Student) any {
+			return StudentToView(student, view)
+		}))
+		return
+	}
+
+	students, err := h.service.ListSchoolStudents(c.Request.Context(), schoolUUID, view)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	out := make([]any, 0, len(students))
+	for _, student := range students {
+		out = append(out, StudentToView(student, view))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *StudentHandler) DeleteStudent(c *gin.Context) {
+	err := h.service.DeleteStudent(c.Request.Context(), c.Param("uuid"))
+	apierror.RespondDelete(c, err)
+}
+
+// UpsertStudent creates or replaces a student keyed by the external system's ID,
+// answering 201 when it was created and 200 when an existing student was updated
+func (h *StudentHandler) UpsertStudent(c *gin.Context) {
+	var req UpsertStudentRequest
+	if !apierror.BindJSON(c, &req) {
+		return
+	}
+
+	student, created, err := h.service.UpsertStudent(c.Request.Context(), UpsertRequestToStudent(c.Param("extid"), &req))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	out := StudentToDTO(student)
+	dto.SetLastModified(c, out.Base)
+	c.JSON(status, out)
+}
+
+// ImportStudents imports a CSV file of students. Valid lines are upserted and every
+// invalid cell is reported with its line and column; the response is 200 when the
+// whole file was imported and 422 when some lines were rejected. The valid lines are
+// stored together: a database failure on any of them stores none.
+func (h *StudentHandler) ImportStudents(c *gin.Context) {
+	rows, importErrs, err := ParseStudentCSV(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, apierror.NewWithCode(http.StatusBadRequest, apierror.CodeInvalidCSV, err.Error()))
+		return
+	}
+
+	created, updated, err := h.service.ImportStudents(c.Request.Context(), rows)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if len(importErrs) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	if importErrs == nil {
+		importErrs = []ImportError{}
+	}
+	c.JSON(status, ImportResultDTO{Created: created, Updated: updated, Errors: importErrs})
+}
+
+// ExportStudents downloads a school's students as CSV. The locale query parameter
+// picks the number and date format: iso (default) for integrations, mk for
+// spreadsheets in the Macedonian locale. Rows stream from the query as the client
+// reads them; a client that stops reading for the idle timeout gets its connection
+// aborted and the query cancelled. The export is exempt from the request timeout.
+func (h *StudentHandler) ExportStudents(c *gin.Context) {
+	format, err := export.Lookup(c.Query("locale"))
+	if err != nil {
+		apierror.Respond(c, apierror.NewValidationError(apierror.Field("locale", "oneof", err.Error())))
+		return
+	}
+	schoolUUID := c.Query("school_uuid")
+	if schoolUUID == "" {
+		apierror.Respond(c, apierror.NewValidationError(apierror.Field("school_uuid", "required", "is required")))
+		return
+	}
+
+	// Exports outlive the request timeout; the idle writer bounds them instead
+	ctx, cancel := middleware.WithoutTimeout(c)
+	defer cancel()
+	out := export.NewIdleWriter(c.Writer, h.exportIdleTimeout, cancel)
+	defer out.Close()
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="students-%s.csv"`, format.Name))
+	c.Status(http.StatusOK)
+
+	csvOut, err := NewStudentCSV(out, format)
+	if err == nil {
+		err = h.service.EachSchoolStudent(ctx, schoolUUID, csvOut.Write)
+	}
+	if err == nil {
+		err = csvOut.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	// Rows are buffered, so errors before the first flush can still be reported
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		apierror.Respond(c, err)
+		return
+	}
+	if errors.Is(err, export.ErrClientStalled) {
+		log.Warn().Err(err).Msg("Aborted student export")
+		return
+	}
+	log.Error().Err(err).Msg("Failed writing student export")
+}
@@ -0,0 +1,572 @@
+package student
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const testSchoolUUID = "5f0f4a4e-8f4e-4b7a-9d36-0d6b1f0c2a11"
+
+// studentColumns are the columns the export query selects
+var studentColumns = []string{"uuid", "first_name", "middle_name", "last_name", "personal_number",
+	"fathers_name", "mothers_name", "date_of_birth", "place_of_residence", "place_of_birth",
+	"citizenship", "school_uuid", "deleted_at", "external_id", "created_at", "updated_at", "version"}
+
+// streamDB serves the export query from a cursor of blank students that is only
+// bounded by remaining (negative for endless) and by the query's context
+type streamDB struct {
+	remaining int
+	delay     time.Duration
+	// queryErr is the query context's error when the cursor was closed
+	queryErr atomic.Value
+}
+
+func (db *streamDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return &streamRows{db: db, ctx: ctx}, nil
+}
+
+func (db *streamDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	panic("unexpected Exec")
+}
+
+func (db *streamDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	panic("unexpected QueryRow")
+}
+
+func (db *streamDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	panic("unexpected CopyFrom")
+}
+
+func (db *streamDB) Begin(context.Context) (pgx.Tx, error) {
+	return &streamTx{db: db}, nil
+}
+
+// streamTx accepts the reporting category's settings and serves the export query
+type streamTx struct {
+	pgx.Tx
+	db *streamDB
+}
+
+func (tx *streamTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tx.db.Query(ctx, sql, args...)
+}
+
+func (tx *streamTx) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *streamTx) Commit(context.Context) error   { return nil }
+func (tx *streamTx) Rollback(context.Context) error { return nil }
+
+type streamRows struct {
+	pgx.Rows
+	db  *streamDB
+	ctx context.Context
+}
+
+func (r *streamRows) Next() bool {
+	if r.db.remaining == 0 {
+		return false
+	}
+	time.Sleep(r.db.delay)
+	if r.ctx.Err() != nil {
+		return false
+	}
+	r.db.remaining--
+	return true
+}
+
+func (r *streamRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(studentColumns))
+	for i, name := range studentColumns {
+		fields[i].Name = name
+	}
+	return fields
+}
+
+func (r *streamRows) Scan(dest ...any) error { return nil }
+func (r *streamRows) Err() error             { return r.ctx.Err() }
+
+// Close records whether the query's context had been cancelled when the cursor closed
+func (r *streamRows) Close() {
+	if err := r.ctx.Err(); err != nil {
+		r.db.queryErr.Store(err)
+	}
+}
+
+func newExportServer(t *testing.T, db *streamDB, requestTimeout, idle time.Duration) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, nil, nil)), "", idle)
+	done := make(chan struct{})
+	router := gin.New()
+	router.Use(middleware.Timeout(requestTimeout))
+	router.GET("/students/export", func(c *gin.Context) {
+		defer close(done)
+		handler.ExportStudents(c)
+	})
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, done
+}
+
+func TestExportStudentsStalledReaderCancelsQuery(t *testing.T) {
+	db := &streamDB{remaining: -1}
+	server, done := newExportServer(t, db, time.Minute, 100*time.Millisecond)
+
+	resp, err := http.Get(server.URL + "/students/export?school_uuid=" + testSchoolUUID)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	// Never read the body; the endless cursor fills the socket buffers and stalls
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("export still running after the client stopped reading")
+	}
+	if err, _ := db.queryErr.Load().(error); err != context.Canceled {
+		t.Errorf("query stopped with %v, want context.Canceled", err)
+	}
+}
+
+func TestExportStudentsOutlivesRequestTimeout(t *testing.T) {
+	const students = 50
+	db := &streamDB{remaining: students, delay: 2 * time.Millisecond}
+	server, _ := newExportServer(t, db, 20*time.Millisecond, time.Second)
+
+	resp, err := http.Get(server.URL + "/students/export?school_uuid=" + testSchoolUUID)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if lines != students+1 {
+		t.Errorf("export has %d lines, want a header and %d students", lines, students)
+	}
+	if err, _ := db.queryErr.Load().(error); err != nil {
+		t.Errorf("query stopped with %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+// deleteDB serves SoftDeleteStudent from an in-memory table of students, keyed by
+// UUID, holding each one's deleted_at
+type deleteDB struct {
+	streamDB
+	students map[[16]byte]*time.Time
+}
+
+func (db *deleteDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	id := args[0].(pgtype.UUID).Bytes
+	deletedAt, ok := db.students[id]
+	if !ok {
+		return rowFunc(func(...any) error { return pgx.ErrNoRows })
+	}
+	if deletedAt == nil {
+		now := time.Now()
+		deletedAt = &now
+		db.students[id] = deletedAt
+	}
+	return rowFunc(func(dest ...any) error {
+		*dest[0].(*pgtype.Timestamptz) = pgtype.Timestamptz{Time: *deletedAt, Valid: true}
+		return nil
+	})
+}
+
+// assertErrorCode checks the code of an apierror body
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, want apierror.Code) {
+	t.Helper()
+	var body apierror.Error
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if body.Code != want {
+		t.Errorf("code = %s, want %s", body.Code, want)
+	}
+}
+
+type rowFunc func(dest ...any) error
+
+func (f rowFunc) Scan(dest ...any) error { return f(dest...) }
+
+func TestDeleteStudent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const existing = "0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80"
+	id, err := utility.ParseUUID(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &deleteDB{students: map[[16]byte]*time.Time{id.Bytes: nil}}
+	handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/students"))
+
+	tests := []struct {
+		name       string
+		uuid       string
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{"first delete", existing, http.StatusNoContent, ""},
+		{"repeated delete", existing, http.StatusNoContent, ""},
+		{"never existed", "9d2e6f4a-1b3c-4e5d-8f7a-6b5c4d3e2f10", http.StatusNotFound, apierror.CodeStudentNotFound},
+		{"malformed uuid", "not-a-uuid", http.StatusBadRequest, apierror.CodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/students/"+tt.uuid, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("DELETE status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				assertErrorCode(t, w, tt.wantCode)
+			}
+		})
+	}
+	if db.students[id.Bytes] == nil {
+		t.Error("student was not marked deleted")
+	}
+}
+
+// upsertDB serves UpsertStudentByExternalId from an in-memory table keyed by external ID
+type upsertDB struct {
+	streamDB
+	byExternalID map[string]pgtype.UUID
+}
+
+func (db *upsertDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	externalID := args[0].(pgtype.Text).String
+	firstName := args[1].(pgtype.Text)
+	schoolUUID := args[11].(pgtype.UUID)
+
+	id, exists := db.byExternalID[externalID]
+	if !exists {
+		id = pgtype.UUID{Bytes: [16]byte{byte(len(db.byExternalID) + 1)}, Valid: true}
+		db.byExternalID[externalID] = id
+	}
+	return rowFunc(func(dest ...any) error {
+		*dest[0].(*pgtype.UUID) = id
+		*dest[1].(*pgtype.Text) = firstName
+		*dest[11].(*pgtype.UUID) = schoolUUID
+		*dest[13].(*pgtype.Text) = pgtype.Text{String: externalID, Valid: true}
+		*dest[17].(*bool) = !exists
+		return nil
+	})
+}
+
+func TestUpsertStudent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &upsertDB{byExternalID: map[string]pgtype.UUID{}}
+	handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/students"))
+
+	put := func(extID, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/students/by-external-id/"+extID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	body := func(firstName string) string {
+		return `{"first_name":"` + firstName + `","last_name":"Stojanova","school_uuid":"` + testSchoolUUID + `"}`
+	}
+
+	created := put("district-17", body("Ana"))
+	if created.Code != http.StatusCreated {
+		t.Fatalf("first upsert = %d, want 201: %s", created.Code, created.Body)
+	}
+	updated := put("district-17", body("Ana Marija"))
+	if updated.Code != http.StatusOK {
+		t.Fatalf("second upsert = %d, want 200: %s", updated.Code, updated.Body)
+	}
+
+	var first, second StudentDTO
+	if err := json.Unmarshal(created.Body.Bytes(), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(updated.Body.Bytes(), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.UUID != second.UUID || second.FirstName != "Ana Marija" || second.ExternalID != "district-17" {
+		t.Errorf("update returned %+v after create returned %+v", second, first)
+	}
+
+	if w := put("district-18", body("Marko")); w.Code != http.StatusCreated {
+		t.Errorf("upsert of another external ID = %d, want 201", w.Code)
+	}
+	if w := put("district-19", `{"first_name":"Ana"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("upsert missing required fields = %d, want 422", w.Code)
+	}
+}
+
+// metadataDB serves GetStudentByUuid with a student created and updated at fixed times
+type metadataDB struct {
+	streamDB
+	createdAt, updatedAt time.Time
+	version              int64
+}
+
+func (db *metadataDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	id := args[0].(pgtype.UUID)
+	return rowFunc(func(dest ...any) error {
+		*dest[0].(*pgtype.UUID) = id
+		*dest[1].(*pgtype.Text) = pgtype.Text{String: "Ana", Valid: true}
+		*dest[14].(*pgtype.Timestamptz) = pgtype.Timestamptz{Time: db.createdAt, Valid: true}
+		*dest[15].(*pgtype.Timestamptz) = pgtype.Timestamptz{Time: db.updatedAt, Valid: true}
+		*dest[16].(*int64) = db.version
+		return nil
+	})
+}
+
+func TestGetStudentMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	skopje := time.FixedZone("CET", 3600)
+	db := &metadataDB{
+		createdAt: time.Date(2026, 9, 1, 8, 0, 0, 0, skopje),
+		updatedAt: time.Date(2026, 10, 2, 14, 30, 15, 0, skopje),
+		version:   3,
+	}
+	handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/students"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d: %s", w.Code, w.Body)
+	}
+	if got, want := w.Header().Get("Last-Modified"), "Fri, 02 Oct 2026 13:30:15 GMT"; got != want {
+		t.Errorf("Last-Modified = %q, want %q", got, want)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"created_at": "2026-09-01T07:00:00Z",
+		"updated_at": "2026-10-02T13:30:15Z",
+		"version":    float64(3),
+	}
+	for field, value := range want {
+		if fields[field] != value {
+			t.Errorf("%s = %v, want %v", field, fields[field], value)
+		}
+	}
+}
+
+// viewDB serves the full and summary student queries, recording which one ran
+type viewDB struct {
+	streamDB
+	queries []string
+}
+
+func (db *viewDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.queries = append(db.queries, sql)
+	id := args[0].(pgtype.UUID)
+	return rowFunc(func(dest ...any) error {
+		*dest[0].(*pgtype.UUID) = id
+		if len(dest) == len(studentColumns) {
+			*dest[1].(*pgtype.Text) = pgtype.Text{String: "Ana", Valid: true}
+			*dest[3].(*pgtype.Text) = pgtype.Text{String: "Petrova", Valid: true}
+			*dest[4].(*pgtype.Text) = pgtype.Text{String: "0101010450001", Valid: true}
+			*dest[5].(*pgtype.Text) = pgtype.Text{String: "Petar", Valid: true}
+			*dest[9].(*pgtype.Text) = pgtype.Text{String: "Skopje", Valid: true}
+			return nil
+		}
+		*dest[2].(*pgtype.Text) = pgtype.Text{String: "Ana", Valid: true}
+		*dest[3].(*pgtype.Text) = pgtype.Text{String: "Petrova", Valid: true}
+		return nil
+	})
+}
+
+func TestGetStudentView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	heavy := []string{"personal_number", "fathers_name", "place_of_birth", "created_at"}
+	tests := []struct {
+		name      string
+		query     string
+		wantQuery string
+		wantHeavy bool
+	}{
+		{name: "default is full", query: "", wantQuery: "GetStudentByUuid", wantHeavy: true},
+		{name: "full", query: "?view=full", wantQuery: "GetStudentByUuid", wantHeavy: true},
+		{name: "summary", query: "?view=summary", wantQuery: "GetStudentSummaryByUuid", wantHeavy: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &viewDB{}
+			handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+			router := gin.New()
+			handler.RegisterRoutes(router.Group("/students"))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET status = %d: %s", w.Code, w.Body)
+			}
+			if len(db.queries) != 1 || !strings.Contains(db.queries[0], "-- name: "+tt.wantQuery+" ") {
+				t.Errorf("queries = %q, want only %s", db.queries, tt.wantQuery)
+			}
+
+			var fields map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range []string{"uuid", "first_name", "last_name", "updated_at", "version"} {
+				if _, ok := fields[field]; !ok {
+					t.Errorf("response is missing %s: %s", field, w.Body)
+				}
+			}
+			for _, field := range heavy {
+				if _, ok := fields[field]; ok != tt.wantHeavy {
+					t.Errorf("%s present = %v, want %v: %s", field, ok, tt.wantHeavy, w.Body)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown view", func(t *testing.T) {
+		db := &viewDB{}
+		handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+		router := gin.New()
+		handler.RegisterRoutes(router.Group("/students"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80?view=compact", nil))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("GET status = %d, want 422", w.Code)
+		}
+		if len(db.queries) != 0 {
+			t.Errorf("queries = %q, want none", db.queries)
+		}
+	})
+}
+
+func TestGetStudentNotFoundCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &deleteDB{students: map[[16]byte]*time.Time{}}
+	handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/students"))
+
+	for _, view := range []string{"full", "summary"} {
+		t.Run(view, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/9d2e6f4a-1b3c-4e5d-8f7a-6b5c4d3e2f10?view="+view, nil))
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("GET status = %d, want 404: %s", w.Code, w.Body)
+			}
+			assertErrorCode(t, w, apierror.CodeStudentNotFound)
+		})
+	}
+}
+
+// importDB keeps upserts made in a transaction apart until it commits; failOn makes
+// the upsert of that external ID fail
+type importDB struct {
+	streamDB
+	byExternalID map[string]pgtype.UUID
+	failOn       string
+}
+
+func (db *importDB) Begin(context.Context) (pgx.Tx, error) {
+	return &importTx{db: db, pending: &upsertDB{byExternalID: maps.Clone(db.byExternalID)}}, nil
+}
+
+type importTx struct {
+	pgx.Tx
+	db      *importDB
+	pending *upsertDB
+}
+
+func (tx *importTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if args[0].(pgtype.Text).String == tx.db.failOn {
+		return rowFunc(func(...any) error { return errors.New("connection reset") })
+	}
+	return tx.pending.QueryRow(ctx, sql, args...)
+}
+
+func (tx *importTx) Commit(context.Context) error {
+	tx.db.byExternalID = tx.pending.byExternalID
+	return nil
+}
+
+func (tx *importTx) Rollback(context.Context) error { return nil }
+
+func TestImportStudentsIsAllOrNothing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	csv := "external_id,first_name,last_name,school_uuid\n" +
+		"d-1,Ana,Stojanova," + testSchoolUUID + "\n" +
+		"d-2,Marko,Petrov," + testSchoolUUID + "\n" +
+		"d-3,Elena,Ilieva," + testSchoolUUID + "\n"
+
+	importCSV := func(db *importDB) *httptest.ResponseRecorder {
+		handler := NewStudentHandler(NewStudentService(NewStudentRepository(db, sqlc.New(db), nil)), "", time.Second)
+		router := gin.New()
+		handler.RegisterRoutes(router.Group("/students"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/students/import", strings.NewReader(csv)))
+		return w
+	}
+
+	db := &importDB{byExternalID: map[string]pgtype.UUID{}, failOn: "d-2"}
+	if w := importCSV(db); w.Code < http.StatusInternalServerError {
+		t.Errorf("import with a failing line = %d, want a server error", w.Code)
+	}
+	if len(db.byExternalID) != 0 {
+		t.Errorf("failed import stored %d students, want none", len(db.byExternalID))
+	}
+
+	db.failOn = ""
+	w := importCSV(db)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import = %d: %s", w.Code, w.Body)
+	}
+	var result ImportResultDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if result.Created != 3 || len(db.byExternalID) != 3 {
+		t.Errorf("import created %d and stored %d students, want 3", result.Created, len(db.byExternalID))
+	}
+}
@@ -0,0 +1,180 @@
+package student
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+)
+
+// ImportError pinpoints a failed cell of an import file. Line is 1-based and counts
+// the header; errors about the header itself use line 1.
+type ImportError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column"`
+	Rule    string `json:"rule"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// ImportRow is a student parsed from a valid import line
+type ImportRow struct {
+	Line    int
+	Student *Student
+}
+
+// importColumn describes a CSV column: whether it must be present and filled, how
+// its cells are checked and where the value is stored
+type importColumn struct {
+	name     string
+	required bool
+	validate func(value string) (rule, message string)
+	assign   func(s *Student, value string)
+}
+
+var importColumns = []importColumn{
+	{name: "external_id", required: true, assign: func(s *Student, v string) { s.ExternalID = v }},
+	{name: "first_name", required: true, assign: func(s *Student, v string) { s.FirstName = v }},
+	{name: "middle_name", assign: func(s *Student, v string) { s.MiddleName = v }},
+	{name: "last_name", required: true, assign: func(s *Student, v string) { s.LastName = v }},
+	{name: "personal_number", validate: validatePersonalNumber, assign: func(s *Student, v string) { s.PersonalNumber = v }},
+	{name: "fathers_name", assign: func(s *Student, v string) { s.FathersName = v }},
+	{name: "mothers_name", assign: func(s *Student, v string) { s.MothersName = v }},
+	{name: "date_of_birth", validate: validateDate, assign: func(s *Student, v string) {
+		if dob, err := time.Parse(time.DateOnly, v); err == nil {
+			s.DateOfBirth = &dob
+		}
+	}},
+	{name: "place_of_residence", assign: func(s *Student, v string) { s.PlaceOfResidence = v }},
+	{name: "place_of_birth", assign: func(s *Student, v string) { s.PlaceOfBirth = v }},
+	{name: "citizenship", assign: func(s *Student, v string) { s.Citizenship = v }},
+	{name: "school_uuid", required: true, validate: validateUUID, assign: func(s *Student, v string) { s.SchoolUUID = v }},
+}
+
+// ParseStudentCSV reads a student import file with a header row naming the columns
+// (in any order). Every cell is validated against its column, so one bad line can
+// report several errors. Lines with errors are left out of the returned rows. The
+// error is only set when the file is not readable CSV.
+func ParseStudentCSV(r io.Reader) ([]ImportRow, []ImportError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, []ImportError{{Line: 1, Rule: "required", Message: "file is empty, expected a header row"}}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed CSV: %w", err)
+	}
+
+	positions, importErrs := mapImportHeader(header)
+	if len(importErrs) > 0 {
+		return nil, importErrs, nil
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		student := &Student{}
+		var lineErrs []ImportError
+		for _, col := range importColumns {
+			idx, ok := positions[col.name]
+			if !ok {
+				continue
+			}
+			value := strings.TrimSpace(record[idx])
+
+			if value == "" {
+				if col.required {
+					lineErrs = append(lineErrs, ImportError{Line: line, Column: col.name, Rule: "required", Message: "must not be empty"})
+				}
+				continue
+			}
+			if col.validate != nil {
+				if rule, message := col.validate(value); rule != "" {
+					lineErrs = append(lineErrs, ImportError{Line: line, Column: col.name, Rule: rule, Value: value, Message: message})
+					continue
+				}
+			}
+			col.assign(student, value)
+		}
+
+		if len(lineErrs) > 0 {
+			importErrs = append(importErrs, lineErrs...)
+			continue
+		}
+		rows = append(rows, ImportRow{Line: line, Student: student})
+	}
+
+	return rows, importErrs, nil
+}
+
+// mapImportHeader resolves the column positions, reporting unknown, duplicate and
+// missing required columns
+func mapImportHeader(header []string) (map[string]int, []ImportError) {
+	known := make(map[string]bool, len(importColumns))
+	for _, col := range importColumns {
+		known[col.name] = true
+	}
+
+	positions := make(map[string]int, len(header))
+	var errs []ImportError
+	for i, raw := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff")))
+		switch {
+		case !known[name]:
+			errs = append(errs, ImportError{Line: 1, Column: raw, Rule: "unknown_column", Value: raw, Message: "unknown column"})
+		case hasKey(positions, name):
+			errs = append(errs, ImportError{Line: 1, Column: name, Rule: "duplicate_column", Value: raw, Message: "column appears more than once"})
+		default:
+			positions[name] = i
+		}
+	}
+
+	for _, col := range importColumns {
+		if col.required && !hasKey(positions, col.name) {
+			errs = append(errs, ImportError{Line: 1, Column: col.name, Rule: "required_column", Message: "required column is missing"})
+		}
+	}
+
+	return positions, errs
+}
+
+func hasKey(m map[string]int, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func validateDate(value string) (string, string) {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return "date", "must be a date in YYYY-MM-DD format"
+	}
+	return "", ""
+}
+
+func validateUUID(value string) (string, string) {
+	if _, err := utility.ParseUUID(value); err != nil {
+		return "uuid", "must be a valid UUID"
+	}
+	return "", ""
+}
+
+// validatePersonalNumber checks the 13-digit personal identification number (EMBG)
+func validatePersonalNumber(value string) (string, string) {
+	if len(value) != 13 || strings.Trim(value, "0123456789") != "" {
+		return "personal_number", "must be exactly 13 digits"
+	}
+	return "", ""
+}
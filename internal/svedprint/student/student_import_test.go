@@ -0,0 +1,107 @@
+package student
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStudentCSVReportsCells(t *testing.T) {
+	const school = testSchoolUUID
+
+	tests := []struct {
+		name      string
+		csv       string
+		wantLines []int
+		wantErrs  []ImportError
+	}{
+		{
+			name: "valid file in any column order",
+			csv: "school_uuid,last_name,first_name,external_id,date_of_birth\n" +
+				school + ",Stojanova,Ana,d-1,2010-04-02\n" +
+				school + ",Petrov,Marko,d-2,\n",
+			wantLines: []int{2, 3},
+		},
+		{
+			name: "errors in specific columns",
+			csv: "external_id,first_name,last_name,personal_number,date_of_birth,school_uuid\n" +
+				"d-1,Ana,Stojanova,0204010455001,2010-04-02," + school + "\n" +
+				"d-2,,Petrov,12345,02.04.2010," + school + "\n" +
+				"d-3,Elena,Ilieva,,,not-a-uuid\n",
+			wantLines: []int{2},
+			wantErrs: []ImportError{
+				{Line: 3, Column: "first_name", Rule: "required", Message: "must not be empty"},
+				{Line: 3, Column: "personal_number", Rule: "personal_number", Value: "12345", Message: "must be exactly 13 digits"},
+				{Line: 3, Column: "date_of_birth", Rule: "date", Value: "02.04.2010", Message: "must be a date in YYYY-MM-DD format"},
+				{Line: 4, Column: "school_uuid", Rule: "uuid", Value: "not-a-uuid", Message: "must be a valid UUID"},
+			},
+		},
+		{
+			name: "line numbers follow quoted newlines",
+			csv: "external_id,first_name,last_name,place_of_birth,school_uuid\n" +
+				"d-1,Ana,Stojanova,\"Skopje\nCentar\"," + school + "\n" +
+				"d-2,Marko,,Bitola," + school + "\n",
+			wantLines: []int{2},
+			wantErrs: []ImportError{
+				{Line: 4, Column: "last_name", Rule: "required", Message: "must not be empty"},
+			},
+		},
+		{
+			name: "bad header",
+			csv:  "\ufeffExternal_ID,first_name,first_name,grade,school_uuid\n",
+			wantErrs: []ImportError{
+				{Line: 1, Column: "first_name", Rule: "duplicate_column", Value: "first_name", Message: "column appears more than once"},
+				{Line: 1, Column: "grade", Rule: "unknown_column", Value: "grade", Message: "unknown column"},
+				{Line: 1, Column: "last_name", Rule: "required_column", Message: "required column is missing"},
+			},
+		},
+		{
+			name:     "empty file",
+			csv:      "",
+			wantErrs: []ImportError{{Line: 1, Rule: "required", Message: "file is empty, expected a header row"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, importErrs, err := ParseStudentCSV(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatalf("ParseStudentCSV: %v", err)
+			}
+
+			var lines []int
+			for _, row := range rows {
+				lines = append(lines, row.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("valid lines = %v, want %v", lines, tt.wantLines)
+			}
+			if !reflect.DeepEqual(importErrs, tt.wantErrs) {
+				t.Errorf("errors =\n%+v\nwant\n%+v", importErrs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestParseStudentCSVAssignsColumns(t *testing.T) {
+	csv := "external_id,first_name,last_name,date_of_birth,school_uuid\n" +
+		"d-1, Ana ,Stojanova,2010-04-02," + testSchoolUUID + "\n"
+
+	rows, importErrs, err := ParseStudentCSV(strings.NewReader(csv))
+	if err != nil || len(importErrs) > 0 || len(rows) != 1 {
+		t.Fatalf("ParseStudentCSV = %d rows, %v, %v", len(rows), importErrs, err)
+	}
+	s := rows[0].Student
+	if s.ExternalID != "d-1" || s.FirstName != "Ana" || s.LastName != "Stojanova" || s.SchoolUUID != testSchoolUUID {
+		t.Errorf("student = %+v", s)
+	}
+	if s.DateOfBirth == nil || s.DateOfBirth.Format("2006-01-02") != "2010-04-02" {
+		t.Errorf("date of birth = %v", s.DateOfBirth)
+	}
+}
+
+func TestParseStudentCSVMalformed(t *testing.T) {
+	csv := "external_id,first_name,last_name,school_uuid\nd-1,\"Ana,Stojanova," + testSchoolUUID + "\n"
+	if _, _, err := ParseStudentCSV(strings.NewReader(csv)); err == nil {
+		t.Error("ParseStudentCSV accepted an unterminated quote")
+	}
+}
@@ -0,0 +1,71 @@
+package student
+
+import (
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+)
+
+func StudentToDTO(s *Student) *StudentDTO {
+	out := &StudentDTO{
+		Base:             dto.NewBase(s.CreatedAt, s.UpdatedAt, s.Version),
+		UUID:             s.UUID,
+		ExternalID:       s.ExternalID,
+		FirstName:        s.FirstName,
+		MiddleName:       s.MiddleName,
+		LastName:         s.LastName,
+		PersonalNumber:   s.PersonalNumber,
+		FathersName:      s.FathersName,
+		MothersName:      s.MothersName,
+		PlaceOfResidence: s.PlaceOfResidence,
+		PlaceOfBirth:     s.PlaceOfBirth,
+		Citizenship:      s.Citizenship,
+		SchoolUUID:       s.SchoolUUID,
+	}
+	if s.DateOfBirth != nil {
+		out.DateOfBirth = s.DateOfBirth.Format("2006-01-02")
+	}
+	return out
+}
+
+func StudentToSummaryDTO(s *Student) *StudentSummaryDTO {
+	return &StudentSummaryDTO{
+		UUID:       s.UUID,
+		ExternalID: s.ExternalID,
+		FirstName:  s.FirstName,
+		LastName:   s.LastName,
+		SchoolUUID: s.SchoolUUID,
+		UpdatedAt:  s.UpdatedAt.UTC(),
+		Version:    s.Version,
+	}
+}
+
+// StudentToView maps a student onto the DTO of the requested view
+func StudentToView(s *Student, view dto.View) any {
+	if view == dto.ViewSummary {
+		return StudentToSummaryDTO(s)
+	}
+	return StudentToDTO(s)
+}
+
+// UpsertRequestToStudent maps an upsert body onto a student identified by its external ID.
+// The date format is already enforced by the binding tags.
+func UpsertRequestToStudent(externalID string, req *UpsertStudentRequest) *Student {
+	s := &Student{
+		ExternalID:       externalID,
+		FirstName:        req.FirstName,
+		MiddleName:       req.MiddleName,
+		LastName:         req.LastName,
+		PersonalNumber:   req.PersonalNumber,
+		FathersName:      req.FathersName,
+		MothersName:      req.MothersName,
+		PlaceOfResidence: req.PlaceOfResidence,
+		PlaceOfBirth:     req.PlaceOfBirth,
+		Citizenship:      req.Citizenship,
+		SchoolUUID:       req.SchoolUUID,
+	}
+	if dob, err := time.Parse("2006-01-02", req.DateOfBirth); err == nil {
+		s.DateOfBirth = &dob
+	}
+	return s
+}
@@ -0,0 +1,324 @@
+package student
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/internal/utility"
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+type StudentRepository struct {
+	db         database.TxBeginner
+	queries    *sqlc.Queries
+	categories map[database.Category]database.CategoryTimeouts
+}
+
+// NewStudentRepository uses queries for everything sqlc generates and transactions
+// from db for the few queries it can't express, such as streaming a cursor. categories
+// overrides database.DefaultCategoryTimeouts for those transactions.
+func NewStudentRepository(db database.TxBeginner, queries *sqlc.Queries, categories map[database.Category]database.CategoryTimeouts) *StudentRepository {
+	return &StudentRepository{db: db, queries: queries, categories: categories}
+}
+
+// InTx runs fn with a repository whose queries share one transaction, committing when
+// fn returns nil and rolling back everything otherwise
+func (r *StudentRepository) InTx(ctx context.Context, fn func(repo *StudentRepository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&StudentRepository{db: r.db, queries: r.queries.WithTx(tx), categories: r.categories}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByUUID returns a student that has not been deleted
+func (r *StudentRepository) GetByUUID(ctx context.Context, uuid string) (*Student, error) {
+	pgUUID, err := utility.ParseUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	sqlcStudent, err := r.queries.GetStudentByUuid(ctx, pgUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.WithCode(fmt.Errorf("student %s: %w", uuid, apierror.ErrNotFound), apierror.CodeStudentNotFound)
+		}
+		return nil, fmt.Errorf("failed to get student: %w", err)
+	}
+
+	return fromSQLCStudent(sqlcStudent), nil
+}
+
+// GetSummaryByUUID fetches only the columns of the summary view; the returned
+// student has just its identifying fields and change metadata set
+func (r *StudentRepository) GetSummaryByUUID(ctx context.Context, uuid string) (*Student, error) {
+	pgUUID, err := utility.ParseUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	row, err := r.queries.GetStudentSummaryByUuid(ctx, pgUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.WithCode(fmt.Errorf("student %s: %w", uuid, apierror.ErrNotFound), apierror.CodeStudentNotFound)
+		}
+		return nil, fmt.Errorf("failed to get student: %w", err)
+	}
+
+	return fromSQLCSummary(sqlc.ListStudentSummariesBySchoolRow(row)), nil
+}
+
+// ListSummariesBySchool is ListBySchool restricted to the summary columns
+func (r *StudentRepository) ListSummariesBySchool(ctx context.Context, schoolUUID string) ([]*Student, error) {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	rows, err := r.queries.ListStudentSummariesBySchool(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	students := make([]*Student, 0, len(rows))
+	for _, row := range rows {
+		students = append(students, fromSQLCSummary(row))
+	}
+	return students, nil
+}
+
+// SoftDelete marks a student as deleted. Deleting an already deleted student
+// succeeds, while a student that never existed returns apierror.ErrNotFound.
+func (r *StudentRepository) SoftDelete(ctx context.Context, uuid string) error {
+	pgUUID, err := utility.ParseUUID(uuid)
+	if err != nil {
+		return fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	if _, err := r.queries.SoftDeleteStudent(ctx, pgUUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.WithCode(fmt.Errorf("student %s: %w", uuid, apierror.ErrNotFound), apierror.CodeStudentNotFound)
+		}
+		return fmt.Errorf("failed to delete student: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertByExternalID creates the student with the given external ID or overwrites the
+// existing one in a single statement, so concurrent syncs cannot create duplicates.
+// The returned flag is true when a new row was inserted; a soft-deleted student is restored.
+func (r *StudentRepository) UpsertByExternalID(ctx context.Context, student *Student) (*Student, bool, error) {
+	schoolUUID, err := utility.ParseUUID(student.SchoolUUID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	row, err := r.queries.UpsertStudentByExternalId(ctx, sqlc.UpsertStudentByExternalIdParams{
+		ExternalID:       utility.StringToText(student.ExternalID),
+		FirstName:        utility.StringToText(student.FirstName),
+		MiddleName:       utility.StringToText(student.MiddleName),
+		LastName:         utility.StringToText(student.LastName),
+		PersonalNumber:   utility.StringToText(student.PersonalNumber),
+		FathersName:      utility.StringToText(student.FathersName),
+		MothersName:      utility.StringToText(student.MothersName),
+		DateOfBirth:      utility.TimeToDate(student.DateOfBirth),
+		PlaceOfResidence: utility.StringToText(student.PlaceOfResidence),
+		PlaceOfBirth:     utility.StringToText(student.PlaceOfBirth),
+		Citizenship:      utility.StringToText(student.Citizenship),
+		SchoolUuid:       schoolUUID,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to upsert student: %w", err)
+	}
+
+	return fromSQLCStudent(sqlc.Student{
+		Uuid:             row.Uuid,
+		FirstName:        row.FirstName,
+		MiddleName:       row.MiddleName,
+		LastName:         row.LastName,
+		PersonalNumber:   row.PersonalNumber,
+		FathersName:      row.FathersName,
+		MothersName:      row.MothersName,
+		DateOfBirth:      row.DateOfBirth,
+		PlaceOfResidence: row.PlaceOfResidence,
+		PlaceOfBirth:     row.PlaceOfBirth,
+		Citizenship:      row.Citizenship,
+		SchoolUuid:       row.SchoolUuid,
+		DeletedAt:        row.DeletedAt,
+		ExternalID:       row.ExternalID,
+		CreatedAt:        row.CreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+		Version:          row.Version,
+	}), row.Inserted, nil
+}
+
+// ListBySchool returns the school's students that are not deleted, ordered by name
+func (r *StudentRepository) ListBySchool(ctx context.Context, schoolUUID string) ([]*Student, error) {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	rows, err := r.queries.ListStudentsBySchool(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	students := make([]*Student, 0, len(rows))
+	for _, row := range rows {
+		students = append(students, fromSQLCStudent(row))
+	}
+	return students, nil
+}
+
+// exportStudentsBySchool is ListStudentsBySchool, owned here because sqlc has no
+// streaming mode; columns are matched to sqlc.Student by name, not position
+const exportStudentsBySchool = `select uuid, first_name, middle_name, last_name, personal_number,
+	fathers_name, mothers_name, date_of_birth, place_of_residence, place_of_birth,
+	citizenship, school_uuid, deleted_at, external_id, created_at, updated_at, version
+from student
+where school_uuid = $1
+and deleted_at is null
+order by last_name, first_name, uuid`
+
+// EachBySchool streams the school's students to fn in ListBySchool order, holding the
+// query open until fn has seen every row or returns an error
+func (r *StudentRepository) EachBySchool(ctx context.Context, schoolUUID string, fn func(*Student) error) error {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	// The cursor stays open as long as the client keeps reading, up to the reporting
+	// statement timeout
+	return database.InCategory(ctx, r.db, r.categories, database.CategoryReporting, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, exportStudentsBySchool, pgUUID)
+		if err != nil {
+			return fmt.Errorf("failed to stream students: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row, err := pgx.RowToStructByName[sqlc.Student](rows)
+			if err != nil {
+				return fmt.Errorf("failed to stream students: %w", err)
+			}
+			if err := fn(fromSQLCStudent(row)); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to stream students: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListBySchoolPage is ListBySchool for one page; it fetches page.FetchLimit rows so
+// the caller can tell whether more follow
+func (r *StudentRepository) ListBySchoolPage(ctx context.Context, schoolUUID string, page dto.PageRequest) ([]*Student, error) {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	rows, err := r.queries.ListStudentsBySchoolPage(ctx, sqlc.ListStudentsBySchoolPageParams{
+		SchoolUuid:  pgUUID,
+		LimitCount:  page.FetchLimit(),
+		OffsetCount: page.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	students := make([]*Student, 0, len(rows))
+	for _, row := range rows {
+		students = append(students, fromSQLCStudent(row))
+	}
+	return students, nil
+}
+
+// ListSummariesBySchoolPage is ListBySchoolPage restricted to the summary columns
+func (r *StudentRepository) ListSummariesBySchoolPage(ctx context.Context, schoolUUID string, page dto.PageRequest) ([]*Student, error) {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	rows, err := r.queries.ListStudentSummariesBySchoolPage(ctx, sqlc.ListStudentSummariesBySchoolPageParams{
+		SchoolUuid:  pgUUID,
+		LimitCount:  page.FetchLimit(),
+		OffsetCount: page.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	students := make([]*Student, 0, len(rows))
+	for _, row := range rows {
+		students = append(students, fromSQLCSummary(sqlc.ListStudentSummariesBySchoolRow(row)))
+	}
+	return students, nil
+}
+
+// CountBySchool counts the school's students that are not deleted
+func (r *StudentRepository) CountBySchool(ctx context.Context, schoolUUID string) (int64, error) {
+	pgUUID, err := utility.ParseUUID(schoolUUID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", apierror.ErrInvalidInput, err)
+	}
+
+	count, err := r.queries.CountStudentsBySchool(ctx, pgUUID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count students: %w", err)
+	}
+	return count, nil
+}
+
+func fromSQLCStudent(s sqlc.Student) *Student {
+	return &Student{
+		UUID:             s.Uuid.String(),
+		ExternalID:       utility.TextToString(s.ExternalID),
+		FirstName:        utility.TextToString(s.FirstName),
+		MiddleName:       utility.TextToString(s.MiddleName),
+		LastName:         utility.TextToString(s.LastName),
+		PersonalNumber:   utility.TextToString(s.PersonalNumber),
+		FathersName:      utility.TextToString(s.FathersName),
+		MothersName:      utility.TextToString(s.MothersName),
+		DateOfBirth:      utility.DateToTime(s.DateOfBirth),
+		PlaceOfResidence: utility.TextToString(s.PlaceOfResidence),
+		PlaceOfBirth:     utility.TextToString(s.PlaceOfBirth),
+		Citizenship:      utility.TextToString(s.Citizenship),
+		SchoolUUID:       s.SchoolUuid.String(),
+		CreatedAt:        s.CreatedAt.Time,
+		UpdatedAt:        s.UpdatedAt.Time,
+		Version:          s.Version,
+	}
+}
+
+func fromSQLCSummary(s sqlc.ListStudentSummariesBySchoolRow) *Student {
+	return &Student{
+		UUID:       s.Uuid.String(),
+		ExternalID: utility.TextToString(s.ExternalID),
+		FirstName:  utility.TextToString(s.FirstName),
+		LastName:   utility.TextToString(s.LastName),
+		SchoolUUID: s.SchoolUuid.String(),
+		UpdatedAt:  s.UpdatedAt.Time,
+		Version:    s.Version,
+	}
+}
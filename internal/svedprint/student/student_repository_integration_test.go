@@ -0,0 +1,74 @@
+package student
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/internal/svedprint/db/sqlc"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestUpsertByExternalIDConcurrent needs a scratch database in TEST_DATABASE_URL; it
+// applies the svedprint migrations to it
+func TestUpsertByExternalIDConcurrent(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	if err := database.RunMigrations(url, "../../../db/svedprint/migrations"); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	const externalID = "upsert-concurrency-test"
+	t.Cleanup(func() { pool.Exec(context.Background(), "delete from student where external_id = $1", externalID) })
+
+	repo := NewStudentRepository(pool, sqlc.New(pool), nil)
+	const syncs = 20
+	var wg sync.WaitGroup
+	inserted := make(chan bool, syncs)
+	for i := 0; i < syncs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, created, err := repo.UpsertByExternalID(ctx, &Student{
+				ExternalID: externalID,
+				FirstName:  "Ana",
+				LastName:   "Stojanova",
+				SchoolUUID: testSchoolUUID,
+			})
+			if err != nil {
+				t.Errorf("UpsertByExternalID: %v", err)
+			}
+			inserted <- created
+		}()
+	}
+	wg.Wait()
+	close(inserted)
+
+	creates := 0
+	for created := range inserted {
+		if created {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("%d upserts reported a create, want 1", creates)
+	}
+
+	var rows int
+	if err := pool.QueryRow(ctx, "select count(*) from student where external_id = $1", externalID).Scan(&rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("%d students with the external ID, want 1", rows)
+	}
+}
@@ -0,0 +1,94 @@
+package student
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/dto"
+)
+
+type StudentService struct {
+	repo *StudentRepository
+}
+
+func NewStudentService(repo *StudentRepository) *StudentService {
+	return &StudentService{repo: repo}
+}
+
+// GetStudentByUUID loads the student with only the columns the view needs
+func (s *StudentService) GetStudentByUUID(ctx context.Context, uuid string, view dto.View) (*Student, error) {
+	if view == dto.ViewSummary {
+		return s.repo.GetSummaryByUUID(ctx, uuid)
+	}
+	return s.repo.GetByUUID(ctx, uuid)
+}
+
+// DeleteStudent soft-deletes a student; repeated deletes are idempotent
+func (s *StudentService) DeleteStudent(ctx context.Context, uuid string) error {
+	return s.repo.SoftDelete(ctx, uuid)
+}
+
+// UpsertStudent creates or replaces the student with the given external ID and
+// reports whether it was created
+func (s *StudentService) UpsertStudent(ctx context.Context, student *Student) (*Student, bool, error) {
+	return s.repo.UpsertByExternalID(ctx, student)
+}
+
+func (s *StudentService) ListSchoolStudents(ctx context.Context, schoolUUID string, view dto.View) ([]*Student, error) {
+	if view == dto.ViewSummary {
+		return s.repo.ListSummariesBySchool(ctx, schoolUUID)
+	}
+	return s.repo.ListBySchool(ctx, schoolUUID)
+}
+
+// EachSchoolStudent streams a school's students to fn, for exports too large to hold
+// in memory
+func (s *StudentService) EachSchoolStudent(ctx context.Context, schoolUUID string, fn func(*Student) error) error {
+	return s.repo.EachBySchool(ctx, schoolUUID, fn)
+}
+
+// ListSchoolStudentsPage returns one page of a school's students. The count strategy
+// decides what the page says about the total; students are filtered by school, so the
+// table-wide planner estimate doesn't apply and CountEstimated counts exactly.
+func (s *StudentService) ListSchoolStudentsPage(ctx context.Context, schoolUUID string, view dto.View, page dto.PageRequest, count dto.CountStrategy) (*dto.Page[*Student], error) {
+	var students []*Student
+	var err error
+	if view == dto.ViewSummary {
+		students, err = s.repo.ListSummariesBySchoolPage(ctx, schoolUUID, page)
+	} else {
+		students, err = s.repo.ListBySchoolPage(ctx, schoolUUID, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewPage(ctx, page, students, count, dto.Counter{
+		Exact: func(ctx context.Context) (int64, error) {
+			return s.repo.CountBySchool(ctx, schoolUUID)
+		},
+	})
+}
+
+// ImportStudents upserts every parsed row by external ID in one transaction, returning
+// how many were created and updated. The first database failure rolls the whole
+// import back, so no row is stored, and is reported with its line.
+func (s *StudentService) ImportStudents(ctx context.Context, rows []ImportRow) (created, updated int, err error) {
+	err = s.repo.InTx(ctx, func(repo *StudentRepository) error {
+		for _, row := range rows {
+			_, wasCreated, err := repo.UpsertByExternalID(ctx, row.Student)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			if wasCreated {
+				created++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return created, updated, nil
+}
@@ -0,0 +1,47 @@
+package utility
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ParseUUID converts a string into a pgtype.UUID
+func ParseUUID(value string) (pgtype.UUID, error) {
+	var id pgtype.UUID
+	if err := id.Scan(value); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("invalid uuid %q: %w", value, err)
+	}
+	return id, nil
+}
+
+// TextToString returns the string value of a nullable text column, or "" when NULL
+func TextToString(t pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.String
+}
+
+// DateToTime returns the time value of a nullable date column, or nil when NULL
+func DateToTime(d pgtype.Date) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// StringToText converts a string into a nullable text column, storing "" as NULL
+func StringToText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// TimeToDate converts an optional time into a nullable date column
+func TimeToDate(t *time.Time) pgtype.Date {
+	if t == nil {
+		return pgtype.Date{}
+	}
+	return pgtype.Date{Time: *t, Valid: true}
+}
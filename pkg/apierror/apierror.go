@@ -0,0 +1,221 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrNotFound is wrapped by services when the requested resource does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidInput is wrapped when a request parameter cannot be parsed (e.g. a malformed UUID)
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrUnavailable is wrapped when a dependency did not answer in time; the request may
+// succeed if retried
+var ErrUnavailable = errors.New("service unavailable")
+
+// FieldError describes a single field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is the structured error body returned to API clients
+type Error struct {
+	Status  int          `json:"-"`
+	Code    Code         `json:"code"`
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an API error with an explicit status
+func New(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+// NewEditConflict reports a concurrent edit that could not be merged, listing the
+// fields both edits changed
+func NewEditConflict(fields ...string) *Error {
+	errs := make([]FieldError, 0, len(fields))
+	for _, field := range fields {
+		errs = append(errs, FieldError{Field: field, Rule: "conflict", Message: "was changed by a concurrent edit"})
+	}
+	return &Error{
+		Status:  http.StatusConflict,
+		Code:    CodeEditConflict,
+		Message: "the resource was modified concurrently; reload it and reapply the conflicting changes",
+		Fields:  errs,
+	}
+}
+
+// ValidationError is returned by services when a well-formed payload breaks a business rule
+// (e.g. an out-of-range grade or an unknown subject)
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(parts, "; "))
+}
+
+// NewValidationError creates a validation error from the given field errors
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Field is a shorthand for building a FieldError
+func Field(field, rule, message string) FieldError {
+	return FieldError{Field: field, Rule: rule, Message: message}
+}
+
+// Map converts an error into an API error with the matching HTTP status:
+// malformed input maps to 400, semantic validation failures map to 422,
+// missing resources map to 404 and anything unrecognised maps to 500. The code is
+// the one err was tagged with by WithCode, or else the generic code for the status.
+func Map(err error) *Error {
+	apiErr := mapStatus(err)
+	if code, ok := codeOf(err); ok {
+		apiErr.Code = code
+	} else if apiErr.Code == "" {
+		apiErr.Code = codeForStatus(apiErr.Status)
+	}
+	return apiErr
+}
+
+// mapStatus builds a fresh API error with the status and message for err
+func mapStatus(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		mapped := *apiErr
+		return &mapped
+	}
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, database.ErrNotFound) {
+		return &Error{
+			Status:  http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, database.ErrUnavailable) {
+		return &Error{
+			Status:  http.StatusServiceUnavailable,
+			Message: "service temporarily unavailable, retry later",
+		}
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return &Error{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "validation failed",
+			Fields:  validationErr.Fields,
+		}
+	}
+
+	var bindingErrs validator.ValidationErrors
+	if errors.As(err, &bindingErrs) {
+		fields := make([]FieldError, 0, len(bindingErrs))
+		for _, fe := range bindingErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: bindingMessage(fe),
+			})
+		}
+		return &Error{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "validation failed",
+			Fields:  fields,
+		}
+	}
+
+	if isMalformed(err) {
+		code := CodeMalformedRequest
+		if errors.Is(err, ErrInvalidInput) {
+			code = CodeInvalidInput
+		}
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    code,
+			Message: fmt.Sprintf("malformed request: %v", err),
+		}
+	}
+
+	return &Error{
+		Status:  http.StatusInternalServerError,
+		Message: "internal server error",
+	}
+}
+
+// Respond writes the mapped error to the response and aborts the request
+func Respond(c *gin.Context, err error) {
+	apiErr := Map(err)
+	c.AbortWithStatusJSON(apiErr.Status, apiErr)
+}
+
+// RespondDelete encodes idempotent DELETE semantics: deleting an existing resource,
+// including one that was already deleted, returns 204 while a resource that never
+// existed returns 404
+func RespondDelete(c *gin.Context, err error) {
+	if err != nil {
+		Respond(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// BindJSON binds the request body into obj, responding with 400 or 422 on failure.
+// It returns false if the handler should stop processing the request.
+func BindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		Respond(c, err)
+		return false
+	}
+	return true
+}
+
+// isMalformed reports whether err was caused by input that could not be parsed at all
+func isMalformed(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, ErrInvalidInput) ||
+		errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// bindingMessage builds a human readable message for a binding tag failure
+func bindingMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
@@ -0,0 +1,179 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMapStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   Code
+	}{
+		{"not found", fmt.Errorf("student 42: %w", ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{"database no rows", fmt.Errorf("failed to get student: %w", fmt.Errorf("%w: %w", database.ErrNotFound, pgx.ErrNoRows)), http.StatusNotFound, CodeNotFound},
+		{"database timeout", fmt.Errorf("failed to list students: %w", database.ErrUnavailable), http.StatusServiceUnavailable, CodeUnavailable},
+		{"unavailable", ErrUnavailable, http.StatusServiceUnavailable, CodeUnavailable},
+		{"coded", WithCode(ErrNotFound, CodeStudentNotFound), http.StatusNotFound, CodeStudentNotFound},
+		{"validation", NewValidationError(Field("name", "required", "is required")), http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"invalid input", fmt.Errorf("%w: bad uuid", ErrInvalidInput), http.StatusBadRequest, CodeInvalidInput},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Map(tt.err)
+			if got.Status != tt.wantStatus || got.Code != tt.wantCode {
+				t.Errorf("Map() = %d %s, want %d %s", got.Status, got.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type gradeRequest struct {
+		Subject string `json:"subject" binding:"required"`
+		Grade   int    `json:"grade" binding:"required,min=1,max=5"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   Code
+		wantFields []string
+	}{
+		{"valid", `{"subject":"math","grade":5}`, http.StatusOK, "", nil},
+		{"empty body", ``, http.StatusBadRequest, CodeMalformedRequest, nil},
+		{"syntax error", `{"subject":"math",`, http.StatusBadRequest, CodeMalformedRequest, nil},
+		{"wrong type", `{"subject":"math","grade":"five"}`, http.StatusBadRequest, CodeMalformedRequest, nil},
+		{"grade out of range", `{"subject":"math","grade":7}`, http.StatusUnprocessableEntity, CodeValidationFailed, []string{"Grade"}},
+		{"missing fields", `{}`, http.StatusUnprocessableEntity, CodeValidationFailed, []string{"Subject", "Grade"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/grades", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			var req gradeRequest
+			if BindJSON(c, &req) {
+				c.Status(http.StatusOK)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			var body Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+			if len(body.Fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %+v, want %v", body.Fields, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if body.Fields[i].Field != field {
+					t.Errorf("fields[%d] = %s, want %s", i, body.Fields[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	if WithCode(nil, CodeStudentNotFound) != nil {
+		t.Error("WithCode(nil) is not nil")
+	}
+
+	err := WithCode(fmt.Errorf("student 42: %w", ErrNotFound), CodeStudentNotFound)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("errors.Is does not see through the code")
+	}
+	if err.Error() != "student 42: not found" {
+		t.Errorf("Error() = %q, want the wrapped message", err.Error())
+	}
+
+	// A code on a shared *Error must not leak into the original
+	shared := New(http.StatusConflict, "already issued")
+	if got := Map(WithCode(shared, CodeCertificateIssued)); got.Status != http.StatusConflict || got.Code != CodeCertificateIssued {
+		t.Errorf("Map() = %d %s, want 409 %s", got.Status, got.Code, CodeCertificateIssued)
+	}
+	if got := Map(shared); got.Code != CodeConflict {
+		t.Errorf("Map(shared) code = %s, want %s", got.Code, CodeConflict)
+	}
+	if shared.Code != "" {
+		t.Errorf("shared error was modified: code %s", shared.Code)
+	}
+}
+
+func TestRespondWritesCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode Code
+	}{
+		{"explicit", NewWithCode(http.StatusTooManyRequests, CodeRateLimited, "slow down"), CodeRateLimited},
+		{"tagged", WithCode(fmt.Errorf("class 1: %w", ErrNotFound), CodeClassNotFound), CodeClassNotFound},
+		{"status only", New(http.StatusPreconditionFailed, "stale"), CodePreconditionFailed},
+		{"unknown status", New(http.StatusTeapot, "teapot"), CodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			Respond(c, tt.err)
+
+			var body Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Message == "" {
+				t.Errorf("body = %s, want code %s with a message", w.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRespondEditConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	Respond(c, NewEditConflict("/subjects/math/grade", "/note"))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", w.Code)
+	}
+	var body Error
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Code != CodeEditConflict || len(body.Fields) != 2 {
+		t.Fatalf("body = %s, want %s listing 2 fields", w.Body, CodeEditConflict)
+	}
+	for i, field := range []string{"/subjects/math/grade", "/note"} {
+		if body.Fields[i].Field != field || body.Fields[i].Rule != "conflict" {
+			t.Errorf("fields[%d] = %+v, want a conflict on %s", i, body.Fields[i], field)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier returned in the "code" field of
+// every error body. Clients should switch on codes rather than statuses or messages,
+// which may change or be localized. Codes are never renamed or reused; new ones may be
+// added, so clients must handle unknown codes by falling back to the HTTP status.
+type Code string
+
+// Generic codes, used when no more specific code applies
+const (
+	CodeBadRequest           Code = "BAD_REQUEST"
+	CodeMalformedRequest     Code = "MALFORMED_REQUEST"
+	CodeInvalidInput         Code = "INVALID_INPUT"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodePreconditionFailed   Code = "PRECONDITION_FAILED"
+	CodePayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+	CodeBadGateway           Code = "BAD_GATEWAY"
+	CodeUnavailable          Code = "SERVICE_UNAVAILABLE"
+	CodeGatewayTimeout       Code = "GATEWAY_TIMEOUT"
+)
+
+// Request and platform codes
+const (
+	CodeMissingToken        Code = "MISSING_TOKEN"
+	CodeMalformedToken      Code = "MALFORMED_TOKEN"
+	CodeTokenExpired        Code = "TOKEN_EXPIRED"
+	CodeTokenRevoked        Code = "TOKEN_REVOKED"
+	CodeInvalidToken        Code = "INVALID_TOKEN"
+	CodeInvalidRefreshToken Code = "INVALID_REFRESH_TOKEN"
+	CodeMissingRole         Code = "MISSING_ROLE"
+	CodeMissingScope        Code = "MISSING_SCOPE"
+	CodeInvalidAudience     Code = "INVALID_AUDIENCE"
+	CodeInvalidTenant       Code = "INVALID_TENANT"
+	CodeInvalidPatch        Code = "INVALID_PATCH"
+	CodeEditConflict        Code = "EDIT_CONFLICT"
+	CodeBatchTooLarge       Code = "BATCH_TOO_LARGE"
+	CodeInvalidCSV          Code = "INVALID_CSV"
+	CodeSchemaViolation     Code = "SCHEMA_VIOLATION"
+	CodeRouteNotFound       Code = "ROUTE_NOT_FOUND"
+	CodeUpstreamUnavailable Code = "UPSTREAM_UNAVAILABLE"
+	CodeIdentityUnavailable Code = "IDENTITY_PROVIDER_UNAVAILABLE"
+	CodeSessionsUnavailable Code = "SESSIONS_UNAVAILABLE"
+	CodeServerOverloaded    Code = "SERVER_OVERLOADED"
+	CodeServiceInitializing Code = "SERVICE_INITIALIZING"
+)
+
+// Domain codes
+const (
+	CodeStudentNotFound        Code = "STUDENT_NOT_FOUND"
+	CodeSubjectNotFound        Code = "SUBJECT_NOT_FOUND"
+	CodeClassNotFound          Code = "CLASS_NOT_FOUND"
+	CodeCertificateNotFound    Code = "CERTIFICATE_NOT_FOUND"
+	CodeSessionNotFound        Code = "SESSION_NOT_FOUND"
+	CodeGradeRequired          Code = "GRADE_REQUIRED"
+	CodeGradeOutOfRange        Code = "GRADE_OUT_OF_RANGE"
+	CodeGradeSchemeMismatch    Code = "GRADE_SCHEME_MISMATCH"
+	CodeUnknownGradeDescriptor Code = "UNKNOWN_GRADE_DESCRIPTOR"
+	CodeSubjectNotInClass      Code = "SUBJECT_NOT_IN_CLASS"
+	CodeCertificateSuperseded  Code = "CERTIFICATE_SUPERSEDED"
+	CodeCertificateIssued      Code = "CERTIFICATE_ALREADY_ISSUED"
+	CodeRegistryNumberTaken    Code = "REGISTRY_NUMBER_TAKEN"
+	CodeDownloadLinkInvalid    Code = "DOWNLOAD_LINK_INVALID"
+	CodeDownloadLinkExpired    Code = "DOWNLOAD_LINK_EXPIRED"
+)
+
+// codedError attaches a code to an error without changing how it maps to a status
+type codedError struct {
+	err  error
+	code Code
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// WithCode tags err with a specific code, e.g. STUDENT_NOT_FOUND on a wrapped
+// ErrNotFound. The status is still derived from err; errors.Is and errors.As see
+// through the tag.
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{err: err, code: code}
+}
+
+// NewWithCode creates an API error with an explicit status and code
+func NewWithCode(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// codeOf returns the code err was tagged with by WithCode, if any
+func codeOf(err error) (Code, bool) {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code, true
+	}
+	return "", false
+}
+
+// statusCodes is the fallback code for errors created with only a status
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusBadGateway:            CodeBadGateway,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+	http.StatusGatewayTimeout:        CodeGatewayTimeout,
+}
+
+// codeForStatus picks the generic code for a status
+func codeForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/rs/zerolog/log"
+)
+
+// resubscribeDelay is the pause before a dropped key event subscription is retried
+const resubscribeDelay = 5 * time.Second
+
+// Cache is a two-level cache: a small per-instance LRU in front of Redis. Hot keys are
+// served locally, and while Redis is unreachable the cache-aside helpers keep working
+// from the LRU alone. Keys are the caller's, without the Redis client's prefix.
+type Cache struct {
+	remote *redis.Client
+	local  *LRU
+}
+
+// New fronts remote with an LRU of size entries that live for ttl. A non-positive
+// size disables the local level, leaving every lookup to Redis.
+func New(remote *redis.Client, size int, ttl time.Duration) *Cache {
+	return &Cache{remote: remote, local: NewLRU(size, ttl)}
+}
+
+// Len returns the number of entries held locally
+func (c *Cache) Len() int {
+	return c.local.Len()
+}
+
+// Get looks the key up locally, then in Redis, keeping a Redis hit locally. It returns
+// redis.ErrCacheMiss or redis.ErrTombstone like redis.Client.Get.
+func (c *Cache) Get(ctx context.Context, key string, target any) error {
+	if data, ok := c.local.Get(key); ok {
+		return json.Unmarshal(data, target)
+	}
+	if err := c.remote.Get(ctx, key, target); err != nil {
+		return err
+	}
+	c.remember(key, target)
+	return nil
+}
+
+// Set stores the value in Redis and drops the local copy, so the next Get reads it back
+func (c *Cache) Set(ctx context.Context, key string, value any) error {
+	c.local.Delete(key)
+	return c.remote.Set(ctx, key, value)
+}
+
+// Delete removes keys from both levels. The local entries are always removed; a Redis
+// failure is returned so callers can tell other instances may still serve stale data.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	c.local.Delete(keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+// GetOrSet is redis.Client.GetOrSet behind the local level: a local hit skips Redis
+// and fn entirely, and whatever Redis or fn produce is kept locally. When Redis is
+// down the client computes the value with fn, so hot keys are then served from here.
+// Missing records are reported, and tombstoned in Redis, as in redis.Client.GetOrSet.
+func (c *Cache) GetOrSet(ctx context.Context, key string, target any, fn func() (any, error)) error {
+	if data, ok := c.local.Get(key); ok {
+		return json.Unmarshal(data, target)
+	}
+	if err := c.remote.GetOrSet(ctx, key, target, fn); err != nil {
+		return err
+	}
+	c.remember(key, target)
+	return nil
+}
+
+// GetOrSetTyped is GetOrSet for a known type, wrapping redis.GetOrSetTyped
+func GetOrSetTyped[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	var value T
+	if data, ok := c.local.Get(key); ok {
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := redis.GetOrSetTyped(ctx, c.remote, key, ttl, fn)
+	if err != nil {
+		return value, err
+	}
+	c.remember(key, value)
+	return value, nil
+}
+
+// remember keeps a copy of value locally; values that don't marshal stay remote only
+func (c *Cache) remember(key string, value any) {
+	if data, err := json.Marshal(value); err == nil {
+		c.local.Set(key, data)
+	}
+}
+
+// WatchRemoteEvictions drops local entries as soon as Redis expires or evicts the same
+// key, until ctx is done, instead of serving them for the rest of the local TTL. A
+// dropped subscription is retried. Without keyspace notifications on the server
+// (notify-keyspace-events "Exe") it logs a warning and stops, leaving the local TTL
+// alone to bound staleness.
+func (c *Cache) WatchRemoteEvictions(ctx context.Context) {
+	invalidate := func(_, key string) {
+		c.local.Delete(key)
+	}
+
+	go func() {
+		for {
+			err := c.remote.SubscribeKeyEvents(ctx, invalidate, redis.KeyEventExpired, redis.KeyEventEvicted)
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, redis.ErrKeyEventsDisabled) {
+				log.Warn().Err(err).Msg("Local cache will not follow Redis evictions")
+				return
+			}
+			log.Warn().Err(err).Msg("Lost Redis key event subscription, retrying")
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+		}
+	}()
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+type report struct {
+	Class  string
+	Grades []int
+}
+
+func newTestCache(t *testing.T, s *miniredis.Miniredis, opts ...redis.Option) *Cache {
+	t.Helper()
+	client, err := redis.NewClient(s.Addr(), "", 0, time.Minute, opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return New(client, 100, time.Minute)
+}
+
+func TestGetOrSetServesLocalCopiesWhileRedisIsDown(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	c := newTestCache(t, s)
+
+	loads := 0
+	load := func() (report, error) {
+		loads++
+		return report{Class: "VI-2", Grades: []int{5, 4}}, nil
+	}
+	if _, err := GetOrSetTyped(ctx, c, "report:class-7", time.Minute, load); err != nil {
+		t.Fatalf("GetOrSetTyped: %v", err)
+	}
+	// Read through Redis, so the local copy comes from a Redis hit
+	if err := c.remote.Set(ctx, "report:class-8", report{Class: "VII-1"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var cached report
+	if err := c.GetOrSet(ctx, "report:class-8", &cached, func() (any, error) { return nil, errors.New("loaded") }); err != nil {
+		t.Fatalf("GetOrSet: %v", err)
+	}
+
+	s.Close()
+
+	got, err := GetOrSetTyped(ctx, c, "report:class-7", time.Minute, load)
+	if err != nil || loads != 1 || got.Class != "VI-2" || !slices.Equal(got.Grades, []int{5, 4}) {
+		t.Errorf("GetOrSetTyped with Redis down = %+v, %v after %d loads, want the local copy", got, err, loads)
+	}
+	var r report
+	err = c.GetOrSet(ctx, "report:class-8", &r, func() (any, error) {
+		t.Error("loaded a key cached locally")
+		return nil, nil
+	})
+	if err != nil || r.Class != "VII-1" {
+		t.Errorf("GetOrSet with Redis down = %+v, %v, want the local copy", r, err)
+	}
+
+	// A key never seen before is computed, and kept locally, with Redis still down
+	for i := 0; i < 2; i++ {
+		if err := c.GetOrSet(ctx, "report:class-9", &r, func() (any, error) { loads++; return report{Class: "VIII-3"}, nil }); err != nil || r.Class != "VIII-3" {
+			t.Fatalf("GetOrSet of a new key with Redis down = %+v, %v", r, err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("loaded %d times, want the new key computed once", loads)
+	}
+}
+
+func TestWritesDropLocalCopies(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, miniredis.RunT(t))
+
+	load := func() (report, error) { return report{Class: "VI-2"}, nil }
+	if _, err := GetOrSetTyped(ctx, c, "report:class-7", time.Minute, load); err != nil {
+		t.Fatalf("GetOrSetTyped: %v", err)
+	}
+	if err := c.Set(ctx, "report:class-7", report{Class: "VI-3"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := GetOrSetTyped(ctx, c, "report:class-7", time.Minute, load); err != nil || got.Class != "VI-3" {
+		t.Errorf("after Set = %+v, %v, want VI-3", got, err)
+	}
+
+	if err := c.Delete(ctx, "report:class-7"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := c.local.Get("report:class-7"); ok {
+		t.Error("Delete left the local copy")
+	}
+	var r report
+	if err := c.Get(ctx, "report:class-7", &r); !errors.Is(err, redis.ErrCacheMiss) {
+		t.Errorf("Get after Delete = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestGetOrSetDoesNotKeepNotFoundLocally(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	c := newTestCache(t, s, redis.WithNegativeTTL(5*time.Second))
+
+	loads := 0
+	load := func() (any, error) {
+		loads++
+		if loads == 1 {
+			return nil, database.ErrNotFound
+		}
+		return report{Class: "VI-2"}, nil
+	}
+
+	var r report
+	for i := 0; i < 2; i++ {
+		if err := c.GetOrSet(ctx, "report:class-7", &r, load); !errors.Is(err, apierror.ErrNotFound) {
+			t.Fatalf("GetOrSet %d = %v, want ErrNotFound", i, err)
+		}
+	}
+	if loads != 1 || c.Len() != 0 {
+		t.Errorf("%d loads, %d local entries, want the tombstone served from Redis only", loads, c.Len())
+	}
+
+	// The Redis tombstone alone bounds how long the record stays missing
+	s.FastForward(6 * time.Second)
+	if err := c.GetOrSet(ctx, "report:class-7", &r, load); err != nil || r.Class != "VI-2" {
+		t.Errorf("GetOrSet after the tombstone expired = %+v, %v, want VI-2", r, err)
+	}
+}
+
+// withKeyspaceConfig makes the server answer CONFIG GET notify-keyspace-events with
+// flags; miniredis has no CONFIG command of its own
+func withKeyspaceConfig(t *testing.T, s *miniredis.Miniredis, flags string) {
+	t.Helper()
+	err := s.Server().Register("CONFIG", func(c *server.Peer, cmd string, args []string) {
+		c.WriteMapLen(1)
+		c.WriteBulk("notify-keyspace-events")
+		c.WriteBulk(flags)
+	})
+	if err != nil {
+		t.Fatalf("Register CONFIG: %v", err)
+	}
+}
+
+func TestWatchRemoteEvictionsDropsLocalEntries(t *testing.T) {
+	s := miniredis.RunT(t)
+	c := newTestCache(t, s, redis.WithKeyPrefix("gateway:"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for key, name := range map[string]string{"student:1": "Ana", "student:2": "Marko"} {
+		var got string
+		if err := c.GetOrSet(ctx, key, &got, func() (any, error) { return name, nil }); err != nil {
+			t.Fatalf("GetOrSet(%s): %v", key, err)
+		}
+	}
+
+	c.WatchRemoteEvictions(ctx)
+	deadline := time.Now().Add(time.Second)
+	for s.PubSubNumSub("__keyevent@0__:evicted")["__keyevent@0__:evicted"] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("never subscribed to key events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Redis evicts student:1 and, behind our back, a new value is written for it
+	if err := s.Set("gateway:student:1", `"Elena"`); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	s.Publish("__keyevent@0__:evicted", "gateway:student:1")
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if _, ok := c.local.Get("student:1"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("evicted key still cached locally")
+		}
+	}
+
+	var name string
+	if err := c.GetOrSet(ctx, "student:1", &name, func() (any, error) { return "Ana", nil }); err != nil || name != "Elena" {
+		t.Errorf("GetOrSet(student:1) after eviction = %q, %v, want the fresh Redis value", name, err)
+	}
+	if _, ok := c.local.Get("student:2"); !ok {
+		t.Error("an untouched key was dropped from the local cache")
+	}
+}
+
+func TestWatchRemoteEvictionsStopsWhenNotificationsAreDisabled(t *testing.T) {
+	s := miniredis.RunT(t)
+	withKeyspaceConfig(t, s, "")
+	c := newTestCache(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.WatchRemoteEvictions(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	if subs := s.PubSubNumSub("__keyevent@0__:expired")["__keyevent@0__:expired"]; subs != 0 {
+		t.Errorf("subscribed %d times with notifications disabled", subs)
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU(2, 0)
+	lru.Set("a", []byte("1"))
+	lru.Set("b", []byte("2"))
+	lru.Get("a") // a is now more recently used than b
+	lru.Set("c", []byte("3"))
+
+	if _, ok := lru.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := lru.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Len = %d, want 2", lru.Len())
+	}
+}
+
+func TestLRUUpdateDoesNotGrow(t *testing.T) {
+	lru := NewLRU(2, 0)
+	lru.Set("a", []byte("1"))
+	lru.Set("a", []byte("2"))
+
+	if got, _ := lru.Get("a"); string(got) != "2" {
+		t.Errorf("a = %q, want 2", got)
+	}
+	if lru.Len() != 1 {
+		t.Errorf("Len = %d, want 1", lru.Len())
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	now := time.Now()
+	lru := NewLRU(10, time.Minute)
+	lru.now = func() time.Time { return now }
+
+	lru.Set("a", []byte("1"))
+	lru.SetWithTTL("b", []byte("2"), time.Hour)
+	lru.SetWithTTL("c", []byte("3"), 0)
+
+	now = now.Add(time.Minute - time.Second)
+	if _, ok := lru.Get("a"); !ok {
+		t.Error("a expired before its TTL")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := lru.Get("a"); ok {
+		t.Error("a was served after its TTL")
+	}
+	if _, ok := lru.Get("b"); !ok {
+		t.Error("b expired before its own TTL")
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, ok := lru.Get("c"); !ok {
+		t.Error("c expired without a TTL")
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	lru := NewLRU(0, 0)
+	lru.Set("a", []byte("1"))
+	if _, ok := lru.Get("a"); ok {
+		t.Error("an LRU without capacity stored a value")
+	}
+}
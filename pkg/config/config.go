@@ -2,67 +2,155 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
 )
 
 type Config struct {
-	ServiceName string
-	Port        string
-	GinMode     string
+	ServiceName string `yaml:"-"`
+	Port        string `yaml:"port" env:"PORT" desc:"HTTP listen port"`
+	GinMode     string `yaml:"gin_mode" env:"GIN_MODE" desc:"Gin mode (debug, release, test); debug also enables console logging"`
 
-	DatabaseURL          string
-	DatabaseMaxConns     int
-	DatabaseMaxIdleConns int
-	DatabaseConnLifetime time.Duration
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" desc:"Time allowed for in-flight requests and shutdown hooks"`
+	RequestTimeout  time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT" desc:"Default per-request deadline"`
 
-	RedisAddr     string
-	RedisPassword string
-	RedisDB       int
-	RedisTTL      time.Duration
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests" env:"MAX_CONCURRENT_REQUESTS" desc:"Maximum in-flight requests (0 disables the limit)"`
+	MaxQueuedRequests     int `yaml:"max_queued_requests" env:"MAX_QUEUED_REQUESTS" desc:"Requests allowed to wait for a slot before 503"`
+	MaxBatchItems         int `yaml:"max_batch_items" env:"MAX_BATCH_ITEMS" desc:"Maximum entries accepted by a single batch request"`
 
-	KeycloakURL          string
-	KeycloakRealm        string
-	KeycloakClientID     string
-	KeycloakClientSecret string
-	KeycloakJWKSURL      string
+	StudentListCount string `yaml:"student_list_count" env:"STUDENT_LIST_COUNT" desc:"How a paged student list reports its total (exact, estimated, none); none returns only has_more"`
 
-	SvedprintServiceURL      string
-	SvedprintAdminServiceURL string
-	SvedprintPrintServiceURL string
-	GatewayDatabaseURL       string
+	ExportIdleTimeout time.Duration `yaml:"export_idle_timeout" env:"EXPORT_IDLE_TIMEOUT" desc:"Abort a streaming export, and its query, when the client reads nothing for this long (0 disables)"`
 
-	LogLevel string
-}
+	CORSAllowedOrigins string        `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" desc:"Comma separated browser origins allowed to call the API (* for any, empty disables CORS)"`
+	CORSMaxAge         time.Duration `yaml:"cors_max_age" env:"CORS_MAX_AGE" desc:"How long browsers may cache a preflight response (0 leaves it to the browser)"`
 
-func Load(serviceName string) (*Config, error) {
-	cfg := &Config{
-		ServiceName: serviceName,
-		Port:        getEnv("PORT", "8080"),
-		GinMode:     getEnv("GIN_MODE", "debug"),
+	DatabaseURL          string        `yaml:"database_url" env:"DATABASE_URL" desc:"PostgreSQL connection URL" required:"gateway,svedprint,svedprint-admin"`
+	DatabaseMaxConns     int           `yaml:"database_max_conns" env:"DATABASE_MAX_CONNS" desc:"Maximum pool connections"`
+	DatabaseMaxIdleConns int           `yaml:"database_max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" desc:"Minimum idle pool connections"`
+	DatabaseConnLifetime time.Duration `yaml:"database_conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME" desc:"Maximum lifetime of a pool connection"`
+	DatabaseCloseTimeout time.Duration `yaml:"database_close_timeout" env:"DATABASE_CLOSE_TIMEOUT" desc:"Wait for busy connections on shutdown before terminating them"`
+	DatabaseReplicas     int           `yaml:"database_expected_replicas" env:"DATABASE_EXPECTED_REPLICAS" desc:"Service instances sharing the database server, used to check max_connections"`
+	DatabaseStrictLimit  bool          `yaml:"database_strict_conn_limit" env:"DATABASE_STRICT_CONN_LIMIT" desc:"Fail startup instead of warning when pools would exceed max_connections"`
+
+	DatabaseInteractiveTimeout time.Duration `yaml:"database_interactive_statement_timeout" env:"DATABASE_INTERACTIVE_STATEMENT_TIMEOUT" desc:"statement_timeout for interactive queries"`
+	DatabaseReportingTimeout   time.Duration `yaml:"database_reporting_statement_timeout" env:"DATABASE_REPORTING_STATEMENT_TIMEOUT" desc:"statement_timeout for reporting queries"`
+	DatabaseAcquireTimeout     time.Duration `yaml:"database_acquire_timeout" env:"DATABASE_ACQUIRE_TIMEOUT" desc:"Maximum wait for a pool connection in categorized queries"`
+	DatabaseQueryTimeout       time.Duration `yaml:"database_query_timeout" env:"DATABASE_QUERY_TIMEOUT" desc:"Default deadline for each repository query, including the wait for a connection; timeouts answer 503 (0 disables)"`
+
+	DatabaseSeed    bool   `yaml:"database_seed" env:"DATABASE_SEED" desc:"Populate empty reference tables with seed data after migrations"`
+	DatabaseSeedDir string `yaml:"database_seed_dir" env:"DATABASE_SEED_DIR" desc:"Directory of seed files used instead of the embedded ones"`
+
+	RedisAddr              string        `yaml:"redis_addr" env:"REDIS_ADDR" desc:"Redis host:port"`
+	RedisPassword          string        `yaml:"redis_password" env:"REDIS_PASSWORD" desc:"Redis password" secret:"true"`
+	RedisDB                int           `yaml:"redis_db" env:"REDIS_DB" desc:"Redis database number"`
+	RedisKeyPrefix         string        `yaml:"redis_key_prefix" env:"REDIS_KEY_PREFIX" desc:"Prefix namespacing this service's Redis keys, e.g. gateway:"`
+	RedisTTL               time.Duration `yaml:"redis_ttl" env:"REDIS_TTL" desc:"Default cache entry TTL"`
+	RedisOOMCooldown       time.Duration `yaml:"redis_oom_cooldown" env:"REDIS_OOM_COOLDOWN" desc:"How long cache writes pause after Redis reports it is out of memory (0 keeps writing)"`
+	RedisCompressThreshold int           `yaml:"redis_compress_threshold" env:"REDIS_COMPRESS_THRESHOLD" desc:"Cached values larger than this many bytes are gzipped (0 disables compression)"`
+	RedisRetries           int           `yaml:"redis_retries" env:"REDIS_RETRIES" desc:"Retries of Redis operations failing with transient errors, e.g. during failover (0 disables retrying)"`
+	RedisRetryBaseDelay    time.Duration `yaml:"redis_retry_base_delay" env:"REDIS_RETRY_BASE_DELAY" desc:"Wait before the first Redis retry, doubled with jitter for each further one"`
+
+	LocalCacheSize int           `yaml:"local_cache_size" env:"LOCAL_CACHE_SIZE" desc:"Entries kept in the per-instance LRU in front of Redis (0 disables it)"`
+	LocalCacheTTL  time.Duration `yaml:"local_cache_ttl" env:"LOCAL_CACHE_TTL" desc:"Lifetime of an entry in the per-instance LRU"`
+
+	KeycloakURL          string `yaml:"keycloak_url" env:"KEYCLOAK_URL" desc:"Keycloak base URL"`
+	KeycloakRealm        string `yaml:"keycloak_realm" env:"KEYCLOAK_REALM" desc:"Keycloak realm"`
+	KeycloakClientID     string `yaml:"keycloak_client_id" env:"KEYCLOAK_CLIENT_ID" desc:"Keycloak client ID"`
+	KeycloakClientSecret string `yaml:"keycloak_client_secret" env:"KEYCLOAK_CLIENT_SECRET" desc:"Keycloak client secret" secret:"true"`
+	KeycloakJWKSURL      string `yaml:"keycloak_jwks_url" env:"KEYCLOAK_JWKS_URL" desc:"Keycloak JWKS endpoint used to verify tokens" required:"gateway"`
+
+	KeycloakJWKSFetchTimeout  time.Duration `yaml:"keycloak_jwks_fetch_timeout" env:"KEYCLOAK_JWKS_FETCH_TIMEOUT" desc:"Timeout for each JWKS fetch; startup attempts are retried"`
+	KeycloakJWKSRefresh       time.Duration `yaml:"keycloak_jwks_refresh_interval" env:"KEYCLOAK_JWKS_REFRESH_INTERVAL" desc:"Interval at which the gateway refreshes signing keys in the background (0 refreshes only on demand)"`
+	KeycloakJWTAlgorithms     string        `yaml:"keycloak_jwt_algorithms" env:"KEYCLOAK_JWT_ALGORITHMS" desc:"Comma separated token signing algorithms the gateway accepts"`
+	KeycloakAudiences         string        `yaml:"keycloak_audiences" env:"KEYCLOAK_AUDIENCES" desc:"Comma separated token audiences the gateway accepts; empty accepts any client of the realm"`
+	KeycloakTokenLeeway       time.Duration `yaml:"keycloak_token_leeway" env:"KEYCLOAK_TOKEN_LEEWAY" desc:"Clock skew tolerated when checking token exp, nbf and iat"`
+	TokenRevocationFailClosed bool          `yaml:"token_revocation_fail_closed" env:"TOKEN_REVOCATION_FAIL_CLOSED" desc:"Reject tokens when the revoked token and session lists in Redis cannot be checked, instead of accepting them"`
+	SessionTTL                time.Duration `yaml:"session_ttl" env:"SESSION_TTL" desc:"How long the gateway keeps session records and revocations; should cover the longest Keycloak session"`
+
+	RenderRateLimitPerUser   int           `yaml:"render_rate_limit_per_user" env:"RENDER_RATE_LIMIT_PER_USER" desc:"Render requests (e.g. certificates) a user may make per window (0 disables the limit)"`
+	RenderRateLimitPerSchool int           `yaml:"render_rate_limit_per_school" env:"RENDER_RATE_LIMIT_PER_SCHOOL" desc:"Render requests a school (the token's tenant claim) may make per window (0 disables the limit)"`
+	RenderRateLimitWindow    time.Duration `yaml:"render_rate_limit_window" env:"RENDER_RATE_LIMIT_WINDOW" desc:"Sliding window the render rate limits are counted over"`
+
+	RenderWorkers     int `yaml:"render_workers" env:"RENDER_WORKERS" desc:"Documents the print service renders at once across all batches (defaults to GOMAXPROCS)"`
+	RenderMaxInFlight int `yaml:"render_max_in_flight" env:"RENDER_MAX_IN_FLIGHT" desc:"Rendered documents batches may hold in memory before they are written out (0 allows twice the workers)"`
+
+	DownloadURLSecret string        `yaml:"download_url_secret" env:"DOWNLOAD_URL_SECRET" desc:"Secret signing document download links; a random one per instance is used when unset" secret:"true"`
+	DownloadURLTTL    time.Duration `yaml:"download_url_ttl" env:"DOWNLOAD_URL_TTL" desc:"How long a rendered document and its download link stay valid"`
+	DownloadDir       string        `yaml:"download_dir" env:"DOWNLOAD_DIR" desc:"Directory rendered documents are kept in until their links expire (defaults to the system temp directory)"`
+	DownloadMaxBytes  int           `yaml:"download_max_bytes" env:"DOWNLOAD_MAX_BYTES" desc:"Total size of the rendered documents kept for download; renders are refused with 503 while it is reached"`
+
+	StorageBackend string `yaml:"storage_backend" env:"STORAGE_BACKEND" desc:"Where the print service keeps rendered documents: local, served by the service itself, or s3"`
+	S3Endpoint     string `yaml:"s3_endpoint" env:"S3_ENDPOINT" desc:"Base URL of the S3-compatible server for the s3 storage backend, e.g. http://minio:9000"`
+	S3Region       string `yaml:"s3_region" env:"S3_REGION" desc:"Bucket region (empty means us-east-1, which MinIO accepts)"`
+	S3Bucket       string `yaml:"s3_bucket" env:"S3_BUCKET" desc:"Bucket rendered documents are exported to"`
+	S3AccessKey    string `yaml:"s3_access_key" env:"S3_ACCESS_KEY" desc:"Object storage access key"`
+	S3SecretKey    string `yaml:"s3_secret_key" env:"S3_SECRET_KEY" desc:"Object storage secret key" secret:"true"`
+	S3Prefix       string `yaml:"s3_prefix" env:"S3_PREFIX" desc:"Key prefix of exported documents, e.g. certificates/"`
+
+	SvedprintServiceURL      string `yaml:"svedprint_service_url" env:"SVEDPRINT_SERVICE_URL" desc:"Internal URL of the svedprint service"`
+	SvedprintAdminServiceURL string `yaml:"svedprint_admin_service_url" env:"SVEDPRINT_ADMIN_SERVICE_URL" desc:"Internal URL of the admin service"`
+	SvedprintPrintServiceURL string `yaml:"svedprint_print_service_url" env:"SVEDPRINT_PRINT_SERVICE_URL" desc:"Internal URL of the print service"`
+
+	SvedprintServiceURLs      []string `yaml:"svedprint_service_urls" env:"SVEDPRINT_SERVICE_URLS" desc:"Comma separated URLs of svedprint service instances the gateway balances across; overrides SVEDPRINT_SERVICE_URL"`
+	SvedprintAdminServiceURLs []string `yaml:"svedprint_admin_service_urls" env:"SVEDPRINT_ADMIN_SERVICE_URLS" desc:"Comma separated URLs of admin service instances; overrides SVEDPRINT_ADMIN_SERVICE_URL"`
+	SvedprintPrintServiceURLs []string `yaml:"svedprint_print_service_urls" env:"SVEDPRINT_PRINT_SERVICE_URLS" desc:"Comma separated URLs of print service instances; overrides SVEDPRINT_PRINT_SERVICE_URL"`
+	GatewayDatabaseURL        string   `yaml:"gateway_database_url"`
+	GatewayRoutesFile         string   `yaml:"gateway_routes_file" env:"GATEWAY_ROUTES_FILE" desc:"YAML route table for the gateway proxy"`
+	GatewayCompressMinSize    int      `yaml:"gateway_compress_min_size" env:"GATEWAY_COMPRESS_MIN_SIZE" desc:"Smallest response body in bytes the gateway gzips (0 disables compression)"`
+
+	GatewayConnMaxLifetime    time.Duration `yaml:"gateway_conn_max_lifetime" env:"GATEWAY_CONN_MAX_LIFETIME" desc:"How long a proxy connection to a downstream is reused before it is recycled (0 keeps it)"`
+	GatewayDNSRefreshInterval time.Duration `yaml:"gateway_dns_refresh_interval" env:"GATEWAY_DNS_REFRESH_INTERVAL" desc:"Interval at which idle proxy connections are dropped so downstream hosts are resolved again (0 disables)"`
+
+	WebhookEndpoints      string `yaml:"webhook_endpoints" env:"WEBHOOK_ENDPOINTS" desc:"Comma separated URLs notified of admin changes"`
+	WebhookSecret         string `yaml:"webhook_secret" env:"WEBHOOK_SECRET" desc:"Shared secret for HMAC-SHA256 webhook signatures" secret:"true"`
+	WebhookSigningKeyFile string `yaml:"webhook_signing_key_file" env:"WEBHOOK_SIGNING_KEY_FILE" desc:"PEM RSA private key; signs webhooks with RSA-SHA256 instead of HMAC"`
+
+	LogLevel        string `yaml:"log_level" env:"LOG_LEVEL" desc:"Log level (debug, info, warn, error, fatal)"`
+	AccessLogFormat string `yaml:"access_log_format" env:"ACCESS_LOG_FORMAT" desc:"Access log format (json, combined, console); defaults to console in debug mode, JSON otherwise"`
+	RequestIDFormat string `yaml:"request_id_format" env:"REQUEST_ID_FORMAT" desc:"Format of generated request IDs (uuid, ulid, short)"`
+	PrettyJSON      bool   `yaml:"pretty_json" env:"PRETTY_JSON" desc:"Indent JSON responses for debugging (buffers every JSON body)"`
 
-		DatabaseURL:          getEnv("DATABASE_URL", ""),
-		DatabaseMaxConns:     getEnvInt("DATABASE_MAX_CONNS", 25),
-		DatabaseMaxIdleConns: getEnvInt("DATABASE_MAX_IDLE_CONNS", 10),
-		DatabaseConnLifetime: getEnvDuration("DATABASE_CONN_MAX_LIFETIME", 5*time.Minute),
+	// Nested sections of the config file, see sections.go
+	CORS       CORSSection       `yaml:"cors"`
+	RateLimits RateLimitsSection `yaml:"rate_limits"`
+	// GatewayRoutes is an inline gateway route table, used when GATEWAY_ROUTES_FILE is unset
+	GatewayRoutes RawYAML `yaml:"routes"`
+}
 
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvInt("REDIS_DB", 0),
-		RedisTTL:      getEnvDuration("REDIS_TTL", 10*time.Minute),
+// Load builds the service configuration from defaults, an optional YAML file
+// referenced by CONFIG_FILE, and environment variables (which take precedence)
+func Load(serviceName string) (*Config, error) {
+	return LoadFromFile(serviceName, os.Getenv("CONFIG_FILE"))
+}
 
-		KeycloakURL:          getEnv("KEYCLOAK_URL", "http://localhost:8080"),
-		KeycloakRealm:        getEnv("KEYCLOAK_REALM", "svedprint"),
-		KeycloakClientID:     getEnv("KEYCLOAK_CLIENT_ID", "svedprint-backend"),
-		KeycloakClientSecret: getEnv("KEYCLOAK_CLIENT_SECRET", ""),
-		KeycloakJWKSURL:      getEnv("KEYCLOAK_JWKS_URL", ""),
+// LoadFromFile builds the service configuration from defaults, the YAML or JSON file
+// at path, and environment variables, which override values from the file. An empty
+// path skips the file. Keys the file sets that no setting uses are logged as warnings.
+func LoadFromFile(serviceName, path string) (*Config, error) {
+	cfg := defaults(serviceName)
 
-		SvedprintServiceURL:      getEnv("SVEDPRINT_SERVICE_URL", "http://svedprint:8001"),
-		SvedprintAdminServiceURL: getEnv("SVEDPRINT_ADMIN_SERVICE_URL", "http://svedprint-admin:8002"),
-		SvedprintPrintServiceURL: getEnv("SVEDPRINT_PRINT_SERVICE_URL", "http://svedprint-print:8003"),
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
 
-		LogLevel: getEnv("LOG_LEVEL", "info"),
+	cfg.applyEnv()
+	if err := cfg.applySecretFiles(); err != nil {
+		return nil, err
 	}
 
 	// Validate required fields based on service
@@ -73,26 +161,324 @@ func Load(serviceName string) (*Config, error) {
 	return cfg, nil
 }
 
+func defaults(serviceName string) *Config {
+	return &Config{
+		ServiceName: serviceName,
+		Port:        "8080",
+		GinMode:     "debug",
+
+		ShutdownTimeout: 15 * time.Second,
+		RequestTimeout:  30 * time.Second,
+
+		MaxQueuedRequests: 100,
+		MaxBatchItems:     500,
+		StudentListCount:  "none",
+		ExportIdleTimeout: 15 * time.Second,
+
+		CORSMaxAge: 10 * time.Minute,
+
+		DatabaseMaxConns:     25,
+		DatabaseMaxIdleConns: 10,
+		DatabaseConnLifetime: 5 * time.Minute,
+		DatabaseCloseTimeout: 10 * time.Second,
+		DatabaseReplicas:     1,
+
+		DatabaseInteractiveTimeout: 5 * time.Second,
+		DatabaseReportingTimeout:   2 * time.Minute,
+		DatabaseAcquireTimeout:     5 * time.Second,
+		DatabaseQueryTimeout:       10 * time.Second,
+
+		DatabaseSeed: true,
+
+		RedisAddr: "localhost:6379",
+		RedisDB:   0,
+		RedisTTL:  10 * time.Minute,
+
+		RedisRetryBaseDelay: 50 * time.Millisecond,
+
+		LocalCacheSize: 1000,
+		LocalCacheTTL:  time.Minute,
+
+		KeycloakURL:      "http://localhost:8080",
+		KeycloakRealm:    "svedprint",
+		KeycloakClientID: "svedprint-backend",
+
+		KeycloakJWKSFetchTimeout: 3 * time.Second,
+		KeycloakJWKSRefresh:      30 * time.Minute,
+		KeycloakJWTAlgorithms:    "RS256",
+		SessionTTL:               10 * time.Hour,
+
+		RenderRateLimitPerUser:   10,
+		RenderRateLimitPerSchool: 100,
+		RenderRateLimitWindow:    time.Minute,
+
+		RenderWorkers: runtime.GOMAXPROCS(0),
+
+		DownloadURLTTL:   15 * time.Minute,
+		DownloadMaxBytes: 1 << 30,
+		StorageBackend:   "local",
+
+		SvedprintServiceURL:      "http://svedprint:8001",
+		SvedprintAdminServiceURL: "http://svedprint-admin:8002",
+		SvedprintPrintServiceURL: "http://svedprint-print:8003",
+		GatewayCompressMinSize:   1024,
+
+		GatewayConnMaxLifetime:    5 * time.Minute,
+		GatewayDNSRefreshInterval: 30 * time.Second,
+
+		LogLevel:        "info",
+		RequestIDFormat: requestid.FormatUUID,
+	}
+}
+
+// loadFile overlays the values present in the YAML file onto the config. JSON files
+// parse too, being valid YAML.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	c.applySections()
+
+	// A misspelled key would otherwise be ignored silently
+	var keys map[string]any
+	if err := yaml.Unmarshal(data, &keys); err == nil {
+		for _, key := range unknownKeys(keys) {
+			log.Warn().Str("file", path).Str("key", key).Msg("Ignoring unknown config key")
+		}
+	}
+
+	return nil
+}
+
+// unknownKeys returns the keys, sorted, that match no field's yaml tag
+func unknownKeys(keys map[string]any) []string {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			known[name] = true
+		}
+	}
+
+	var unknown []string
+	for key := range keys {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// applyEnv overrides config values with any environment variables that are set
+func (c *Config) applyEnv() {
+	c.Port = getEnv("PORT", c.Port)
+	c.GinMode = getEnv("GIN_MODE", c.GinMode)
+
+	c.ShutdownTimeout = getEnvDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	c.RequestTimeout = getEnvDuration("REQUEST_TIMEOUT", c.RequestTimeout)
+
+	c.MaxConcurrentRequests = getEnvInt("MAX_CONCURRENT_REQUESTS", c.MaxConcurrentRequests)
+	c.MaxQueuedRequests = getEnvInt("MAX_QUEUED_REQUESTS", c.MaxQueuedRequests)
+	c.MaxBatchItems = getEnvInt("MAX_BATCH_ITEMS", c.MaxBatchItems)
+	c.StudentListCount = getEnv("STUDENT_LIST_COUNT", c.StudentListCount)
+	c.ExportIdleTimeout = getEnvDuration("EXPORT_IDLE_TIMEOUT", c.ExportIdleTimeout)
+
+	c.CORSAllowedOrigins = getEnv("CORS_ALLOWED_ORIGINS", c.CORSAllowedOrigins)
+	c.CORSMaxAge = getEnvDuration("CORS_MAX_AGE", c.CORSMaxAge)
+
+	c.DatabaseURL = getEnv("DATABASE_URL", c.DatabaseURL)
+	c.DatabaseMaxConns = getEnvInt("DATABASE_MAX_CONNS", c.DatabaseMaxConns)
+	c.DatabaseMaxIdleConns = getEnvInt("DATABASE_MAX_IDLE_CONNS", c.DatabaseMaxIdleConns)
+	c.DatabaseConnLifetime = getEnvDuration("DATABASE_CONN_MAX_LIFETIME", c.DatabaseConnLifetime)
+	c.DatabaseCloseTimeout = getEnvDuration("DATABASE_CLOSE_TIMEOUT", c.DatabaseCloseTimeout)
+	c.DatabaseReplicas = getEnvInt("DATABASE_EXPECTED_REPLICAS", c.DatabaseReplicas)
+	c.DatabaseStrictLimit = getEnvBool("DATABASE_STRICT_CONN_LIMIT", c.DatabaseStrictLimit)
+	c.DatabaseInteractiveTimeout = getEnvDuration("DATABASE_INTERACTIVE_STATEMENT_TIMEOUT", c.DatabaseInteractiveTimeout)
+	c.DatabaseReportingTimeout = getEnvDuration("DATABASE_REPORTING_STATEMENT_TIMEOUT", c.DatabaseReportingTimeout)
+	c.DatabaseAcquireTimeout = getEnvDuration("DATABASE_ACQUIRE_TIMEOUT", c.DatabaseAcquireTimeout)
+	c.DatabaseQueryTimeout = getEnvDuration("DATABASE_QUERY_TIMEOUT", c.DatabaseQueryTimeout)
+	c.DatabaseSeed = getEnvBool("DATABASE_SEED", c.DatabaseSeed)
+	c.DatabaseSeedDir = getEnv("DATABASE_SEED_DIR", c.DatabaseSeedDir)
+
+	c.RedisAddr = getEnv("REDIS_ADDR", c.RedisAddr)
+	c.RedisPassword = getEnv("REDIS_PASSWORD", c.RedisPassword)
+	c.RedisDB = getEnvInt("REDIS_DB", c.RedisDB)
+	c.RedisKeyPrefix = getEnv("REDIS_KEY_PREFIX", c.RedisKeyPrefix)
+	c.RedisTTL = getEnvDuration("REDIS_TTL", c.RedisTTL)
+	c.RedisOOMCooldown = getEnvDuration("REDIS_OOM_COOLDOWN", c.RedisOOMCooldown)
+	c.RedisCompressThreshold = getEnvInt("REDIS_COMPRESS_THRESHOLD", c.RedisCompressThreshold)
+	c.RedisRetries = getEnvInt("REDIS_RETRIES", c.RedisRetries)
+	c.RedisRetryBaseDelay = getEnvDuration("REDIS_RETRY_BASE_DELAY", c.RedisRetryBaseDelay)
+	c.LocalCacheSize = getEnvInt("LOCAL_CACHE_SIZE", c.LocalCacheSize)
+	c.LocalCacheTTL = getEnvDuration("LOCAL_CACHE_TTL", c.LocalCacheTTL)
+
+	c.KeycloakURL = getEnv("KEYCLOAK_URL", c.KeycloakURL)
+	c.KeycloakRealm = getEnv("KEYCLOAK_REALM", c.KeycloakRealm)
+	c.KeycloakClientID = getEnv("KEYCLOAK_CLIENT_ID", c.KeycloakClientID)
+	c.KeycloakClientSecret = getEnv("KEYCLOAK_CLIENT_SECRET", c.KeycloakClientSecret)
+	c.KeycloakJWKSURL = getEnv("KEYCLOAK_JWKS_URL", c.KeycloakJWKSURL)
+	c.KeycloakJWKSFetchTimeout = getEnvDuration("KEYCLOAK_JWKS_FETCH_TIMEOUT", c.KeycloakJWKSFetchTimeout)
+	c.KeycloakJWKSRefresh = getEnvDuration("KEYCLOAK_JWKS_REFRESH_INTERVAL", c.KeycloakJWKSRefresh)
+	c.KeycloakJWTAlgorithms = getEnv("KEYCLOAK_JWT_ALGORITHMS", c.KeycloakJWTAlgorithms)
+	c.KeycloakAudiences = getEnv("KEYCLOAK_AUDIENCES", c.KeycloakAudiences)
+	c.KeycloakTokenLeeway = getEnvDuration("KEYCLOAK_TOKEN_LEEWAY", c.KeycloakTokenLeeway)
+	c.TokenRevocationFailClosed = getEnvBool("TOKEN_REVOCATION_FAIL_CLOSED", c.TokenRevocationFailClosed)
+	c.SessionTTL = getEnvDuration("SESSION_TTL", c.SessionTTL)
+
+	c.RenderRateLimitPerUser = getEnvInt("RENDER_RATE_LIMIT_PER_USER", c.RenderRateLimitPerUser)
+	c.RenderRateLimitPerSchool = getEnvInt("RENDER_RATE_LIMIT_PER_SCHOOL", c.RenderRateLimitPerSchool)
+	c.RenderRateLimitWindow = getEnvDuration("RENDER_RATE_LIMIT_WINDOW", c.RenderRateLimitWindow)
+	c.RenderWorkers = getEnvInt("RENDER_WORKERS", c.RenderWorkers)
+	c.RenderMaxInFlight = getEnvInt("RENDER_MAX_IN_FLIGHT", c.RenderMaxInFlight)
+	c.DownloadURLSecret = getEnv("DOWNLOAD_URL_SECRET", c.DownloadURLSecret)
+	c.DownloadURLTTL = getEnvDuration("DOWNLOAD_URL_TTL", c.DownloadURLTTL)
+	c.DownloadDir = getEnv("DOWNLOAD_DIR", c.DownloadDir)
+	c.DownloadMaxBytes = getEnvInt("DOWNLOAD_MAX_BYTES", c.DownloadMaxBytes)
+	c.StorageBackend = getEnv("STORAGE_BACKEND", c.StorageBackend)
+	c.S3Endpoint = getEnv("S3_ENDPOINT", c.S3Endpoint)
+	c.S3Region = getEnv("S3_REGION", c.S3Region)
+	c.S3Bucket = getEnv("S3_BUCKET", c.S3Bucket)
+	c.S3AccessKey = getEnv("S3_ACCESS_KEY", c.S3AccessKey)
+	c.S3SecretKey = getEnv("S3_SECRET_KEY", c.S3SecretKey)
+	c.S3Prefix = getEnv("S3_PREFIX", c.S3Prefix)
+
+	c.SvedprintServiceURL = getEnv("SVEDPRINT_SERVICE_URL", c.SvedprintServiceURL)
+	c.SvedprintAdminServiceURL = getEnv("SVEDPRINT_ADMIN_SERVICE_URL", c.SvedprintAdminServiceURL)
+	c.SvedprintPrintServiceURL = getEnv("SVEDPRINT_PRINT_SERVICE_URL", c.SvedprintPrintServiceURL)
+	c.SvedprintServiceURLs = getEnvList("SVEDPRINT_SERVICE_URLS", c.SvedprintServiceURLs)
+	c.SvedprintAdminServiceURLs = getEnvList("SVEDPRINT_ADMIN_SERVICE_URLS", c.SvedprintAdminServiceURLs)
+	c.SvedprintPrintServiceURLs = getEnvList("SVEDPRINT_PRINT_SERVICE_URLS", c.SvedprintPrintServiceURLs)
+	c.GatewayRoutesFile = getEnv("GATEWAY_ROUTES_FILE", c.GatewayRoutesFile)
+	c.GatewayCompressMinSize = getEnvInt("GATEWAY_COMPRESS_MIN_SIZE", c.GatewayCompressMinSize)
+	c.GatewayConnMaxLifetime = getEnvDuration("GATEWAY_CONN_MAX_LIFETIME", c.GatewayConnMaxLifetime)
+	c.GatewayDNSRefreshInterval = getEnvDuration("GATEWAY_DNS_REFRESH_INTERVAL", c.GatewayDNSRefreshInterval)
+
+	c.WebhookEndpoints = getEnv("WEBHOOK_ENDPOINTS", c.WebhookEndpoints)
+	c.WebhookSecret = getEnv("WEBHOOK_SECRET", c.WebhookSecret)
+	c.WebhookSigningKeyFile = getEnv("WEBHOOK_SIGNING_KEY_FILE", c.WebhookSigningKeyFile)
+
+	c.LogLevel = getEnv("LOG_LEVEL", c.LogLevel)
+	c.AccessLogFormat = getEnv("ACCESS_LOG_FORMAT", c.AccessLogFormat)
+	c.RequestIDFormat = getEnv("REQUEST_ID_FORMAT", c.RequestIDFormat)
+	c.PrettyJSON = getEnvBool("PRETTY_JSON", c.PrettyJSON)
+}
+
 func (c *Config) validate() error {
 	if c.ServiceName == "" {
 		return fmt.Errorf("service name is required")
 	}
 
+	switch c.AccessLogFormat {
+	case "", "json", "combined", "console":
+	default:
+		return fmt.Errorf("unknown ACCESS_LOG_FORMAT %q (expected json, combined or console)", c.AccessLogFormat)
+	}
+	if _, err := requestid.New(c.RequestIDFormat); err != nil {
+		return fmt.Errorf("invalid REQUEST_ID_FORMAT: %w", err)
+	}
+	switch c.StudentListCount {
+	case "exact", "estimated", "none":
+	default:
+		return fmt.Errorf("unknown STUDENT_LIST_COUNT %q (expected exact, estimated or none)", c.StudentListCount)
+	}
+
+	// middleware.Timeout(0) would expire every request immediately
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("REQUEST_TIMEOUT must be positive, got %s", c.RequestTimeout)
+	}
+	if c.DatabaseQueryTimeout < 0 {
+		return fmt.Errorf("DATABASE_QUERY_TIMEOUT must not be negative, got %s", c.DatabaseQueryTimeout)
+	}
+	if c.ExportIdleTimeout < 0 {
+		return fmt.Errorf("EXPORT_IDLE_TIMEOUT must not be negative, got %s", c.ExportIdleTimeout)
+	}
+
+	if c.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative, got %s", c.CORSMaxAge)
+	}
+	if c.RedisOOMCooldown < 0 {
+		return fmt.Errorf("REDIS_OOM_COOLDOWN must not be negative, got %s", c.RedisOOMCooldown)
+	}
+	if c.RedisCompressThreshold < 0 {
+		return fmt.Errorf("REDIS_COMPRESS_THRESHOLD must not be negative, got %d", c.RedisCompressThreshold)
+	}
+	if c.RedisRetries < 0 {
+		return fmt.Errorf("REDIS_RETRIES must not be negative, got %d", c.RedisRetries)
+	}
+	if c.RedisRetryBaseDelay < 0 {
+		return fmt.Errorf("REDIS_RETRY_BASE_DELAY must not be negative, got %s", c.RedisRetryBaseDelay)
+	}
+	if c.KeycloakTokenLeeway < 0 {
+		return fmt.Errorf("KEYCLOAK_TOKEN_LEEWAY must not be negative, got %s", c.KeycloakTokenLeeway)
+	}
+
+	if err := c.validateRequired(); err != nil {
+		return err
+	}
+	if c.DatabaseURL != "" {
+		if err := validateDatabaseURL("DATABASE_URL", c.DatabaseURL); err != nil {
+			return err
+		}
+	}
+
 	// Service-specific validation
 	switch c.ServiceName {
 	case "gateway":
-		if c.DatabaseURL == "" {
-			return fmt.Errorf("DATABASE_URL is required for gateway service")
+		if (c.RenderRateLimitPerUser > 0 || c.RenderRateLimitPerSchool > 0) && c.RenderRateLimitWindow <= 0 {
+			return fmt.Errorf("RENDER_RATE_LIMIT_WINDOW must be positive when a render rate limit is set, got %s", c.RenderRateLimitWindow)
 		}
-		if c.KeycloakJWKSURL == "" {
-			return fmt.Errorf("KEYCLOAK_JWKS_URL is required for gateway service")
+		if err := validateURL("SVEDPRINT_SERVICE_URL", c.SvedprintServiceURL); err != nil {
+			return err
 		}
-	case "svedprint", "svedprint-admin":
-		if c.DatabaseURL == "" {
-			return fmt.Errorf("DATABASE_URL is required for %s service", c.ServiceName)
+		if err := validateURL("SVEDPRINT_ADMIN_SERVICE_URL", c.SvedprintAdminServiceURL); err != nil {
+			return err
+		}
+		if err := validateURL("SVEDPRINT_PRINT_SERVICE_URL", c.SvedprintPrintServiceURL); err != nil {
+			return err
+		}
+		if err := validateURLs("SVEDPRINT_SERVICE_URLS", c.SvedprintServiceURLs); err != nil {
+			return err
+		}
+		if err := validateURLs("SVEDPRINT_ADMIN_SERVICE_URLS", c.SvedprintAdminServiceURLs); err != nil {
+			return err
+		}
+		if err := validateURLs("SVEDPRINT_PRINT_SERVICE_URLS", c.SvedprintPrintServiceURLs); err != nil {
+			return err
 		}
+	case "svedprint", "svedprint-admin":
 	case "svedprint-print":
 		// Print service is stateless, no database required
+		if c.RenderWorkers <= 0 {
+			return fmt.Errorf("RENDER_WORKERS must be positive, got %d", c.RenderWorkers)
+		}
+		if c.RenderMaxInFlight < 0 {
+			return fmt.Errorf("RENDER_MAX_IN_FLIGHT must not be negative, got %d", c.RenderMaxInFlight)
+		}
+		if c.DownloadURLTTL <= 0 {
+			return fmt.Errorf("DOWNLOAD_URL_TTL must be positive, got %s", c.DownloadURLTTL)
+		}
+		if c.DownloadMaxBytes <= 0 {
+			return fmt.Errorf("DOWNLOAD_MAX_BYTES must be positive, got %d", c.DownloadMaxBytes)
+		}
+		switch c.StorageBackend {
+		case "local":
+		case "s3":
+			if err := validateURL("S3_ENDPOINT", c.S3Endpoint); err != nil {
+				return err
+			}
+			if c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+				return fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required for the s3 storage backend")
+			}
+		default:
+			return fmt.Errorf("unknown STORAGE_BACKEND %q (expected local or s3)", c.StorageBackend)
+		}
 	default:
 		return fmt.Errorf("unknown service name: %s", c.ServiceName)
 	}
@@ -100,6 +486,64 @@ func (c *Config) validate() error {
 	return nil
 }
 
+// validateRequired checks that every field whose required tag names this service is set
+func (c *Config) validateRequired() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if requiredFor(t.Field(i), c.ServiceName) && v.Field(i).IsZero() {
+			return fmt.Errorf("%s is required for %s service", t.Field(i).Tag.Get("env"), c.ServiceName)
+		}
+	}
+	return nil
+}
+
+// requiredFor reports whether field's required tag, a comma separated list of
+// service names, includes serviceName
+func requiredFor(field reflect.StructField, serviceName string) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("required"), ","), serviceName)
+}
+
+// ServiceInstances returns the instance URLs of a downstream: the list when one is
+// configured, otherwise the single URL
+func ServiceInstances(urls []string, single string) []string {
+	if len(urls) > 0 {
+		return urls
+	}
+	return []string{single}
+}
+
+// validateURLs checks that every instance URL is an absolute http(s) URL
+func validateURLs(name string, urls []string) error {
+	for _, raw := range urls {
+		if err := validateURL(name+" entry", raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateURL checks that raw is an absolute http(s) URL, e.g. not missing its scheme
+func validateURL(name, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s %q (expected an http or https URL)", name, raw)
+	}
+	return nil
+}
+
+// validateDatabaseURL checks that dsn is a connection string pgx can parse, as a
+// postgres:// URL or keyword/value pairs. pgx masks the password in its errors.
+func validateDatabaseURL(name, dsn string) error {
+	if _, err := pgconn.ParseConfig(dsn); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -107,6 +551,41 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// applySecretFiles reads sensitive values from files mounted by Docker or Kubernetes
+// secrets, named by <KEY>_FILE. A value set directly in <KEY> still wins.
+func (c *Config) applySecretFiles() error {
+	secrets := map[string]*string{
+		"DATABASE_URL":           &c.DatabaseURL,
+		"REDIS_PASSWORD":         &c.RedisPassword,
+		"KEYCLOAK_CLIENT_SECRET": &c.KeycloakClientSecret,
+		"WEBHOOK_SECRET":         &c.WebhookSecret,
+	}
+	for key, field := range secrets {
+		value, ok, err := getEnvFile(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*field = value
+		}
+	}
+	return nil
+}
+
+// getEnvFile returns the trimmed contents of the file named by key_FILE, unless key
+// itself is set
+func getEnvFile(key string) (string, bool, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" || os.Getenv(key) != "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
@@ -116,6 +595,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -124,3 +612,18 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma separated variable, dropping blank entries
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,495 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// gatewayEnv is the minimal environment a gateway config loads with
+var gatewayEnv = map[string]string{
+	"DATABASE_URL":      "postgres://app:secret@db:5432/svedprint",
+	"KEYCLOAK_JWKS_URL": "http://keycloak:8080/realms/svedprint/protocol/openid-connect/certs",
+}
+
+func setEnv(t *testing.T, env ...map[string]string) {
+	t.Helper()
+	for _, vars := range env {
+		for k, v := range vars {
+			t.Setenv(k, v)
+		}
+	}
+}
+
+func TestLoadValidatesConnectionURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "valid gateway", service: "gateway"},
+		{name: "keyword DSN", service: "svedprint", env: map[string]string{"DATABASE_URL": "host=db user=app dbname=svedprint"}},
+		{name: "malformed DSN", service: "svedprint", env: map[string]string{"DATABASE_URL": "postgres://app:secret@db:port/svedprint"}, wantErr: "invalid DATABASE_URL"},
+		{name: "malformed keyword DSN", service: "svedprint-admin", env: map[string]string{"DATABASE_URL": "host=db port=notaport"}, wantErr: "invalid DATABASE_URL"},
+		{name: "scheme-less service URL", service: "gateway", env: map[string]string{"SVEDPRINT_SERVICE_URL": "svedprint:8001"}, wantErr: "invalid SVEDPRINT_SERVICE_URL"},
+		{name: "non-http service URL", service: "gateway", env: map[string]string{"SVEDPRINT_PRINT_SERVICE_URL": "ftp://print:8003"}, wantErr: "invalid SVEDPRINT_PRINT_SERVICE_URL"},
+		{name: "scheme-less instance URL", service: "gateway", env: map[string]string{"SVEDPRINT_ADMIN_SERVICE_URLS": "http://admin-1:8002,admin-2:8002"}, wantErr: "invalid SVEDPRINT_ADMIN_SERVICE_URLS entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, gatewayEnv, tt.env)
+
+			_, err := LoadFromFile(tt.service, "")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("LoadFromFile: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("LoadFromFile error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "secret") {
+				t.Errorf("error leaks the database password: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileSampleWithEnvOverrides(t *testing.T) {
+	setEnv(t, map[string]string{
+		"CORS_MAX_AGE":               "1h",
+		"RENDER_RATE_LIMIT_PER_USER": "3",
+		"REDIS_TTL":                  "1m",
+	})
+
+	cfg, err := LoadFromFile("gateway", "../../config/config.example.yaml")
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		// From the file's flat keys
+		{"request_timeout", cfg.RequestTimeout, 30 * time.Second},
+		{"svedprint_print_service_url", cfg.SvedprintPrintServiceURL, "http://svedprint-print:8003"},
+		// From the file's sections
+		{"cors.allowed_origins", cfg.CORSAllowedOrigins, "https://svedprint.mk,https://admin.svedprint.mk"},
+		{"rate_limits.render.per_school", cfg.RenderRateLimitPerSchool, 100},
+		{"rate_limits.render.window", cfg.RenderRateLimitWindow, time.Minute},
+		// Environment variables win over the file
+		{"REDIS_TTL", cfg.RedisTTL, time.Minute},
+		{"CORS_MAX_AGE", cfg.CORSMaxAge, time.Hour},
+		{"RENDER_RATE_LIMIT_PER_USER", cfg.RenderRateLimitPerUser, 3},
+		// Untouched settings keep their defaults
+		{"shutdown_timeout", cfg.ShutdownTimeout, defaults("gateway").ShutdownTimeout},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if !strings.Contains(string(cfg.GatewayRoutes), "svedprint-print") {
+		t.Errorf("routes section not kept: %q", cfg.GatewayRoutes)
+	}
+}
+
+// writeConfig writes a config file named name into a temp directory
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFileEnvOverridesFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{name: "yaml", file: "config.yaml", data: "database_url: postgres://db/svedprint\nredis_ttl: 10m\nlog_level: debug\n"},
+		{name: "json", file: "config.json", data: `{"database_url": "postgres://db/svedprint", "redis_ttl": "10m", "log_level": "debug"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{"REDIS_TTL": "1m"})
+
+			cfg, err := LoadFromFile("svedprint", writeConfig(t, tt.file, tt.data))
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.RedisTTL != time.Minute {
+				t.Errorf("RedisTTL = %s, want the environment's 1m", cfg.RedisTTL)
+			}
+			if cfg.LogLevel != "debug" || cfg.DatabaseURL != "postgres://db/svedprint" {
+				t.Errorf("LogLevel = %q, DatabaseURL = %q, want the file's values", cfg.LogLevel, cfg.DatabaseURL)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileValidatesFileValues(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "database_url: postgres://db/svedprint\nrequest_timeout: 0s\n")
+	if _, err := LoadFromFile("svedprint", path); err == nil || !strings.Contains(err.Error(), "REQUEST_TIMEOUT") {
+		t.Errorf("LoadFromFile error = %v, want a REQUEST_TIMEOUT error", err)
+	}
+
+	if _, err := LoadFromFile("svedprint", filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFromFile succeeded without the file")
+	}
+}
+
+func TestLoadFromFileWarnsOnUnknownKeys(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	path := writeConfig(t, "config.yaml", "database_url: postgres://db/svedprint\nredis_tll: 5m\ncors:\n  max_age: 20m\nmetrics: true\n")
+	cfg, err := LoadFromFile("svedprint", path)
+	if err != nil {
+		t.Fatalf("LoadFromFile with unknown keys: %v", err)
+	}
+	if cfg.RedisTTL != defaults("svedprint").RedisTTL {
+		t.Errorf("RedisTTL = %s, want the default", cfg.RedisTTL)
+	}
+
+	var warned []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, "Ignoring unknown config key") {
+			warned = append(warned, line)
+		}
+	}
+	if len(warned) != 2 || !strings.Contains(warned[0], `"key":"metrics"`) || !strings.Contains(warned[1], `"key":"redis_tll"`) {
+		t.Errorf("warnings = %q, want metrics and redis_tll", warned)
+	}
+}
+
+func TestSectionOverridesFlatKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "database_url: postgres://db/svedprint\ncors_max_age: 5m\ncors:\n  max_age: 20m\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile("svedprint", path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if cfg.CORSMaxAge != 20*time.Minute {
+		t.Errorf("CORSMaxAge = %v, want the section's 20m", cfg.CORSMaxAge)
+	}
+}
+
+func TestLoadRejectsNonPositiveRequestTimeout(t *testing.T) {
+	for _, value := range []string{"0s", "-1s"} {
+		t.Run(value, func(t *testing.T) {
+			setEnv(t, gatewayEnv, map[string]string{"REQUEST_TIMEOUT": value})
+			_, err := LoadFromFile("svedprint", "")
+			if err == nil || !strings.Contains(err.Error(), "REQUEST_TIMEOUT") {
+				t.Errorf("LoadFromFile error = %v, want a REQUEST_TIMEOUT error", err)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesCORSMaxAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "30m", want: 30 * time.Minute},
+		{value: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			setEnv(t, gatewayEnv, map[string]string{"CORS_MAX_AGE": tt.value})
+
+			cfg, err := LoadFromFile("gateway", "")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "CORS_MAX_AGE") {
+					t.Fatalf("LoadFromFile error = %v, want a CORS_MAX_AGE error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.CORSMaxAge != tt.want {
+				t.Errorf("CORSMaxAge = %v, want %v", cfg.CORSMaxAge, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRenderPoolLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantWorkers  int
+		wantInFlight int
+		wantErr      string
+	}{
+		{name: "defaults", wantWorkers: runtime.GOMAXPROCS(0)},
+		{name: "configured", env: map[string]string{"RENDER_WORKERS": "3", "RENDER_MAX_IN_FLIGHT": "8"}, wantWorkers: 3, wantInFlight: 8},
+		{name: "no workers", env: map[string]string{"RENDER_WORKERS": "0"}, wantErr: "RENDER_WORKERS"},
+		{name: "negative in flight", env: map[string]string{"RENDER_MAX_IN_FLIGHT": "-1"}, wantErr: "RENDER_MAX_IN_FLIGHT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+
+			cfg, err := LoadFromFile("svedprint-print", "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFromFile error = %v, want a %s error", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.RenderWorkers != tt.wantWorkers || cfg.RenderMaxInFlight != tt.wantInFlight {
+				t.Errorf("render limits = %d, %d, want %d, %d", cfg.RenderWorkers, cfg.RenderMaxInFlight, tt.wantWorkers, tt.wantInFlight)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesStorageBackend(t *testing.T) {
+	s3 := map[string]string{
+		"STORAGE_BACKEND": "s3",
+		"S3_ENDPOINT":     "http://minio:9000",
+		"S3_BUCKET":       "certificates",
+		"S3_ACCESS_KEY":   "minio",
+		"S3_SECRET_KEY":   "minio-secret",
+	}
+	tests := []struct {
+		name    string
+		env     []map[string]string
+		wantErr string
+	}{
+		{name: "local by default"},
+		{name: "s3", env: []map[string]string{s3}},
+		{name: "s3 without bucket", env: []map[string]string{s3, {"S3_BUCKET": ""}}, wantErr: "S3_BUCKET"},
+		{name: "s3 with scheme-less endpoint", env: []map[string]string{s3, {"S3_ENDPOINT": "minio:9000"}}, wantErr: "invalid S3_ENDPOINT"},
+		{name: "unknown backend", env: []map[string]string{{"STORAGE_BACKEND": "gcs"}}, wantErr: "STORAGE_BACKEND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env...)
+
+			cfg, err := LoadFromFile("svedprint-print", "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFromFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.DownloadURLTTL != 15*time.Minute {
+				t.Errorf("DownloadURLTTL = %s, want the 15m default", cfg.DownloadURLTTL)
+			}
+		})
+	}
+}
+
+func TestLoadParsesServiceInstanceLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "svedprint_admin_service_urls:\n  - http://admin-1:8002\n  - http://admin-2:8002\nsvedprint_print_service_urls:\n  - http://print-1:8003\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, gatewayEnv, map[string]string{
+		"SVEDPRINT_SERVICE_URL":        "http://svedprint:8001",
+		"SVEDPRINT_SERVICE_URLS":       " http://svedprint-1:8001, ,http://svedprint-2:8001,",
+		"SVEDPRINT_PRINT_SERVICE_URLS": "https://print-a:8003,https://print-b:8003",
+	})
+
+	cfg, err := LoadFromFile("gateway", path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	checks := []struct {
+		name   string
+		urls   []string
+		single string
+		want   []string
+	}{
+		// Blank entries and surrounding spaces are dropped; the list beats the single URL
+		{"SVEDPRINT_SERVICE_URLS", cfg.SvedprintServiceURLs, cfg.SvedprintServiceURL, []string{"http://svedprint-1:8001", "http://svedprint-2:8001"}},
+		// List form in the config file
+		{"svedprint_admin_service_urls", cfg.SvedprintAdminServiceURLs, cfg.SvedprintAdminServiceURL, []string{"http://admin-1:8002", "http://admin-2:8002"}},
+		// The environment wins over the file
+		{"SVEDPRINT_PRINT_SERVICE_URLS", cfg.SvedprintPrintServiceURLs, cfg.SvedprintPrintServiceURL, []string{"https://print-a:8003", "https://print-b:8003"}},
+	}
+	for _, c := range checks {
+		if got := ServiceInstances(c.urls, c.single); !slices.Equal(got, c.want) {
+			t.Errorf("%s instances = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestServiceInstancesFallsBackToSingleURL(t *testing.T) {
+	setEnv(t, gatewayEnv, map[string]string{"SVEDPRINT_SERVICE_URL": "http://svedprint:8001"})
+	cfg, err := LoadFromFile("gateway", "")
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if got := ServiceInstances(cfg.SvedprintServiceURLs, cfg.SvedprintServiceURL); !slices.Equal(got, []string{"http://svedprint:8001"}) {
+		t.Errorf("instances = %q, want the single URL", got)
+	}
+}
+
+func TestLoadValidatesEveryInstanceURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "all valid", env: map[string]string{"SVEDPRINT_SERVICE_URLS": "http://svedprint-1:8001,https://svedprint-2"}},
+		{name: "second entry scheme-less", env: map[string]string{"SVEDPRINT_SERVICE_URLS": "http://svedprint-1:8001,svedprint-2:8001"}, wantErr: `invalid SVEDPRINT_SERVICE_URLS entry "svedprint-2:8001"`},
+		{name: "non-http entry", env: map[string]string{"SVEDPRINT_PRINT_SERVICE_URLS": "ftp://print-1:8003"}, wantErr: `invalid SVEDPRINT_PRINT_SERVICE_URLS entry "ftp://print-1:8003"`},
+		{name: "entry without a host", env: map[string]string{"SVEDPRINT_ADMIN_SERVICE_URLS": "http://"}, wantErr: "invalid SVEDPRINT_ADMIN_SERVICE_URLS entry"},
+		{name: "unparsable entry", env: map[string]string{"SVEDPRINT_ADMIN_SERVICE_URLS": "http://admin 1:8002"}, wantErr: "invalid SVEDPRINT_ADMIN_SERVICE_URLS entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, gatewayEnv, tt.env)
+			_, err := LoadFromFile("gateway", "")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("LoadFromFile: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("LoadFromFile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesRequestIDFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: "uuid"},
+		{value: "ulid", want: "ulid"},
+		{value: "short", want: "short"},
+		{value: "snowflake", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(cmp.Or(tt.value, "unset"), func(t *testing.T) {
+			setEnv(t, gatewayEnv, map[string]string{"REQUEST_ID_FORMAT": tt.value})
+
+			cfg, err := LoadFromFile("gateway", "")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "REQUEST_ID_FORMAT") {
+					t.Fatalf("LoadFromFile error = %v, want a REQUEST_ID_FORMAT error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.RequestIDFormat != tt.want {
+				t.Errorf("RequestIDFormat = %q, want %q", cfg.RequestIDFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReadsSecretFiles(t *testing.T) {
+	secret := writeConfig(t, "keycloak_client_secret", "from-file\n")
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		want    string
+		wantErr string
+	}{
+		{name: "file only", env: map[string]string{"KEYCLOAK_CLIENT_SECRET_FILE": secret}, want: "from-file"},
+		{name: "direct only", env: map[string]string{"KEYCLOAK_CLIENT_SECRET": "from-env"}, want: "from-env"},
+		{name: "both", env: map[string]string{"KEYCLOAK_CLIENT_SECRET": "from-env", "KEYCLOAK_CLIENT_SECRET_FILE": secret}, want: "from-env"},
+		{name: "secret file over config file", env: map[string]string{"KEYCLOAK_CLIENT_SECRET_FILE": secret}, file: "keycloak_client_secret: from-config\n", want: "from-file"},
+		{name: "config file only", file: "keycloak_client_secret: from-config\n", want: "from-config"},
+		{name: "unreadable file", env: map[string]string{"KEYCLOAK_CLIENT_SECRET_FILE": filepath.Join(t.TempDir(), "missing")}, wantErr: "KEYCLOAK_CLIENT_SECRET_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, gatewayEnv, map[string]string{"KEYCLOAK_CLIENT_SECRET": "", "KEYCLOAK_CLIENT_SECRET_FILE": ""}, tt.env)
+			var path string
+			if tt.file != "" {
+				path = writeConfig(t, "config.yaml", tt.file)
+			}
+
+			cfg, err := LoadFromFile("gateway", path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFromFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromFile: %v", err)
+			}
+			if cfg.KeycloakClientSecret != tt.want {
+				t.Errorf("KeycloakClientSecret = %q, want %q", cfg.KeycloakClientSecret, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReadsEverySecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secrets := map[string]string{
+		"DATABASE_URL":           "postgres://app:from-file@db:5432/svedprint",
+		"REDIS_PASSWORD":         "redis-from-file",
+		"KEYCLOAK_CLIENT_SECRET": "keycloak-from-file",
+		"WEBHOOK_SECRET":         "webhook-from-file",
+	}
+	env := map[string]string{}
+	for key, value := range secrets {
+		path := filepath.Join(dir, strings.ToLower(key))
+		if err := os.WriteFile(path, []byte("  "+value+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		env[key] = ""
+		env[key+"_FILE"] = path
+	}
+	setEnv(t, gatewayEnv, env)
+
+	cfg, err := LoadFromFile("gateway", "")
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	got := map[string]string{
+		"DATABASE_URL":           cfg.DatabaseURL,
+		"REDIS_PASSWORD":         cfg.RedisPassword,
+		"KEYCLOAK_CLIENT_SECRET": cfg.KeycloakClientSecret,
+		"WEBHOOK_SECRET":         cfg.WebhookSecret,
+	}
+	for key, want := range secrets {
+		if got[key] != want {
+			t.Errorf("%s = %q, want the trimmed file contents %q", key, got[key], want)
+		}
+	}
+}
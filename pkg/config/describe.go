@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// EnvVarDoc documents a single environment variable read by Load
+type EnvVarDoc struct {
+	Name        string
+	Type        string
+	Default     string
+	Required    bool
+	Description string
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Describe documents every environment variable for the config's service, derived
+// from the env, desc and required struct tags and the service defaults
+func (c *Config) Describe() []EnvVarDoc {
+	defaultCfg := defaults(c.ServiceName)
+	defaultValue := reflect.ValueOf(defaultCfg).Elem()
+	t := defaultValue.Type()
+
+	var docs []EnvVarDoc
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		docs = append(docs, EnvVarDoc{
+			Name:        name,
+			Type:        typeName(field.Type),
+			Default:     formatDefault(defaultValue.Field(i)),
+			Required:    requiredFor(field, c.ServiceName),
+			Description: field.Tag.Get("desc"),
+		})
+	}
+
+	return docs
+}
+
+func typeName(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+	if t.Kind() == reflect.Slice {
+		return "list"
+	}
+	return t.Kind().String()
+}
+
+func formatDefault(v reflect.Value) string {
+	if v.IsZero() && (v.Kind() == reflect.String || v.Kind() == reflect.Slice) {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// loadedEnvVars lists the variables applyEnv reads, taken from its getEnv* calls
+func loadedEnvVars(t *testing.T) map[string]bool {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse config.go: %v", err)
+	}
+
+	vars := map[string]bool{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "applyEnv" {
+			continue
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			if ident, ok := call.Fun.(*ast.Ident); !ok || !strings.HasPrefix(ident.Name, "getEnv") {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				name, _ := strconv.Unquote(lit.Value)
+				vars[name] = true
+			}
+			return true
+		})
+	}
+	if len(vars) == 0 {
+		t.Fatal("found no getEnv calls in applyEnv")
+	}
+	return vars
+}
+
+func TestDescribeCoversEveryLoadedVariable(t *testing.T) {
+	loaded := loadedEnvVars(t)
+
+	for _, service := range []string{"gateway", "svedprint", "svedprint-admin", "svedprint-print"} {
+		t.Run(service, func(t *testing.T) {
+			described := map[string]bool{}
+			for _, doc := range defaults(service).Describe() {
+				if described[doc.Name] {
+					t.Errorf("%s is described twice", doc.Name)
+				}
+				described[doc.Name] = true
+				if doc.Description == "" {
+					t.Errorf("%s has no description", doc.Name)
+				}
+				if doc.Type == "" {
+					t.Errorf("%s has no type", doc.Name)
+				}
+				if !loaded[doc.Name] {
+					t.Errorf("%s is described but never loaded", doc.Name)
+				}
+			}
+			for name := range loaded {
+				if !described[name] {
+					t.Errorf("%s is loaded but missing from Describe", name)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeTypesDefaultsAndRequired(t *testing.T) {
+	docs := map[string]EnvVarDoc{}
+	for _, doc := range defaults("gateway").Describe() {
+		docs[doc.Name] = doc
+	}
+
+	tests := []struct {
+		name     string
+		typ      string
+		required bool
+	}{
+		{"PORT", "string", false},
+		{"REQUEST_TIMEOUT", "duration", false},
+		{"DATABASE_MAX_CONNS", "int", false},
+		{"PRETTY_JSON", "bool", false},
+		{"SVEDPRINT_SERVICE_URLS", "list", false},
+		{"DATABASE_URL", "string", true},
+		{"KEYCLOAK_JWKS_URL", "string", true},
+	}
+	for _, tt := range tests {
+		doc, ok := docs[tt.name]
+		if !ok {
+			t.Errorf("%s missing", tt.name)
+			continue
+		}
+		if doc.Type != tt.typ || doc.Required != tt.required {
+			t.Errorf("%s = %s required=%v, want %s required=%v", tt.name, doc.Type, doc.Required, tt.typ, tt.required)
+		}
+	}
+	if docs["PORT"].Default == "" {
+		t.Error("PORT has no default")
+	}
+
+	// The required tag is per service: the print service needs no database
+	for _, doc := range defaults("svedprint-print").Describe() {
+		if doc.Name == "DATABASE_URL" && doc.Required {
+			t.Error("DATABASE_URL is required for svedprint-print")
+		}
+	}
+}
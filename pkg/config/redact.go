@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces secrets in Redacted output
+const redactedValue = "****"
+
+// dsnPassword matches the password in a keyword/value DSN such as
+// "host=db user=app password=secret"
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// queryPassword matches a password parameter in a connection URL's query
+var queryPassword = regexp.MustCompile(`(?i)((?:^|&)password=)[^&]*`)
+
+// Redacted returns the effective configuration as yaml_name=value pairs for startup
+// logging. Fields tagged secret are masked, as are passwords in connection URLs and
+// DSNs, so hosts and database names stay readable. Sections are left out; their values
+// show in the flat fields they set.
+func (c *Config) Redacted() string {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	parts := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || isSection(field.Type) {
+			continue
+		}
+
+		var value string
+		switch f := v.Field(i); {
+		case field.Tag.Get("secret") == "true":
+			if !f.IsZero() {
+				value = redactedValue
+			}
+		case f.Kind() == reflect.String:
+			value = redactConnString(f.String())
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+			items := make([]string, f.Len())
+			for j := range items {
+				items[j] = redactConnString(f.Index(j).String())
+			}
+			value = strings.Join(items, ",")
+		default:
+			value = fmt.Sprint(f.Interface())
+		}
+		parts = append(parts, name+"="+quoteIfNeeded(value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactConnString masks the password of a URL's userinfo, a password query
+// parameter, or a password=... DSN keyword, leaving anything else unchanged
+func redactConnString(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		u.RawQuery = queryPassword.ReplaceAllString(u.RawQuery, "${1}"+redactedValue)
+		// Redacted masks the userinfo password as xxxxx
+		return strings.Replace(u.Redacted(), ":xxxxx@", ":"+redactedValue+"@", 1)
+	}
+	return dsnPassword.ReplaceAllString(s, "${1}"+redactedValue)
+}
+
+// quoteIfNeeded quotes values that would otherwise be ambiguous in key=value output
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
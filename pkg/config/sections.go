@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Sections group related settings in the config file as nested YAML, as an
+// alternative to their flat keys. A value set in a section overrides the flat key,
+// and environment variables override both:
+//
+//	cors:
+//	  allowed_origins: [https://svedprint.mk]
+//	  max_age: 10m
+//	rate_limits:
+//	  render: {per_user: 10, per_school: 100, window: 1m}
+//	routes:
+//	  - name: svedprint
+//	    prefix: /api/svedprint
+//	    upstream: svedprint
+
+// CORSSection is the cors section, setting CORS_ALLOWED_ORIGINS and CORS_MAX_AGE
+type CORSSection struct {
+	AllowedOrigins []string       `yaml:"allowed_origins"`
+	MaxAge         *time.Duration `yaml:"max_age"`
+}
+
+// RateLimitsSection is the rate_limits section, one tier per kind of request
+type RateLimitsSection struct {
+	Render RateTier `yaml:"render"`
+}
+
+// RateTier limits one kind of request per user and per school over a sliding window
+type RateTier struct {
+	PerUser   *int           `yaml:"per_user"`
+	PerSchool *int           `yaml:"per_school"`
+	Window    *time.Duration `yaml:"window"`
+}
+
+// RawYAML keeps a section as YAML for the package that owns its schema, as the
+// gateway does for the routes section
+type RawYAML []byte
+
+// UnmarshalYAML stores the section's YAML unparsed
+func (r *RawYAML) UnmarshalYAML(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// applySections copies the values set in the file's sections onto their flat fields
+func (c *Config) applySections() {
+	if c.CORS.AllowedOrigins != nil {
+		c.CORSAllowedOrigins = strings.Join(c.CORS.AllowedOrigins, ",")
+	}
+	setIfPresent(&c.CORSMaxAge, c.CORS.MaxAge)
+
+	render := c.RateLimits.Render
+	setIfPresent(&c.RenderRateLimitPerUser, render.PerUser)
+	setIfPresent(&c.RenderRateLimitPerSchool, render.PerSchool)
+	setIfPresent(&c.RenderRateLimitWindow, render.Window)
+}
+
+// isSection reports whether a field is a nested section rather than a setting
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || t == reflect.TypeOf(RawYAML(nil))
+}
+
+func setIfPresent[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
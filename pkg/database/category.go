@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Category groups queries that share timeout expectations
+type Category string
+
+const (
+	// CategoryInteractive is request-path CRUD that should fail fast
+	CategoryInteractive Category = "interactive"
+	// CategoryReporting is large aggregations and exports that may run for minutes
+	CategoryReporting Category = "reporting"
+)
+
+// CategoryTimeouts bounds queries run in a category. Zero values leave the server
+// and context defaults in place.
+type CategoryTimeouts struct {
+	// Statement is applied as statement_timeout for the transaction only
+	Statement time.Duration
+	// Acquire bounds the wait for a pool connection
+	Acquire time.Duration
+}
+
+// DefaultCategoryTimeouts keeps interactive queries short and gives reports room
+var DefaultCategoryTimeouts = map[Category]CategoryTimeouts{
+	CategoryInteractive: {Statement: 5 * time.Second, Acquire: 2 * time.Second},
+	CategoryReporting:   {Statement: 2 * time.Minute, Acquire: 10 * time.Second},
+}
+
+// NewCategoryTimeouts bounds interactive and reporting queries with the configured
+// statement timeouts, sharing one pool acquire timeout, for Config.CategoryTimeouts
+func NewCategoryTimeouts(interactive, reporting, acquire time.Duration) map[Category]CategoryTimeouts {
+	return map[Category]CategoryTimeouts{
+		CategoryInteractive: {Statement: interactive, Acquire: acquire},
+		CategoryReporting:   {Statement: reporting, Acquire: acquire},
+	}
+}
+
+// InCategory runs fn in a transaction whose statement_timeout is set for the
+// category with SET LOCAL semantics, so the setting never leaks to other users of
+// the pooled connection. Passing a TenantDB as db keeps the transaction on the
+// context's tenant schema. timeouts overrides DefaultCategoryTimeouts per category,
+// e.g. Config.CategoryTimeouts. The transaction commits when fn returns nil.
+func InCategory(ctx context.Context, db TxBeginner, timeouts map[Category]CategoryTimeouts, category Category, fn func(pgx.Tx) error) error {
+	limits, ok := timeouts[category]
+	if !ok {
+		limits, ok = DefaultCategoryTimeouts[category]
+		if !ok {
+			return fmt.Errorf("unknown query category %q", category)
+		}
+	}
+
+	// Beginning acquires the pooled connection, so the acquire timeout bounds it
+	beginCtx := ctx
+	if limits.Acquire > 0 {
+		var cancel context.CancelFunc
+		beginCtx, cancel = context.WithTimeout(ctx, limits.Acquire)
+		defer cancel()
+	}
+
+	tx, err := db.Begin(beginCtx)
+	if err != nil {
+		return fmt.Errorf("failed to begin %s transaction: %w", category, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if limits.Statement > 0 {
+		ms := strconv.FormatInt(limits.Statement.Milliseconds(), 10)
+		if _, err := tx.Exec(ctx, "select set_config('statement_timeout', $1, true)", ms); err != nil {
+			return fmt.Errorf("failed to set %s statement timeout: %w", category, err)
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit %s transaction: %w", category, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestInCategoryAppliesStatementTimeout needs a scratch database in TEST_DATABASE_URL
+func TestInCategoryAppliesStatementTimeout(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	cfg := Config{
+		URL: url,
+		// One connection, so every transaction reuses the one a category configured
+		MaxConns:        1,
+		ConnMaxLifetime: time.Hour,
+		CategoryTimeouts: map[Category]CategoryTimeouts{
+			CategoryInteractive: {Statement: 100 * time.Millisecond},
+			CategoryReporting:   {Statement: 5 * time.Second},
+		},
+	}
+	ctx := context.Background()
+	pool, err := OpenPool(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenPool: %v", err)
+	}
+	defer pool.Close()
+
+	longQuery := func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "select pg_sleep(0.5)")
+		return err
+	}
+
+	err = InCategory(ctx, pool, cfg.CategoryTimeouts, CategoryInteractive, longQuery)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "57014" {
+		t.Errorf("interactive long query = %v, want a statement timeout (57014)", err)
+	}
+
+	if err := InCategory(ctx, pool, cfg.CategoryTimeouts, CategoryReporting, longQuery); err != nil {
+		t.Errorf("reporting long query = %v, want success", err)
+	}
+
+	// SET LOCAL must not leak the category's timeout onto the pooled connection
+	var interactive string
+	if err := InCategory(ctx, pool, cfg.CategoryTimeouts, CategoryInteractive, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, "show statement_timeout").Scan(&interactive)
+	}); err != nil {
+		t.Fatalf("show statement_timeout: %v", err)
+	}
+	var outside string
+	if err := pool.QueryRow(ctx, "show statement_timeout").Scan(&outside); err != nil {
+		t.Fatalf("show statement_timeout: %v", err)
+	}
+	if interactive != "100ms" || outside == interactive {
+		t.Errorf("statement_timeout is %q in the transaction and %q after it", interactive, outside)
+	}
+}
+
+func TestInCategoryRejectsUnknownCategory(t *testing.T) {
+	err := InCategory(context.Background(), nil, nil, "batch", func(pgx.Tx) error {
+		t.Error("ran fn for an unknown category")
+		return nil
+	})
+	if err == nil {
+		t.Error("InCategory with an unknown category = nil, want an error")
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestInCategoryKeepsTenantSearchPath(t *testing.T) {
+	rec := &recorder{}
+	db := NewTenantDB(fakePool{rec})
+	ctx := tenant.WithContext(context.Background(), "school_a")
+	timeouts := map[Category]CategoryTimeouts{CategoryReporting: {Statement: 90 * time.Second}}
+
+	err := InCategory(ctx, db, timeouts, CategoryReporting, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "select 1")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("InCategory: %v", err)
+	}
+
+	// The timeout and the query run in the transaction that set the search_path
+	want := []string{
+		"begin", `tx: set local search_path to "school_a"`,
+		"tx: select set_config('statement_timeout', $1, true)", "tx: select 1",
+		"commit", "rollback",
+	}
+	if !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("statements:\n got %q\nwant %q", rec.log, want)
+	}
+}
@@ -2,19 +2,78 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrConnectionBudget is returned in strict mode when every replica's pool at full
+// size would exceed the server's max_connections
+var ErrConnectionBudget = errors.New("database connection budget exceeded")
+
+// tooManyConnections is the SQLSTATE Postgres returns once max_connections is reached
+const tooManyConnections = "53300"
+
+// forceCloseGrace is how long CloseWithTimeout waits for connections to be released
+// after their backends have been terminated
+const forceCloseGrace = 5 * time.Second
+
+// trackers maps each pool created by NewPool to the backend PIDs of its open connections
+var trackers sync.Map
+
+// backendTracker records the server PIDs of a pool's connections so a stuck
+// shutdown can terminate them
+type backendTracker struct {
+	mu   sync.Mutex
+	pids map[uint32]struct{}
+}
+
+func newBackendTracker() *backendTracker {
+	return &backendTracker{pids: make(map[uint32]struct{})}
+}
+
+func (t *backendTracker) add(pid uint32) {
+	t.mu.Lock()
+	t.pids[pid] = struct{}{}
+	t.mu.Unlock()
+}
+
+func (t *backendTracker) remove(pid uint32) {
+	t.mu.Lock()
+	delete(t.pids, pid)
+	t.mu.Unlock()
+}
+
+func (t *backendTracker) snapshot() []int32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pids := make([]int32, 0, len(t.pids))
+	for pid := range t.pids {
+		pids = append(pids, int32(pid))
+	}
+	return pids
+}
+
 // Config holds database configuration
 type Config struct {
 	URL             string
 	MaxConns        int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// ExpectedReplicas is how many instances of the service share the server (defaults to 1)
+	ExpectedReplicas int
+	// StrictConnectionLimit fails NewPool instead of warning when the pools would
+	// over-commit the server's max_connections
+	StrictConnectionLimit bool
+	// CategoryTimeouts overrides DefaultCategoryTimeouts for InCategory
+	CategoryTimeouts map[Category]CategoryTimeouts
 }
 
 func GetConfig(dbURL string, maxConns int, maxIdleConns int, connMaxLifetime time.Duration) Config {
@@ -41,8 +100,24 @@ func SetupDatabasePool(cfg Config) *pgxpool.Pool {
 	return dbPool
 }
 
-// NewPool creates a new database connection pool
+// NewPool creates a new database connection pool and verifies it can reach the server
 func NewPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
+	pool, err := OpenPool(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Verify(ctx, pool, cfg); err != nil {
+		Close(pool)
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// OpenPool creates a connection pool without connecting; connections are opened
+// lazily, so the pool can be handed out before the database is reachable
+func OpenPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
@@ -55,23 +130,134 @@ func NewPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
 	poolConfig.MaxConnIdleTime = 10 * time.Minute
 	poolConfig.HealthCheckPeriod = 1 * time.Minute
 
+	tracker := newBackendTracker()
+	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		tracker.add(conn.PgConn().PID())
+		return nil
+	}
+	poolConfig.BeforeClose = func(conn *pgx.Conn) {
+		tracker.remove(conn.PgConn().PID())
+	}
+
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
+	trackers.Store(pool, tracker)
+
+	return pool, nil
+}
 
-	// Test the connection
+// Verify pings the database and checks the pool fits the server's connection limit
+func Verify(ctx context.Context, pool *pgxpool.Pool, cfg Config) error {
 	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == tooManyConnections {
+			return fmt.Errorf("database server has no free connections (max_connections reached): %w", err)
+		}
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return pool, nil
+	return checkConnectionBudget(ctx, pool, cfg)
+}
+
+// checkConnectionBudget compares the connections all replicas may open against the
+// server's max_connections minus the slots reserved for superusers
+func checkConnectionBudget(ctx context.Context, db DBTX, cfg Config) error {
+	var maxConns, reserved int
+	err := db.QueryRow(ctx,
+		"select current_setting('max_connections')::int, current_setting('superuser_reserved_connections')::int",
+	).Scan(&maxConns, &reserved)
+	if err != nil {
+		log.Printf("Unable to read database max_connections, skipping connection budget check: %v", err)
+		return nil
+	}
+
+	replicas := max(cfg.ExpectedReplicas, 1)
+	required := cfg.MaxConns * replicas
+	available := maxConns - reserved
+	if required <= available {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d replicas x %d pool connections = %d, but the server allows %d (max_connections %d, %d reserved)",
+		replicas, cfg.MaxConns, required, available, maxConns, reserved)
+	if cfg.StrictConnectionLimit {
+		return fmt.Errorf("%w: %s", ErrConnectionBudget, msg)
+	}
+
+	log.Printf("Warning: database pool may over-commit connections: %s", msg)
+	return nil
 }
 
 // Close closes the database connection pool
 func Close(pool *pgxpool.Pool) {
 	if pool != nil {
 		pool.Close()
+		trackers.Delete(pool)
+	}
+}
+
+// CloseWithTimeout closes the pool, waiting at most timeout for in-use connections
+// to be released. After the deadline the backends of still-open connections are
+// terminated so a stuck query cannot hang shutdown.
+func CloseWithTimeout(pool *pgxpool.Pool, timeout time.Duration) error {
+	if pool == nil {
+		return nil
+	}
+	defer trackers.Delete(pool)
+
+	done := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	busy := pool.Stat().AcquiredConns()
+	log.Printf("Database pool did not close within %s, %d connections still busy; terminating backends", timeout, busy)
+
+	if err := terminateBackends(pool); err != nil {
+		log.Printf("Failed to terminate busy database backends: %v", err)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(forceCloseGrace):
+		return fmt.Errorf("database pool did not close after terminating backends: %d connections still busy", pool.Stat().AcquiredConns())
+	}
+}
+
+// terminateBackends kills the server-side sessions of every connection owned by the pool
+func terminateBackends(pool *pgxpool.Pool) error {
+	value, ok := trackers.Load(pool)
+	if !ok {
+		return fmt.Errorf("pool was not created by NewPool, cannot identify its backends")
+	}
+
+	pids := value.(*backendTracker).snapshot()
+	if len(pids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), forceCloseGrace)
+	defer cancel()
+
+	conn, err := pgx.ConnectConfig(ctx, pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("failed to open termination connection: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "select pg_terminate_backend(pid) from unnest($1::int[]) as pid", pids); err != nil {
+		return fmt.Errorf("failed to terminate backends: %w", err)
 	}
+
+	return nil
 }
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCloseWithTimeoutTerminatesStuckQuery needs a scratch database in TEST_DATABASE_URL
+func TestCloseWithTimeoutTerminatesStuckQuery(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := OpenPool(context.Background(), Config{URL: url, MaxConns: 2, ConnMaxLifetime: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenPool: %v", err)
+	}
+
+	started := make(chan struct{})
+	queryErr := make(chan error, 1)
+	go func() {
+		conn, err := pool.Acquire(context.Background())
+		if err != nil {
+			close(started)
+			queryErr <- err
+			return
+		}
+		defer conn.Release()
+		close(started)
+		_, err = conn.Exec(context.Background(), "select pg_sleep(60)")
+		queryErr <- err
+	}()
+	<-started
+	// let the sleep reach the server before closing
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	if err := CloseWithTimeout(pool, 500*time.Millisecond); err != nil {
+		t.Fatalf("CloseWithTimeout: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond+forceCloseGrace {
+		t.Errorf("CloseWithTimeout took %s", elapsed)
+	}
+
+	select {
+	case err := <-queryErr:
+		if err == nil {
+			t.Error("stuck query finished without error, want it terminated")
+		}
+	case <-time.After(forceCloseGrace):
+		t.Error("stuck query still running after CloseWithTimeout returned")
+	}
+	if _, ok := trackers.Load(pool); ok {
+		t.Error("pool tracker not removed")
+	}
+}
+
+func TestCloseWithTimeoutNilPool(t *testing.T) {
+	if err := CloseWithTimeout(nil, time.Second); err != nil {
+		t.Errorf("CloseWithTimeout(nil) = %v, want nil", err)
+	}
+}
+
+// TestNewPoolStrictConnectionLimit needs a scratch database in TEST_DATABASE_URL
+func TestNewPoolStrictConnectionLimit(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	// No real server allows a million connections
+	_, err := NewPool(context.Background(), Config{URL: url, MaxConns: 1_000_000, StrictConnectionLimit: true})
+	if !errors.Is(err, ErrConnectionBudget) {
+		t.Errorf("NewPool = %v, want ErrConnectionBudget", err)
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// settingsDB answers the connection budget query with fixed server settings
+type settingsDB struct {
+	DBTX
+	maxConns, reserved int
+	err                error
+}
+
+func (db settingsDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return settingsRow(db)
+}
+
+type settingsRow settingsDB
+
+func (r settingsRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int) = r.maxConns
+	*dest[1].(*int) = r.reserved
+	return nil
+}
+
+func TestCheckConnectionBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		db      settingsDB
+		cfg     Config
+		wantErr bool
+	}{
+		{"fits", settingsDB{maxConns: 100, reserved: 3}, Config{MaxConns: 25, ExpectedReplicas: 3}, false},
+		{"exactly fits", settingsDB{maxConns: 100, reserved: 3}, Config{MaxConns: 97}, false},
+		{"low server limit warns", settingsDB{maxConns: 20, reserved: 3}, Config{MaxConns: 25}, false},
+		{"low server limit fails in strict mode", settingsDB{maxConns: 20, reserved: 3}, Config{MaxConns: 25, StrictConnectionLimit: true}, true},
+		{"replicas over-commit in strict mode", settingsDB{maxConns: 100, reserved: 3}, Config{MaxConns: 25, ExpectedReplicas: 4, StrictConnectionLimit: true}, true},
+		{"unreadable settings are skipped", settingsDB{err: errors.New("permission denied")}, Config{MaxConns: 25, StrictConnectionLimit: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkConnectionBudget(context.Background(), tt.db, tt.cfg)
+			if tt.wantErr != errors.Is(err, ErrConnectionBudget) {
+				t.Errorf("checkConnectionBudget() = %v, want budget error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkConnectionBudget() = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoEstimate is returned by EstimateRows for a table the planner has no
+// statistics on yet, i.e. one never vacuumed or analyzed
+var ErrNoEstimate = errors.New("no row estimate for table")
+
+// RowQuerier is satisfied by pools, connections and transactions
+type RowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// EstimateRows returns the planner's estimate of a table's row count from
+// pg_class.reltuples. It costs a catalog lookup instead of a scan, but counts the
+// whole table (soft-deleted rows included) as of the last ANALYZE, so it suits
+// unfiltered lists only.
+func EstimateRows(ctx context.Context, db RowQuerier, table string) (int64, error) {
+	var estimate float64
+	if err := db.QueryRow(ctx, "select reltuples from pg_class where oid = $1::regclass", table).Scan(&estimate); err != nil {
+		return 0, fmt.Errorf("failed to estimate rows of %s: %w", table, err)
+	}
+	if estimate < 0 {
+		return 0, fmt.Errorf("%s: %w", table, ErrNoEstimate)
+	}
+	return int64(estimate), nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// estimateRow answers the reltuples lookup with estimate, or err
+type estimateRow struct {
+	estimate float64
+	err      error
+}
+
+func (r estimateRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*float64) = r.estimate
+	return nil
+}
+
+type estimateDB struct {
+	row   estimateRow
+	table string
+}
+
+func (db *estimateDB) QueryRow(_ context.Context, _ string, args ...any) pgx.Row {
+	db.table = args[0].(string)
+	return db.row
+}
+
+func TestEstimateRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     estimateRow
+		want    int64
+		wantErr error
+	}{
+		{name: "analyzed table", row: estimateRow{estimate: 12345.6}, want: 12345},
+		{name: "empty table", row: estimateRow{estimate: 0}, want: 0},
+		{name: "never analyzed", row: estimateRow{estimate: -1}, wantErr: ErrNoEstimate},
+		{name: "unknown table", row: estimateRow{err: pgx.ErrNoRows}, wantErr: pgx.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &estimateDB{row: tt.row}
+			got, err := EstimateRows(context.Background(), db, "students")
+			if db.table != "students" {
+				t.Errorf("estimated table %q, want students", db.table)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("EstimateRows error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("EstimateRows = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
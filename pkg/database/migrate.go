@@ -1,15 +1,55 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
 )
 
-// RunMigrations runs database migrations from the specified directory
+// ErrMigrationChecksum is returned when an already applied migration file has been
+// edited or removed since it ran
+var ErrMigrationChecksum = errors.New("applied migration changed")
+
+// migrationFile is a local up migration and the hash of its contents
+type migrationFile struct {
+	version  uint64
+	name     string
+	checksum string
+}
+
+// RunMigrations runs database migrations from the specified directory. The checksum
+// of every applied migration is recorded, and startup fails if one of them has
+// changed since, instead of silently diverging from the schema it produced.
 func RunMigrations(databaseURL, migrationsPath string) error {
+	ctx := context.Background()
+
+	files, err := readMigrationFiles(migrationsPath)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect for migration checksums: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if err := verifyChecksums(ctx, conn, files); err != nil {
+		return err
+	}
+
 	m, err := migrate.New(
 		fmt.Sprintf("file://%s", migrationsPath),
 		databaseURL,
@@ -23,5 +63,124 @@ func RunMigrations(databaseURL, migrationsPath string) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	version, dirty, err := m.Version()
+	if err != nil || dirty {
+		return nil
+	}
+
+	return recordChecksums(ctx, conn, files, uint64(version))
+}
+
+// readMigrationFiles hashes every *.up.sql file in dir, keyed by its version prefix
+func readMigrationFiles(dir string) (map[uint64]migrationFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	files := make(map[uint64]migrationFile, len(paths))
+	for _, path := range paths {
+		name := filepath.Base(path)
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sum := sha256.Sum256(data)
+		files[version] = migrationFile{version: version, name: name, checksum: hex.EncodeToString(sum[:])}
+	}
+
+	return files, nil
+}
+
+// verifyChecksums compares the recorded checksums of applied migrations with the
+// files on disk, reporting every migration that was edited or deleted
+func verifyChecksums(ctx context.Context, conn *pgx.Conn, files map[uint64]migrationFile) error {
+	_, err := conn.Exec(ctx, `create table if not exists schema_migration_checksums (
+		version bigint primary key,
+		name text not null,
+		checksum text not null,
+		applied_at timestamptz not null default now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration checksum table: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, "select version, name, checksum from schema_migration_checksums order by version")
+	if err != nil {
+		return fmt.Errorf("failed to load migration checksums: %w", err)
+	}
+	recorded, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (migrationFile, error) {
+		var f migrationFile
+		var version int64
+		err := row.Scan(&version, &f.name, &f.checksum)
+		f.version = uint64(version)
+		return f, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load migration checksums: %w", err)
+	}
+
+	return compareChecksums(recorded, files)
+}
+
+// compareChecksums reports every recorded migration that no longer matches its file
+func compareChecksums(recorded []migrationFile, files map[uint64]migrationFile) error {
+	var problems []string
+	for _, applied := range recorded {
+		local, ok := files[applied.version]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s was applied but no longer exists", applied.name))
+		case local.checksum != applied.checksum:
+			problems = append(problems, fmt.Sprintf("%s was edited after it was applied (recorded sha256 %s, file has %s)",
+				local.name, shortChecksum(applied.checksum), shortChecksum(local.checksum)))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w; add a new migration instead of changing history: %s", ErrMigrationChecksum, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// shortChecksum abbreviates a checksum for messages; a recorded one may be shorter
+// than expected if the table was edited by hand
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
+
+// recordChecksums pins the checksum of every migration up to the current version.
+// Migrations applied before checksums were introduced are pinned on first run.
+func recordChecksums(ctx context.Context, conn *pgx.Conn, files map[uint64]migrationFile, current uint64) error {
+	versions := make([]uint64, 0, len(files))
+	for version := range files {
+		if version <= current {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, version := range versions {
+		f := files[version]
+		_, err := conn.Exec(ctx,
+			"insert into schema_migration_checksums (version, name, checksum) values ($1, $2, $3) on conflict (version) do nothing",
+			int64(f.version), f.name, f.checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of %s: %w", f.name, err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TestRunMigrationsFailsOnEditedMigration needs a scratch database in TEST_DATABASE_URL;
+// it drops the tables it creates
+func TestRunMigrationsFailsOnEditedMigration(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close(ctx)
+	cleanup := func() {
+		conn.Exec(ctx, "drop table if exists migrate_test_student, schema_migrations, schema_migration_checksums")
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	dir := t.TempDir()
+	writeMigration(t, dir, "1_create_student.up.sql", "create table migrate_test_student (id int);")
+	writeMigration(t, dir, "1_create_student.down.sql", "drop table migrate_test_student;")
+
+	if err := RunMigrations(url, dir); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if err := RunMigrations(url, dir); err != nil {
+		t.Fatalf("unchanged rerun: %v", err)
+	}
+
+	writeMigration(t, dir, "1_create_student.up.sql", "create table migrate_test_student (id bigint);")
+	if err := RunMigrations(url, dir); !errors.Is(err, ErrMigrationChecksum) {
+		t.Fatalf("run after editing an applied migration = %v, want ErrMigrationChecksum", err)
+	}
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigration(t *testing.T, dir, name, sql string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareChecksums(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, "1_create_student.up.sql", "create table student (id int);")
+	writeMigration(t, dir, "2_add_name.up.sql", "alter table student add column name text;")
+	files, err := readMigrationFiles(dir)
+	if err != nil {
+		t.Fatalf("readMigrationFiles: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		recorded []migrationFile
+		wantErr  string
+	}{
+		{name: "unchanged", recorded: []migrationFile{files[1], files[2]}},
+		{name: "not yet applied", recorded: []migrationFile{files[1]}},
+		{
+			name:     "edited",
+			recorded: []migrationFile{files[1], {version: 2, name: "2_add_name.up.sql", checksum: strings.Repeat("ab", 32)}},
+			wantErr:  "2_add_name.up.sql was edited after it was applied (recorded sha256 abababababab",
+		},
+		{
+			name:     "short recorded checksum",
+			recorded: []migrationFile{{version: 1, name: "1_create_student.up.sql", checksum: "abc"}},
+			wantErr:  "recorded sha256 abc,",
+		},
+		{
+			name:     "deleted",
+			recorded: []migrationFile{{version: 3, name: "3_drop_name.up.sql", checksum: "abc"}},
+			wantErr:  "3_drop_name.up.sql was applied but no longer exists",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compareChecksums(tt.recorded, files)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("compareChecksums: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMigrationChecksum) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compareChecksums = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotFound is wrapped by QueryDB errors for queries that matched no rows
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable is wrapped by QueryDB errors when the database did not answer in
+	// time; the query may succeed if retried
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// DBTX is the widest query interface sqlc generates against (CopyFrom is only needed by
+// :copyfrom queries); pools, connections, transactions and QueryDB satisfy it
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// QueryDB wraps the DBTX behind sqlc queries so every query gets a default timeout and
+// errors are translated in one place: no rows wraps ErrNotFound and a timed out query
+// or pool acquire wraps ErrUnavailable, which apierror maps to 404 and 503. The
+// original pgx error stays in the chain, so errors.Is(err, pgx.ErrNoRows) keeps working.
+type QueryDB struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+// NewQueryDB wraps db, e.g. sqlc.New(database.NewQueryDB(pool, timeout)). The timeout
+// only ever shortens a request's own deadline; a non-positive timeout adds none.
+func NewQueryDB(db DBTX, timeout time.Duration) *QueryDB {
+	return &QueryDB{db: db, timeout: timeout}
+}
+
+type noQueryTimeoutKey struct{}
+
+// WithoutQueryTimeout marks ctx for queries that legitimately outlive the default
+// timeout, such as a cursor streamed to a client as it reads
+func WithoutQueryTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryTimeoutKey{}, true)
+}
+
+func (q *QueryDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if q.timeout <= 0 || ctx.Value(noQueryTimeoutKey{}) != nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, q.timeout)
+}
+
+// Exec runs sql within the default timeout
+func (q *QueryDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	tag, err := q.db.Exec(ctx, sql, args...)
+	return tag, translate(err)
+}
+
+// CopyFrom bulk loads rows within the default timeout
+func (q *QueryDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	n, err := q.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return n, translate(err)
+}
+
+// Query keeps the timeout running until the rows are closed
+func (q *QueryDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := q.withTimeout(ctx)
+
+	rows, err := q.db.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, translate(err)
+	}
+	return &timedRows{Rows: rows, cancel: cancel}, nil
+}
+
+// QueryRow keeps the timeout running until the row is scanned
+func (q *QueryDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := q.withTimeout(ctx)
+	return &timedRow{row: q.db.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+type timedRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timedRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+func (r *timedRows) Err() error {
+	return translate(r.Rows.Err())
+}
+
+type timedRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timedRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return translate(r.row.Scan(dest...))
+}
+
+// translate maps pgx errors onto the package's sentinels, keeping the original error
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err):
+		return fmt.Errorf("%w: no response in time: %w", ErrUnavailable, err)
+	}
+	return err
+}
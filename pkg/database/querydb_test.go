@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// deadlineDB records the deadline of each statement's context and fails with err
+type deadlineDB struct {
+	deadline time.Time
+	hasLimit bool
+	ctx      context.Context
+	err      error
+}
+
+func (db *deadlineDB) record(ctx context.Context) {
+	db.ctx = ctx
+	db.deadline, db.hasLimit = ctx.Deadline()
+}
+
+func (db *deadlineDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	db.record(ctx)
+	return pgconn.CommandTag{}, db.err
+}
+
+func (db *deadlineDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	db.record(ctx)
+	if db.err != nil {
+		return nil, db.err
+	}
+	return &emptyRows{}, nil
+}
+
+func (db *deadlineDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.record(ctx)
+	return errRow{err: db.err}
+}
+
+func (db *deadlineDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	db.record(ctx)
+	return 0, db.err
+}
+
+type emptyRows struct {
+	pgx.Rows
+}
+
+func (r *emptyRows) Close()     {}
+func (r *emptyRows) Err() error { return nil }
+
+func TestQueryDBAppliesDefaultTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		ctx       func() (context.Context, context.CancelFunc)
+		wantLimit time.Duration
+	}{
+		{
+			name:      "default timeout",
+			timeout:   time.Second,
+			ctx:       func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			wantLimit: time.Second,
+		},
+		{
+			name:    "shorter request deadline wins",
+			timeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+			wantLimit: time.Second,
+		},
+		{
+			name:    "disabled",
+			timeout: 0,
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+		},
+		{
+			name:    "opted out",
+			timeout: time.Second,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return WithoutQueryTimeout(context.Background()), func() {}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &deadlineDB{}
+			q := NewQueryDB(db, tt.timeout)
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			if _, err := q.Exec(ctx, "update student set version = version + 1"); err != nil {
+				t.Fatalf("Exec: %v", err)
+			}
+			if !db.hasLimit {
+				if tt.wantLimit != 0 {
+					t.Fatal("statement ran without a deadline")
+				}
+				return
+			}
+			if tt.wantLimit == 0 {
+				t.Fatalf("statement ran with a deadline %v away", time.Until(db.deadline))
+			}
+			if remaining := time.Until(db.deadline); remaining > tt.wantLimit || remaining < tt.wantLimit-time.Second/2 {
+				t.Errorf("deadline %v away, want about %v", remaining, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestQueryDBKeepsTimeoutUntilRowsClose(t *testing.T) {
+	db := &deadlineDB{}
+	q := NewQueryDB(db, time.Minute)
+
+	rows, err := q.Query(context.Background(), "select 1")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if db.ctx.Err() != nil {
+		t.Fatal("query context ended before the rows were read")
+	}
+	rows.Close()
+	if db.ctx.Err() == nil {
+		t.Error("query context still running after the rows were closed")
+	}
+}
+
+func TestQueryDBTranslatesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		wantIs []error
+	}{
+		{name: "no rows", err: pgx.ErrNoRows, wantIs: []error{ErrNotFound, pgx.ErrNoRows}},
+		{name: "deadline", err: context.DeadlineExceeded, wantIs: []error{ErrUnavailable, context.DeadlineExceeded}},
+		{name: "server error", err: &pgconn.PgError{Code: "23505"}, wantIs: []error{}},
+		{name: "other", err: errors.New("syntax error"), wantIs: []error{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueryDB(&deadlineDB{err: tt.err}, time.Second)
+
+			var dest int
+			errs := map[string]error{
+				"Exec":     second(q.Exec(context.Background(), "delete from student")),
+				"Query":    second(q.Query(context.Background(), "select 1")),
+				"QueryRow": q.QueryRow(context.Background(), "select 1").Scan(&dest),
+				"CopyFrom": second(q.CopyFrom(context.Background(), pgx.Identifier{"student"}, nil, nil)),
+			}
+			for method, err := range errs {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("%s: %v lost the original error", method, err)
+				}
+				for _, want := range tt.wantIs {
+					if !errors.Is(err, want) {
+						t.Errorf("%s: %v is not %v", method, err, want)
+					}
+				}
+				if len(tt.wantIs) == 0 && (errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnavailable)) {
+					t.Errorf("%s: %v was translated", method, err)
+				}
+			}
+		})
+	}
+}
+
+func second[T any](_ T, err error) error { return err }
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ScanAll collects the rows of an ad-hoc query (dynamic filters, search) into a slice
+// of T, matching columns to fields by their `db:"column"` tag, or else by field name.
+// Fields without a matching column keep their zero value, so one struct serves
+// queries selecting different column sets. Nullable columns need a pointer or pgtype
+// field; NULL leaves a pointer nil. Columns without a matching field are an error.
+// The rows are always closed.
+func ScanAll[T any](rows pgx.Rows) ([]T, error) {
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[T])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan rows: %w", err)
+	}
+	return items, nil
+}
+
+// ScanOne is ScanAll for queries expected to return a single row; it returns an error
+// wrapping pgx.ErrNoRows when there is none
+func ScanOne[T any](rows pgx.Rows) (T, error) {
+	item, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[T])
+	if err != nil {
+		return item, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return item, nil
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// column is a result column; nil values in textRows are NULL
+type column struct {
+	name string
+	oid  uint32
+}
+
+// textRows serves rows in Postgres' text format and decodes them with pgx's own type
+// map, the way a connection does
+type textRows struct {
+	pgx.Rows
+	columns []column
+	values  [][]*string
+	row     int
+	closed  bool
+	types   *pgtype.Map
+}
+
+func newTextRows(columns []column, values ...[]*string) *textRows {
+	return &textRows{columns: columns, values: values, types: pgtype.NewMap()}
+}
+
+func (r *textRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, c := range r.columns {
+		fields[i] = pgconn.FieldDescription{Name: c.name, DataTypeOID: c.oid, Format: pgtype.TextFormatCode}
+	}
+	return fields
+}
+
+func (r *textRows) Next() bool {
+	if r.closed || r.row >= len(r.values) {
+		return false
+	}
+	r.row++
+	return true
+}
+
+func (r *textRows) Scan(dest ...any) error {
+	for i, value := range r.values[r.row-1] {
+		var src []byte
+		if value != nil {
+			src = []byte(*value)
+		}
+		if err := r.types.Scan(r.columns[i].oid, pgtype.TextFormatCode, src, dest[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *textRows) Close()     { r.closed = true }
+func (r *textRows) Err() error { return nil }
+
+func text(s string) *string { return &s }
+
+type studentRow struct {
+	UUID        string      `db:"uuid"`
+	FirstName   string      `db:"first_name"`
+	MiddleName  *string     `db:"middle_name"`
+	DateOfBirth pgtype.Date `db:"date_of_birth"`
+	DeletedAt   *time.Time  `db:"deleted_at"`
+	Version     int64
+}
+
+var studentColumns = []column{
+	{"uuid", pgtype.UUIDOID},
+	{"first_name", pgtype.TextOID},
+	{"middle_name", pgtype.TextOID},
+	{"date_of_birth", pgtype.DateOID},
+	{"deleted_at", pgtype.TimestamptzOID},
+	{"version", pgtype.Int8OID},
+}
+
+func TestScanAll(t *testing.T) {
+	rows := newTextRows(studentColumns,
+		[]*string{text("0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80"), text("Ana"), text("Petrova"), text("2010-03-14"), text("2026-09-01 08:00:00+00"), text("3")},
+		[]*string{text("9d2e6f4a-1b3c-4e5d-8f7a-6b5c4d3e2f10"), text("Marko"), nil, nil, nil, text("1")},
+	)
+
+	students, err := ScanAll[studentRow](rows)
+	if err != nil {
+		t.Fatalf("ScanAll: %v", err)
+	}
+	if !rows.closed {
+		t.Error("rows were not closed")
+	}
+	if len(students) != 2 {
+		t.Fatalf("scanned %d students, want 2", len(students))
+	}
+
+	ana := students[0]
+	if ana.UUID != "0b8a3c1e-4d8f-4a7e-9c55-3f1a2b6d7e80" || ana.FirstName != "Ana" || ana.Version != 3 {
+		t.Errorf("students[0] = %+v", ana)
+	}
+	if ana.MiddleName == nil || *ana.MiddleName != "Petrova" {
+		t.Errorf("middle name = %v, want Petrova", ana.MiddleName)
+	}
+	if !ana.DateOfBirth.Valid || !ana.DateOfBirth.Time.Equal(time.Date(2010, 3, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date of birth = %+v, want 2010-03-14", ana.DateOfBirth)
+	}
+	if ana.DeletedAt == nil || !ana.DeletedAt.Equal(time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("deleted at = %v, want 2026-09-01 08:00 UTC", ana.DeletedAt)
+	}
+
+	// NULLs leave pointers nil and pgtype values invalid
+	marko := students[1]
+	if marko.FirstName != "Marko" || marko.MiddleName != nil || marko.DateOfBirth.Valid || marko.DeletedAt != nil {
+		t.Errorf("students[1] = %+v, want NULL columns left empty", marko)
+	}
+}
+
+func TestScanAllColumnSubset(t *testing.T) {
+	rows := newTextRows([]column{{"first_name", pgtype.TextOID}, {"version", pgtype.Int8OID}},
+		[]*string{text("Ana"), text("2")},
+	)
+
+	students, err := ScanAll[studentRow](rows)
+	if err != nil {
+		t.Fatalf("ScanAll: %v", err)
+	}
+	if len(students) != 1 || students[0] != (studentRow{FirstName: "Ana", Version: 2}) {
+		t.Errorf("students = %+v, want only the selected fields set", students)
+	}
+}
+
+func TestScanAllErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []column
+		values  []*string
+	}{
+		{name: "column without a field", columns: []column{{"first_name", pgtype.TextOID}, {"school_uuid", pgtype.UUIDOID}}, values: []*string{text("Ana"), text("5f0f4a4e-8f4e-4b7a-9d36-0d6b1f0c2a11")}},
+		{name: "NULL into a non-nullable field", columns: []column{{"first_name", pgtype.TextOID}}, values: []*string{nil}},
+		{name: "value of the wrong type", columns: []column{{"version", pgtype.Int8OID}}, values: []*string{text("three")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newTextRows(tt.columns, tt.values)
+			if students, err := ScanAll[studentRow](rows); err == nil {
+				t.Errorf("ScanAll = %+v, want an error", students)
+			}
+			if !rows.closed {
+				t.Error("rows were not closed")
+			}
+		})
+	}
+}
+
+func TestScanOne(t *testing.T) {
+	rows := newTextRows([]column{{"first_name", pgtype.TextOID}, {"middle_name", pgtype.TextOID}},
+		[]*string{text("Ana"), nil},
+	)
+	student, err := ScanOne[studentRow](rows)
+	if err != nil {
+		t.Fatalf("ScanOne: %v", err)
+	}
+	if student.FirstName != "Ana" || student.MiddleName != nil {
+		t.Errorf("student = %+v", student)
+	}
+
+	empty := newTextRows(studentColumns)
+	if _, err := ScanOne[studentRow](empty); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("ScanOne on no rows = %v, want pgx.ErrNoRows", err)
+	}
+	if !empty.closed {
+		t.Error("rows were not closed")
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// seedFileName matches seed files named after the table they populate, with a
+// numeric prefix setting the order, e.g. 01_grading_scale.sql
+var seedFileName = regexp.MustCompile(`^\d+_([a-z_][a-z0-9_]*)\.sql$`)
+
+// Seed populates reference tables on first run. Every NN_<table>.sql file in fsys
+// is executed, in name order, only if its table is empty, so tables an operator has
+// filled or edited are left alone and reruns are no-ops. Each file runs in its own
+// transaction holding an exclusive lock on the table, so replicas starting together
+// don't seed twice. It must run after migrations have created the tables.
+func Seed(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read seed files: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		if !seedFileName.MatchString(entry.Name()) {
+			return fmt.Errorf("seed file %s must be named NN_<table>.sql", entry.Name())
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		table := seedFileName.FindStringSubmatch(name)[1]
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read seed file %s: %w", name, err)
+		}
+
+		seeded, err := seedTable(ctx, pool, table, string(script))
+		if err != nil {
+			return fmt.Errorf("seed file %s: %w", name, err)
+		}
+		if seeded {
+			log.Info().Str("table", table).Msg("Seeded reference data")
+		}
+	}
+	return nil
+}
+
+// seedTable runs script if table is empty and reports whether it did
+func seedTable(ctx context.Context, pool *pgxpool.Pool, table, script string) (bool, error) {
+	ident := pgx.Identifier{table}.Sanitize()
+	seeded := false
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "lock table "+ident+" in exclusive mode"); err != nil {
+			return fmt.Errorf("failed to lock %s: %w", table, err)
+		}
+
+		var populated bool
+		if err := tx.QueryRow(ctx, "select exists (select 1 from "+ident+")").Scan(&populated); err != nil {
+			return fmt.Errorf("failed to check %s: %w", table, err)
+		}
+		if populated {
+			return nil
+		}
+
+		// Sent without arguments, so the file may hold several statements
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return fmt.Errorf("failed to seed %s: %w", table, err)
+		}
+		seeded = true
+		return nil
+	})
+	return seeded, err
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestSeedOnlyPopulatesEmptyTables needs a scratch database in TEST_DATABASE_URL; it
+// drops the tables it creates
+func TestSeedOnlyPopulatesEmptyTables(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := OpenPool(ctx, Config{URL: url, MaxConns: 2, ConnMaxLifetime: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenPool: %v", err)
+	}
+	defer pool.Close()
+	cleanup := func() {
+		pool.Exec(ctx, "drop table if exists seed_test_scale, seed_test_subject")
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	if _, err := pool.Exec(ctx, `
+		create table seed_test_scale (name text primary key);
+		create table seed_test_subject (name text primary key);
+		insert into seed_test_subject values ('Edited by an operator');
+	`); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+
+	files := fstest.MapFS{
+		"01_seed_test_scale.sql":   {Data: []byte("insert into seed_test_scale values ('default'); insert into seed_test_scale values ('descriptive');")},
+		"02_seed_test_subject.sql": {Data: []byte("insert into seed_test_subject values ('Mathematics');")},
+		"README.md":                {Data: []byte("not a seed file")},
+	}
+	names := func(table string) []string {
+		t.Helper()
+		rows, err := pool.Query(ctx, "select name from "+table+" order by name")
+		if err != nil {
+			t.Fatalf("query %s: %v", table, err)
+		}
+		var out []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatalf("scan %s: %v", table, err)
+			}
+			out = append(out, name)
+		}
+		return out
+	}
+
+	// A rerun finds every table populated and changes nothing
+	for run := 1; run <= 2; run++ {
+		if err := Seed(ctx, pool, files); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if got := names("seed_test_scale"); len(got) != 2 || got[0] != "default" || got[1] != "descriptive" {
+			t.Errorf("run %d: seed_test_scale = %q, want the seeded rows once", run, got)
+		}
+		if got := names("seed_test_subject"); len(got) != 1 || got[0] != "Edited by an operator" {
+			t.Errorf("run %d: seed_test_subject = %q, want the populated table left alone", run, got)
+		}
+	}
+}
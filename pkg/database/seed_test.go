@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSeedRejectsMisnamedFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "no order prefix", file: "grading_scale.sql"},
+		{name: "uppercase table", file: "01_GradingScale.sql"},
+		{name: "quoted table", file: `01_grading"scale.sql`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fstest.MapFS{tt.file: {Data: []byte("insert into grading_scale default values;")}}
+			// Names are checked before anything is run, so no pool is needed
+			err := Seed(context.Background(), nil, files)
+			if err == nil || !strings.Contains(err.Error(), "must be named NN_<table>.sql") {
+				t.Errorf("Seed = %v, want a naming error", err)
+			}
+		})
+	}
+}
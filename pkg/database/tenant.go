@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNoTenant is returned when a tenant-scoped query runs without a tenant in the context
+var ErrNoTenant = errors.New("no tenant in context")
+
+// TxBeginner starts transactions; pools and TenantDB satisfy it
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTenant runs fn in a transaction whose search_path points at the tenant's schema.
+// The setting is applied with SET LOCAL, so it is discarded when the transaction ends
+// and never leaks to other requests through pooled connections.
+func WithTenant(ctx context.Context, db TxBeginner, tenantID string, fn func(tx pgx.Tx) error) error {
+	tx, err := beginTenant(ctx, db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tenant transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WithContextTenant runs fn scoped to the tenant stored in ctx (see tenant.WithContext)
+func WithContextTenant(ctx context.Context, db TxBeginner, fn func(tx pgx.Tx) error) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return WithTenant(ctx, db, tenantID, fn)
+}
+
+// beginTenant starts a transaction with search_path set to the tenant's schema
+func beginTenant(ctx context.Context, db TxBeginner, tenantID string) (pgx.Tx, error) {
+	if err := tenant.Validate(tenantID); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tenant transaction: %w", err)
+	}
+
+	searchPath := pgx.Identifier{tenantID}.Sanitize()
+	if _, err := tx.Exec(ctx, "set local search_path to "+searchPath); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to set search_path for tenant %s: %w", tenantID, err)
+	}
+	return tx, nil
+}
+
+// Pool is what TenantDB needs from a connection pool
+type Pool interface {
+	DBTX
+	TxBeginner
+}
+
+// TenantDB routes every statement to the schema of the tenant in the request context
+// (see middleware.Tenant) through WithContextTenant, so repositories stay unaware of
+// tenancy. Statements without a tenant, as in single-school deployments, use the
+// default search_path.
+type TenantDB struct {
+	pool Pool
+}
+
+// NewTenantDB wraps pool, e.g. sqlc.New(database.NewQueryDB(database.NewTenantDB(pool), timeout))
+func NewTenantDB(pool Pool) *TenantDB {
+	return &TenantDB{pool: pool}
+}
+
+// Begin starts a transaction scoped to the context's tenant, if any
+func (db *TenantDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		return beginTenant(ctx, db.pool, tenantID)
+	}
+	return db.pool.Begin(ctx)
+}
+
+// Exec runs sql in the context's tenant schema
+func (db *TenantDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if _, ok := tenant.FromContext(ctx); !ok {
+		return db.pool.Exec(ctx, sql, args...)
+	}
+
+	var tag pgconn.CommandTag
+	err := WithContextTenant(ctx, db.pool, func(tx pgx.Tx) error {
+		var err error
+		tag, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+// CopyFrom bulk loads rows into the context's tenant schema
+func (db *TenantDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	if _, ok := tenant.FromContext(ctx); !ok {
+		return db.pool.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	}
+
+	var n int64
+	err := WithContextTenant(ctx, db.pool, func(tx pgx.Tx) error {
+		var err error
+		n, err = tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+		return err
+	})
+	return n, err
+}
+
+// Query keeps the tenant transaction open until the rows are closed
+func (db *TenantDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return db.pool.Query(ctx, sql, args...)
+	}
+
+	tx, err := beginTenant(ctx, db.pool, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return &tenantRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+// QueryRow keeps the tenant transaction open until the row is scanned
+func (db *TenantDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return db.pool.QueryRow(ctx, sql, args...)
+	}
+
+	tx, err := beginTenant(ctx, db.pool, tenantID)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return &tenantRow{row: tx.QueryRow(ctx, sql, args...), ctx: ctx, tx: tx}
+}
+
+// tenantRows ends its transaction when closed, committing only if every row was read
+// without error
+type tenantRows struct {
+	pgx.Rows
+	ctx    context.Context
+	tx     pgx.Tx
+	closed bool
+	err    error
+}
+
+func (r *tenantRows) Close() {
+	r.Rows.Close()
+	if r.closed {
+		return
+	}
+	r.closed = true
+
+	if r.Rows.Err() != nil {
+		r.tx.Rollback(r.ctx)
+		return
+	}
+	if err := r.tx.Commit(r.ctx); err != nil {
+		r.err = fmt.Errorf("failed to commit tenant transaction: %w", err)
+	}
+}
+
+func (r *tenantRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// pgx closes rows once they are exhausted; end the transaction with them
+	r.Close()
+	return false
+}
+
+func (r *tenantRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+type tenantRow struct {
+	row pgx.Row
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+func (r *tenantRow) Scan(dest ...any) error {
+	if err := r.row.Scan(dest...); err != nil {
+		r.tx.Rollback(r.ctx)
+		return err
+	}
+	if err := r.tx.Commit(r.ctx); err != nil {
+		return fmt.Errorf("failed to commit tenant transaction: %w", err)
+	}
+	return nil
+}
+
+// errRow reports an error from starting a tenant transaction on Scan
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestTenantDBQueriesHitTenantSchema needs a scratch database in TEST_DATABASE_URL
+func TestTenantDBQueriesHitTenantSchema(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	schools := []string{"tenant_test_a", "tenant_test_b"}
+	for _, school := range schools {
+		schema := pgx.Identifier{school}.Sanitize()
+		_, err := pool.Exec(ctx, "create schema "+schema+"; create table "+schema+".student (name text); insert into "+schema+".student values ('"+school+"')")
+		if err != nil {
+			t.Fatalf("create %s: %v", school, err)
+		}
+		t.Cleanup(func() { pool.Exec(context.Background(), "drop schema "+schema+" cascade") })
+	}
+
+	db := NewQueryDB(NewTenantDB(pool), 0)
+	for _, school := range schools {
+		tenantCtx := tenant.WithContext(ctx, school)
+
+		var name string
+		if err := db.QueryRow(tenantCtx, "select name from student").Scan(&name); err != nil {
+			t.Fatalf("QueryRow as %s: %v", school, err)
+		}
+		if name != school {
+			t.Errorf("QueryRow as %s read %q", school, name)
+		}
+
+		rows, err := db.Query(tenantCtx, "select name from student")
+		if err != nil {
+			t.Fatalf("Query as %s: %v", school, err)
+		}
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			t.Fatalf("Query as %s: %v", school, err)
+		}
+		if len(names) != 1 || names[0] != school {
+			t.Errorf("Query as %s read %q", school, names)
+		}
+
+		if _, err := db.Exec(tenantCtx, "insert into student values ('added')"); err != nil {
+			t.Fatalf("Exec as %s: %v", school, err)
+		}
+	}
+
+	for _, school := range schools {
+		var n int
+		if err := pool.QueryRow(ctx, "select count(*) from "+pgx.Identifier{school, "student"}.Sanitize()).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 {
+			t.Errorf("%s has %d students, want 2", school, n)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recorder logs every statement and transaction boundary it sees
+type recorder struct {
+	log []string
+}
+
+type fakePool struct {
+	*recorder
+}
+
+func (p fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	p.log = append(p.log, "begin")
+	return &fakeTx{recorder: p.recorder}, nil
+}
+
+func (p fakePool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	p.log = append(p.log, "pool: "+sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (p fakePool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	p.log = append(p.log, "pool: "+sql)
+	return nil, errors.New("not implemented")
+}
+
+func (p fakePool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	p.log = append(p.log, "pool: "+sql)
+	return errRow{}
+}
+
+func (p fakePool) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	p.log = append(p.log, "pool: copy")
+	return 0, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	*recorder
+	scanErr error
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.log = append(tx.log, "tx: "+sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	tx.log = append(tx.log, "tx: "+sql)
+	return errRow{err: tx.scanErr}
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.log = append(tx.log, "commit")
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.log = append(tx.log, "rollback")
+	return nil
+}
+
+func TestTenantDBScopesStatementsToContextTenant(t *testing.T) {
+	rec := &recorder{}
+	db := NewTenantDB(fakePool{rec})
+
+	ctxA := tenant.WithContext(context.Background(), "school_a")
+	ctxB := tenant.WithContext(context.Background(), "school_b")
+
+	if _, err := db.Exec(ctxA, "delete from student"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if err := db.QueryRow(ctxB, "select 1").Scan(); err != nil {
+		t.Fatalf("QueryRow: %v", err)
+	}
+	if _, err := db.Exec(context.Background(), "select 2"); err != nil {
+		t.Fatalf("Exec without tenant: %v", err)
+	}
+
+	want := []string{
+		"begin", `tx: set local search_path to "school_a"`, "tx: delete from student", "commit", "rollback",
+		"begin", `tx: set local search_path to "school_b"`, "tx: select 1", "commit",
+		"pool: select 2",
+	}
+	if !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("statements:\n got %q\nwant %q", rec.log, want)
+	}
+}
+
+func TestTenantDBRejectsInvalidTenant(t *testing.T) {
+	rec := &recorder{}
+	db := NewTenantDB(fakePool{rec})
+	ctx := tenant.WithContext(context.Background(), `a"; drop schema public; --`)
+
+	if _, err := db.Exec(ctx, "select 1"); err == nil {
+		t.Error("Exec with an invalid tenant succeeded")
+	}
+	if err := db.QueryRow(ctx, "select 1").Scan(); err == nil {
+		t.Error("QueryRow with an invalid tenant succeeded")
+	}
+	if len(rec.log) != 0 {
+		t.Errorf("statements ran for an invalid tenant: %q", rec.log)
+	}
+}
+
+func TestWithContextTenantRequiresTenant(t *testing.T) {
+	err := WithContextTenant(context.Background(), fakePool{&recorder{}}, func(pgx.Tx) error { return nil })
+	if !errors.Is(err, ErrNoTenant) {
+		t.Errorf("err = %v, want ErrNoTenant", err)
+	}
+}
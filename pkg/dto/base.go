@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Base is the change metadata embedded in every entity DTO, letting clients cache
+// entities and detect updates consistently
+type Base struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
+}
+
+// NewBase builds the metadata from an entity's timestamps and version
+func NewBase(createdAt, updatedAt time.Time, version int64) Base {
+	return Base{CreatedAt: createdAt.UTC(), UpdatedAt: updatedAt.UTC(), Version: version}
+}
+
+// SetLastModified sets the Last-Modified header of a single-entity response
+func SetLastModified(c *gin.Context, b Base) {
+	if b.UpdatedAt.IsZero() {
+		return
+	}
+	c.Header("Last-Modified", b.UpdatedAt.UTC().Format(http.TimeFormat))
+}
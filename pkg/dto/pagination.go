@@ -0,0 +1,147 @@
+package dto
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes for the limit query parameter
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// CountStrategy selects how a paged list reports the size of the whole result. Each
+// endpoint picks its own: an exact COUNT is fine for small tables but scans every
+// matching row on large ones.
+type CountStrategy string
+
+const (
+	// CountExact runs a COUNT over the filtered rows
+	CountExact CountStrategy = "exact"
+	// CountEstimated reads the planner's row estimate (pg_class.reltuples), which is
+	// cheap but only as fresh as the last ANALYZE
+	CountEstimated CountStrategy = "estimated"
+	// CountNone reports only whether another page follows
+	CountNone CountStrategy = "none"
+)
+
+// PageRequest is the window of a list requested through the limit and offset query parameters
+type PageRequest struct {
+	Limit  int32
+	Offset int32
+}
+
+// FetchLimit is the number of rows to query: one past the page, so HasMore can be
+// told without counting
+func (p PageRequest) FetchLimit() int32 {
+	return p.Limit + 1
+}
+
+// ParsePage reads the limit and offset query parameters. It reports false when neither
+// is set, so endpoints that returned everything before pagination keep doing so.
+func ParsePage(c *gin.Context) (PageRequest, bool, error) {
+	rawLimit, rawOffset := c.Query("limit"), c.Query("offset")
+	if rawLimit == "" && rawOffset == "" {
+		return PageRequest{}, false, nil
+	}
+
+	page := PageRequest{Limit: DefaultPageLimit}
+	if rawLimit != "" {
+		limit, err := strconv.ParseInt(rawLimit, 10, 32)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return PageRequest{}, false, apierror.NewValidationError(apierror.Field("limit", "range",
+				"must be a number between 1 and "+strconv.Itoa(MaxPageLimit)))
+		}
+		page.Limit = int32(limit)
+	}
+	if rawOffset != "" {
+		offset, err := strconv.ParseInt(rawOffset, 10, 32)
+		if err != nil || offset < 0 {
+			return PageRequest{}, false, apierror.NewValidationError(apierror.Field("offset", "min",
+				"must be a non-negative number"))
+		}
+		page.Offset = int32(offset)
+	}
+	return page, true, nil
+}
+
+// Counter counts the whole result of a paged list. Estimate may be nil when the list
+// has no cheap estimate; CountEstimated then falls back to Exact, as it does when
+// Estimate fails (e.g. a table not analyzed yet).
+type Counter struct {
+	Exact    func(ctx context.Context) (int64, error)
+	Estimate func(ctx context.Context) (int64, error)
+}
+
+// Page is one page of a list. Total is omitted under CountNone, and TotalEstimated
+// marks a total taken from planner statistics.
+type Page[T any] struct {
+	Items          []T    `json:"items"`
+	Limit          int32  `json:"limit"`
+	Offset         int32  `json:"offset"`
+	HasMore        bool   `json:"has_more"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+}
+
+// NewPage builds a page from rows fetched with req.FetchLimit, dropping the extra row
+// and counting the whole result as the strategy says. The count is skipped when the
+// last page was reached, as the total is then known.
+func NewPage[T any](ctx context.Context, req PageRequest, rows []T, strategy CountStrategy, counter Counter) (*Page[T], error) {
+	page := &Page[T]{Items: rows, Limit: req.Limit, Offset: req.Offset}
+	if int32(len(rows)) > req.Limit {
+		page.Items = rows[:req.Limit]
+		page.HasMore = true
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if strategy == CountNone {
+		return page, nil
+	}
+
+	if !page.HasMore && (len(page.Items) > 0 || req.Offset == 0) {
+		total := int64(req.Offset) + int64(len(page.Items))
+		page.Total = &total
+		return page, nil
+	}
+
+	if strategy == CountEstimated && counter.Estimate != nil {
+		if total, err := counter.Estimate(ctx); err == nil {
+			// An estimate may lag behind rows that are known to exist
+			if seen := int64(req.Offset) + int64(len(rows)); total < seen {
+				total = seen
+			}
+			page.Total = &total
+			page.TotalEstimated = true
+			return page, nil
+		}
+	}
+
+	total, err := counter.Exact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page.Total = &total
+	return page, nil
+}
+
+// MapPage converts the items of a page, keeping its paging fields
+func MapPage[T, U any](page *Page[T], convert func(T) U) *Page[U] {
+	items := make([]U, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, convert(item))
+	}
+	return &Page[U]{
+		Items:          items,
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+		HasMore:        page.HasMore,
+		Total:          page.Total,
+		TotalEstimated: page.TotalEstimated,
+	}
+}
@@ -0,0 +1,180 @@
+package dto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// rowsFrom returns what a query fetching req.FetchLimit rows at req.Offset would
+// return from a table of total rows
+func rowsFrom(total int, req PageRequest) []int {
+	rows := []int{}
+	for i := int(req.Offset); i < total && len(rows) < int(req.FetchLimit()); i++ {
+		rows = append(rows, i)
+	}
+	return rows
+}
+
+// fakeCounter counts its calls and returns exact, or estimate unless estimateErr is set
+type fakeCounter struct {
+	exact, estimate       int64
+	estimateErr           error
+	exactCalls, estimates int
+}
+
+func (f *fakeCounter) counter() Counter {
+	return Counter{
+		Exact: func(context.Context) (int64, error) {
+			f.exactCalls++
+			return f.exact, nil
+		},
+		Estimate: func(context.Context) (int64, error) {
+			f.estimates++
+			return f.estimate, f.estimateErr
+		},
+	}
+}
+
+func TestNewPageHasMoreAtBoundaries(t *testing.T) {
+	const total = 20
+	tests := []struct {
+		name        string
+		req         PageRequest
+		wantItems   int
+		wantHasMore bool
+	}{
+		{name: "first page", req: PageRequest{Limit: 5}, wantItems: 5, wantHasMore: true},
+		{name: "page ending one before the last row", req: PageRequest{Limit: 5, Offset: 14}, wantItems: 5, wantHasMore: true},
+		{name: "page ending on the last row", req: PageRequest{Limit: 5, Offset: 15}, wantItems: 5},
+		{name: "limit equal to the total", req: PageRequest{Limit: 20}, wantItems: 20},
+		{name: "limit one short of the total", req: PageRequest{Limit: 19}, wantItems: 19, wantHasMore: true},
+		{name: "partial last page", req: PageRequest{Limit: 8, Offset: 16}, wantItems: 4},
+		{name: "past the end", req: PageRequest{Limit: 5, Offset: 40}, wantItems: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &fakeCounter{exact: total}
+			page, err := NewPage(context.Background(), tt.req, rowsFrom(total, tt.req), CountNone, counter.counter())
+			if err != nil {
+				t.Fatalf("NewPage: %v", err)
+			}
+			if len(page.Items) != tt.wantItems || page.HasMore != tt.wantHasMore {
+				t.Errorf("page has %d items, has_more %v, want %d, %v", len(page.Items), page.HasMore, tt.wantItems, tt.wantHasMore)
+			}
+			if len(page.Items) > 0 && page.Items[0] != int(tt.req.Offset) {
+				t.Errorf("first item = %d, want %d", page.Items[0], tt.req.Offset)
+			}
+			if page.Items == nil {
+				t.Error("items are nil, want an empty list")
+			}
+			if page.Total != nil || counter.exactCalls+counter.estimates > 0 {
+				t.Errorf("total = %v after %d counts, want no count", page.Total, counter.exactCalls+counter.estimates)
+			}
+		})
+	}
+}
+
+func TestNewPageCountStrategies(t *testing.T) {
+	const total = 20
+	tests := []struct {
+		name          string
+		strategy      CountStrategy
+		req           PageRequest
+		counter       *fakeCounter
+		noEstimate    bool
+		wantTotal     int64
+		wantEstimated bool
+		wantExact     int
+		wantEstimates int
+	}{
+		{name: "exact", strategy: CountExact, req: PageRequest{Limit: 5}, counter: &fakeCounter{exact: total, estimate: 18},
+			wantTotal: total, wantExact: 1},
+		{name: "estimated", strategy: CountEstimated, req: PageRequest{Limit: 5}, counter: &fakeCounter{exact: total, estimate: 18},
+			wantTotal: 18, wantEstimated: true, wantEstimates: 1},
+		{name: "estimate below the rows seen", strategy: CountEstimated, req: PageRequest{Limit: 5, Offset: 10}, counter: &fakeCounter{exact: total, estimate: 3},
+			wantTotal: 16, wantEstimated: true, wantEstimates: 1},
+		{name: "estimate failed", strategy: CountEstimated, req: PageRequest{Limit: 5}, counter: &fakeCounter{exact: total, estimateErr: errors.New("no estimate")},
+			wantTotal: total, wantExact: 1, wantEstimates: 1},
+		{name: "no estimate for the list", strategy: CountEstimated, req: PageRequest{Limit: 5}, counter: &fakeCounter{exact: total}, noEstimate: true,
+			wantTotal: total, wantExact: 1},
+		{name: "last page needs no count", strategy: CountExact, req: PageRequest{Limit: 5, Offset: 15}, counter: &fakeCounter{exact: total},
+			wantTotal: total},
+		{name: "last page needs no estimate", strategy: CountEstimated, req: PageRequest{Limit: 8, Offset: 16}, counter: &fakeCounter{exact: total, estimate: 18},
+			wantTotal: total},
+		{name: "past the end is counted", strategy: CountExact, req: PageRequest{Limit: 5, Offset: 40}, counter: &fakeCounter{exact: total},
+			wantTotal: total, wantExact: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := tt.counter.counter()
+			if tt.noEstimate {
+				counter.Estimate = nil
+			}
+			page, err := NewPage(context.Background(), tt.req, rowsFrom(total, tt.req), tt.strategy, counter)
+			if err != nil {
+				t.Fatalf("NewPage: %v", err)
+			}
+			if page.Total == nil || *page.Total != tt.wantTotal || page.TotalEstimated != tt.wantEstimated {
+				t.Errorf("total = %v (estimated %v), want %d (estimated %v)", page.Total, page.TotalEstimated, tt.wantTotal, tt.wantEstimated)
+			}
+			if tt.counter.exactCalls != tt.wantExact || tt.counter.estimates != tt.wantEstimates {
+				t.Errorf("%d exact counts and %d estimates, want %d and %d", tt.counter.exactCalls, tt.counter.estimates, tt.wantExact, tt.wantEstimates)
+			}
+		})
+	}
+}
+
+func TestNewPageCountError(t *testing.T) {
+	countErr := errors.New("connection refused")
+	counter := Counter{Exact: func(context.Context) (int64, error) { return 0, countErr }}
+	req := PageRequest{Limit: 5}
+	if _, err := NewPage(context.Background(), req, rowsFrom(20, req), CountExact, counter); !errors.Is(err, countErr) {
+		t.Errorf("NewPage = %v, want the count error", err)
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query     string
+		want      PageRequest
+		wantPaged bool
+		wantField string
+	}{
+		{query: "", wantPaged: false},
+		{query: "limit=10", want: PageRequest{Limit: 10}, wantPaged: true},
+		{query: "offset=40", want: PageRequest{Limit: DefaultPageLimit, Offset: 40}, wantPaged: true},
+		{query: "limit=500&offset=0", want: PageRequest{Limit: MaxPageLimit}, wantPaged: true},
+		{query: "limit=0", wantField: "limit"},
+		{query: "limit=501", wantField: "limit"},
+		{query: "limit=ten", wantField: "limit"},
+		{query: "offset=-1", wantField: "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/students?"+tt.query, nil)
+
+			page, paged, err := ParsePage(c)
+			if tt.wantField != "" {
+				var verr *apierror.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.wantField {
+					t.Errorf("ParsePage error = %v, want a validation error on %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePage: %v", err)
+			}
+			if page != tt.want || paged != tt.wantPaged {
+				t.Errorf("ParsePage = %+v, %v, want %+v, %v", page, paged, tt.want, tt.wantPaged)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"fmt"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// View selects how much of an entity an endpoint returns, via the view query parameter
+type View string
+
+const (
+	// ViewFull is the complete entity, the default for every endpoint
+	ViewFull View = "full"
+	// ViewSummary carries only identifying fields, for lists and mobile clients
+	ViewSummary View = "summary"
+)
+
+// ParseView reads the view query parameter, defaulting to ViewFull. An unknown view
+// is reported as a validation error.
+func ParseView(c *gin.Context) (View, error) {
+	switch view := View(c.Query("view")); view {
+	case "", ViewFull:
+		return ViewFull, nil
+	case ViewSummary:
+		return ViewSummary, nil
+	default:
+		return "", apierror.NewValidationError(apierror.Field("view", "oneof",
+			fmt.Sprintf("must be one of [%s %s]", ViewSummary, ViewFull)))
+	}
+}
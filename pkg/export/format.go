@@ -0,0 +1,74 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/numeric"
+)
+
+// Format controls how numbers and dates are written in exported files. Every value
+// of a kind goes through the same Format so a file never mixes conventions.
+type Format struct {
+	Name string
+	// DecimalSeparator replaces the decimal point in GPAs and other fractions
+	DecimalSeparator string
+	DateLayout       string
+	// CSVDelimiter separates fields; locales with a decimal comma use ';' so
+	// spreadsheets split columns correctly
+	CSVDelimiter rune
+}
+
+var (
+	// ISO is meant for integration partners: decimal point and YYYY-MM-DD dates
+	ISO = Format{Name: "iso", DecimalSeparator: ".", DateLayout: time.DateOnly, CSVDelimiter: ','}
+	// Macedonian matches what spreadsheets in the mk locale expect: decimal comma
+	// and DD.MM.YYYY dates
+	Macedonian = Format{Name: "mk", DecimalSeparator: ",", DateLayout: "02.01.2006", CSVDelimiter: ';'}
+)
+
+var formats = map[string]Format{
+	ISO.Name:        ISO,
+	Macedonian.Name: Macedonian,
+}
+
+// Lookup returns the format with the given name; an empty name selects ISO
+func Lookup(name string) (Format, error) {
+	if name == "" {
+		return ISO, nil
+	}
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown export format %q (expected %s or %s)", name, ISO.Name, Macedonian.Name)
+	}
+	return f, nil
+}
+
+// GPA writes the average with two decimals, e.g. 4.50 or 4,50
+func (f Format) GPA(g numeric.GPA) string {
+	return strings.Replace(g.String(), ".", f.DecimalSeparator, 1)
+}
+
+// Grade writes a discrete grade; grades are integers in every format
+func (f Format) Grade(g numeric.Grade) string {
+	return strconv.Itoa(int(g))
+}
+
+// Date writes t in the format's layout, or an empty cell for a missing date
+func (f Format) Date(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(f.DateLayout)
+}
+
+// NewCSVWriter returns a CSV writer using the format's field delimiter
+func (f Format) NewCSVWriter(w io.Writer) *csv.Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = f.CSVDelimiter
+	return cw
+}
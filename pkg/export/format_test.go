@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/numeric"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", ISO.Name, false},
+		{"iso", ISO.Name, false},
+		{"MK", Macedonian.Name, false},
+		{"de", "", true},
+	}
+	for _, tt := range tests {
+		f, err := Lookup(tt.name)
+		if (err != nil) != tt.wantErr || f.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v", tt.name, f.Name, err)
+		}
+	}
+}
+
+func TestFormatsWriteTheSameDataDifferently(t *testing.T) {
+	born := time.Date(2010, time.April, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format    Format
+		wantGPA   string
+		wantGrade string
+		wantDate  string
+		wantCSV   string
+	}{
+		{ISO, "4.50", "5", "2010-04-02", "name,gpa,grade,born\nAna,4.50,5,2010-04-02\n"},
+		{Macedonian, "4,50", "5", "02.04.2010", "name;gpa;grade;born\nAna;4,50;5;02.04.2010\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.Name, func(t *testing.T) {
+			f := tt.format
+			gpa, grade, date := f.GPA(numeric.NewGPA(4.5)), f.Grade(numeric.Grade(5)), f.Date(&born)
+			if gpa != tt.wantGPA || grade != tt.wantGrade || date != tt.wantDate {
+				t.Errorf("GPA, grade, date = %q, %q, %q, want %q, %q, %q", gpa, grade, date, tt.wantGPA, tt.wantGrade, tt.wantDate)
+			}
+			if got := f.Date(nil); got != "" {
+				t.Errorf("missing date = %q, want an empty cell", got)
+			}
+
+			var buf bytes.Buffer
+			cw := f.NewCSVWriter(&buf)
+			cw.Write([]string{"name", "gpa", "grade", "born"})
+			cw.Write([]string{"Ana", gpa, grade, date})
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				t.Fatal(err)
+			}
+			// The decimal comma never needs quoting because mk separates fields with ';'
+			if buf.String() != tt.wantCSV {
+				t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), tt.wantCSV)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrClientStalled is returned by an IdleWriter once the client stops reading
+var ErrClientStalled = errors.New("client stopped reading the export")
+
+// IdleWriter streams an export to a client and gives up on clients that stop
+// reading. Each write must complete within the idle timeout; when one doesn't, the
+// connection's write is aborted and cancel is called, so a query feeding the export
+// releases its cursor and connection instead of waiting on the client.
+type IdleWriter struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	idle   time.Duration
+	cancel context.CancelFunc
+}
+
+// NewIdleWriter wraps w; a non-positive idle disables the timeout
+func NewIdleWriter(w http.ResponseWriter, idle time.Duration, cancel context.CancelFunc) *IdleWriter {
+	return &IdleWriter{w: w, rc: http.NewResponseController(w), idle: idle, cancel: cancel}
+}
+
+// Write writes p and flushes it to the client, so the client's reading paces the
+// export rather than the server's buffers
+func (iw *IdleWriter) Write(p []byte) (int, error) {
+	if iw.idle > 0 {
+		// Unsupported on test recorders; there the export simply has no idle timeout
+		if err := iw.rc.SetWriteDeadline(time.Now().Add(iw.idle)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+	}
+
+	n, err := iw.w.Write(p)
+	if err == nil {
+		err = iw.rc.Flush()
+		if errors.Is(err, http.ErrNotSupported) {
+			err = nil
+		}
+	}
+	if err != nil {
+		iw.cancel()
+		return n, fmt.Errorf("%w: %v", ErrClientStalled, err)
+	}
+	return n, nil
+}
+
+// Close clears the write deadline so it doesn't outlive the export on a kept-alive
+// connection
+func (iw *IdleWriter) Close() error {
+	if iw.idle <= 0 {
+		return nil
+	}
+	if err := iw.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	LivePath  = "/livez"
+	ReadyPath = "/readyz"
+)
+
+// Gate tracks whether a service has finished initializing. The process is live as
+// soon as it serves HTTP, but only ready once migrations, database checks and any
+// warmup have completed.
+type Gate struct {
+	ready atomic.Bool
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// Check reports whether a dependency is healthy; it should return quickly
+type Check func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   Check
+}
+
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// MarkReady flips the gate open
+func (g *Gate) MarkReady() {
+	g.ready.Store(true)
+}
+
+// Ready reports whether initialization has completed
+func (g *Gate) Ready() bool {
+	return g.ready.Load()
+}
+
+// AddCheck registers a dependency probed on every readiness request once the gate is
+// open. A failing check reports the service as degraded but keeps it ready, so an
+// outage of a shared dependency doesn't take every instance out of rotation at once.
+func (g *Gate) AddCheck(name string, fn Check) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checks = append(g.checks, check{name: name, fn: fn})
+}
+
+// failingChecks runs the registered checks and returns the error of each that failed
+func (g *Gate) failingChecks(ctx context.Context) map[string]string {
+	g.mu.RLock()
+	checks := g.checks
+	g.mu.RUnlock()
+
+	failing := map[string]string{}
+	for _, ch := range checks {
+		if err := ch.fn(ctx); err != nil {
+			failing[ch.name] = err.Error()
+		}
+	}
+	return failing
+}
+
+// RegisterRoutes registers the liveness and readiness probes
+func (g *Gate) RegisterRoutes(router gin.IRoutes) {
+	router.GET(LivePath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	})
+	router.GET(ReadyPath, func(c *gin.Context) {
+		if !g.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "initializing"})
+			return
+		}
+		if failing := g.failingChecks(c.Request.Context()); len(failing) > 0 {
+			c.JSON(http.StatusOK, gin.H{"status": "degraded", "failing": failing})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+}
+
+// Middleware rejects application traffic with 503 until the gate is open; probe
+// endpoints are always served
+func (g *Gate) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if g.Ready() {
+			c.Next()
+			return
+		}
+
+		switch c.Request.URL.Path {
+		case LivePath, ReadyPath, "/health":
+			c.Next()
+		default:
+			c.Header("Retry-After", "1")
+			apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeServiceInitializing, "service is initializing"))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newGatedRouter(gate *Gate) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gate.Middleware())
+	gate.RegisterRoutes(router)
+	router.GET("/api/students", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestGateFlipsReadyAfterInitialization(t *testing.T) {
+	gate := NewGate()
+	router := newGatedRouter(gate)
+
+	if w := get(router, LivePath); w.Code != http.StatusOK {
+		t.Errorf("livez during init = %d, want 200", w.Code)
+	}
+	if w := get(router, ReadyPath); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz during init = %d, want 503", w.Code)
+	}
+	w := get(router, "/api/students")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("traffic during init = %d (Retry-After %q), want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	gate.MarkReady()
+
+	if w := get(router, ReadyPath); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ready"`) {
+		t.Errorf("readyz after init = %d %s, want 200 ready", w.Code, w.Body)
+	}
+	if w := get(router, "/api/students"); w.Code != http.StatusOK {
+		t.Errorf("traffic after init = %d, want 200", w.Code)
+	}
+}
+
+func TestGateReportsDegradedButStaysReady(t *testing.T) {
+	gate := NewGate()
+	gate.AddCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	gate.AddCheck("database", func(ctx context.Context) error { return nil })
+	router := newGatedRouter(gate)
+	gate.MarkReady()
+
+	w := get(router, ReadyPath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("readyz with a failing check = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"degraded"`) || !strings.Contains(body, "redis") || strings.Contains(body, "database") {
+		t.Errorf("readyz body = %s, want degraded listing only redis", body)
+	}
+}
@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Header carries the user's resolved locale from the gateway to internal services
+const Header = "X-User-Locale"
+
+// Locales documents are rendered in
+const (
+	Macedonian = "mk"
+	Albanian   = "sq"
+	English    = "en"
+)
+
+// Default is used when nothing the user sent is supported
+const Default = Macedonian
+
+var supported = []string{Macedonian, Albanian, English}
+
+// Match returns the first candidate that names a supported locale. Candidates are
+// language tags such as "sq" or "mk-MK"; only the primary language is considered.
+func Match(candidates ...string) (string, bool) {
+	for _, candidate := range candidates {
+		lang, _, _ := strings.Cut(strings.TrimSpace(candidate), "-")
+		lang, _, _ = strings.Cut(lang, "_")
+		lang = strings.ToLower(lang)
+		if slices.Contains(supported, lang) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// ParseAcceptLanguage returns the tags of an Accept-Language header in order of
+// preference, dropping those with q=0
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, t.tag)
+	}
+	return out
+}
+
+// FromRequest returns the locale for a request: the gateway's X-User-Locale, or for
+// direct calls the Accept-Language header, falling back to Default
+func FromRequest(req *http.Request) string {
+	if locale, ok := Match(req.Header.Get(Header)); ok {
+		return locale
+	}
+	if locale, ok := Match(ParseAcceptLanguage(req.Header.Get("Accept-Language"))...); ok {
+		return locale
+	}
+	return Default
+}
@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{name: "supported tag", candidates: []string{"sq"}, want: Albanian},
+		{name: "region is ignored", candidates: []string{"mk-MK"}, want: Macedonian},
+		{name: "underscore region", candidates: []string{"en_GB"}, want: English},
+		{name: "case-insensitive", candidates: []string{" SQ-al "}, want: Albanian},
+		{name: "first supported wins", candidates: []string{"de", "en", "sq"}, want: English},
+		{name: "none supported", candidates: []string{"de", "fr-FR"}},
+		{name: "no candidates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(tt.candidates...)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("Match(%q) = %q, %v, want %q", tt.candidates, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "sq", want: []string{"sq"}},
+		{header: "en;q=0.5, mk-MK, sq;q=0.8", want: []string{"mk-MK", "sq", "en"}},
+		{header: "de, en;q=0.9, *;q=0.1", want: []string{"de", "en"}},
+		// Refused and malformed entries are dropped
+		{header: "mk;q=0, en;q=high, sq", want: []string{"sq"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		userLocale     string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "gateway locale", userLocale: "sq", acceptLanguage: "en", want: Albanian},
+		{name: "direct call", acceptLanguage: "de, en;q=0.8", want: English},
+		{name: "unsupported gateway locale", userLocale: "de", acceptLanguage: "en", want: English},
+		{name: "nothing supported", acceptLanguage: "de", want: Default},
+		{name: "no headers", want: Default},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/render/transcript", nil)
+			if tt.userLocale != "" {
+				req.Header.Set(Header, tt.userLocale)
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := FromRequest(req); got != tt.want {
+				t.Errorf("FromRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClaimsCacheSize bounds how many validated tokens are remembered
+	DefaultClaimsCacheSize = 1024
+	// DefaultClaimsCacheTTL is how long a validated token skips signature verification
+	DefaultClaimsCacheTTL = 30 * time.Second
+)
+
+// claimsCache remembers recently validated tokens by the SHA-256 of the token string,
+// so the raw token is never kept in memory
+type claimsCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]cachedClaims
+	now     func() time.Time
+}
+
+type cachedClaims struct {
+	claims    *KeycloakClaims
+	expiresAt time.Time
+}
+
+func newClaimsCache(size int, ttl time.Duration) *claimsCache {
+	return &claimsCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]cachedClaims),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached claims of token if they are still fresh
+func (c *claimsCache) get(token string) (*KeycloakClaims, bool) {
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	claims := *entry.claims
+	return &claims, true
+}
+
+// put caches claims until the cache TTL passes or the token expires, whichever is first
+func (c *claimsCache) put(token string, claims *KeycloakClaims) {
+	now := c.now()
+	expiresAt := now.Add(c.ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	stored := *claims
+	c.entries[sha256.Sum256([]byte(token))] = cachedClaims{claims: &stored, expiresAt: expiresAt}
+}
+
+// evict drops expired entries, or an arbitrary one if none have expired
+func (c *claimsCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < c.size {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// forgetKeys drops the validator's signing keys and empties the JWKS, so any token
+// that needs its signature verified again fails
+func forgetKeys(v *Validator, server *jwksServer) {
+	server.keys.Store([]JWK{})
+	v.mu.Lock()
+	v.keys = map[string]crypto.PublicKey{}
+	v.mu.Unlock()
+}
+
+func TestClaimsCacheHitSkipsSignatureVerification(t *testing.T) {
+	ctx := context.Background()
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web")
+
+	token := signToken(t, testKid, validClaims(server))
+	first, err := v.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	forgetKeys(v, server)
+	second, err := v.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken of a cached token verified the signature again: %v", err)
+	}
+	if second.Subject != first.Subject || second == first {
+		t.Errorf("cache returned %+v after %+v, want an equal copy", second, first)
+	}
+
+	other := validClaims(server)
+	other.ID = "token-2"
+	if _, err := v.ValidateToken(ctx, signToken(t, testKid, other)); err == nil {
+		t.Error("uncached token validated without signing keys")
+	}
+}
+
+func TestClaimsCacheStillRejectsExpiredAndRevokedTokens(t *testing.T) {
+	ctx := context.Background()
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	checker := &fakeRevocationChecker{revoked: map[string]bool{}}
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithRevocationChecker(checker, false))
+
+	// The cache believes it is an hour ago, so the entry outlives the token's expiry
+	v.claimsCache.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired := validClaims(server)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	expiredToken := signToken(t, testKid, expired)
+	v.claimsCache.put(expiredToken, expired)
+
+	if _, err := v.ValidateToken(ctx, expiredToken); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("cached expired token = %v, want ErrTokenExpired", err)
+	}
+
+	token := signToken(t, testKid, validClaims(server))
+	if _, err := v.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	checker.revoked["token-1"] = true
+	if _, err := v.ValidateToken(ctx, token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("cached revoked token = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestClaimsCacheExpiry(t *testing.T) {
+	now := time.Now()
+	cache := newClaimsCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	long := &KeycloakClaims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}}
+	short := &KeycloakClaims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Second))}}
+	cache.put("long", long)
+	cache.put("short", short)
+	cache.put("expired", &KeycloakClaims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now)}})
+
+	if _, ok := cache.get("expired"); ok {
+		t.Error("an already expired token was cached")
+	}
+
+	now = now.Add(10 * time.Second)
+	if _, ok := cache.get("short"); ok {
+		t.Error("entry outlived its token's expiry")
+	}
+	if _, ok := cache.get("long"); !ok {
+		t.Error("entry expired before the cache TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("long"); ok {
+		t.Error("entry outlived the cache TTL")
+	}
+}
+
+func TestClaimsCacheIsBounded(t *testing.T) {
+	cache := newClaimsCache(3, time.Minute)
+	claims := &KeycloakClaims{}
+	for _, token := range []string{"a", "b", "c", "d", "e"} {
+		cache.put(token, claims)
+	}
+	if len(cache.entries) > 3 {
+		t.Errorf("cache holds %d entries, want at most 3", len(cache.entries))
+	}
+	if _, ok := cache.get("e"); !ok {
+		t.Error("most recent entry was evicted")
+	}
+}
+
+func TestWithClaimsCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, time.Minute))
+
+	token := signToken(t, testKid, validClaims(server))
+	if _, err := v.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	forgetKeys(v, server)
+	if _, err := v.ValidateToken(ctx, token); err == nil {
+		t.Error("token validated without signing keys while the cache is disabled")
+	}
+}
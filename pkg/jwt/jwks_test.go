@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPagedJWKSServer serves each page at /page/<name>; next maps a page to the page
+// it links to and linkHeader selects a Link header over the body's next field
+func newPagedJWKSServer(t *testing.T, pages map[string][]JWK, next map[string]string, linkHeader bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/page/")
+		keys, ok := pages[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		resp := JWKSResponse{Keys: keys}
+		if target, ok := next[name]; ok {
+			if linkHeader {
+				w.Header().Set("Link", `</page/`+target+`>; rel="next"`)
+			} else {
+				resp.Next = "/page/" + target
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchKeysFollowsPagination(t *testing.T) {
+	secondKey := mustRSAKey()
+	pages := map[string][]JWK{
+		"1": {rsaJWK("kid-1", &testKey.PublicKey)},
+		"2": {rsaJWK("kid-2", &secondKey.PublicKey)},
+	}
+
+	for _, linkHeader := range []bool{false, true} {
+		name := "next field"
+		if linkHeader {
+			name = "link header"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := newPagedJWKSServer(t, pages, map[string]string{"1": "2"}, linkHeader)
+			v := NewValidator(server.URL+"/page/1", testRealm, "svedprint-web")
+
+			if err := v.fetchKeys(context.Background()); err != nil {
+				t.Fatalf("fetchKeys: %v", err)
+			}
+			for _, kid := range []string{"kid-1", "kid-2"} {
+				if _, ok := v.keys[kid]; !ok {
+					t.Errorf("key %s from a JWKS page is missing", kid)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchKeysPaginationLimits(t *testing.T) {
+	key := []JWK{rsaJWK(testKid, &testKey.PublicKey)}
+	tests := []struct {
+		name     string
+		pages    map[string][]JWK
+		next     map[string]string
+		maxPages int
+		wantErr  string
+	}{
+		{"loop", map[string][]JWK{"1": key, "2": key}, map[string]string{"1": "2", "2": "1"}, 10, "loops back"},
+		{"too many pages", map[string][]JWK{"1": key, "2": key, "3": key}, map[string]string{"1": "2", "2": "3"}, 2, "exceeded 2 pages"},
+		{"missing page", map[string][]JWK{"1": key}, map[string]string{"1": "2"}, 10, "status 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newPagedJWKSServer(t, tt.pages, tt.next, false)
+			v := NewValidator(server.URL+"/page/1", testRealm, "svedprint-web", WithMaxJWKSPages(tt.maxPages))
+			v.keys = map[string]crypto.PublicKey{"previous": &testKey.PublicKey}
+
+			err := v.fetchKeys(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("fetchKeys = %v, want an error containing %q", err, tt.wantErr)
+			}
+			// A failed pagination keeps the previous keys rather than a partial set
+			if _, ok := v.keys["previous"]; !ok || len(v.keys) != 1 {
+				t.Errorf("keys replaced after a failed fetch: %v", v.keys)
+			}
+		})
+	}
+}
+
+func TestFetchKeysSinglePage(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web")
+
+	if err := v.fetchKeys(context.Background()); err != nil {
+		t.Fatalf("fetchKeys: %v", err)
+	}
+	if len(v.keys) != 1 || server.fetches.Load() != 1 {
+		t.Errorf("%d keys after %d fetches, want 1 and 1", len(v.keys), server.fetches.Load())
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		headers []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{`<https://idp/jwks?page=2>; rel="next"`}, "https://idp/jwks?page=2"},
+		{[]string{`<https://idp/jwks?page=1>; rel="prev", <https://idp/jwks?page=3>; rel=next`}, "https://idp/jwks?page=3"},
+		{[]string{`<https://idp/jwks?page=1>; rel="prev"`}, ""},
+	}
+	for _, tt := range tests {
+		if got := nextLink(tt.headers); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.headers, got, tt.want)
+		}
+	}
+}
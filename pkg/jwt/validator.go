@@ -2,23 +2,74 @@ package jwt
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
 // JWKSResponse represents the JWKS endpoint response
 type JWKSResponse struct {
 	Keys []JWK `json:"keys"`
+	// Next links to the following page for providers that paginate their JWKS
+	Next string `json:"next,omitempty"`
+}
+
+// DefaultMaxJWKSPages bounds how many JWKS pages are followed, guarding against link loops
+const DefaultMaxJWKSPages = 10
+
+// DefaultFetchTimeout bounds each JWKS fetch, tighter than the HTTP client timeout
+const DefaultFetchTimeout = 3 * time.Second
+
+// DefaultWarmupPolicy retries the startup key fetch a few times before giving up
+var DefaultWarmupPolicy = retry.Policy{MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+// DefaultValidMethods are the signing algorithms accepted unless WithValidMethods pins
+// fewer
+var DefaultValidMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// ErrTokenExpired is wrapped by ValidateToken errors for tokens past their expiry
+var ErrTokenExpired = jwt.ErrTokenExpired
+
+// Claims is implemented by custom claims structs passed to ValidateTokenInto, usually
+// by embedding RegisteredClaims
+type Claims = jwt.Claims
+
+// RegisteredClaims are the standard claims every token carries
+type RegisteredClaims = jwt.RegisteredClaims
+
+// ErrTokenRevoked is returned by ValidateToken for revoked tokens and sessions
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationChecker reports whether the token or session with the given ID (jti or sid
+// claim) has been revoked, e.g. from a deny list shared by every replica
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// revocationCheck runs a RevocationChecker against one claim of the token
+type revocationCheck struct {
+	checker    RevocationChecker
+	subject    string
+	id         func(claims *KeycloakClaims) string
+	failClosed bool
 }
 
 // JWK represents a JSON Web Key
@@ -29,6 +80,9 @@ type JWK struct {
 	Use string `json:"use"`
 	N   string `json:"n"`
 	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
 }
 
 // KeycloakClaims represents the JWT claims from Keycloak
@@ -41,6 +95,15 @@ type KeycloakClaims struct {
 	FamilyName    string                 `json:"family_name"`
 	RealmAccess   map[string]interface{} `json:"realm_access"`
 	ResourceAccess map[string]interface{} `json:"resource_access"`
+	// SessionID identifies the Keycloak login session the token was issued for
+	SessionID string `json:"sid"`
+	// Locale is the user's preferred language, set when the realm has internationalization enabled
+	Locale string `json:"locale"`
+	// Scope lists the granted OAuth scopes, space-delimited
+	Scope string `json:"scope"`
+	// Tenant is the school the user belongs to, i.e. its Postgres schema, mapped into
+	// the token from a Keycloak user attribute in multi-tenant deployments
+	Tenant string `json:"tenant"`
 }
 
 // Validator handles JWT validation
@@ -48,38 +111,302 @@ type Validator struct {
 	jwksURL    string
 	realm      string
 	clientID   string
-	keys       map[string]*rsa.PublicKey
+	keys       map[string]crypto.PublicKey
 	mu         sync.RWMutex
 	lastFetch  time.Time
+	refreshing singleflight.Group
 	httpClient *http.Client
+	maxPages   int
+
+	fetchTimeout time.Duration
+	warmupPolicy retry.Policy
+
+	claimsCache      *claimsCache
+	revocationChecks []revocationCheck
+
+	validMethods []string
+	audiences    []string
+	leeway       time.Duration
+}
+
+// Option configures optional Validator behaviour
+type Option func(*Validator)
+
+// WithHTTPClient replaces the client used to fetch the JWKS, e.g. to point at a test
+// server or to add tracing/metrics transports
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Validator) {
+		v.httpClient = client
+	}
+}
+
+// WithMaxJWKSPages limits how many pages of a paginated JWKS are fetched
+func WithMaxJWKSPages(n int) Option {
+	return func(v *Validator) {
+		v.maxPages = n
+	}
+}
+
+// WithFetchTimeout bounds each JWKS fetch, including every Warmup attempt and the
+// shared fetch behind concurrent refreshes.
+func WithFetchTimeout(d time.Duration) Option {
+	return func(v *Validator) {
+		v.fetchTimeout = d
+	}
+}
+
+// WithWarmupPolicy sets how the startup key fetch is retried
+func WithWarmupPolicy(policy retry.Policy) Option {
+	return func(v *Validator) {
+		v.warmupPolicy = policy
+	}
+}
+
+// WithClaimsCache remembers up to size validated tokens for at most ttl (never past
+// their expiry), so validating the same token again skips parsing and signature
+// verification. A non-positive size disables the cache.
+func WithClaimsCache(size int, ttl time.Duration) Option {
+	return func(v *Validator) {
+		v.claimsCache = nil
+		if size > 0 {
+			v.claimsCache = newClaimsCache(size, ttl)
+		}
+	}
+}
+
+// WithRevocationChecker rejects tokens whose ID checker reports as revoked, after the
+// signature is verified and on every cache hit. When checker fails, failClosed rejects
+// the token; otherwise it is let through so an outage doesn't lock every user out.
+func WithRevocationChecker(checker RevocationChecker, failClosed bool) Option {
+	return func(v *Validator) {
+		v.revocationChecks = append(v.revocationChecks, revocationCheck{
+			checker:    checker,
+			subject:    "token",
+			id:         func(claims *KeycloakClaims) string { return claims.ID },
+			failClosed: failClosed,
+		})
+	}
+}
+
+// WithSessionRevocationChecker is WithRevocationChecker for the token's session (sid
+// claim), rejecting every token of a revoked session
+func WithSessionRevocationChecker(checker RevocationChecker, failClosed bool) Option {
+	return func(v *Validator) {
+		v.revocationChecks = append(v.revocationChecks, revocationCheck{
+			checker:    checker,
+			subject:    "session",
+			id:         func(claims *KeycloakClaims) string { return claims.SessionID },
+			failClosed: failClosed,
+		})
+	}
+}
+
+// WithValidMethods pins the signing algorithms (alg header) a token may use, e.g.
+// only RS256. "none" is never accepted, even if listed; an empty list keeps
+// DefaultValidMethods.
+func WithValidMethods(methods ...string) Option {
+	return func(v *Validator) {
+		var pinned []string
+		for _, method := range methods {
+			method = strings.TrimSpace(method)
+			if method != "" && !strings.EqualFold(method, "none") {
+				pinned = append(pinned, method)
+			}
+		}
+		if len(pinned) > 0 {
+			v.validMethods = pinned
+		}
+	}
+}
+
+// WithAudiences rejects tokens whose aud claim names none of audiences, for realms
+// shared by several clients. Without audiences any client's token is accepted.
+func WithAudiences(audiences ...string) Option {
+	return func(v *Validator) {
+		v.audiences = nil
+		for _, audience := range audiences {
+			if audience = strings.TrimSpace(audience); audience != "" {
+				v.audiences = append(v.audiences, audience)
+			}
+		}
+	}
+}
+
+// WithLeeway tolerates clock skew between Keycloak and this host by accepting tokens
+// up to leeway past their exp, and before their nbf and iat. Zero keeps exact checks.
+func WithLeeway(leeway time.Duration) Option {
+	return func(v *Validator) {
+		v.leeway = max(leeway, 0)
+	}
 }
 
 // NewValidator creates a new JWT validator
-func NewValidator(jwksURL, realm, clientID string) *Validator {
-	return &Validator{
+func NewValidator(jwksURL, realm, clientID string, opts ...Option) *Validator {
+	v := &Validator{
 		jwksURL:  jwksURL,
 		realm:    realm,
 		clientID: clientID,
-		keys:     make(map[string]*rsa.PublicKey),
+		keys:     make(map[string]crypto.PublicKey),
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		maxPages:     DefaultMaxJWKSPages,
+		fetchTimeout: DefaultFetchTimeout,
+		warmupPolicy: DefaultWarmupPolicy,
+		claimsCache:  newClaimsCache(DefaultClaimsCacheSize, DefaultClaimsCacheTTL),
+		validMethods: DefaultValidMethods,
 	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// Warmup loads the signing keys before the first request is served. Every attempt is
+// bounded by the fetch timeout and failures are retried under the warmup policy.
+func (v *Validator) Warmup(ctx context.Context) error {
+	err := retry.Do(ctx, v.warmupPolicy, func(error) bool { return ctx.Err() == nil }, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, v.fetchTimeout)
+		defer cancel()
+		return v.refreshKeys(attemptCtx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to warm up JWKS keys: %w", err)
+	}
+	return nil
+}
+
+// StartBackgroundRefresh refreshes the signing keys every interval until ctx is done,
+// so requests are not the ones paying for the JWKS round-trip. Validation keeps using
+// the last fetched keys while a refresh runs or after one fails, and still refreshes
+// lazily if the keys go stale. A non-positive interval does nothing.
+func (v *Validator) StartBackgroundRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := v.refreshKeys(ctx); err != nil && ctx.Err() == nil {
+					log.Warn().Err(err).Msg("Background JWKS refresh failed, keeping previous keys")
+				}
+			}
+		}
+	}()
+}
+
+// ValidateToken validates a JWT token and returns the claims. Recently validated
+// tokens are served from the claims cache, still checking expiry and revocation.
 func (v *Validator) ValidateToken(ctx context.Context, tokenString string) (*KeycloakClaims, error) {
+	if v.claimsCache != nil {
+		if claims, ok := v.claimsCache.get(tokenString); ok {
+			if err := v.checkCachedClaims(ctx, claims); err != nil {
+				return nil, err
+			}
+			return claims, nil
+		}
+	}
+
+	claims, err := v.parseToken(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkRevocation(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	if v.claimsCache != nil {
+		v.claimsCache.put(tokenString, claims)
+	}
+	return claims, nil
+}
+
+// ValidateTokenInto validates a JWT token like ValidateToken but decodes its claims
+// into claims, a pointer to the caller's own struct, for services that read claims
+// KeycloakClaims doesn't know about. Revocation is still checked; the claims cache is
+// not used.
+func (v *Validator) ValidateTokenInto(ctx context.Context, tokenString string, claims Claims) error {
+	if err := v.parseInto(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	if len(v.revocationChecks) == 0 {
+		return nil
+	}
+
+	// The signature is verified, so the revocation claims can be read without checking it again
+	var keycloakClaims KeycloakClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &keycloakClaims); err != nil {
+		return fmt.Errorf("token validation failed: %w", err)
+	}
+	return v.checkRevocation(ctx, &keycloakClaims)
+}
+
+// checkCachedClaims repeats the time-dependent checks that caching would otherwise skip
+func (v *Validator) checkCachedClaims(ctx context.Context, claims *KeycloakClaims) error {
+	if claims.ExpiresAt != nil && !time.Now().Before(claims.ExpiresAt.Add(v.leeway)) {
+		return fmt.Errorf("token validation failed: %w", jwt.ErrTokenExpired)
+	}
+	return v.checkRevocation(ctx, claims)
+}
+
+// checkRevocation runs every revocation checker whose claim the token carries
+func (v *Validator) checkRevocation(ctx context.Context, claims *KeycloakClaims) error {
+	for _, check := range v.revocationChecks {
+		id := check.id(claims)
+		if id == "" {
+			continue
+		}
+
+		revoked, err := check.checker.IsRevoked(ctx, id)
+		if err != nil {
+			if check.failClosed {
+				return fmt.Errorf("failed to check %s revocation: %w", check.subject, err)
+			}
+			log.Warn().Err(err).Msgf("Failed to check %s revocation, accepting token", check.subject)
+			continue
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// parseToken fully parses the token and verifies its signature and issuer
+func (v *Validator) parseToken(ctx context.Context, tokenString string) (*KeycloakClaims, error) {
+	claims := &KeycloakClaims{}
+	if err := v.parseInto(ctx, tokenString, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseInto parses the token into claims and verifies its signature, issuer and audience
+func (v *Validator) parseInto(ctx context.Context, tokenString string, claims Claims) error {
 	// Refresh keys if needed (cache for 1 hour)
-	if time.Since(v.lastFetch) > 1*time.Hour {
+	v.mu.RLock()
+	lastFetch := v.lastFetch
+	v.mu.RUnlock()
+	if time.Since(lastFetch) > 1*time.Hour {
 		if err := v.refreshKeys(ctx); err != nil {
-			return nil, fmt.Errorf("failed to refresh JWKS keys: %w", err)
+			return fmt.Errorf("failed to refresh JWKS keys: %w", err)
 		}
 	}
 
 	// Parse and validate the token
-	token, err := jwt.ParseWithClaims(tokenString, &KeycloakClaims{}, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
@@ -110,76 +437,183 @@ func (v *Validator) ValidateToken(ctx context.Context, tokenString string) (*Key
 		}
 
 		return key, nil
-	})
+	}, jwt.WithValidMethods(v.validMethods), jwt.WithLeeway(v.leeway))
 
 	if err != nil {
-		return nil, fmt.Errorf("token validation failed: %w", err)
+		return fmt.Errorf("token validation failed: %w", err)
 	}
-
-	claims, ok := token.Claims.(*KeycloakClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token claims")
+	if !token.Valid {
+		return errors.New("invalid token claims")
 	}
 
 	// Validate issuer
 	expectedIssuer := fmt.Sprintf("%s/realms/%s", v.jwksURL[:len(v.jwksURL)-len("/protocol/openid-connect/certs")], v.realm)
-	if claims.Issuer != expectedIssuer {
-		return nil, fmt.Errorf("invalid issuer: expected %s, got %s", expectedIssuer, claims.Issuer)
+	issuer, err := claims.GetIssuer()
+	if err != nil || issuer != expectedIssuer {
+		return fmt.Errorf("invalid issuer: expected %s, got %s", expectedIssuer, issuer)
 	}
 
-	// Validate audience (optional, depending on your Keycloak setup)
-	// Uncomment if you want strict audience validation
-	// if !claims.VerifyAudience(v.clientID, true) {
-	// 	return nil, errors.New("invalid audience")
-	// }
+	audience, err := claims.GetAudience()
+	if err != nil || !v.audienceAllowed(audience) {
+		return fmt.Errorf("invalid audience: expected one of %v, got %v", v.audiences, audience)
+	}
 
-	return claims, nil
+	return nil
 }
 
-// refreshKeys fetches and caches the public keys from Keycloak
+// audienceAllowed reports whether the token was issued for one of the configured
+// audiences; every token is allowed when none are configured
+func (v *Validator) audienceAllowed(audience jwt.ClaimStrings) bool {
+	if len(v.audiences) == 0 {
+		return true
+	}
+	for _, aud := range audience {
+		if slices.Contains(v.audiences, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshKeys fetches and caches the public keys from Keycloak. Concurrent refreshes,
+// e.g. from every request carrying a freshly rotated kid, share a single fetch and its
+// outcome. The fetch is detached from the caller that started it and bounded by the
+// fetch timeout, so one cancelled request doesn't fail the others; each caller stops
+// waiting when its own ctx is done. A failed fetch is not remembered, so the next
+// call tries again.
 func (v *Validator) refreshKeys(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	result := v.refreshing.DoChan("jwks", func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), v.fetchTimeout)
+		defer cancel()
+		return nil, v.fetchKeys(fetchCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-result:
+		return res.Err
+	}
+}
+
+// fetchKeys downloads the JWKS and replaces the cached keys. Paginated JWKS endpoints
+// are followed via their next link and all pages are collected before the cached keys
+// are replaced.
+func (v *Validator) fetchKeys(ctx context.Context) error {
+	newKeys := make(map[string]crypto.PublicKey)
+	visited := make(map[string]bool)
+	var convertErr error
+	skipped := 0
+
+	pageURL := v.jwksURL
+	for page := 1; pageURL != ""; page++ {
+		if page > v.maxPages {
+			return fmt.Errorf("JWKS pagination exceeded %d pages", v.maxPages)
+		}
+		if visited[pageURL] {
+			return fmt.Errorf("JWKS pagination loops back to %s", pageURL)
+		}
+		visited[pageURL] = true
+
+		jwks, next, err := v.fetchKeysPage(ctx, pageURL)
+		if err != nil {
+			return err
+		}
+
+		// Convert JWKs to public keys, skipping key types we cannot verify with
+		for _, jwk := range jwks.Keys {
+			var key crypto.PublicKey
+			var err error
+			switch jwk.Kty {
+			case "RSA":
+				key, err = jwkToRSAPublicKey(jwk)
+			case "EC":
+				key, err = jwkToECDSAPublicKey(jwk)
+			default:
+				continue
+			}
+			if err != nil {
+				// One malformed key must not take the provider's other keys down with it
+				convertErr = fmt.Errorf("failed to convert %s JWK %s to a public key: %w", jwk.Kty, jwk.Kid, err)
+				skipped++
+				log.Warn().Err(err).Str("kid", jwk.Kid).Str("kty", jwk.Kty).Msg("Skipping malformed JWK")
+				continue
+			}
+
+			newKeys[jwk.Kid] = key
+		}
+
+		pageURL = next
+	}
+
+	if len(newKeys) == 0 && convertErr != nil {
+		return fmt.Errorf("no usable keys in JWKS, %d malformed: %w", skipped, convertErr)
+	}
+
+	v.mu.Lock()
+	v.keys = newKeys
+	v.lastFetch = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// fetchKeysPage fetches a single JWKS page and resolves the link to the next page,
+// taken from the body's next field or a Link: <...>; rel="next" header
+func (v *Validator) fetchKeysPage(ctx context.Context, pageURL string) (*JWKSResponse, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := v.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch JWKS: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("JWKS endpoint returned status %d: %s", resp.StatusCode, string(body))
+		return nil, "", fmt.Errorf("JWKS endpoint returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var jwks JWKSResponse
 	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
-		return fmt.Errorf("failed to decode JWKS response: %w", err)
+		return nil, "", fmt.Errorf("failed to decode JWKS response: %w", err)
 	}
 
-	// Convert JWKs to RSA public keys
-	newKeys := make(map[string]*rsa.PublicKey)
-	for _, jwk := range jwks.Keys {
-		if jwk.Kty != "RSA" {
-			continue
-		}
-
-		key, err := jwkToRSAPublicKey(jwk)
-		if err != nil {
-			return fmt.Errorf("failed to convert JWK to RSA public key: %w", err)
-		}
-
-		newKeys[jwk.Kid] = key
+	next := jwks.Next
+	if next == "" {
+		next = nextLink(resp.Header.Values("Link"))
+	}
+	if next == "" {
+		return &jwks, "", nil
 	}
 
-	v.mu.Lock()
-	v.keys = newKeys
-	v.lastFetch = time.Now()
-	v.mu.Unlock()
+	nextURL, err := resp.Request.URL.Parse(next)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid JWKS next link %q: %w", next, err)
+	}
+	return &jwks, nextURL.String(), nil
+}
 
-	return nil
+// nextLink extracts the rel="next" target from RFC 8288 Link headers
+func nextLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") && strings.Trim(value, `"`) == "next" {
+					return strings.Trim(strings.TrimSpace(target), "<>")
+				}
+			}
+		}
+	}
+	return ""
 }
 
 // jwkToRSAPublicKey converts a JWK to an RSA public key
@@ -200,12 +634,49 @@ func jwkToRSAPublicKey(jwk JWK) (*rsa.PublicKey, error) {
 	n := new(big.Int).SetBytes(nBytes)
 	e := new(big.Int).SetBytes(eBytes)
 
+	// rsa.PublicKey.E is an int, and crypto/rsa itself rejects exponents past 2^31-1
+	if e.Sign() <= 0 || !e.IsInt64() || e.Int64() > math.MaxInt32 {
+		return nil, fmt.Errorf("unsupported exponent of %d bytes", len(eBytes))
+	}
+
 	return &rsa.PublicKey{
 		N: n,
 		E: int(e.Int64()),
 	}, nil
 }
 
+// jwkToECDSAPublicKey converts an EC JWK to an ECDSA public key
+func jwkToECDSAPublicKey(jwk JWK) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode x coordinate: %w", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode y coordinate: %w", err)
+	}
+
+	x := new(big.Int).SetBytes(xBytes)
+	y := new(big.Int).SetBytes(yBytes)
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", jwk.Crv)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
 // GetUserID extracts the user ID from claims
 func (c *KeycloakClaims) GetUserID() string {
 	return c.Subject
@@ -240,3 +711,49 @@ func (c *KeycloakClaims) HasRealmRole(role string) bool {
 
 	return false
 }
+
+// Scopes lists the OAuth scopes granted to the token
+func (c *KeycloakClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope checks if the token was granted a specific scope
+func (c *KeycloakClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasResourceRole checks if the user has a specific role of a client (resource)
+func (c *KeycloakClaims) HasResourceRole(client, role string) bool {
+	for _, r := range c.ResourceRoles(client) {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceRoles lists the roles the user has for a client (resource)
+func (c *KeycloakClaims) ResourceRoles(client string) []string {
+	if c.ResourceAccess == nil {
+		return nil
+	}
+
+	access, ok := c.ResourceAccess[client].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	roles, ok := access["roles"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(roles))
+	for _, r := range roles {
+		if roleStr, ok := r.(string); ok {
+			result = append(result, roleStr)
+		}
+	}
+
+	return result
+}
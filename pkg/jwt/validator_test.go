@@ -0,0 +1,1029 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testRealm    = "svedprint"
+	testKid      = "test-key"
+	testJWKSPath = "/protocol/openid-connect/certs"
+)
+
+// testKey is shared by the tests; generating RSA keys is slow
+var testKey = mustRSAKey()
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// jwksServer serves its keys at testJWKSPath and counts the fetches
+type jwksServer struct {
+	*httptest.Server
+	keys    atomic.Value
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...JWK) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.keys.Store(keys)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != testJWKSPath {
+			http.NotFound(w, r)
+			return
+		}
+		s.fetches.Add(1)
+		json.NewEncoder(w).Encode(JWKSResponse{Keys: s.keys.Load().([]JWK)})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) jwksURL() string {
+	return s.URL + testJWKSPath
+}
+
+// issuer is the iss a token must carry for a validator of this server's JWKS
+func (s *jwksServer) issuer() string {
+	return s.URL + "/realms/" + testRealm
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kid: kid,
+		Kty: "RSA",
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// validClaims are claims that pass validation against server
+func validClaims(server *jwksServer) *KeycloakClaims {
+	now := time.Now()
+	return &KeycloakClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    server.issuer(),
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{"svedprint-web"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			ID:        "token-1",
+		},
+		Tenant: "school_a",
+	}
+}
+
+func signToken(t *testing.T, kid string, claims jwt.Claims) string {
+	t.Helper()
+	return signTokenWith(t, jwt.SigningMethodRS256, testKey, kid, claims)
+}
+
+// signTokenWith signs claims with an arbitrary method and key
+func signTokenWith(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// countingTransport counts the requests made through it
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (rt *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClientIsUsedForJWKSFetch(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	transport := &countingTransport{}
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithHTTPClient(&http.Client{Transport: transport}))
+
+	claims, err := v.ValidateToken(context.Background(), signToken(t, testKid, validClaims(server)))
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("subject = %q", claims.Subject)
+	}
+	if got := transport.requests.Load(); got != 1 {
+		t.Errorf("custom client made %d requests, want 1", got)
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+}
+
+func TestWarmupBoundsAndRetriesSlowFetch(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web",
+		WithFetchTimeout(50*time.Millisecond),
+		WithWarmupPolicy(retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	start := time.Now()
+	err := v.Warmup(context.Background())
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Warmup = %v, want a deadline error", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("Warmup made %d attempts, want 3", got)
+	}
+	// Three attempts bounded by 50ms each, far below the client's 10s timeout
+	if elapsed > 2*time.Second {
+		t.Errorf("Warmup took %s", elapsed)
+	}
+}
+
+func TestWarmupRecoversAfterTransientFailure(t *testing.T) {
+	var attempts atomic.Int32
+	keys := JWKSResponse{Keys: []JWK{rsaJWK(testKid, &testKey.PublicKey)}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer server.Close()
+
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web",
+		WithWarmupPolicy(retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if err := v.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if attempts.Load() != 2 || len(v.keys) != 1 {
+		t.Errorf("%d attempts and %d keys, want 2 and 1", attempts.Load(), len(v.keys))
+	}
+}
+
+func TestWarmupStopsWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web",
+		WithWarmupPolicy(retry.Policy{MaxAttempts: 5, BaseDelay: time.Hour}))
+	if err := v.Warmup(ctx); err == nil {
+		t.Error("Warmup succeeded with a cancelled context")
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (v *Validator) hasKey(kid string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.keys[kid]
+	return ok
+}
+
+func TestStartBackgroundRefreshPicksUpRotatedKeys(t *testing.T) {
+	rotated := mustRSAKey()
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0))
+	if err := v.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.keys.Store([]JWK{rsaJWK(testKid, &testKey.PublicKey), rsaJWK("rotated", &rotated.PublicKey)})
+	v.StartBackgroundRefresh(ctx, 10*time.Millisecond)
+
+	// No token is validated until the refresh has already happened
+	waitFor(t, "the rotated key", func() bool { return v.hasKey("rotated") })
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+
+	fetches := server.fetches.Load()
+	token := signTokenWith(t, jwt.SigningMethodRS256, rotated, "rotated", validClaims(server))
+	if _, err := v.ValidateToken(context.Background(), token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if got := server.fetches.Load(); got != fetches {
+		t.Errorf("validation fetched the JWKS %d times, want the key already loaded", got-fetches)
+	}
+}
+
+func TestStartBackgroundRefreshKeepsKeysOnFailure(t *testing.T) {
+	var failing atomic.Bool
+	var fetches atomic.Int32
+	keys := JWKSResponse{Keys: []JWK{rsaJWK(testKid, &testKey.PublicKey)}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer server.Close()
+
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web", WithClaimsCache(0, 0))
+	if err := v.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failing.Store(true)
+	v.StartBackgroundRefresh(ctx, 10*time.Millisecond)
+	waitFor(t, "failed refreshes", func() bool { return fetches.Load() >= 3 })
+
+	claims := validClaims(&jwksServer{Server: server})
+	if _, err := v.ValidateToken(context.Background(), signToken(t, testKid, claims)); err != nil {
+		t.Errorf("ValidateToken after failed refreshes: %v", err)
+	}
+}
+
+func TestStartBackgroundRefreshStopsWithContext(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.StartBackgroundRefresh(ctx, 10*time.Millisecond)
+	waitFor(t, "a background fetch", func() bool { return server.fetches.Load() >= 1 })
+	cancel()
+
+	// Let a refresh that was already running finish
+	time.Sleep(30 * time.Millisecond)
+	stopped := server.fetches.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := server.fetches.Load(); got != stopped {
+		t.Errorf("%d fetches after the context was cancelled", got-stopped)
+	}
+}
+
+func TestStartBackgroundRefreshIgnoresNonPositiveInterval(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v.StartBackgroundRefresh(ctx, 0)
+	time.Sleep(30 * time.Millisecond)
+	if got := server.fetches.Load(); got != 0 {
+		t.Errorf("JWKS fetched %d times, want none", got)
+	}
+}
+
+// gatedJWKSServer holds every JWKS request until released, so concurrent refreshes
+// are guaranteed to overlap
+type gatedJWKSServer struct {
+	*httptest.Server
+	keys     atomic.Value
+	failing  atomic.Bool
+	fetches  atomic.Int32
+	arrived  chan struct{}
+	released chan struct{}
+}
+
+func newGatedJWKSServer(t *testing.T, keys ...JWK) *gatedJWKSServer {
+	t.Helper()
+	s := &gatedJWKSServer{arrived: make(chan struct{}, 100), released: make(chan struct{})}
+	s.keys.Store(keys)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.arrived <- struct{}{}
+		<-s.released
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(JWKSResponse{Keys: s.keys.Load().([]JWK)})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// validateConcurrently validates token from n goroutines while the server holds the
+// first fetch, releasing it once the others had time to pile up behind it
+func validateConcurrently(t *testing.T, v *Validator, server *gatedJWKSServer, token string, n int) []error {
+	t.Helper()
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = v.ValidateToken(context.Background(), token)
+		}()
+	}
+
+	select {
+	case <-server.arrived:
+	case <-time.After(time.Second):
+		t.Fatal("no JWKS fetch was made")
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(server.released)
+	wg.Wait()
+	return errs
+}
+
+func TestRefreshKeysCollapsesConcurrentFetches(t *testing.T) {
+	rotated := mustRSAKey()
+	server := newGatedJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey), rsaJWK("rotated", &rotated.PublicKey))
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web", WithClaimsCache(0, 0))
+
+	claims := validClaims(&jwksServer{Server: server.Server})
+	token := signTokenWith(t, jwt.SigningMethodRS256, rotated, "rotated", claims)
+	for i, err := range validateConcurrently(t, v, server, token, 50) {
+		if err != nil {
+			t.Errorf("goroutine %d: %v", i, err)
+		}
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times by 50 concurrent validations, want 1", got)
+	}
+}
+
+func TestRefreshKeysSharesErrorsAndRetries(t *testing.T) {
+	server := newGatedJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	server.failing.Store(true)
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web", WithClaimsCache(0, 0))
+
+	token := signToken(t, testKid, validClaims(&jwksServer{Server: server.Server}))
+	for i, err := range validateConcurrently(t, v, server, token, 50) {
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("goroutine %d: error = %v, want the failed fetch's error", i, err)
+		}
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times by 50 concurrent validations, want 1", got)
+	}
+
+	// The failure is not cached; the next validation fetches again
+	server.failing.Store(false)
+	if _, err := v.ValidateToken(context.Background(), token); err != nil {
+		t.Fatalf("ValidateToken after recovery: %v", err)
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want a retry after the failure", got)
+	}
+}
+
+func TestRefreshKeysOutlivesTheCallerThatStartedIt(t *testing.T) {
+	server := newGatedJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web", WithClaimsCache(0, 0))
+
+	// The first caller starts the fetch and gives up while it is held
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() { first <- v.refreshKeys(ctx) }()
+	select {
+	case <-server.arrived:
+	case <-time.After(time.Second):
+		t.Fatal("no JWKS fetch was made")
+	}
+	second := make(chan error, 1)
+	go func() { second <- v.refreshKeys(context.Background()) }()
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller = %v, want context.Canceled", err)
+	}
+
+	// The shared fetch carries on for the caller still waiting
+	close(server.released)
+	if err := <-second; err != nil {
+		t.Errorf("waiting caller = %v, want the fetched keys", err)
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+}
+
+func TestRefreshKeysBoundsFetchWithTimeout(t *testing.T) {
+	server := newGatedJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	t.Cleanup(func() { close(server.released) })
+	v := NewValidator(server.URL+testJWKSPath, testRealm, "svedprint-web", WithFetchTimeout(50*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() { done <- v.refreshKeys(context.Background()) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("refreshKeys = %v, want the fetch timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("refreshKeys outlived the fetch timeout")
+	}
+}
+
+func TestRefreshKeysSkipsMalformedKeys(t *testing.T) {
+	malformed := rsaJWK("malformed", &testKey.PublicKey)
+	malformed.N = "not base64url!"
+	server := newJWKSServer(t, malformed, rsaJWK(testKid, &testKey.PublicKey))
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web")
+
+	if err := v.refreshKeys(context.Background()); err != nil {
+		t.Fatalf("refreshKeys: %v", err)
+	}
+	if !v.hasKey(testKid) || v.hasKey("malformed") {
+		t.Error("want only the valid key loaded")
+	}
+	if _, err := v.ValidateToken(context.Background(), signToken(t, testKid, validClaims(server))); err != nil {
+		t.Errorf("ValidateToken with the valid key: %v", err)
+	}
+
+	// With nothing usable left the refresh fails
+	server.keys.Store([]JWK{malformed})
+	err := v.refreshKeys(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no usable keys in JWKS, 1 malformed") {
+		t.Errorf("refreshKeys with only a malformed key = %v, want no usable keys", err)
+	}
+}
+
+func TestWithValidMethods(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	claims := validClaims(server)
+	publicKeyBytes := testKey.PublicKey.N.Bytes()
+
+	tokens := map[string]string{
+		"RS256": signTokenWith(t, jwt.SigningMethodRS256, testKey, testKid, claims),
+		"RS512": signTokenWith(t, jwt.SigningMethodRS512, testKey, testKid, claims),
+		"none":  signTokenWith(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, testKid, claims),
+		// An HMAC keyed with the public key must never pass as RSA
+		"HS256": signTokenWith(t, jwt.SigningMethodHS256, publicKeyBytes, testKid, claims),
+	}
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		valid []string
+	}{
+		{name: "defaults", valid: []string{"RS256", "RS512"}},
+		{name: "only RS256", opts: []Option{WithValidMethods("RS256")}, valid: []string{"RS256"}},
+		{name: "none is never pinned", opts: []Option{WithValidMethods("none", "RS256")}, valid: []string{"RS256"}},
+		{name: "empty list keeps defaults", opts: []Option{WithValidMethods(" ", "")}, valid: []string{"RS256", "RS512"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithClaimsCache(0, 0)}, tt.opts...)
+			v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", opts...)
+			for alg, token := range tokens {
+				_, err := v.ValidateToken(context.Background(), token)
+				if want := slices.Contains(tt.valid, alg); (err == nil) != want {
+					t.Errorf("%s token: err = %v, want valid %v", alg, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithAudiences(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+
+	tests := []struct {
+		name      string
+		audiences []string
+		aud       jwt.ClaimStrings
+		wantErr   bool
+	}{
+		{name: "no audiences configured", aud: jwt.ClaimStrings{"svedprint-admin"}},
+		{name: "no audiences configured, no aud claim", aud: nil},
+		{name: "matching audience", audiences: []string{"svedprint-backend"}, aud: jwt.ClaimStrings{"svedprint-backend"}},
+		{name: "one of several", audiences: []string{"svedprint-backend", "svedprint-print"}, aud: jwt.ClaimStrings{"account", "svedprint-print"}},
+		{name: "mismatched audience", audiences: []string{"svedprint-backend"}, aud: jwt.ClaimStrings{"svedprint-admin"}, wantErr: true},
+		{name: "missing audience", audiences: []string{"svedprint-backend"}, aud: nil, wantErr: true},
+		{name: "blank audiences are ignored", audiences: []string{" ", ""}, aud: jwt.ClaimStrings{"svedprint-admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0), WithAudiences(tt.audiences...))
+			claims := validClaims(server)
+			claims.Audience = tt.aud
+
+			_, err := v.ValidateToken(context.Background(), signToken(t, testKid, claims))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid audience") {
+					t.Errorf("ValidateToken error = %v, want invalid audience", err)
+				}
+			} else if err != nil {
+				t.Errorf("ValidateToken: %v", err)
+			}
+		})
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) JWK {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return JWK{
+		Kid: kid,
+		Kty: "EC",
+		Use: "sig",
+		Crv: key.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func TestValidateTokenECKeys(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := ecJWK("off-curve", &p256.PublicKey)
+	offCurve.Y = offCurve.X
+
+	// Unknown key types and malformed keys are skipped, not fatal
+	server := newJWKSServer(t,
+		JWK{Kid: "hmac", Kty: "oct"},
+		offCurve,
+		ecJWK("es256", &p256.PublicKey),
+		ecJWK("es384", &p384.PublicKey),
+		rsaJWK(testKid, &testKey.PublicKey),
+	)
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0))
+	claims := validClaims(server)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "ES256", token: signTokenWith(t, jwt.SigningMethodES256, p256, "es256", claims)},
+		{name: "ES384", token: signTokenWith(t, jwt.SigningMethodES384, p384, "es384", claims)},
+		{name: "RS256 alongside EC keys", token: signToken(t, testKid, claims)},
+		{name: "ES256 signed by another key", token: signTokenWith(t, jwt.SigningMethodES256, p256, "es384", claims), wantErr: true},
+		{name: "RS256 against an EC key", token: signTokenWith(t, jwt.SigningMethodRS256, testKey, "es256", claims), wantErr: true},
+		{name: "skipped off-curve key", token: signTokenWith(t, jwt.SigningMethodES256, p256, "off-curve", claims), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.ValidateToken(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToken error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKToECDSAPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := ecJWK("kid", &key.PublicKey)
+
+	tests := []struct {
+		name    string
+		mutate  func(*JWK)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(*JWK) {}},
+		{name: "unsupported curve", mutate: func(j *JWK) { j.Crv = "secp256k1" }, wantErr: "unsupported curve"},
+		{name: "bad x", mutate: func(j *JWK) { j.X = "!!" }, wantErr: "x coordinate"},
+		{name: "bad y", mutate: func(j *JWK) { j.Y = "!!" }, wantErr: "y coordinate"},
+		{name: "off curve", mutate: func(j *JWK) { j.Y = j.X }, wantErr: "not on curve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwk := valid
+			tt.mutate(&jwk)
+			got, err := jwkToECDSAPublicKey(jwk)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("jwkToECDSAPublicKey: %v", err)
+			}
+			if !got.Equal(&key.PublicKey) {
+				t.Error("converted key does not match the original")
+			}
+		})
+	}
+}
+
+// rsaKeyWithExponent builds an RSA key with a custom public exponent, which
+// rsa.GenerateKey cannot do
+func rsaKeyWithExponent(t *testing.T, e int) *rsa.PrivateKey {
+	t.Helper()
+	exponent := big.NewInt(int64(e))
+	one := big.NewInt(1)
+	for {
+		p, err := rand.Prime(rand.Reader, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := rand.Prime(rand.Reader, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(exponent, phi)
+		if p.Cmp(q) == 0 || d == nil {
+			continue
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: e},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		key.Precompute()
+		return key
+	}
+}
+
+func TestJWKToRSAPublicKeyThreeByteExponent(t *testing.T) {
+	const exponent = 0xC0FFEF
+	key := rsaKeyWithExponent(t, exponent)
+
+	jwk := rsaJWK("hsm-key", &key.PublicKey)
+	if eBytes, _ := base64.RawURLEncoding.DecodeString(jwk.E); len(eBytes) != 3 {
+		t.Fatalf("exponent encodes to %d bytes, want 3", len(eBytes))
+	}
+
+	got, err := jwkToRSAPublicKey(jwk)
+	if err != nil {
+		t.Fatalf("jwkToRSAPublicKey: %v", err)
+	}
+	if got.E != exponent {
+		t.Errorf("E = %#x, want %#x", got.E, exponent)
+	}
+
+	digest := sha256.Sum256([]byte("svedprint"))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15: %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(got, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("converted key does not verify the signature: %v", err)
+	}
+
+	server := newJWKSServer(t, jwk)
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0))
+	token := signTokenWith(t, jwt.SigningMethodRS256, key, "hsm-key", validClaims(server))
+	if _, err := v.ValidateToken(context.Background(), token); err != nil {
+		t.Errorf("ValidateToken: %v", err)
+	}
+}
+
+func TestJWKToRSAPublicKeyExponent(t *testing.T) {
+	encode := func(b ...byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	modulus := base64.RawURLEncoding.EncodeToString(testKey.N.Bytes())
+
+	tests := []struct {
+		name    string
+		e       string
+		want    int
+		wantErr string
+	}{
+		{name: "65537", e: "AQAB", want: 65537},
+		{name: "leading zero byte", e: encode(0, 1, 0, 1), want: 65537},
+		{name: "largest supported", e: encode(0x7f, 0xff, 0xff, 0xff), want: math.MaxInt32},
+		{name: "past int32", e: encode(0x80, 0, 0, 0), wantErr: "unsupported exponent"},
+		{name: "past int64", e: encode(1, 0, 0, 0, 0, 0, 0, 0, 1), wantErr: "unsupported exponent"},
+		{name: "zero", e: encode(0), wantErr: "unsupported exponent"},
+		{name: "empty", e: "", wantErr: "unsupported exponent"},
+		{name: "not base64", e: "!!", wantErr: "failed to decode exponent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jwkToRSAPublicKey(JWK{Kty: "RSA", N: modulus, E: tt.e})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("jwkToRSAPublicKey: %v", err)
+			}
+			if got.E != tt.want {
+				t.Errorf("E = %d, want %d", got.E, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		access    map[string]interface{}
+		wantRoles []string
+	}{
+		{name: "nil map", access: nil},
+		{name: "missing client", access: map[string]interface{}{"account": map[string]interface{}{"roles": []interface{}{"view-profile"}}}},
+		{name: "client is not an object", access: map[string]interface{}{"svedprint-backend": []interface{}{"teacher"}}},
+		{name: "no roles", access: map[string]interface{}{"svedprint-backend": map[string]interface{}{}}},
+		{name: "roles is not a list", access: map[string]interface{}{"svedprint-backend": map[string]interface{}{"roles": "teacher"}}},
+		{
+			name:      "non-string roles are skipped",
+			access:    map[string]interface{}{"svedprint-backend": map[string]interface{}{"roles": []interface{}{"teacher", 7, nil, "principal"}}},
+			wantRoles: []string{"teacher", "principal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &KeycloakClaims{ResourceAccess: tt.access}
+			if got := claims.ResourceRoles("svedprint-backend"); !slices.Equal(got, tt.wantRoles) {
+				t.Errorf("ResourceRoles = %v, want %v", got, tt.wantRoles)
+			}
+			if got, want := claims.HasResourceRole("svedprint-backend", "teacher"), len(tt.wantRoles) > 0; got != want {
+				t.Errorf("HasResourceRole(teacher) = %v, want %v", got, want)
+			}
+			if claims.HasResourceRole("svedprint-backend", "admin") {
+				t.Error("HasResourceRole(admin) = true")
+			}
+		})
+	}
+}
+
+func TestResourceRolesFromToken(t *testing.T) {
+	payload := `{"realm_access":{"roles":["admin"]},"resource_access":{"svedprint-backend":{"roles":["teacher"]},"account":{"roles":["manage-account"]}}}`
+	var claims KeycloakClaims
+	if err := json.Unmarshal([]byte(payload), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if !claims.HasResourceRole("svedprint-backend", "teacher") {
+		t.Error("missing svedprint-backend teacher role")
+	}
+	// Roles are scoped to their client and kept apart from realm roles
+	if claims.HasResourceRole("account", "teacher") || claims.HasResourceRole("svedprint-backend", "admin") {
+		t.Error("role leaked across clients or from the realm")
+	}
+	if got := claims.ResourceRoles("account"); !slices.Equal(got, []string{"manage-account"}) {
+		t.Errorf("account roles = %v", got)
+	}
+}
+
+// fakeRevocationChecker reports the IDs in revoked, or fails every check with err
+type fakeRevocationChecker struct {
+	revoked map[string]bool
+	err     error
+	checked []string
+}
+
+func (c *fakeRevocationChecker) IsRevoked(_ context.Context, jti string) (bool, error) {
+	c.checked = append(c.checked, jti)
+	return c.revoked[jti], c.err
+}
+
+func TestWithRevocationChecker(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+
+	tests := []struct {
+		name        string
+		checker     *fakeRevocationChecker
+		failClosed  bool
+		jti         string
+		wantErr     error
+		wantChecked bool
+	}{
+		{name: "not revoked", checker: &fakeRevocationChecker{revoked: map[string]bool{"token-2": true}}, jti: "token-1", wantChecked: true},
+		{name: "revoked", checker: &fakeRevocationChecker{revoked: map[string]bool{"token-1": true}}, jti: "token-1", wantErr: ErrTokenRevoked, wantChecked: true},
+		{name: "checker error fails open", checker: &fakeRevocationChecker{err: errors.New("redis: connection refused")}, jti: "token-1", wantChecked: true},
+		{name: "checker error fails closed", checker: &fakeRevocationChecker{err: errors.New("redis: connection refused")}, failClosed: true, jti: "token-1", wantErr: errors.New("failed to check token revocation"), wantChecked: true},
+		{name: "token without an ID", checker: &fakeRevocationChecker{err: errors.New("redis: connection refused")}, failClosed: true, jti: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0), WithRevocationChecker(tt.checker, tt.failClosed))
+			claims := validClaims(server)
+			claims.ID = tt.jti
+
+			_, err := v.ValidateToken(context.Background(), signToken(t, testKid, claims))
+			switch {
+			case tt.wantErr == nil:
+				if err != nil {
+					t.Errorf("ValidateToken: %v", err)
+				}
+			case errors.Is(tt.wantErr, ErrTokenRevoked):
+				if !errors.Is(err, ErrTokenRevoked) {
+					t.Errorf("ValidateToken error = %v, want ErrTokenRevoked", err)
+				}
+			default:
+				if err == nil || errors.Is(err, ErrTokenRevoked) || !strings.Contains(err.Error(), tt.wantErr.Error()) {
+					t.Errorf("ValidateToken error = %v, want %q", err, tt.wantErr)
+				}
+			}
+			if checked := len(tt.checker.checked) > 0; checked != tt.wantChecked {
+				t.Errorf("checker consulted = %v, want %v", checked, tt.wantChecked)
+			}
+		})
+	}
+}
+
+func TestWithRevocationCheckerOnCachedClaims(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	checker := &fakeRevocationChecker{revoked: map[string]bool{}}
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(10, time.Minute), WithRevocationChecker(checker, false))
+	token := signToken(t, testKid, validClaims(server))
+
+	if _, err := v.ValidateToken(context.Background(), token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	// Revoking a token must take effect even though its claims are cached
+	checker.revoked["token-1"] = true
+	if _, err := v.ValidateToken(context.Background(), token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("ValidateToken from cache = %v, want ErrTokenRevoked", err)
+	}
+	if len(checker.checked) != 2 {
+		t.Errorf("checker consulted %d times, want 2", len(checker.checked))
+	}
+}
+
+func TestWithSessionRevocationChecker(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	tokens := &fakeRevocationChecker{revoked: map[string]bool{}}
+	sessions := &fakeRevocationChecker{revoked: map[string]bool{"sid-laptop": true}}
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-web",
+		WithRevocationChecker(tokens, false), WithSessionRevocationChecker(sessions, false))
+
+	for sid, wantErr := range map[string]error{"sid-laptop": ErrTokenRevoked, "sid-phone": nil, "": nil} {
+		claims := validClaims(server)
+		claims.SessionID = sid
+		if _, err := v.ValidateToken(context.Background(), signToken(t, testKid, claims)); !errors.Is(err, wantErr) {
+			t.Errorf("ValidateToken in session %q = %v, want %v", sid, err, wantErr)
+		}
+	}
+	slices.Sort(sessions.checked)
+	if !slices.Equal(sessions.checked, []string{"sid-laptop", "sid-phone"}) {
+		t.Errorf("sessions checked = %v, want each session ID once", sessions.checked)
+	}
+
+	// A failing session check follows its own fail-closed policy
+	failing := &fakeRevocationChecker{err: errors.New("redis: connection refused")}
+	v = NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(0, 0), WithSessionRevocationChecker(failing, true))
+	claims := validClaims(server)
+	claims.SessionID = "sid-phone"
+	if _, err := v.ValidateToken(context.Background(), signToken(t, testKid, claims)); err == nil || !strings.Contains(err.Error(), "failed to check session revocation") {
+		t.Errorf("ValidateToken with the session check down = %v, want it rejected", err)
+	}
+}
+
+func TestWithLeeway(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+
+	tests := []struct {
+		name    string
+		leeway  time.Duration
+		exp     time.Duration
+		nbf     time.Duration
+		wantErr error
+	}{
+		{name: "expired two seconds ago, no leeway", exp: -2 * time.Second, wantErr: ErrTokenExpired},
+		{name: "expired two seconds ago, three second leeway", leeway: 3 * time.Second, exp: -2 * time.Second},
+		{name: "expired past the leeway", leeway: 3 * time.Second, exp: -5 * time.Second, wantErr: ErrTokenExpired},
+		{name: "not valid for two more seconds, no leeway", exp: time.Hour, nbf: 2 * time.Second, wantErr: jwt.ErrTokenNotValidYet},
+		{name: "not valid for two more seconds, three second leeway", leeway: 3 * time.Second, exp: time.Hour, nbf: 2 * time.Second},
+		{name: "negative leeway is ignored", leeway: -time.Minute, exp: -2 * time.Second, wantErr: ErrTokenExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Cached claims must get the same leeway as freshly parsed ones
+			v := NewValidator(server.jwksURL(), testRealm, "svedprint-web", WithClaimsCache(10, time.Minute), WithLeeway(tt.leeway))
+			now := time.Now()
+			claims := validClaims(server)
+			claims.ExpiresAt = jwt.NewNumericDate(now.Add(tt.exp))
+			if tt.nbf != 0 {
+				claims.NotBefore = jwt.NewNumericDate(now.Add(tt.nbf))
+			}
+			token := signToken(t, testKid, claims)
+
+			for _, attempt := range []string{"first", "cached"} {
+				_, err := v.ValidateToken(context.Background(), token)
+				if tt.wantErr == nil && err != nil {
+					t.Errorf("%s ValidateToken: %v", attempt, err)
+				} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s ValidateToken error = %v, want %v", attempt, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// adminClaims is the kind of claims struct a service defines for its own claims
+type adminClaims struct {
+	RegisteredClaims
+	SchoolID     string `json:"school_id"`
+	Organization string `json:"organization"`
+}
+
+func TestValidateTokenInto(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK(testKid, &testKey.PublicKey))
+	now := time.Now()
+	registered := RegisteredClaims{
+		Issuer:    server.issuer(),
+		Subject:   "admin-1",
+		Audience:  jwt.ClaimStrings{"svedprint-admin"},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		ID:        "token-1",
+	}
+	token := signToken(t, testKid, adminClaims{RegisteredClaims: registered, SchoolID: "school-7", Organization: "Skopje municipality"})
+
+	v := NewValidator(server.jwksURL(), testRealm, "svedprint-admin", WithAudiences("svedprint-admin"))
+	var claims adminClaims
+	if err := v.ValidateTokenInto(context.Background(), token, &claims); err != nil {
+		t.Fatalf("ValidateTokenInto: %v", err)
+	}
+	if claims.Subject != "admin-1" || claims.SchoolID != "school-7" || claims.Organization != "Skopje municipality" {
+		t.Errorf("claims = %+v, want the custom claims decoded", claims)
+	}
+
+	otherKey := mustRSAKey()
+	wrongIssuer := registered
+	wrongIssuer.Issuer = "https://evil.example/realms/" + testRealm
+	wrongAudience := registered
+	wrongAudience.Audience = jwt.ClaimStrings{"svedprint-web"}
+	expired := registered
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+
+	tests := []struct {
+		name    string
+		token   string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "wrong issuer", token: signToken(t, testKid, adminClaims{RegisteredClaims: wrongIssuer}), wantErr: "invalid issuer"},
+		{name: "wrong audience", token: signToken(t, testKid, adminClaims{RegisteredClaims: wrongAudience}), wantErr: "invalid audience"},
+		{name: "expired", token: signToken(t, testKid, adminClaims{RegisteredClaims: expired}), wantErr: "token is expired"},
+		{name: "signed by another key", token: signTokenWith(t, jwt.SigningMethodRS256, otherKey, testKid, adminClaims{RegisteredClaims: registered}), wantErr: "verification error"},
+		{name: "revoked", token: token, opts: []Option{WithRevocationChecker(&fakeRevocationChecker{revoked: map[string]bool{"token-1": true}}, false)}, wantErr: ErrTokenRevoked.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithAudiences("svedprint-admin")}, tt.opts...)
+			v := NewValidator(server.jwksURL(), testRealm, "svedprint-admin", opts...)
+
+			var claims adminClaims
+			err := v.ValidateTokenInto(context.Background(), tt.token, &claims)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTokenInto error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
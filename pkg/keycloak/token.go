@@ -0,0 +1,107 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidGrant is returned when Keycloak rejects the presented grant
+// (e.g. an expired, revoked or malformed refresh token)
+var ErrInvalidGrant = errors.New("invalid grant")
+
+// TokenResponse is the token endpoint response
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope,omitempty"`
+}
+
+// tokenError is the OAuth2 error body returned by the token endpoint
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// TokenClient exchanges grants at Keycloak's token endpoint on behalf of a confidential client
+type TokenClient struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewTokenClient creates a token client for the given realm
+func NewTokenClient(baseURL, realm, clientID, clientSecret string) *TokenClient {
+	return &TokenClient{
+		tokenURL:     fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", strings.TrimSuffix(baseURL, "/"), realm),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair
+func (tc *TokenClient) Refresh(ctx context.Context, refreshToken string) (*TokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	return tc.exchange(ctx, form)
+}
+
+// ClientCredentials obtains an access token for the client itself, for service-to-service calls
+func (tc *TokenClient) ClientCredentials(ctx context.Context) (*TokenResponse, error) {
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+	}
+	return tc.exchange(ctx, form)
+}
+
+// exchange posts a grant to the token endpoint and decodes the token response
+func (tc *TokenClient) exchange(ctx context.Context, form url.Values) (*TokenResponse, error) {
+	form.Set("client_id", tc.clientID)
+	if tc.clientSecret != "" {
+		form.Set("client_secret", tc.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tc.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := tc.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		var tokenErr tokenError
+		if json.Unmarshal(body, &tokenErr) == nil && tokenErr.Error == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGrant, tokenErr.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,93 @@
+package keycloak
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshBuffer is how long before expiry a service token is replaced
+	DefaultRefreshBuffer = 30 * time.Second
+	// DefaultRefreshJitter spreads refreshes of replicas that fetched tokens together
+	DefaultRefreshJitter = 10 * time.Second
+)
+
+// SourceOption configures optional TokenSource behaviour
+type SourceOption func(*TokenSource)
+
+// WithRefreshBuffer replaces tokens once less than buffer of their lifetime remains,
+// leaving room for calls that queue before they reach the downstream service
+func WithRefreshBuffer(buffer time.Duration) SourceOption {
+	return func(s *TokenSource) {
+		s.buffer = max(buffer, 0)
+	}
+}
+
+// WithRefreshJitter refreshes up to jitter earlier than the buffer, chosen at random
+// per token, so replicas don't all hit Keycloak at the same moment
+func WithRefreshJitter(jitter time.Duration) SourceOption {
+	return func(s *TokenSource) {
+		s.jitter = max(jitter, 0)
+	}
+}
+
+// TokenSource hands out the client's own access token from the client credentials
+// grant, fetching a new one shortly before the current one expires
+type TokenSource struct {
+	client *TokenClient
+	buffer time.Duration
+	jitter time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// NewTokenSource creates a token source backed by client
+func NewTokenSource(client *TokenClient, opts ...SourceOption) *TokenSource {
+	s := &TokenSource{
+		client: client,
+		buffer: DefaultRefreshBuffer,
+		jitter: DefaultRefreshJitter,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Token returns a valid access token, fetching a new one when the current one is
+// inside its refresh window. Concurrent callers share a single fetch.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.token != "" && now.Before(s.refreshAt) {
+		return s.token, nil
+	}
+
+	resp, err := s.client.ClientCredentials(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch service token: %w", err)
+	}
+
+	s.token = resp.AccessToken
+	s.refreshAt = s.refreshTime(now, time.Duration(resp.ExpiresIn)*time.Second)
+	return s.token, nil
+}
+
+// refreshTime picks when a token issued at issued and valid for lifetime is replaced.
+// The early margin never exceeds half the lifetime, so short-lived tokens are still reused.
+func (s *TokenSource) refreshTime(issued time.Time, lifetime time.Duration) time.Time {
+	early := s.buffer
+	if s.jitter > 0 {
+		early += rand.N(s.jitter)
+	}
+	return issued.Add(lifetime - min(early, lifetime/2))
+}
@@ -0,0 +1,121 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer issues client credentials tokens valid for expiresIn seconds, numbering
+// them so tests can tell a cached token from a fresh one
+type tokenServer struct {
+	*httptest.Server
+	grants atomic.Int32
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	t.Helper()
+	s := &tokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+			return
+		}
+		n := s.grants.Add(1)
+		json.NewEncoder(w).Encode(TokenResponse{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: expiresIn})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tokenServer) source(opts ...SourceOption) (*TokenSource, *time.Time) {
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	source := NewTokenSource(NewTokenClient(s.URL, "svedprint", "svedprint-print", "secret"), opts...)
+	source.now = func() time.Time { return now }
+	return source, &now
+}
+
+func TestTokenSourceRefreshesWithinBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+		buffer    time.Duration
+		// refreshAfter is when the token is first replaced, counted from its issue
+		refreshAfter time.Duration
+	}{
+		{name: "default buffer", expiresIn: 300, buffer: DefaultRefreshBuffer, refreshAfter: 270 * time.Second},
+		{name: "longer buffer", expiresIn: 300, buffer: 2 * time.Minute, refreshAfter: 180 * time.Second},
+		{name: "no buffer", expiresIn: 300, buffer: 0, refreshAfter: 300 * time.Second},
+		{name: "buffer capped at half the lifetime", expiresIn: 60, buffer: 5 * time.Minute, refreshAfter: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := newTokenServer(t, tt.expiresIn)
+			source, now := server.source(WithRefreshBuffer(tt.buffer), WithRefreshJitter(0))
+			issued := *now
+
+			token := func() string {
+				t.Helper()
+				token, err := source.Token(ctx)
+				if err != nil {
+					t.Fatalf("Token: %v", err)
+				}
+				return token
+			}
+
+			if got := token(); got != "token-1" {
+				t.Fatalf("first token = %q, want token-1", got)
+			}
+			*now = issued.Add(tt.refreshAfter - time.Second)
+			if got := token(); got != "token-1" {
+				t.Errorf("token just outside the buffer = %q, want the cached token-1", got)
+			}
+			*now = issued.Add(tt.refreshAfter)
+			if got := token(); got != "token-2" {
+				t.Errorf("token inside the buffer = %q, want a fresh token-2", got)
+			}
+			if got := server.grants.Load(); got != 2 {
+				t.Errorf("%d grants, want 2", got)
+			}
+		})
+	}
+}
+
+func TestTokenSourceJitterSpreadsRefreshes(t *testing.T) {
+	const (
+		lifetime = 5 * time.Minute
+		buffer   = 30 * time.Second
+		jitter   = 20 * time.Second
+	)
+	source := NewTokenSource(nil, WithRefreshBuffer(buffer), WithRefreshJitter(jitter))
+	issued := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	earliest, latest := issued.Add(lifetime-buffer-jitter), issued.Add(lifetime-buffer)
+	distinct := map[time.Time]bool{}
+	for range 200 {
+		at := source.refreshTime(issued, lifetime)
+		if at.Before(earliest) || at.After(latest) {
+			t.Fatalf("refresh at %s, want between %s and %s", at.Sub(issued), earliest.Sub(issued), latest.Sub(issued))
+		}
+		distinct[at] = true
+	}
+	// Replicas that fetched together must not all come back at the same moment
+	if len(distinct) < 100 {
+		t.Errorf("only %d distinct refresh times in 200 tokens", len(distinct))
+	}
+}
+
+func TestTokenSourceNegativeOptionsAreIgnored(t *testing.T) {
+	source := NewTokenSource(nil, WithRefreshBuffer(-time.Minute), WithRefreshJitter(-time.Minute))
+	issued := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	if got, want := source.refreshTime(issued, time.Minute), issued.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("refresh at %s, want at expiry", got.Sub(issued))
+	}
+}
@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultShutdownTimeout is used when no explicit shutdown timeout is configured
+const DefaultShutdownTimeout = 15 * time.Second
+
+// Hook is a cleanup function executed during shutdown
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+// Lifecycle runs an HTTP server until a termination signal arrives, then shuts
+// it down and executes the registered shutdown hooks
+type Lifecycle struct {
+	shutdownTimeout time.Duration
+	startHooks      []namedHook
+	readyFuncs      []func()
+	hooks           []namedHook
+}
+
+// New creates a new Lifecycle with the given shutdown timeout
+func New(shutdownTimeout time.Duration) *Lifecycle {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Lifecycle{shutdownTimeout: shutdownTimeout}
+}
+
+// OnStart registers an initialization hook (migrations, warmup, ...). Start hooks run
+// in registration order once the server is listening, so liveness probes answer while
+// they run; if one fails the server is shut down.
+func (l *Lifecycle) OnStart(name string, hook Hook) {
+	l.startHooks = append(l.startHooks, namedHook{name: name, fn: hook})
+}
+
+// OnReady registers a callback invoked once every start hook has succeeded
+func (l *Lifecycle) OnReady(fn func()) {
+	l.readyFuncs = append(l.readyFuncs, fn)
+}
+
+// OnShutdown registers a hook to run on shutdown. Hooks run in reverse
+// registration order, so resources are released before their dependencies.
+func (l *Lifecycle) OnShutdown(name string, hook Hook) {
+	l.hooks = append(l.hooks, namedHook{name: name, fn: hook})
+}
+
+// Run serves srv until SIGINT/SIGTERM is received, then gracefully shuts down
+func (l *Lifecycle) Run(srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- l.Start(ctx)
+	}()
+
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			if err != nil {
+				l.Shutdown()
+				return fmt.Errorf("server failed: %w", err)
+			}
+			return nil
+		case err := <-startErr:
+			if err != nil {
+				l.shutdownServer(srv)
+				l.Shutdown()
+				return fmt.Errorf("initialization failed: %w", err)
+			}
+			// Initialization finished; keep serving until a signal arrives
+			startErr = nil
+		case <-ctx.Done():
+			log.Info().Msg("Shutdown signal received")
+			break wait
+		}
+	}
+
+	l.shutdownServer(srv)
+	l.Shutdown()
+	return nil
+}
+
+// Start runs the start hooks and, if all succeed, the ready callbacks. Run calls it
+// once the server is listening; it is exported for tests that boot a service's
+// wiring without serving.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, hook := range l.startHooks {
+		if err := hook.fn(ctx); err != nil {
+			return fmt.Errorf("%s: %w", hook.name, err)
+		}
+		log.Info().Str("hook", hook.name).Msg("Start hook completed")
+	}
+
+	for _, fn := range l.readyFuncs {
+		fn()
+	}
+	log.Info().Msg("Service is ready")
+	return nil
+}
+
+func (l *Lifecycle) shutdownServer(srv *http.Server) {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("HTTP server did not shut down cleanly")
+	}
+}
+
+// Shutdown executes all registered hooks, logging (but not stopping on) failures
+func (l *Lifecycle) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if err := hook.fn(ctx); err != nil {
+			log.Error().Err(err).Str("hook", hook.name).Msg("Shutdown hook failed")
+			continue
+		}
+		log.Info().Str("hook", hook.name).Msg("Shutdown hook completed")
+	}
+}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartRunsHooksBeforeReady(t *testing.T) {
+	l := New(time.Second)
+	var ready atomic.Bool
+	var order []string
+	release := make(chan struct{})
+
+	l.OnStart("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	l.OnStart("migrations", func(ctx context.Context) error {
+		<-release
+		order = append(order, "migrations")
+		return nil
+	})
+	l.OnReady(func() { ready.Store(true) })
+
+	done := make(chan error, 1)
+	go func() { done <- l.Start(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if ready.Load() {
+		t.Fatal("ready before the start hooks finished")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if !ready.Load() {
+		t.Error("not ready after the start hooks finished")
+	}
+	if strings.Join(order, ",") != "database,migrations" {
+		t.Errorf("hooks ran in order %v", order)
+	}
+}
+
+func TestStartFailureNeverReady(t *testing.T) {
+	l := New(time.Second)
+	var ready, laterRan bool
+	l.OnStart("migrations", func(ctx context.Context) error { return errors.New("dirty schema") })
+	l.OnStart("warmup", func(ctx context.Context) error {
+		laterRan = true
+		return nil
+	})
+	l.OnReady(func() { ready = true })
+
+	err := l.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "migrations") {
+		t.Errorf("start = %v, want an error naming the migrations hook", err)
+	}
+	if ready || laterRan {
+		t.Errorf("ready=%v laterRan=%v after a failed start hook", ready, laterRan)
+	}
+}
+
+func TestShutdownRunsHooksInReverse(t *testing.T) {
+	l := New(time.Second)
+	var order []string
+	for _, name := range []string{"database", "redis", "http"} {
+		l.OnShutdown(name, func(ctx context.Context) error {
+			order = append(order, name)
+			if name == "redis" {
+				return errors.New("already closed")
+			}
+			return nil
+		})
+	}
+
+	l.Shutdown()
+	if strings.Join(order, ",") != "http,redis,database" {
+		t.Errorf("shutdown order %v, want every hook in reverse despite a failure", order)
+	}
+}
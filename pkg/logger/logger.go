@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"os"
 	"strings"
@@ -63,3 +64,16 @@ func parseLevel(level string) zerolog.Level {
 func Get() *zerolog.Logger {
 	return &log.Logger
 }
+
+// WithContext returns a copy of ctx carrying l, retrievable with Ctx
+func WithContext(ctx context.Context, l zerolog.Logger) context.Context {
+	return l.WithContext(ctx)
+}
+
+// Ctx returns the request-scoped logger stored in ctx, falling back to the global logger
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// combinedLine parses the combined log format: ip ident user [time] "request" status bytes "referer" "agent"
+var combinedLine = regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(\S+) (\S+) (\S+)" (\d{3}) (\S+) "([^"]*)" "([^"]*)"$`)
+
+func serveLogged(format string, req *http.Request) string {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(AccessLog(format, &buf))
+	router.GET("/students", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	router.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return buf.String()
+}
+
+func loggedRequest(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "10.0.0.7:5123"
+	req.Header.Set(UserIDHeader, "user-1")
+	req.Header.Set("Referer", "https://svedprint.mk/students")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11)")
+	return req
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	line := serveLogged(AccessLogJSON, loggedRequest("/students?page=2"))
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("access log is not JSON: %v: %s", err, line)
+	}
+	want := map[string]any{
+		"client_ip":  "10.0.0.7",
+		"user_id":    "user-1",
+		"method":     "GET",
+		"uri":        "/students?page=2",
+		"proto":      "HTTP/1.1",
+		"status":     float64(200),
+		"bytes":      float64(5),
+		"referer":    "https://svedprint.mk/students",
+		"user_agent": "Mozilla/5.0 (X11)",
+	}
+	for field, value := range want {
+		if entry[field] != value {
+			t.Errorf("%s = %v, want %v", field, entry[field], value)
+		}
+	}
+	for _, field := range []string{"time", "latency_ms"} {
+		if _, ok := entry[field]; !ok {
+			t.Errorf("%s missing", field)
+		}
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+		want []string
+	}{
+		{
+			name: "full request",
+			req:  loggedRequest("/students?page=2"),
+			want: []string{"10.0.0.7", "user-1", "GET", "/students?page=2", "HTTP/1.1", "200", "5", "https://svedprint.mk/students", "Mozilla/5.0 (X11)"},
+		},
+		{
+			name: "anonymous without body",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/empty", nil)
+				req.RemoteAddr = "10.0.0.8:5123"
+				return req
+			}(),
+			want: []string{"10.0.0.8", "-", "GET", "/empty", "HTTP/1.1", "204", "-", "-", "-"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := strings.TrimSuffix(serveLogged(AccessLogCombined, tt.req), "\n")
+			m := combinedLine.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("not in combined log format: %s", line)
+			}
+			if _, err := time.Parse(combinedTimeFormat, m[3]); err != nil {
+				t.Errorf("timestamp %q: %v", m[3], err)
+			}
+			got := append(m[1:3:3], m[4:]...)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ClaimsKey is the gin context key the validated token claims are stored under
+const ClaimsKey = "claims"
+
+// TokenValidator validates a bearer token and returns its claims
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*jwt.KeycloakClaims, error)
+}
+
+// Auth rejects requests without a valid bearer token with 401 and stores the claims
+// of accepted ones under ClaimsKey. Missing headers, non-Bearer schemes, expired and
+// revoked and otherwise invalid tokens each get their own error code in the body. It does not call
+// c.Next, so it can also be invoked inline by handlers that only protect some of their
+// paths.
+func Auth(validator TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			rejectToken(c, `Bearer`, apierror.CodeMissingToken, "missing bearer token")
+			return
+		}
+
+		scheme, token, _ := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			rejectToken(c, `Bearer error="invalid_request"`, apierror.CodeMalformedToken, "authorization header must use the Bearer scheme")
+			return
+		}
+
+		claims, err := validator.ValidateToken(c.Request.Context(), token)
+		if err != nil {
+			log.Debug().Err(err).Str("path", c.Request.URL.Path).Msg("Rejected bearer token")
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				rejectToken(c, `Bearer error="invalid_token", error_description="token expired"`, apierror.CodeTokenExpired, "token has expired")
+				return
+			}
+			if errors.Is(err, jwt.ErrTokenRevoked) {
+				rejectToken(c, `Bearer error="invalid_token", error_description="token revoked"`, apierror.CodeTokenRevoked, "token has been revoked")
+				return
+			}
+			rejectToken(c, `Bearer error="invalid_token"`, apierror.CodeInvalidToken, "invalid token")
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+	}
+}
+
+// rejectToken aborts with 401, a WWW-Authenticate challenge and a JSON error body
+func rejectToken(c *gin.Context, challenge string, code apierror.Code, message string) {
+	c.Header("WWW-Authenticate", challenge)
+	apierror.Respond(c, apierror.NewWithCode(http.StatusUnauthorized, code, message))
+}
+
+// RequireRole rejects authenticated requests whose token lacks the realm role with 403.
+// It must run after Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok || !claims.HasRealmRole(role) {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusForbidden, apierror.CodeMissingRole, "requires the "+role+" role"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClaimsFromContext returns the claims stored by Auth, if the request was authenticated
+func ClaimsFromContext(c *gin.Context) (*jwt.KeycloakClaims, bool) {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*jwt.KeycloakClaims)
+	return claims, ok
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeValidator accepts "valid", and fails "expired" and "revoked" the way the real
+// validator does; any other token is invalid
+type fakeValidator struct {
+	calls int
+}
+
+func (v *fakeValidator) ValidateToken(_ context.Context, token string) (*jwt.KeycloakClaims, error) {
+	v.calls++
+	switch token {
+	case "valid":
+		claims := &jwt.KeycloakClaims{Tenant: "school_a"}
+		claims.Subject = "user-1"
+		return claims, nil
+	case "expired":
+		return nil, fmt.Errorf("token validation failed: %w", jwt.ErrTokenExpired)
+	case "revoked":
+		return nil, jwt.ErrTokenRevoked
+	}
+	return nil, errors.New("token validation failed: signature is invalid")
+}
+
+func TestAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		header        string
+		wantStatus    int
+		wantCode      apierror.Code
+		wantChallenge string
+		wantValidated bool
+	}{
+		{name: "valid token", header: "Bearer valid", wantStatus: http.StatusOK, wantValidated: true},
+		{name: "scheme is case-insensitive", header: "bearer valid", wantStatus: http.StatusOK, wantValidated: true},
+		{name: "missing header", header: "", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeMissingToken, wantChallenge: `Bearer`},
+		{name: "basic scheme", header: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeMalformedToken, wantChallenge: `invalid_request`},
+		{name: "bearer without token", header: "Bearer ", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeMalformedToken, wantChallenge: `invalid_request`},
+		{name: "expired token", header: "Bearer expired", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeTokenExpired, wantChallenge: `token expired`, wantValidated: true},
+		{name: "revoked token", header: "Bearer revoked", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeTokenRevoked, wantChallenge: `token revoked`, wantValidated: true},
+		{name: "invalid token", header: "Bearer forged", wantStatus: http.StatusUnauthorized, wantCode: apierror.CodeInvalidToken, wantChallenge: `invalid_token`, wantValidated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &fakeValidator{}
+			var claims *jwt.KeycloakClaims
+			router := gin.New()
+			router.GET("/students", Auth(validator), func(c *gin.Context) {
+				claims, _ = ClaimsFromContext(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/students", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if validated := validator.calls > 0; validated != tt.wantValidated {
+				t.Errorf("validator called = %v, want %v", validated, tt.wantValidated)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if claims == nil || claims.Subject != "user-1" {
+					t.Errorf("claims in context = %+v, want user-1", claims)
+				}
+				return
+			}
+
+			var body apierror.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Message == "" {
+				t.Errorf("body = %s, want code %s with a message", w.Body, tt.wantCode)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer") || !strings.Contains(got, tt.wantChallenge) {
+				t.Errorf("WWW-Authenticate = %q, want a Bearer challenge with %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestClaimsFromContextWithoutAuth(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if claims, ok := ClaimsFromContext(c); ok || claims != nil {
+		t.Errorf("ClaimsFromContext = %+v, %v, want nothing", claims, ok)
+	}
+}
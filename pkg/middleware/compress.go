@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// incompressibleTypes are content types that are already compressed
+var incompressibleTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"text/event-stream",
+}
+
+// Compress gzip- or deflate-encodes responses of at least minSize bytes for clients
+// that accept it, skipping already-compressed content such as PDFs. The body is
+// buffered only until minSize is reached, so large downloads still stream.
+// A non-positive minSize disables compression.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if minSize <= 0 || c.Request.Method == http.MethodHead || c.GetHeader("Range") != "" {
+			c.Next()
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = cw
+		defer func() {
+			cw.finish()
+			c.Writer = cw.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// compressWriter holds back the start of the response until it knows whether the
+// body is big enough to compress
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.status != 0 || len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush pushes out what has been compressed so far. Before the size is known only
+// event streams are flushed, as they must never be held back or compressed.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if !isEventStream(w.Header().Get("Content-Type")) {
+			return
+		}
+		_ = w.decide()
+	}
+
+	if fl, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = fl.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide commits the headers, switching to the encoder when the response qualifies,
+// and writes out the buffered start of the body
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if w.shouldCompress() {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Add("Vary", "Accept-Encoding")
+
+		if w.encoding == "gzip" {
+			w.enc = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.enc, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) shouldCompress() bool {
+	if len(w.buf) < w.minSize {
+		return false
+	}
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < w.minSize {
+		return false
+	}
+	return compressible(header.Get("Content-Type"))
+}
+
+// finish flushes a response that never reached the threshold and closes the encoder
+func (w *compressWriter) finish() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return
+		}
+		_ = w.decide()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	for _, t := range incompressibleTypes {
+		if strings.HasPrefix(mediaType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEventStream(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), "text/event-stream")
+}
+
+// negotiateEncoding picks gzip, then deflate, from an Accept-Encoding header,
+// honouring q=0 exclusions. It returns "" when neither is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if allowed, ok := accepted[encoding]; ok {
+			if allowed {
+				return encoding
+			}
+			continue
+		}
+		if allowed, ok := accepted["*"]; ok && allowed {
+			return encoding
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const compressThreshold = 1024
+
+func newCompressRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(compressThreshold))
+
+	roster := strings.Repeat(`{"first_name":"Ana","last_name":"Stojanova"},`, 100)
+	router.GET("/roster", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(roster)) })
+	router.GET("/small", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(`{"ok":true}`)) })
+	router.GET("/certificate.pdf", func(c *gin.Context) { c.Data(http.StatusOK, "application/pdf", []byte(roster)) })
+	router.GET("/streamed", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		for range 10 {
+			c.Writer.WriteString(roster[:compressThreshold/4])
+		}
+	})
+	return router
+}
+
+// decodeBody undoes the response's Content-Encoding
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = zr
+	case "deflate":
+		r = flate.NewReader(w.Body)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompress(t *testing.T) {
+	router := newCompressRouter()
+	plain := map[string]string{}
+	for _, path := range []string{"/roster", "/small", "/certificate.pdf", "/streamed"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		plain[path] = w.Body.String()
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"above threshold", "/roster", "gzip, deflate, br", "gzip"},
+		{"deflate only", "/roster", "deflate", "deflate"},
+		{"gzip refused", "/roster", "gzip;q=0, *", "deflate"},
+		{"wildcard", "/roster", "*", "gzip"},
+		{"no encoding support", "/roster", "", ""},
+		{"unsupported encoding", "/roster", "br", ""},
+		{"below threshold", "/small", "gzip", ""},
+		{"pdf", "/certificate.pdf", "gzip", ""},
+		{"streamed in small writes", "/streamed", "gzip", "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantEncoding != "" {
+				if w.Body.Len() >= len(plain[tt.path]) {
+					t.Errorf("compressed body is %d bytes, uncompressed %d", w.Body.Len(), len(plain[tt.path]))
+				}
+				if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+					t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Get("Vary"))
+				}
+			}
+			if body := decodeBody(t, w); body != plain[tt.path] {
+				t.Errorf("decoded body differs from the uncompressed response (%d vs %d bytes)", len(body), len(plain[tt.path]))
+			}
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(0))
+	body := bytes.Repeat([]byte("a"), 4*compressThreshold)
+	router.GET("/", func(c *gin.Context) { c.Data(http.StatusOK, "text/plain", body) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "" || !bytes.Equal(w.Body.Bytes(), body) {
+		t.Errorf("Compress(0) encoded the response as %q", w.Header().Get("Content-Encoding"))
+	}
+}
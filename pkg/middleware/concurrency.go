@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimit caps the number of requests handled at once. Up to maxQueued
+// further requests wait for a free slot (bounded by their context deadline); anything
+// beyond that is rejected with 503. A non-positive maxInFlight disables the limit.
+func ConcurrencyLimit(maxInFlight, maxQueued int) gin.HandlerFunc {
+	if maxInFlight <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+
+	slots := make(chan struct{}, maxInFlight)
+	var queued atomic.Int64
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if queued.Add(1) > int64(maxQueued) {
+				queued.Add(-1)
+				rejectOverloaded(c)
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+				queued.Add(-1)
+			case <-c.Request.Context().Done():
+				queued.Add(-1)
+				rejectOverloaded(c)
+				return
+			}
+		}
+		defer func() { <-slots }()
+
+		c.Next()
+	}
+}
+
+func rejectOverloaded(c *gin.Context) {
+	c.Header("Retry-After", "1")
+	apierror.Respond(c, apierror.NewWithCode(http.StatusServiceUnavailable, apierror.CodeServerOverloaded, "server is overloaded, retry later"))
+}
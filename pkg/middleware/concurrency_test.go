@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitedRouter serves /work through ConcurrencyLimit; each request signals
+// entered and then blocks until release receives a value
+func newLimitedRouter(maxInFlight, maxQueued int) (*gin.Engine, chan struct{}, chan struct{}) {
+	gin.SetMode(gin.TestMode)
+	entered := make(chan struct{}, 16)
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(ConcurrencyLimit(maxInFlight, maxQueued))
+	router.GET("/work", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return router, entered, release
+}
+
+func serve(ctx context.Context, router http.Handler) <-chan int {
+	status := make(chan int, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil).WithContext(ctx))
+		status <- w.Code
+	}()
+	return status
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestConcurrencyLimitRejectsBeyondCapAndQueue(t *testing.T) {
+	router, entered, release := newLimitedRouter(2, 1)
+	ctx := context.Background()
+
+	first, second := serve(ctx, router), serve(ctx, router)
+	waitFor(t, entered, "first request")
+	waitFor(t, entered, "second request")
+
+	queued := serve(ctx, router)
+	// Give the third request time to take the only queue place
+	time.Sleep(50 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request beyond cap and queue = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("503 without Retry-After")
+	}
+
+	// Completing one request lets the queued one in
+	release <- struct{}{}
+	waitFor(t, entered, "queued request")
+	close(release)
+
+	for name, status := range map[string]<-chan int{"first": first, "second": second, "queued": queued} {
+		if code := <-status; code != http.StatusOK {
+			t.Errorf("%s request = %d, want 200", name, code)
+		}
+	}
+}
+
+func TestConcurrencyLimitFreesCapacity(t *testing.T) {
+	router, entered, release := newLimitedRouter(1, 0)
+	close(release)
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+		<-entered
+		if w.Code != http.StatusOK {
+			t.Fatalf("sequential request %d = %d, want 200", i, w.Code)
+		}
+	}
+}
+
+func TestConcurrencyLimitQueuedRequestGivesUpWithItsContext(t *testing.T) {
+	router, entered, release := newLimitedRouter(1, 1)
+	defer close(release)
+
+	busy := serve(context.Background(), router)
+	waitFor(t, entered, "first request")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if code := <-serve(ctx, router); code != http.StatusServiceUnavailable {
+		t.Errorf("queued request past its deadline = %d, want 503", code)
+	}
+
+	release <- struct{}{}
+	if code := <-busy; code != http.StatusOK {
+		t.Errorf("first request = %d, want 200", code)
+	}
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	router, entered, release := newLimitedRouter(0, 0)
+	ctx := context.Background()
+
+	statuses := make([]<-chan int, 5)
+	for i := range statuses {
+		statuses[i] = serve(ctx, router)
+	}
+	for range statuses {
+		waitFor(t, entered, "unlimited request")
+	}
+	close(release)
+	for _, status := range statuses {
+		if code := <-status; code != http.StatusOK {
+			t.Errorf("unlimited request = %d, want 200", code)
+		}
+	}
+}
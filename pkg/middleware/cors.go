@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsMethods are the methods advertised to browsers in preflight responses
+const corsMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// CORS allows browsers on allowedOrigins ("*" for any) to call the API. Preflight
+// requests are answered directly; maxAge tells the browser how long it may cache the
+// answer, and zero leaves that to the browser's own default. Without origins CORS is
+// disabled and requests pass through untouched.
+func CORS(allowedOrigins []string, maxAge time.Duration) gin.HandlerFunc {
+	var origins []string
+	for _, origin := range allowedOrigins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	anyOrigin := slices.Contains(origins, "*")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origins) == 0 || origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !anyOrigin && !slices.Contains(origins, origin) {
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Origin", origin)
+
+		if c.Request.Method != http.MethodOptions || c.GetHeader("Access-Control-Request-Method") == "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", corsMethods)
+		if headers := c.GetHeader("Access-Control-Request-Headers"); headers != "" {
+			c.Header("Access-Control-Allow-Headers", headers)
+		}
+		if seconds := int(maxAge / time.Second); seconds > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(seconds))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSPreflightMaxAge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		want   string
+	}{
+		{name: "ten minutes", maxAge: 10 * time.Minute, want: "600"},
+		{name: "sub-second parts are dropped", maxAge: 90*time.Second + 500*time.Millisecond, want: "90"},
+		{name: "zero omits the header", maxAge: 0, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORS([]string{"https://svedprint.mk"}, tt.maxAge))
+			router.GET("/students", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodOptions, "/students", nil)
+			req.Header.Set("Origin", "https://svedprint.mk")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", "Authorization")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("preflight status = %d, want 204", w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != tt.want {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization" {
+				t.Errorf("Access-Control-Allow-Headers = %q, want Authorization", got)
+			}
+		})
+	}
+}
+
+func TestCORSMaxAgeOnlyOnPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CORS([]string{"https://svedprint.mk"}, time.Hour))
+	router.GET("/students", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  bool
+		wantOrigin string
+	}{
+		{name: "simple request", method: http.MethodGet, origin: "https://svedprint.mk", wantOrigin: "https://svedprint.mk"},
+		{name: "OPTIONS without a request method", method: http.MethodOptions, origin: "https://svedprint.mk", wantOrigin: "https://svedprint.mk"},
+		{name: "preflight from another origin", method: http.MethodOptions, origin: "https://evil.example", preflight: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/students", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+				t.Errorf("Access-Control-Max-Age = %q, want none", got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
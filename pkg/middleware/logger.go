@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/PegasusMKD/svedprint-go/pkg/logger"
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// UserIDHeader carries the authenticated user's ID from the gateway to internal services.
+// The gateway sets it from the validated token and drops any value sent by the client.
+const UserIDHeader = "X-User-ID"
+
+// RequestLogger stores a logger in the request context bound to the request ID and the
+// caller's tenant and user, so every logger.Ctx(ctx) line within the request carries
+// them. It must run after RequestID and Tenant so the tenant has already been validated.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		lc := log.Logger.With()
+
+		if id, ok := requestid.FromContext(ctx); ok {
+			lc = lc.Str("request_id", id)
+		}
+		if id, ok := tenant.FromContext(ctx); ok {
+			lc = lc.Str("tenant_id", id)
+		}
+		if userID := c.GetHeader(UserIDHeader); userID != "" {
+			lc = lc.Str("user_id", userID)
+		}
+
+		c.Request = c.Request.WithContext(logger.WithContext(ctx, lc.Logger()))
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/logger"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestRequestLoggerBindsRequestFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	router := gin.New()
+	router.Use(RequestID(func() string { return "req-1" }), Tenant(), RequestLogger())
+	router.GET("/students", func(c *gin.Context) {
+		logger.Ctx(c.Request.Context()).Info().Msg("listing students")
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "gateway identity",
+			headers: map[string]string{UserIDHeader: "user-1", tenant.Header: "school_a"},
+			want:    map[string]string{"request_id": "req-1", "user_id": "user-1", "tenant_id": "school_a"},
+		},
+		{
+			name: "anonymous",
+			want: map[string]string{"request_id": "req-1", "user_id": "", "tenant_id": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/students", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("log line %q: %v", buf.String(), err)
+			}
+			if line["message"] != "listing students" {
+				t.Fatalf("logged %q", buf.String())
+			}
+			for field, want := range tt.want {
+				got, _ := line[field].(string)
+				if got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
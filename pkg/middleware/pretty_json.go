@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prettyJSONIndent is the indentation used for pretty-printed responses
+const prettyJSONIndent = "  "
+
+// PrettyJSON indents JSON response bodies for developers reading them by hand. The
+// data is unchanged; other content types pass through untouched. JSON bodies are
+// buffered in full, so it is meant for debugging rather than production. It does
+// nothing when disabled.
+func PrettyJSON(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		pw := &prettyWriter{ResponseWriter: c.Writer}
+		c.Writer = pw
+		defer func() {
+			pw.finish()
+			c.Writer = pw.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// prettyWriter holds back JSON bodies until the handler is done so they can be
+// indented as a whole
+type prettyWriter struct {
+	gin.ResponseWriter
+
+	status    int
+	decided   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+func (w *prettyWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *prettyWriter) WriteHeaderNow() {
+	if w.decided && !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *prettyWriter) Status() int {
+	if w.status != 0 && (!w.decided || w.buffering) {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *prettyWriter) Written() bool {
+	return w.status != 0 || w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *prettyWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.buffering {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *prettyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush is ignored while a JSON body is buffered, as a partial document can't be indented
+func (w *prettyWriter) Flush() {
+	if w.decided && !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// decide buffers uncompressed JSON bodies and commits the headers of everything else
+func (w *prettyWriter) decide() {
+	w.decided = true
+	header := w.Header()
+	if isJSON(header.Get("Content-Type")) && header.Get("Content-Encoding") == "" {
+		w.buffering = true
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		return
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish writes out the buffered body, indented unless it turns out not to be valid JSON
+func (w *prettyWriter) finish() {
+	if !w.decided {
+		if w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+		return
+	}
+	if !w.buffering {
+		return
+	}
+
+	body := w.buf.Bytes()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", prettyJSONIndent); err == nil {
+		indented.WriteByte('\n')
+		body = indented.Bytes()
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	student := gin.H{"name": "Ana", "grades": []int{5, 4}}
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		handler    gin.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "indented when enabled", enabled: true,
+			handler:    func(c *gin.Context) { c.JSON(http.StatusCreated, student) },
+			wantStatus: http.StatusCreated,
+			wantBody:   "{\n  \"grades\": [\n    5,\n    4\n  ],\n  \"name\": \"Ana\"\n}\n",
+		},
+		{
+			name:       "compact when disabled",
+			handler:    func(c *gin.Context) { c.JSON(http.StatusCreated, student) },
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"grades":[5,4],"name":"Ana"}`,
+		},
+		{
+			name: "aborted errors are indented", enabled: true,
+			handler:    func(c *gin.Context) { c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"}) },
+			wantStatus: http.StatusNotFound,
+			wantBody:   "{\n  \"error\": \"not found\"\n}\n",
+		},
+		{
+			name: "JSON suffix types are indented", enabled: true,
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusBadRequest, "application/problem+json", []byte(`{"title":"bad"}`))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "{\n  \"title\": \"bad\"\n}\n",
+		},
+		{
+			name: "other content types pass through", enabled: true,
+			handler:    func(c *gin.Context) { c.String(http.StatusOK, `{"name":"Ana"}`) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"Ana"}`,
+		},
+		{
+			name: "invalid JSON is left as is", enabled: true,
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json", []byte(`{"name":`))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":`,
+		},
+		{
+			name: "encoded bodies pass through", enabled: true,
+			handler: func(c *gin.Context) {
+				c.Header("Content-Encoding", "gzip")
+				c.Data(http.StatusOK, "application/json", []byte(`{"name":"Ana"}`))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"Ana"}`,
+		},
+		{
+			name: "status without a body", enabled: true,
+			handler:    func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(PrettyJSON(tt.enabled))
+			router.GET("/students/1", tt.handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/1", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPrettyJSONStatusVisibleToOuterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var status int
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		status = c.Writer.Status()
+	}, PrettyJSON(true))
+	router.GET("/students/1", func(c *gin.Context) { c.JSON(http.StatusAccepted, gin.H{"queued": true}) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/students/1", nil))
+	if status != http.StatusAccepted {
+		t.Errorf("status seen by outer middleware = %d, want %d", status, http.StatusAccepted)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestIDLength bounds request IDs accepted from the caller
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, echoed in the X-Request-ID response header and
+// stored in the request context for RequestLogger. An ID sent by the caller, such as
+// the gateway, is kept so one request can be followed across services; otherwise
+// generate makes a new one.
+func RequestID(generate requestid.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !validRequestID(id) {
+			id = generate()
+			c.Request.Header.Set(requestid.Header, id)
+		}
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII, so a caller can't inject
+// arbitrary content into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	generate := func() string { return "0M4K7RQX0B2D" }
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "generated when missing", header: "", want: "0M4K7RQX0B2D"},
+		{name: "caller ID kept", header: "01JA2Z3K4M5N6P7Q8R9S0T1V2W", want: "01JA2Z3K4M5N6P7Q8R9S0T1V2W"},
+		{name: "ID with spaces replaced", header: "abc def", want: "0M4K7RQX0B2D"},
+		{name: "ID with control characters replaced", header: "abc\x1b[31m", want: "0M4K7RQX0B2D"},
+		{name: "overlong ID replaced", header: strings.Repeat("a", maxRequestIDLength+1), want: "0M4K7RQX0B2D"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inContext, forwarded string
+			router := gin.New()
+			router.Use(RequestID(generate))
+			router.GET("/students", func(c *gin.Context) {
+				inContext, _ = requestid.FromContext(c.Request.Context())
+				forwarded = c.Request.Header.Get(requestid.Header)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/students", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get(requestid.Header); got != tt.want {
+				t.Errorf("response ID = %q, want %q", got, tt.want)
+			}
+			if inContext != tt.want || forwarded != tt.want {
+				t.Errorf("context ID = %q, request header = %q, want %q", inContext, forwarded, tt.want)
+			}
+		})
+	}
+}
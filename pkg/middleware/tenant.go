@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant resolves the tenant from the X-Tenant-ID header and stores it in the request
+// context, where database.TenantDB picks it up. The gateway sets the header from the
+// caller's validated claims and drops any value sent by the client, so internal
+// services must only be reachable through it.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(tenant.Header)
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		if err := tenant.Validate(id); err != nil {
+			apierror.Respond(c, apierror.NewWithCode(http.StatusBadRequest, apierror.CodeInvalidTenant, err.Error()))
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
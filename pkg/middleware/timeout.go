@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeoutHeader carries the caller's remaining time budget in milliseconds
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// DefaultRequestTimeout is used by services that don't configure a request timeout
+const DefaultRequestTimeout = 30 * time.Second
+
+// untimedKey is the gin context key Timeout stores the request's own context under
+const untimedKey = "untimed_context"
+
+// Timeout bounds each request's context by defaultTimeout, or by the smaller budget
+// advertised by the caller in the X-Request-Timeout header
+func Timeout(defaultTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := defaultTimeout
+		if budget, ok := ParseBudget(c.GetHeader(RequestTimeoutHeader)); ok && budget < timeout {
+			timeout = budget
+		}
+
+		c.Set(untimedKey, c.Request.Context())
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// WithoutTimeout returns the request context without the deadline set by Timeout, for
+// handlers such as streaming exports that run as long as the client keeps reading. It
+// keeps the context's values and is still cancelled when the client disconnects.
+func WithoutTimeout(c *gin.Context) (context.Context, context.CancelFunc) {
+	value, ok := c.Get(untimedKey)
+	if !ok {
+		return context.WithCancel(c.Request.Context())
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+	stop := context.AfterFunc(value.(context.Context), cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// ParseBudget parses an X-Request-Timeout header value
+func ParseBudget(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// PropagateBudget sets X-Request-Timeout on an outgoing request to the time remaining
+// before its context deadline, so downstreams don't outlive the caller
+func PropagateBudget(req *http.Request) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		req.Header.Del(RequestTimeoutHeader)
+		return
+	}
+
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 1 {
+		remaining = 1
+	}
+	req.Header.Set(RequestTimeoutHeader, strconv.FormatInt(remaining, 10))
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testKey struct{}
+
+func TestWithoutTimeoutDropsOnlyTheDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+
+	var checked bool
+	router := gin.New()
+	router.Use(Timeout(time.Millisecond))
+	router.GET("/export", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), testKey{}, "kept"))
+		ctx, cancel := WithoutTimeout(c)
+		defer cancel()
+
+		<-c.Request.Context().Done()
+		if err := ctx.Err(); err != nil {
+			t.Errorf("context ended with the request deadline: %v", err)
+		}
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("context still has a deadline")
+		}
+		if ctx.Value(testKey{}) != "kept" {
+			t.Error("context lost its values")
+		}
+
+		disconnect()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("context not cancelled when the client disconnected")
+		}
+		checked = true
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil).WithContext(clientCtx)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if !checked {
+		t.Fatal("handler did not run")
+	}
+}
+
+func TestTimeoutHonoursSmallerBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		budget string
+		want   time.Duration
+	}{
+		{name: "default", want: time.Minute},
+		{name: "smaller budget", budget: "500", want: 500 * time.Millisecond},
+		{name: "larger budget", budget: "600000", want: time.Minute},
+		{name: "invalid budget", budget: "soon", want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var remaining time.Duration
+			router := gin.New()
+			router.Use(Timeout(time.Minute))
+			router.GET("/", func(c *gin.Context) {
+				deadline, _ := c.Request.Context().Deadline()
+				remaining = time.Until(deadline)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.budget != "" {
+				req.Header.Set(RequestTimeoutHeader, tt.budget)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if remaining > tt.want || remaining < tt.want-time.Second {
+				t.Errorf("remaining = %v, want about %v", remaining, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package numeric
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GPA is a grade average stored in hundredths so it never picks up float error.
+// It always serializes with two decimals (4 -> 4.00) and parses numbers or numeric
+// strings with any number of decimals, rounding half up to two.
+type GPA int64
+
+// NewGPA rounds f to two decimals
+func NewGPA(f float64) GPA {
+	if f < 0 {
+		return -GPA(-f*100 + 0.5)
+	}
+	return GPA(f*100 + 0.5)
+}
+
+// Float returns the GPA as a float for arithmetic that tolerates rounding
+func (g GPA) Float() float64 {
+	return float64(g) / 100
+}
+
+func (g GPA) String() string {
+	sign := ""
+	v := int64(g)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (g GPA) MarshalJSON() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+func (g *GPA) UnmarshalJSON(data []byte) error {
+	s, err := unquote(data)
+	if err != nil {
+		return fmt.Errorf("invalid GPA: %w", err)
+	}
+
+	v, err := parseHundredths(s)
+	if err != nil {
+		return fmt.Errorf("invalid GPA %q: %w", s, err)
+	}
+	*g = GPA(v)
+	return nil
+}
+
+// Grade is a discrete grade. It always serializes as an integer and accepts
+// integral values in any form (4, 4.0, "4", "4.00").
+type Grade int
+
+func (g Grade) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(g))), nil
+}
+
+func (g *Grade) UnmarshalJSON(data []byte) error {
+	s, err := unquote(data)
+	if err != nil {
+		return fmt.Errorf("invalid grade: %w", err)
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	v, err := strconv.Atoi(whole)
+	if err != nil || strings.Trim(frac, "0") != "" {
+		return fmt.Errorf("invalid grade %q: must be a whole number", s)
+	}
+	*g = Grade(v)
+	return nil
+}
+
+// unquote accepts a JSON number or a JSON string holding a number
+func unquote(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	if bytes.Equal(data, []byte("null")) || len(data) == 0 {
+		return "", fmt.Errorf("value is required")
+	}
+	return string(data), nil
+}
+
+// parseHundredths parses a decimal string into hundredths without going through float
+func parseHundredths(s string) (int64, error) {
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return int64(NewGPA(f)), nil
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("not a number")
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a number")
+	}
+
+	frac = (frac + "000")[:3]
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a number")
+	}
+
+	// Round half up on the third decimal
+	v := w*100 + (f+5)/10
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
@@ -0,0 +1,117 @@
+package numeric
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGPAMarshalsTwoDecimals(t *testing.T) {
+	tests := []struct {
+		gpa  GPA
+		want string
+	}{
+		{NewGPA(4), "4.00"},
+		{NewGPA(4.5), "4.50"},
+		{NewGPA(3.456), "3.46"},
+		{NewGPA(0.05), "0.05"},
+		{NewGPA(-1.25), "-1.25"},
+		{0, "0.00"},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.gpa)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%d) = %s, want %s", tt.gpa, got, tt.want)
+		}
+	}
+}
+
+func TestGPAUnmarshal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    GPA
+		wantErr bool
+	}{
+		{`4`, 400, false},
+		{`4.0`, 400, false},
+		{`4.00`, 400, false},
+		{`"4.00"`, 400, false},
+		{`" 3.5 "`, 350, false},
+		{`3.456`, 346, false},
+		{`3.454`, 345, false},
+		{`4.995`, 500, false},
+		{`".5"`, 50, false},
+		{`-1.25`, -125, false},
+		{`4e0`, 400, false},
+		{`null`, 0, true},
+		{`"abc"`, 0, true},
+		{`"."`, 0, true},
+		{`true`, 0, true},
+	}
+	for _, tt := range tests {
+		var got GPA
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGPARoundTripsExactly(t *testing.T) {
+	type summary struct {
+		GPA GPA `json:"gpa"`
+	}
+	for _, input := range []string{`{"gpa":4.00}`, `{"gpa":3.33}`, `{"gpa":0.10}`, `{"gpa":5.00}`} {
+		var s summary
+		if err := json.Unmarshal([]byte(input), &s); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", input, err)
+		}
+		out, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(out) != input {
+			t.Errorf("round trip of %s = %s", input, out)
+		}
+	}
+}
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Grade
+		wantErr bool
+	}{
+		{`4`, 4, false},
+		{`4.0`, 4, false},
+		{`"4"`, 4, false},
+		{`"4.00"`, 4, false},
+		{`4.5`, 0, true},
+		{`"four"`, 0, true},
+		{`null`, 0, true},
+	}
+	for _, tt := range tests {
+		var got Grade
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.input, got, tt.want)
+		}
+		out, _ := json.Marshal(got)
+		if string(out) != "4" {
+			t.Errorf("Marshal(%d) = %s, want 4", got, out)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const gradingDoc = `{"subject":"math","grades":[4,5],"note":"term 1"}`
+
+// jsonEqual compares two documents ignoring formatting and member order
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want is not JSON: %v", err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"add appends to array", `[{"op":"add","path":"/grades/-","value":3}]`, `{"subject":"math","grades":[4,5,3],"note":"term 1"}`},
+		{"add inserts into array", `[{"op":"add","path":"/grades/0","value":2}]`, `{"subject":"math","grades":[2,4,5],"note":"term 1"}`},
+		{"add member", `[{"op":"add","path":"/teacher","value":"Petrovski"}]`, `{"subject":"math","grades":[4,5],"note":"term 1","teacher":"Petrovski"}`},
+		{"remove array element", `[{"op":"remove","path":"/grades/0"}]`, `{"subject":"math","grades":[5],"note":"term 1"}`},
+		{"remove member", `[{"op":"remove","path":"/note"}]`, `{"subject":"math","grades":[4,5]}`},
+		{"replace array element", `[{"op":"replace","path":"/grades/1","value":3}]`, `{"subject":"math","grades":[4,3],"note":"term 1"}`},
+		{"move", `[{"op":"move","from":"/note","path":"/comment"}]`, `{"subject":"math","grades":[4,5],"comment":"term 1"}`},
+		{"copy", `[{"op":"copy","from":"/grades/0","path":"/grades/-"}]`, `{"subject":"math","grades":[4,5,4],"note":"term 1"}`},
+		{"test then replace", `[{"op":"test","path":"/subject","value":"math"},{"op":"replace","path":"/subject","value":"physics"}]`, `{"subject":"physics","grades":[4,5],"note":"term 1"}`},
+		{"escaped pointer", `[{"op":"add","path":"/a~1b","value":1}]`, `{"subject":"math","grades":[4,5],"note":"term 1","a/b":1}`},
+		{"test compares numbers by value", `[{"op":"test","path":"/grades/0","value":4.0},{"op":"remove","path":"/note"}]`, `{"subject":"math","grades":[4,5]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(gradingDoc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("JSONPatch: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("JSONPatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"not an array", `{"op":"add","path":"/x","value":1}`},
+		{"missing path", `[{"op":"remove","path":"/teacher"}]`},
+		{"replace missing member", `[{"op":"replace","path":"/teacher","value":"x"}]`},
+		{"index out of bounds", `[{"op":"replace","path":"/grades/5","value":3}]`},
+		{"non numeric index", `[{"op":"remove","path":"/grades/first"}]`},
+		{"path without leading slash", `[{"op":"add","path":"grades","value":[]}]`},
+		{"parent not a container", `[{"op":"add","path":"/subject/name","value":"x"}]`},
+		{"missing value", `[{"op":"add","path":"/teacher"}]`},
+		{"failed test", `[{"op":"test","path":"/subject","value":"physics"}]`},
+		{"failed numeric test", `[{"op":"test","path":"/grades","value":[4,5.5]}]`},
+		{"move into own child", `[{"op":"move","from":"/grades","path":"/grades/0"}]`},
+		{"unknown op", `[{"op":"increment","path":"/grades/0"}]`},
+		{"remove root", `[{"op":"remove","path":""}]`},
+		{"negative index", `[{"op":"remove","path":"/grades/-1"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := JSONPatch([]byte(gradingDoc), []byte(tt.patch)); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("JSONPatch = %v, want ErrInvalidPatch", err)
+			}
+		})
+	}
+}
+
+func TestJSONPatchIsAtomic(t *testing.T) {
+	doc := []byte(gradingDoc)
+	patch := `[{"op":"remove","path":"/note"},{"op":"remove","path":"/missing"}]`
+	if _, err := JSONPatch(doc, []byte(patch)); !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("JSONPatch = %v, want ErrInvalidPatch", err)
+	}
+	if string(doc) != gradingDoc {
+		t.Errorf("failed patch modified the document: %s", doc)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	got, err := MergePatch([]byte(gradingDoc), []byte(`{"grades":[3],"note":null,"teacher":"Petrovski"}`))
+	if err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	if want := `{"subject":"math","grades":[3],"teacher":"Petrovski"}`; !jsonEqual(t, got, want) {
+		t.Errorf("MergePatch = %s, want %s", got, want)
+	}
+}
+
+func TestApplySelectsFormatByContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        string
+		wantErr     error
+	}{
+		{"json patch", ContentTypeJSONPatch, `[{"op":"remove","path":"/note"}]`, `{"subject":"math","grades":[4,5]}`, nil},
+		{"merge patch with charset", ContentTypeMergePatch + "; charset=utf-8", `{"note":null}`, `{"subject":"math","grades":[4,5]}`, nil},
+		{"plain json", "application/json", `{"note":null}`, "", ErrUnsupportedMediaType},
+		{"malformed content type", "", `{}`, "", ErrUnsupportedMediaType},
+		{"merge patch that is not json", ContentTypeMergePatch, `{`, "", ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.contentType, []byte(gradingDoc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Apply = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+const studentBase = `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":3}},"note":"term 1"}`
+
+func TestThreeWayMergesDisjointEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		incoming string
+		want     string
+	}{
+		{
+			name:     "different subjects",
+			current:  `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":3}},"note":"term 1"}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":2}},"note":"term 1"}`,
+			want:     `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":2}},"note":"term 1"}`,
+		},
+		{
+			name:     "same change on both sides",
+			current:  `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":3}},"note":"term 1"}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":3}},"note":"term 2"}`,
+			want:     `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":3}},"note":"term 2"}`,
+		},
+		{
+			name:     "removal and addition",
+			current:  `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":3}}}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":3},"art":{"grade":5}},"note":"term 1"}`,
+			want:     `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":3},"art":{"grade":5}}}`,
+		},
+		{
+			name:     "null is a value",
+			current:  `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":3}},"note":null}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":5}},"note":"term 1"}`,
+			want:     `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":5}},"note":null}`,
+		},
+		{
+			name:     "no concurrent change",
+			current:  studentBase,
+			incoming: `{"name":"Ana Petrova","subjects":{"math":{"grade":4},"physics":{"grade":3}},"note":"term 1"}`,
+			want:     `{"name":"Ana Petrova","subjects":{"math":{"grade":4},"physics":{"grade":3}},"note":"term 1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := ThreeWay([]byte(studentBase), []byte(tt.current), []byte(tt.incoming))
+			if err != nil {
+				t.Fatalf("ThreeWay: %v", err)
+			}
+			if len(conflicts) > 0 {
+				t.Fatalf("conflicts = %q, want none", conflicts)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("ThreeWay = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreeWayReportsConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		current  string
+		incoming string
+		want     []string
+	}{
+		{
+			name:     "same grade changed differently",
+			base:     studentBase,
+			current:  `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":3}},"note":"term 1"}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":2},"physics":{"grade":3}},"note":"term 1"}`,
+			want:     []string{"/subjects/math/grade"},
+		},
+		{
+			name:     "every overlap listed, sorted",
+			base:     studentBase,
+			current:  `{"name":"Ana","subjects":{"math":{"grade":5},"physics":{"grade":5}},"note":"a"}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":3},"physics":{"grade":2}},"note":"b"}`,
+			want:     []string{"/note", "/subjects/math/grade", "/subjects/physics/grade"},
+		},
+		{
+			name:     "removed on one side, changed on the other",
+			base:     studentBase,
+			current:  `{"name":"Ana","subjects":{"math":{"grade":4}},"note":"term 1"}`,
+			incoming: `{"name":"Ana","subjects":{"math":{"grade":4},"physics":{"grade":5}},"note":"term 1"}`,
+			want:     []string{"/subjects/physics"},
+		},
+		{
+			name:     "arrays are leaves",
+			base:     `{"grades":[4,5]}`,
+			current:  `{"grades":[4,5,3]}`,
+			incoming: `{"grades":[2,4,5]}`,
+			want:     []string{"/grades"},
+		},
+		{
+			name:     "pointer tokens escaped",
+			base:     `{"a/b":1,"c~d":1}`,
+			current:  `{"a/b":2,"c~d":2}`,
+			incoming: `{"a/b":3,"c~d":3}`,
+			want:     []string{"/a~1b", "/c~0d"},
+		},
+		{
+			name:     "whole document replaced",
+			base:     `{"grade":4}`,
+			current:  `[4]`,
+			incoming: `"4"`,
+			want:     []string{"/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := ThreeWay([]byte(tt.base), []byte(tt.current), []byte(tt.incoming))
+			if err != nil {
+				t.Fatalf("ThreeWay: %v", err)
+			}
+			if got != nil {
+				t.Errorf("ThreeWay merged %s despite conflicts", got)
+			}
+			if !reflect.DeepEqual(conflicts, tt.want) {
+				t.Errorf("conflicts = %q, want %q", conflicts, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreeWayRejectsInvalidIncoming(t *testing.T) {
+	if _, _, err := ThreeWay([]byte(studentBase), []byte(studentBase), []byte(`{"name":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("ThreeWay = %v, want ErrInvalidPatch", err)
+	}
+}
@@ -0,0 +1,94 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ThreeWay merges two concurrent edits of the JSON document base: current is what is
+// stored now and incoming is the client's edit of the base it read. Objects are merged
+// member by member, so edits to different fields, e.g. two subjects of the same
+// student, both survive. Any other value is a leaf that only one side may change; when
+// both changed a leaf to different values its JSON Pointer is reported as a conflict
+// and nothing is merged.
+func ThreeWay(base, current, incoming []byte) ([]byte, []string, error) {
+	b, err := decode(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode base document: %w", err)
+	}
+	cur, err := decode(current)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode current document: %w", err)
+	}
+	in, err := decode(incoming)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var conflicts []string
+	merged, present := mergeThreeWay("", b, cur, in, true, true, true, &conflicts)
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, conflicts, nil
+	}
+	if !present {
+		merged = nil
+	}
+	out, err := json.Marshal(merged)
+	return out, nil, err
+}
+
+// mergeThreeWay merges one member; the in* flags tell a missing member apart from null
+func mergeThreeWay(path string, base, current, incoming any, inBase, inCurrent, inIncoming bool, conflicts *[]string) (any, bool) {
+	currentChanged := inBase != inCurrent || !reflect.DeepEqual(base, current)
+	incomingChanged := inBase != inIncoming || !reflect.DeepEqual(base, incoming)
+	switch {
+	case !incomingChanged:
+		return current, inCurrent
+	case !currentChanged:
+		return incoming, inIncoming
+	case inCurrent == inIncoming && reflect.DeepEqual(current, incoming):
+		return incoming, inIncoming
+	}
+
+	baseObj, baseOK := base.(map[string]any)
+	curObj, curOK := current.(map[string]any)
+	inObj, inOK := incoming.(map[string]any)
+	if !baseOK || !curOK || !inOK {
+		*conflicts = append(*conflicts, pathOrRoot(path))
+		return nil, false
+	}
+
+	keys := map[string]struct{}{}
+	for _, obj := range []map[string]any{baseObj, curObj, inObj} {
+		for key := range obj {
+			keys[key] = struct{}{}
+		}
+	}
+	merged := make(map[string]any, len(keys))
+	for key := range keys {
+		b, inB := baseObj[key]
+		c, inC := curObj[key]
+		i, inI := inObj[key]
+		if value, ok := mergeThreeWay(path+"/"+escapeToken(key), b, c, i, inB, inC, inI, conflicts); ok {
+			merged[key] = value
+		}
+	}
+	return merged, true
+}
+
+// escapeToken encodes key as a JSON Pointer reference token
+func escapeToken(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// pathOrRoot reports a conflict on the whole document as "/"
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
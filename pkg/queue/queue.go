@@ -0,0 +1,258 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrEmpty is returned by Reserve when no job is ready
+	ErrEmpty = errors.New("queue is empty")
+	// ErrLeaseLost is returned by Ack and Nack when the job's visibility timeout ran
+	// out and it may already be running elsewhere
+	ErrLeaseLost = errors.New("job lease lost")
+)
+
+// DefaultOptions redeliver a job that was not acked within a minute and give up on
+// it after five attempts
+var DefaultOptions = Options{
+	VisibilityTimeout: time.Minute,
+	Retry:             retry.Policy{MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 5 * time.Minute},
+}
+
+// Options tune delivery of a queue's jobs
+type Options struct {
+	// VisibilityTimeout is how long a reserved job is hidden from other workers; if
+	// it is neither acked nor nacked by then it is delivered again
+	VisibilityTimeout time.Duration
+	// Retry sets how often a job is attempted and how long a nacked job waits
+	// before it is delivered again. Exhausted jobs move to the dead-letter list.
+	Retry retry.Policy
+}
+
+// Job is a unit of work taken off the queue
+type Job struct {
+	ID         string
+	Payload    json.RawMessage
+	Attempts   int
+	EnqueuedAt time.Time
+	LastError  string
+
+	lease string
+}
+
+// Decode unmarshals the job payload into target
+func (j *Job) Decode(target any) error {
+	return json.Unmarshal(j.Payload, target)
+}
+
+// Queue is a reliable FIFO job queue in Redis. Reserved jobs stay in an in-flight
+// set until acked, so a crashed worker's jobs are redelivered once their visibility
+// timeout passes and survive restarts of every service.
+type Queue struct {
+	client *redis.Client
+	name   string
+	opts   Options
+	now    func() time.Time
+}
+
+// New returns the queue called name. Services sharing a name share the jobs.
+func New(client *redis.Client, name string, opts Options) *Queue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = DefaultOptions.VisibilityTimeout
+	}
+	if opts.Retry.MaxAttempts < 1 {
+		opts.Retry.MaxAttempts = 1
+	}
+	return &Queue{client: client, name: name, opts: opts, now: time.Now}
+}
+
+// The braces keep every key of a queue in one cluster slot, as the scripts need
+func (q *Queue) readyKey() string    { return "queue:{" + q.name + "}:ready" }
+func (q *Queue) inflightKey() string { return "queue:{" + q.name + "}:inflight" }
+func (q *Queue) deadKey() string     { return "queue:{" + q.name + "}:dead" }
+func (q *Queue) jobPrefix() string   { return "queue:{" + q.name + "}:job:" }
+
+var enqueueScript = goredis.NewScript(`
+redis.call('HMSET', KEYS[2], 'payload', ARGV[2], 'attempts', 0, 'enqueued_at', ARGV[3])
+redis.call('LPUSH', KEYS[1], ARGV[1])
+return 1
+`)
+
+// reserveScript first returns expired in-flight jobs to the ready list (or the
+// dead-letter list once they used up their attempts), then leases the oldest job
+var reserveScript = goredis.NewScript(`
+local now = tonumber(ARGV[1])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', now)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	local key = ARGV[5] .. id
+	local attempts = tonumber(redis.call('HGET', key, 'attempts') or '0')
+	if attempts >= tonumber(ARGV[3]) then
+		if not redis.call('HGET', key, 'last_error') then
+			redis.call('HSET', key, 'last_error', 'visibility timeout expired')
+		end
+		redis.call('LPUSH', KEYS[3], id)
+	else
+		redis.call('RPUSH', KEYS[1], id)
+	end
+end
+
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+local key = ARGV[5] .. id
+redis.call('ZADD', KEYS[2], now + tonumber(ARGV[2]), id)
+redis.call('HSET', key, 'lease', ARGV[4])
+local attempts = redis.call('HINCRBY', key, 'attempts', 1)
+local fields = redis.call('HMGET', key, 'payload', 'enqueued_at', 'last_error')
+return {id, fields[1] or '', tostring(attempts), fields[2] or '0', fields[3] or ''}
+`)
+
+var ackScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[4], 'lease') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('LREM', KEYS[2], 0, ARGV[1])
+redis.call('LREM', KEYS[3], 0, ARGV[1])
+redis.call('DEL', KEYS[4])
+return 1
+`)
+
+// nackScript returns -1 if the lease was lost, 1 if the job was dead-lettered and
+// 0 if it was scheduled for another attempt
+var nackScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[4], 'lease') ~= ARGV[2] then
+	return -1
+end
+redis.call('HMSET', KEYS[4], 'last_error', ARGV[5], 'lease', '')
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('LREM', KEYS[2], 0, ARGV[1])
+if tonumber(redis.call('HGET', KEYS[4], 'attempts')) >= tonumber(ARGV[4]) then
+	redis.call('LPUSH', KEYS[3], ARGV[1])
+	return 1
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 0
+`)
+
+// Enqueue adds a job with a JSON-encoded payload and returns its ID
+func (q *Queue) Enqueue(ctx context.Context, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	err = q.client.RunScript(ctx, enqueueScript, []string{q.readyKey(), q.jobPrefix() + id},
+		id, data, q.now().UnixMilli()).Err()
+	if err != nil {
+		return "", fmt.Errorf("failed to enqueue job on %s: %w", q.name, err)
+	}
+	return id, nil
+}
+
+// Reserve leases the oldest ready job for the visibility timeout. It returns
+// ErrEmpty when there is nothing to do.
+func (q *Queue) Reserve(ctx context.Context) (*Job, error) {
+	lease, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := q.client.RunScript(ctx, reserveScript,
+		[]string{q.readyKey(), q.inflightKey(), q.deadKey()},
+		q.now().UnixMilli(), q.opts.VisibilityTimeout.Milliseconds(), q.opts.Retry.MaxAttempts, lease, q.client.Key(q.jobPrefix()),
+	).StringSlice()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve job on %s: %w", q.name, err)
+	}
+
+	attempts, _ := strconv.Atoi(res[2])
+	enqueuedAt, _ := strconv.ParseInt(res[3], 10, 64)
+	return &Job{
+		ID:         res[0],
+		Payload:    json.RawMessage(res[1]),
+		Attempts:   attempts,
+		EnqueuedAt: time.UnixMilli(enqueuedAt),
+		LastError:  res[4],
+		lease:      lease,
+	}, nil
+}
+
+// Ack removes a finished job from the queue
+func (q *Queue) Ack(ctx context.Context, job *Job) error {
+	acked, err := q.client.RunScript(ctx, ackScript,
+		[]string{q.inflightKey(), q.readyKey(), q.deadKey(), q.jobPrefix() + job.ID},
+		job.ID, job.lease,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to ack job %s: %w", job.ID, err)
+	}
+	if acked == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Nack reports a failed attempt. The job is delivered again after the retry delay,
+// or moved to the dead-letter list once it used up its attempts; dead reports which.
+func (q *Queue) Nack(ctx context.Context, job *Job, cause error) (dead bool, err error) {
+	message := "unknown error"
+	if cause != nil {
+		message = cause.Error()
+	}
+	retryAt := q.now().Add(q.opts.Retry.Delay(job.Attempts)).UnixMilli()
+
+	result, err := q.client.RunScript(ctx, nackScript,
+		[]string{q.inflightKey(), q.readyKey(), q.deadKey(), q.jobPrefix() + job.ID},
+		job.ID, job.lease, retryAt, q.opts.Retry.MaxAttempts, message,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to nack job %s: %w", job.ID, err)
+	}
+	if result < 0 {
+		return false, ErrLeaseLost
+	}
+	return result == 1, nil
+}
+
+// DeadLetters returns the IDs of up to limit jobs that exhausted their attempts,
+// most recent first. Their payloads stay under the job key for inspection.
+func (q *Queue) DeadLetters(ctx context.Context, limit int) ([]string, error) {
+	ids, err := q.client.RunScript(ctx, deadLettersScript, []string{q.deadKey()}, limit).StringSlice()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("failed to list dead letters on %s: %w", q.name, err)
+	}
+	return ids, nil
+}
+
+var deadLettersScript = goredis.NewScript(`
+return redis.call('LRANGE', KEYS[1], 0, tonumber(ARGV[1]) - 1)
+`)
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
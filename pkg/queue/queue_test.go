@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/redis"
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// fakeClock drives a queue's notion of now so timeouts pass instantly
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestQueue(t *testing.T, opts Options) (*Queue, *fakeClock) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	// The prefix makes sure the scripts build job keys the way the client does
+	client, err := redis.NewClient(server.Addr(), "", 0, time.Minute, redis.WithKeyPrefix("print:"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	clock := &fakeClock{now: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)}
+	q := New(client, "renders", opts)
+	q.now = func() time.Time { return clock.now }
+	return q, clock
+}
+
+type renderJob struct {
+	Certificate string `json:"certificate"`
+}
+
+func reserve(t *testing.T, q *Queue) *Job {
+	t.Helper()
+	job, err := q.Reserve(context.Background())
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	return job
+}
+
+func expectEmpty(t *testing.T, q *Queue) {
+	t.Helper()
+	if job, err := q.Reserve(context.Background()); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("Reserve = %+v, %v, want ErrEmpty", job, err)
+	}
+}
+
+func TestEnqueueReserveAck(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue(t, DefaultOptions)
+
+	first, err := q.Enqueue(ctx, renderJob{Certificate: "first"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := q.Enqueue(ctx, renderJob{Certificate: "second"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	job := reserve(t, q)
+	if job.ID != first || job.Attempts != 1 {
+		t.Errorf("reserved %s attempt %d, want %s attempt 1", job.ID, job.Attempts, first)
+	}
+	var payload renderJob
+	if err := job.Decode(&payload); err != nil || payload.Certificate != "first" {
+		t.Errorf("Decode = %+v, %v, want the first job", payload, err)
+	}
+	if err := q.Ack(ctx, job); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if err := q.Ack(ctx, job); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("second Ack = %v, want ErrLeaseLost", err)
+	}
+
+	second := reserve(t, q)
+	if err := second.Decode(&payload); err != nil || payload.Certificate != "second" {
+		t.Errorf("Decode = %+v, %v, want the second job", payload, err)
+	}
+	expectEmpty(t, q)
+}
+
+func TestReserveRedeliversAfterVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	q, clock := newTestQueue(t, Options{VisibilityTimeout: 30 * time.Second, Retry: retry.Policy{MaxAttempts: 3}})
+
+	id, err := q.Enqueue(ctx, renderJob{Certificate: "slow"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	stalled := reserve(t, q)
+
+	clock.advance(29 * time.Second)
+	expectEmpty(t, q)
+
+	clock.advance(2 * time.Second)
+	redelivered := reserve(t, q)
+	if redelivered.ID != id || redelivered.Attempts != 2 {
+		t.Errorf("redelivered %s attempt %d, want %s attempt 2", redelivered.ID, redelivered.Attempts, id)
+	}
+
+	// The stalled worker lost its lease to the redelivery
+	if err := q.Ack(ctx, stalled); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("stale Ack = %v, want ErrLeaseLost", err)
+	}
+	if _, err := q.Nack(ctx, stalled, errors.New("late")); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("stale Nack = %v, want ErrLeaseLost", err)
+	}
+	if err := q.Ack(ctx, redelivered); err != nil {
+		t.Errorf("Ack: %v", err)
+	}
+	expectEmpty(t, q)
+}
+
+func TestNackDeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	q, clock := newTestQueue(t, Options{
+		VisibilityTimeout: time.Minute,
+		Retry:             retry.Policy{MaxAttempts: 2, BaseDelay: 10 * time.Second},
+	})
+
+	id, err := q.Enqueue(ctx, renderJob{Certificate: "broken"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	job := reserve(t, q)
+	if dead, err := q.Nack(ctx, job, errors.New("template missing")); err != nil || dead {
+		t.Fatalf("first Nack = %v, %v, want a retry", dead, err)
+	}
+
+	// The retry waits out the backoff delay
+	expectEmpty(t, q)
+	clock.advance(10 * time.Second)
+	job = reserve(t, q)
+	if job.ID != id || job.Attempts != 2 || job.LastError != "template missing" {
+		t.Errorf("retry = %s attempt %d error %q, want %s attempt 2 with the last error", job.ID, job.Attempts, job.LastError, id)
+	}
+
+	if dead, err := q.Nack(ctx, job, errors.New("template still missing")); err != nil || !dead {
+		t.Fatalf("last Nack = %v, %v, want dead-lettered", dead, err)
+	}
+	clock.advance(time.Hour)
+	expectEmpty(t, q)
+
+	dead, err := q.DeadLetters(ctx, 10)
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+	if !slices.Equal(dead, []string{id}) {
+		t.Errorf("DeadLetters = %v, want [%s]", dead, id)
+	}
+}
+
+func TestVisibilityTimeoutDeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	q, clock := newTestQueue(t, Options{VisibilityTimeout: time.Second, Retry: retry.Policy{MaxAttempts: 1}})
+
+	id, err := q.Enqueue(ctx, renderJob{Certificate: "crashes"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	reserve(t, q)
+
+	clock.advance(2 * time.Second)
+	expectEmpty(t, q)
+
+	dead, err := q.DeadLetters(ctx, 10)
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+	if !slices.Equal(dead, []string{id}) {
+		t.Errorf("DeadLetters = %v, want [%s]", dead, id)
+	}
+}
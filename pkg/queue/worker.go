@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Handler processes one job; returning an error nacks it for a later retry
+type Handler func(ctx context.Context, job *Job) error
+
+// Worker runs jobs from a queue in the background, acking the ones its handler
+// completes and nacking the ones that fail
+type Worker struct {
+	queue        *Queue
+	handler      Handler
+	concurrency  int
+	pollInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker processes up to concurrency jobs at a time, polling the queue every
+// pollInterval while it is empty
+func NewWorker(queue *Queue, handler Handler, concurrency int, pollInterval time.Duration) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &Worker{queue: queue, handler: handler, concurrency: concurrency, pollInterval: pollInterval}
+}
+
+// Start launches the worker loops
+func (w *Worker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop(ctx)
+		}()
+	}
+}
+
+// Close stops reserving jobs and waits for running ones to finish or ctx to end.
+// Jobs cut off by ctx are redelivered after their visibility timeout.
+func (w *Worker) Close(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.queue.Reserve(ctx)
+		if err != nil {
+			if !errors.Is(err, ErrEmpty) && ctx.Err() == nil {
+				log.Error().Err(err).Str("queue", w.queue.name).Msg("Failed to reserve job")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+
+		w.run(job)
+	}
+}
+
+// run handles a job outside the stop context, so shutdown lets it finish instead of
+// cancelling it halfway
+func (w *Worker) run(job *Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.queue.opts.VisibilityTimeout)
+	defer cancel()
+
+	logger := log.With().Str("queue", w.queue.name).Str("job", job.ID).Int("attempt", job.Attempts).Logger()
+
+	if err := w.handler(ctx, job); err != nil {
+		dead, nackErr := w.queue.Nack(ctx, job, err)
+		switch {
+		case nackErr != nil:
+			logger.Error().Err(nackErr).Msg("Failed to nack job")
+		case dead:
+			logger.Error().Err(err).Msg("Job failed for the last time, moved to dead letters")
+		default:
+			logger.Warn().Err(err).Msg("Job failed, will be retried")
+		}
+		return
+	}
+
+	if err := w.queue.Ack(ctx, job); err != nil {
+		logger.Error().Err(err).Msg("Failed to ack job")
+	}
+}
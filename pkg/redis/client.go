@@ -2,21 +2,134 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/PegasusMKD/svedprint-go/pkg/retry"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
 // Client wraps the Redis client with helper methods
 type Client struct {
-	client *redis.Client
-	ttl    time.Duration
+	client      *redis.Client
+	ttl         time.Duration
+	retryPolicy retry.Policy
+	codec       Codec
+
+	// readOnlyFor is how long writes are skipped after an OOM reply; zero disables it
+	readOnlyFor   time.Duration
+	readOnlyUntil atomic.Int64
+
+	coalesce bool
+	flights  singleflight.Group
+
+	// compressAbove is the encoded size past which values are gzipped; zero disables it
+	compressAbove int
+
+	// prefix is prepended to every key the client touches
+	prefix string
+
+	// negativeTTL is how long GetOrSet remembers a key as not found; zero disables it
+	negativeTTL time.Duration
+}
+
+// DefaultNegativeTTL is how long GetOrSet remembers a key as not found by default. It
+// is kept short so a record created after the lookup shows up quickly.
+const DefaultNegativeTTL = 30 * time.Second
+
+// Option configures optional Client behaviour
+type Option func(*Client)
+
+// WithRetryPolicy retries operations that fail with transient errors (connection
+// resets, failover LOADING/MOVED/ASK replies). By default operations are not retried.
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// maxRetryDelay caps the backoff of WithRetry; a failover that outlasts it surfaces
+// as an error rather than stalling requests
+const maxRetryDelay = time.Second
+
+// WithRetry retries transient failures up to retries times, waiting baseDelay before
+// the first retry and doubling it, with jitter, before each further one. Cache misses
+// and encoding errors are never retried. Zero retries keeps the default of none.
+func WithRetry(retries int, baseDelay time.Duration) Option {
+	return WithRetryPolicy(retry.Policy{
+		MaxAttempts: retries + 1,
+		BaseDelay:   baseDelay,
+		MaxDelay:    maxRetryDelay,
+		Jitter:      true,
+	})
+}
+
+// WithKeyPrefix namespaces every key the client reads or writes, e.g. "gateway:" so
+// services sharing one Redis don't collide on generic keys like "user:<id>". Callers
+// keep using unprefixed keys. Pub/sub channels are not prefixed.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// WithCodec sets the codec used by writes that don't pick one explicitly (JSON by
+// default); codecs other than JSONCodec and GobCodec must be registered first
+func WithCodec(codec Codec) Option {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithReadOnlyOnOOM stops sending cache writes for cooldown once Redis rejects one
+// because it reached maxmemory, so a full server isn't hammered with writes that will
+// fail anyway. Writes return ErrCacheFull until the cooldown passes; reads continue.
+func WithReadOnlyOnOOM(cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.readOnlyFor = max(cooldown, 0)
+	}
+}
+
+// WithCompression gzips values whose encoded size exceeds threshold bytes, e.g. large
+// report payloads. Get decompresses them transparently, and values written before
+// compression was enabled stay readable. A threshold of 0 disables compression.
+func WithCompression(threshold int) Option {
+	return func(c *Client) {
+		c.compressAbove = max(threshold, 0)
+	}
+}
+
+// WithStampedeProtection makes concurrent GetOrSet misses on the same key wait for a
+// single call of fn instead of each computing the value. It coalesces callers within
+// this process only; other instances may still compute the value once each.
+func WithStampedeProtection() Option {
+	return func(c *Client) {
+		c.coalesce = true
+	}
+}
+
+// WithNegativeTTL sets how long GetOrSet and GetOrSetTyped remember that fn found no
+// record. A non-positive ttl disables negative caching.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.negativeTTL = max(ttl, 0)
+	}
 }
 
 // NewClient creates a new Redis client
-func NewClient(addr, password string, db int, ttl time.Duration) (*Client, error) {
+func NewClient(addr, password string, db int, ttl time.Duration, opts ...Option) (*Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
@@ -31,10 +144,59 @@ func NewClient(addr, password string, db int, ttl time.Duration) (*Client, error
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &Client{
-		client: client,
-		ttl:    ttl,
-	}, nil
+	c := &Client{
+		client:      client,
+		ttl:         ttl,
+		retryPolicy: retry.NoRetry,
+		codec:       JSONCodec,
+		negativeTTL: DefaultNegativeTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// healthTimeout bounds Health so a hung Redis can't stall a readiness probe
+const healthTimeout = time.Second
+
+// Ping checks that Redis answers, waiting as long as ctx allows
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
+	}
+	return nil
+}
+
+// Health is Ping bounded by a short timeout, for readiness probes
+func (c *Client) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	return c.Ping(ctx)
+}
+
+// Key returns the raw Redis key for key, with the client's prefix, for key names a
+// script receives as arguments rather than in KEYS
+func (c *Client) Key(key string) string {
+	return c.prefix + key
+}
+
+// keys prefixes each of keys
+func (c *Client) keys(keys []string) []string {
+	if c.prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return prefixed
+}
+
+// PoolStats returns the connection pool counters of the underlying client
+func (c *Client) PoolStats() *redis.PoolStats {
+	return c.client.PoolStats()
 }
 
 // Close closes the Redis connection
@@ -44,19 +206,28 @@ func (c *Client) Close() error {
 
 // Get retrieves a value from Redis and unmarshals it into the target
 func (c *Client) Get(ctx context.Context, key string, target any) error {
-	val, err := c.client.Get(ctx, key).Result()
+	val, err := c.getEncoded(ctx, key)
 	if err != nil {
-		if err == redis.Nil {
-			return ErrCacheMiss
-		}
-		return fmt.Errorf("failed to get from Redis: %w", err)
+		return err
 	}
+	return decode(val, target)
+}
 
-	if err := json.Unmarshal([]byte(val), target); err != nil {
-		return fmt.Errorf("failed to unmarshal Redis value: %w", err)
+// getEncoded retrieves a value from Redis without decoding it
+func (c *Client) getEncoded(ctx context.Context, key string) ([]byte, error) {
+	var val []byte
+	err := c.withRetry(ctx, func() error {
+		var err error
+		val, err = c.client.Get(ctx, c.Key(key)).Bytes()
+		return err
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrCacheMiss
+		}
+		return nil, fmt.Errorf("failed to get from Redis: %w", err)
 	}
-
-	return nil
+	return val, nil
 }
 
 // Set stores a value in Redis with the default TTL
@@ -66,29 +237,150 @@ func (c *Client) Set(ctx context.Context, key string, value any) error {
 
 // SetWithTTL stores a value in Redis with a custom TTL
 func (c *Client) SetWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
-	data, err := json.Marshal(value)
+	return c.SetWithCodec(ctx, key, value, ttl, c.codec)
+}
+
+// SetWithCodec stores a value serialized with a specific codec, e.g. GobCodec for a
+// large grade matrix, while other keys keep the client's default. Get decodes it
+// transparently.
+func (c *Client) SetWithCodec(ctx context.Context, key string, value any, ttl time.Duration, codec Codec) error {
+	data, err := c.encode(codec, value)
 	if err != nil {
-		return fmt.Errorf("failed to marshal value: %w", err)
+		return err
 	}
+	return c.setEncoded(ctx, key, data, ttl)
+}
 
-	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+// setEncoded stores a value that was already encoded
+func (c *Client) setEncoded(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	err := c.write(ctx, func() error {
+		return c.client.Set(ctx, c.Key(key), data, ttl).Err()
+	})
+	if err != nil {
 		return fmt.Errorf("failed to set in Redis: %w", err)
 	}
 
 	return nil
 }
 
+// SetTombstone records key as known to be missing for ttl, so lookups of IDs that
+// don't exist stop reaching the database. Get returns ErrTombstone for it.
+func (c *Client) SetTombstone(ctx context.Context, key string, ttl time.Duration) error {
+	err := c.write(ctx, func() error {
+		return c.client.Set(ctx, c.Key(key), []byte{formatMarker, tombstoneID}, ttl).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tombstone in Redis: %w", err)
+	}
+	return nil
+}
+
+// SetMany stores many values in a single round trip, e.g. when warming the cache.
+// MSET has no per-key TTL, so this pipelines one SET per entry instead.
+// A non-positive ttl uses the default TTL.
+func (c *Client) SetMany(ctx context.Context, entries map[string]any, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	values := make(map[string][]byte, len(entries))
+	for key, value := range entries {
+		data, err := c.encode(c.codec, value)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key, err)
+		}
+		values[key] = data
+	}
+
+	err := c.write(ctx, func() error {
+		_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for key, data := range values {
+				pipe.Set(ctx, c.Key(key), data, ttl)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set many in Redis: %w", err)
+	}
+
+	return nil
+}
+
+// MSet is SetMany, named to pair with MGet
+func (c *Client) MSet(ctx context.Context, entries map[string]any, ttl time.Duration) error {
+	return c.SetMany(ctx, entries, ttl)
+}
+
+// MGet reads many keys in a single round trip, decoding each hit into the target at
+// the same index. The returned slice reports which keys were hits; missing keys and
+// tombstones leave their target untouched.
+func (c *Client) MGet(ctx context.Context, keys []string, targets []any) ([]bool, error) {
+	if len(keys) != len(targets) {
+		return nil, fmt.Errorf("MGet got %d keys but %d targets", len(keys), len(targets))
+	}
+	hits := make([]bool, len(keys))
+	if len(keys) == 0 {
+		return hits, nil
+	}
+
+	var cmds []redis.Cmder
+	err := c.withRetry(ctx, func() error {
+		var err error
+		cmds, err = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Get(ctx, c.Key(key))
+			}
+			return nil
+		})
+		// A pipeline reports the first command's error; misses are expected here
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get many from Redis: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		data, err := cmd.(*redis.StringCmd).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s from Redis: %w", keys[i], err)
+		}
+		if err := decode(data, targets[i]); err != nil {
+			if errors.Is(err, ErrTombstone) {
+				continue
+			}
+			return nil, fmt.Errorf("key %s: %w", keys[i], err)
+		}
+		hits[i] = true
+	}
+	return hits, nil
+}
+
 // Delete removes a key from Redis
 func (c *Client) Delete(ctx context.Context, keys ...string) error {
-	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+	err := c.withRetry(ctx, func() error {
+		return c.client.Del(ctx, c.keys(keys)...).Err()
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete from Redis: %w", err)
 	}
 	return nil
 }
 
-// DeletePattern deletes all keys matching a pattern
+// DeletePattern deletes all keys matching a pattern. The pattern is matched within the
+// client's prefix, so it never reaches another namespace.
 func (c *Client) DeletePattern(ctx context.Context, pattern string) error {
-	iter := c.client.Scan(ctx, 0, pattern, 0).Iterator()
+	iter := c.client.Scan(ctx, 0, escapeGlob(c.prefix)+pattern, 0).Iterator()
 	var keys []string
 
 	for iter.Next(ctx) {
@@ -110,7 +402,12 @@ func (c *Client) DeletePattern(ctx context.Context, pattern string) error {
 
 // Exists checks if a key exists in Redis
 func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
-	count, err := c.client.Exists(ctx, key).Result()
+	var count int64
+	err := c.withRetry(ctx, func() error {
+		var err error
+		count, err = c.client.Exists(ctx, c.Key(key)).Result()
+		return err
+	})
 	if err != nil {
 		return false, fmt.Errorf("failed to check key existence: %w", err)
 	}
@@ -119,7 +416,10 @@ func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 
 // Expire sets an expiration time on a key
 func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) error {
-	if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
+	err := c.withRetry(ctx, func() error {
+		return c.client.Expire(ctx, c.Key(key), ttl).Err()
+	})
+	if err != nil {
 		return fmt.Errorf("failed to set expiration: %w", err)
 	}
 	return nil
@@ -127,7 +427,7 @@ func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) erro
 
 // Increment increments a key by 1
 func (c *Client) Increment(ctx context.Context, key string) (int64, error) {
-	val, err := c.client.Incr(ctx, key).Result()
+	val, err := c.client.Incr(ctx, c.Key(key)).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment: %w", err)
 	}
@@ -136,47 +436,424 @@ func (c *Client) Increment(ctx context.Context, key string) (int64, error) {
 
 // IncrementBy increments a key by a specific amount
 func (c *Client) IncrementBy(ctx context.Context, key string, amount int64) (int64, error) {
-	val, err := c.client.IncrBy(ctx, key, amount).Result()
+	val, err := c.client.IncrBy(ctx, c.Key(key), amount).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment by: %w", err)
 	}
 	return val, nil
 }
 
-// GetOrSet implements the cache-aside pattern: get from cache, or execute fn and cache the result
+// tagKeyPrefix namespaces the sets that index cached keys by tag
+const tagKeyPrefix = "tag:"
+
+// invalidateTagScript atomically deletes every key indexed under a tag along with the tag set itself
+var invalidateTagScript = redis.NewScript(`
+local keys = redis.call('SMEMBERS', KEYS[1])
+for i = 1, #keys, 500 do
+	redis.call('DEL', unpack(keys, i, math.min(i + 499, #keys)))
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+// SetWithTags stores a value and indexes its key under each tag (e.g. "class:42") so it
+// can later be removed with InvalidateTag. A non-positive ttl uses the default TTL.
+func (c *Client) SetWithTags(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	data, err := c.encode(c.codec, value)
+	if err != nil {
+		return err
+	}
+
+	err = c.write(ctx, func() error {
+		_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, c.Key(key), data, ttl)
+			for _, tag := range tags {
+				tagKey := c.Key(tagKeyPrefix + tag)
+				// Members are raw keys so invalidateTagScript can delete them directly
+				pipe.SAdd(ctx, tagKey, c.Key(key))
+				// Keep the index alive at least as long as its longest-lived member
+				pipe.ExpireNX(ctx, tagKey, ttl)
+				pipe.ExpireGT(ctx, tagKey, ttl)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tagged value in Redis: %w", err)
+	}
+
+	return nil
+}
+
+// TagMembers returns the keys indexed under tag. Keys that have since expired or
+// been deleted may still be listed until the index itself expires.
+func (c *Client) TagMembers(ctx context.Context, tag string) ([]string, error) {
+	var keys []string
+	err := c.withRetry(ctx, func() error {
+		var err error
+		keys, err = c.client.SMembers(ctx, c.Key(tagKeyPrefix+tag)).Result()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag %s: %w", tag, err)
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, c.prefix)
+	}
+	return keys, nil
+}
+
+// InvalidateTag deletes all keys tagged with tag and removes the tag index.
+// It returns the number of keys that were indexed under the tag.
+func (c *Client) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	count, err := invalidateTagScript.Run(ctx, c.client, []string{c.Key(tagKeyPrefix + tag)}).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate tag %s: %w", tag, err)
+	}
+	return count, nil
+}
+
+// GetOrSet implements the cache-aside pattern: get from cache, or execute fn and cache
+// the result. Caching is best effort; a failed write still returns the result. When fn
+// reports the record missing (database.ErrNotFound or apierror.ErrNotFound) a
+// tombstone is cached for the negative TTL and fn's error is returned wrapped in
+// apierror.ErrNotFound; a tombstoned key returns it without calling fn. With
+// WithStampedeProtection, concurrent misses on a key in this process share one call
+// of fn.
 func (c *Client) GetOrSet(ctx context.Context, key string, target any, fn func() (any, error)) error {
-	// Try to get from cache
-	err := c.Get(ctx, key, target)
+	data, err := c.getEncoded(ctx, key)
+	if err == nil {
+		err = decode(data, target)
+	}
 	if err == nil {
-		return nil // Cache hit
+		return nil
+	}
+	if errors.Is(err, ErrTombstone) {
+		return fmt.Errorf("%w: %w", apierror.ErrNotFound, err)
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		log.Debug().Err(err).Str("key", key).Msg("Cache read failed, computing value")
 	}
 
-	if err != ErrCacheMiss {
-		// Log the error but continue to execute fn
-		// In production, you might want to use proper logging here
+	load := func() (any, error) {
+		result, err := fn()
+		if isNotFound(err) {
+			c.rememberNotFound(ctx, key)
+			return nil, notFoundError(err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.encode(c.codec, result)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.setEncoded(ctx, key, data, c.ttl)
+		return data, nil
 	}
 
-	// Cache miss - execute the function
-	result, err := fn()
+	var loaded any
+	if c.coalesce {
+		loaded, err, _ = c.flights.Do(key, load)
+	} else {
+		loaded, err = load()
+	}
 	if err != nil {
 		return err
 	}
 
-	// Store in cache (best effort - don't fail if caching fails)
-	_ = c.Set(ctx, key, result)
+	// Each caller decodes its own copy, so waiters never share the computed value
+	return decode(loaded.([]byte), target)
+}
+
+// GetOrSetTyped is GetOrSet for a known type: a hit is decoded straight into a T and a
+// computed value is cached with ttl and returned as is, without a decode round-trip.
+// Under WithStampedeProtection, callers that waited on another's call of fn receive
+// the same value, so it should not be mutated. Missing records are cached and reported
+// as in GetOrSet.
+func GetOrSetTyped[T any](ctx context.Context, c *Client, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	var value T
+	data, err := c.getEncoded(ctx, key)
+	if err == nil {
+		err = decode(data, &value)
+	}
+	if err == nil {
+		return value, nil
+	}
+	if errors.Is(err, ErrTombstone) {
+		return value, fmt.Errorf("%w: %w", apierror.ErrNotFound, err)
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		log.Debug().Err(err).Str("key", key).Msg("Cache read failed, computing value")
+	}
 
-	// Copy result to target
-	data, err := json.Marshal(result)
+	load := func() (any, error) {
+		result, err := fn()
+		if isNotFound(err) {
+			c.rememberNotFound(ctx, key)
+			return nil, notFoundError(err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if data, err := c.encode(c.codec, result); err == nil {
+			_ = c.setEncoded(ctx, key, data, ttl)
+		}
+		return result, nil
+	}
+
+	var result any
+	if c.coalesce {
+		// Keyed by type too, so GetOrSet or another T on the same key never shares the flight
+		result, err, _ = c.flights.Do(fmt.Sprintf("%T\x00%s", value, key), load)
+	} else {
+		result, err = load()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal result: %w", err)
+		var zero T
+		return zero, err
 	}
+	return result.(T), nil
+}
 
-	if err := json.Unmarshal(data, target); err != nil {
-		return fmt.Errorf("failed to unmarshal result: %w", err)
+// isNotFound reports whether a GetOrSet loader found no record. QueryDB already
+// wraps pgx's no rows error in database.ErrNotFound.
+func isNotFound(err error) bool {
+	return errors.Is(err, database.ErrNotFound) || errors.Is(err, apierror.ErrNotFound)
+}
+
+// notFoundError reports a loader's not found error as apierror.ErrNotFound, keeping
+// the loader's error in the chain
+func notFoundError(err error) error {
+	if errors.Is(err, apierror.ErrNotFound) {
+		return err
 	}
+	return fmt.Errorf("%w: %w", apierror.ErrNotFound, err)
+}
 
-	return nil
+// rememberNotFound caches key as not found for the negative TTL, best effort
+func (c *Client) rememberNotFound(ctx context.Context, key string) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+	if err := c.SetTombstone(ctx, key, c.negativeTTL); err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("Failed to cache not found, will load again")
+	}
 }
 
 // ErrCacheMiss is returned when a key is not found in the cache
 var ErrCacheMiss = fmt.Errorf("cache miss")
+
+// ErrCacheFull is wrapped by write errors when Redis is out of memory (maxmemory with a
+// non-evicting policy) or the client is read-only after such an error
+var ErrCacheFull = errors.New("redis is out of memory")
+
+// ErrCacheReadOnly is returned for writes skipped while the client is read-only; it
+// wraps ErrCacheFull
+var ErrCacheReadOnly = fmt.Errorf("%w, cache writes paused", ErrCacheFull)
+
+// write runs a cache write under the retry policy, translating OOM replies into
+// ErrCacheFull and skipping the write entirely while the client is read-only
+func (c *Client) write(ctx context.Context, fn func() error) error {
+	if c.ReadOnly() {
+		return ErrCacheReadOnly
+	}
+
+	err := c.withRetry(ctx, fn)
+	if !isOOM(err) {
+		return err
+	}
+
+	if c.readOnlyFor > 0 {
+		until := time.Now().Add(c.readOnlyFor).UnixNano()
+		if previous := c.readOnlyUntil.Swap(until); previous < time.Now().UnixNano() {
+			log.Warn().Dur("cooldown", c.readOnlyFor).Msg("Redis is out of memory, pausing cache writes")
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrCacheFull, err)
+}
+
+// ReadOnly reports whether cache writes are paused after an OOM reply
+func (c *Client) ReadOnly() bool {
+	return time.Now().UnixNano() < c.readOnlyUntil.Load()
+}
+
+// isOOM reports whether err is Redis refusing a write because it reached maxmemory
+func isOOM(err error) bool {
+	var redisErr redis.Error
+	return errors.As(err, &redisErr) && strings.HasPrefix(redisErr.Error(), "OOM ")
+}
+
+// withRetry runs a raw Redis command under the client's retry policy. Non-idempotent
+// commands such as INCR are deliberately not wrapped.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	return retry.Do(ctx, c.retryPolicy, isRetryable, fn)
+}
+
+// retryableReplyPrefixes are Redis error replies that indicate a transient server state
+var retryableReplyPrefixes = []string{"LOADING ", "MOVED ", "ASK ", "TRYAGAIN ", "CLUSTERDOWN ", "MASTERDOWN "}
+
+// escapeGlob escapes the characters SCAN MATCH treats as a pattern
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isRetryable reports whether err is a transient failure worth retrying. Logical
+// results such as a missing key are never retried.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, ErrCacheMiss) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, prefix := range retryableReplyPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunScript runs a Lua script with the client, for packages such as queue whose
+// operations span several keys and must apply atomically. keys are prefixed like any
+// other; key names passed in args must be built with Key.
+func (c *Client) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	return script.Run(ctx, c.client, c.keys(keys), args...)
+}
+
+// dedupKeyPrefix namespaces the markers written by ClaimOnce
+const dedupKeyPrefix = "dedup:"
+
+// ClaimOnce atomically claims id for ttl using SETNX. Exactly one caller across all
+// replicas gets true; everyone else gets false until the claim expires.
+func (c *Client) ClaimOnce(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	claimed, err := c.client.SetNX(ctx, c.Key(dedupKeyPrefix+id), time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim %s: %w", id, err)
+	}
+	return claimed, nil
+}
+
+// lockKeyPrefix namespaces the locks taken by AcquireLock
+const lockKeyPrefix = "lock:"
+
+var (
+	// ErrLockNotAcquired is returned by AcquireLock when another holder owns the lock
+	ErrLockNotAcquired = errors.New("lock is held by another owner")
+	// ErrLockNotHeld is returned by ReleaseLock when the lock expired or was taken over
+	ErrLockNotHeld = errors.New("lock is not held by this owner")
+)
+
+// releaseLockScript deletes the lock only if it still holds the caller's token
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock takes the lock on key for ttl with SET NX PX, so only one caller across
+// all replicas holds it. The returned token identifies the holder and must be passed
+// to ReleaseLock. When the lock is already held, acquired is false and err is
+// ErrLockNotAcquired. The lock expires after ttl even if it is never released.
+func (c *Client) AcquireLock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token = hex.EncodeToString(buf)
+
+	acquired, err = c.client.SetNX(ctx, c.Key(lockKeyPrefix+key), token, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return "", false, ErrLockNotAcquired
+	}
+	return token, true, nil
+}
+
+// ReleaseLock releases a lock taken by AcquireLock. A lock that has since expired or
+// been taken by someone else is left alone and ErrLockNotHeld is returned.
+func (c *Client) ReleaseLock(ctx context.Context, key, token string) error {
+	deleted, err := releaseLockScript.Run(ctx, c.client, []string{c.Key(lockKeyPrefix + key)}, token).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", key, err)
+	}
+	if deleted == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// rateLimitKeyPrefix namespaces the request logs kept by AllowN
+const rateLimitKeyPrefix = "ratelimit:"
+
+// slidingWindowScript keeps a sorted set of request timestamps (in microseconds of the
+// Redis clock, so replicas agree) and admits a request while fewer than the limit
+// fall inside the window. Returns {allowed, remaining}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count >= limit then
+	return {0, 0}
+end
+
+redis.call('ZADD', KEYS[1], now, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+return {1, limit - count - 1}
+`)
+
+// AllowN records a request against key and reports whether it fits within limit
+// requests per sliding window, along with how many more requests the window admits.
+// Denied requests are not recorded, so a client backing off recovers as soon as its
+// oldest request leaves the window. The check is a single atomic script, safe across
+// replicas.
+func (c *Client) AllowN(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, err error) {
+	if limit <= 0 {
+		return false, 0, nil
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return false, 0, fmt.Errorf("failed to generate request ID: %w", err)
+	}
+
+	result, err := slidingWindowScript.Run(ctx, c.client, []string{c.Key(rateLimitKeyPrefix + key)},
+		limit, window.Microseconds(), hex.EncodeToString(buf)).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check rate limit %s: %w", key, err)
+	}
+	return result[0] == 1, int(result[1]), nil
+}
@@ -0,0 +1,938 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PegasusMKD/svedprint-go/pkg/apierror"
+	"github.com/PegasusMKD/svedprint-go/pkg/database"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetOrSetServesTombstoneUntilItExpires(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	if err := client.SetTombstone(ctx, "student:42", time.Minute); err != nil {
+		t.Fatalf("SetTombstone: %v", err)
+	}
+
+	calls := 0
+	load := func() (any, error) {
+		calls++
+		return "Ana", nil
+	}
+
+	var name string
+	err := client.GetOrSet(ctx, "student:42", &name, load)
+	if !errors.Is(err, apierror.ErrNotFound) || !errors.Is(err, ErrTombstone) {
+		t.Fatalf("GetOrSet on a tombstone = %v, want ErrNotFound", err)
+	}
+	if calls != 0 {
+		t.Fatalf("GetOrSet loaded a tombstoned key %d times", calls)
+	}
+
+	server.FastForward(time.Minute + time.Second)
+
+	if err := client.GetOrSet(ctx, "student:42", &name, load); err != nil {
+		t.Fatalf("GetOrSet after the tombstone expired: %v", err)
+	}
+	if calls != 1 || name != "Ana" {
+		t.Errorf("after expiry: %d loads, name %q", calls, name)
+	}
+}
+
+func TestGetOrSetTypedServesTombstone(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, miniredis.RunT(t))
+
+	if err := client.SetTombstone(ctx, "student:42", time.Minute); err != nil {
+		t.Fatalf("SetTombstone: %v", err)
+	}
+	_, err := GetOrSetTyped(ctx, client, "student:42", time.Minute, func() (string, error) {
+		t.Error("loaded a tombstoned key")
+		return "Ana", nil
+	})
+	if !errors.Is(err, apierror.ErrNotFound) {
+		t.Errorf("GetOrSetTyped on a tombstone = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetOrSetCachesNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	for _, notFound := range []error{fmt.Errorf("get student: %w", database.ErrNotFound), apierror.ErrNotFound} {
+		server := miniredis.RunT(t)
+		client := newTestClient(t, server)
+
+		calls := 0
+		load := func() (any, error) {
+			calls++
+			return nil, notFound
+		}
+
+		var name string
+		if err := client.GetOrSet(ctx, "student:42", &name, load); !errors.Is(err, apierror.ErrNotFound) || !errors.Is(err, notFound) {
+			t.Fatalf("%v: GetOrSet = %v, want ErrNotFound wrapping the loader's error", notFound, err)
+		}
+		if err := client.GetOrSet(ctx, "student:42", &name, load); !errors.Is(err, apierror.ErrNotFound) {
+			t.Fatalf("%v: GetOrSet on the tombstone = %v, want ErrNotFound", notFound, err)
+		}
+		if calls != 1 {
+			t.Errorf("%v: loaded %d times, want once", notFound, calls)
+		}
+		if ttl := server.TTL("student:42"); ttl != DefaultNegativeTTL {
+			t.Errorf("%v: tombstone TTL = %s, want %s", notFound, ttl, DefaultNegativeTTL)
+		}
+	}
+}
+
+func TestGetOrSetTypedCachesNotFound(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithNegativeTTL(5*time.Second))
+
+	calls := 0
+	load := func() (report, error) {
+		calls++
+		if calls == 1 {
+			return report{}, database.ErrNotFound
+		}
+		return report{Class: "VI-2"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := GetOrSetTyped(ctx, client, "report:class-7", time.Minute, load); !errors.Is(err, apierror.ErrNotFound) {
+			t.Fatalf("GetOrSetTyped %d = %v, want ErrNotFound", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loaded %d times while the tombstone was live, want once", calls)
+	}
+
+	// A record created after the lookup shows up once the tombstone expires
+	server.FastForward(6 * time.Second)
+	if got, err := GetOrSetTyped(ctx, client, "report:class-7", time.Minute, load); err != nil || got.Class != "VI-2" {
+		t.Errorf("GetOrSetTyped after expiry = %+v, %v, want VI-2", got, err)
+	}
+}
+
+func TestGetOrSetNegativeCachingDisabled(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithNegativeTTL(0))
+
+	var name string
+	err := client.GetOrSet(ctx, "student:42", &name, func() (any, error) { return nil, database.ErrNotFound })
+	if !errors.Is(err, apierror.ErrNotFound) {
+		t.Errorf("GetOrSet = %v, want ErrNotFound", err)
+	}
+	if server.Exists("student:42") {
+		t.Error("a tombstone was cached with negative caching disabled")
+	}
+}
+
+func TestInvalidateTagDeletesEveryTaggedKey(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	entries := map[string][]string{
+		"class:42:roster":  {"class:42"},
+		"class:42:grades":  {"class:42", "school:7"},
+		"class:43:roster":  {"class:43"},
+		"school:7:summary": {"school:7"},
+	}
+	for key, tags := range entries {
+		if err := client.SetWithTags(ctx, key, key, time.Minute, tags...); err != nil {
+			t.Fatalf("SetWithTags(%s): %v", key, err)
+		}
+	}
+
+	members, err := client.TagMembers(ctx, "class:42")
+	if err != nil {
+		t.Fatalf("TagMembers: %v", err)
+	}
+	sort.Strings(members)
+	if want := []string{"class:42:grades", "class:42:roster"}; !slices.Equal(members, want) {
+		t.Errorf("TagMembers(class:42) = %v, want %v", members, want)
+	}
+
+	count, err := client.InvalidateTag(ctx, "class:42")
+	if err != nil {
+		t.Fatalf("InvalidateTag: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("InvalidateTag removed %d keys, want 2", count)
+	}
+
+	for key, wantExists := range map[string]bool{
+		"class:42:roster":  false,
+		"class:42:grades":  false,
+		"class:43:roster":  true,
+		"school:7:summary": true,
+	} {
+		if got := server.Exists(client.Key(key)); got != wantExists {
+			t.Errorf("%s exists = %v, want %v", key, got, wantExists)
+		}
+	}
+	if server.Exists(client.Key(tagKeyPrefix + "class:42")) {
+		t.Error("tag index survived invalidation")
+	}
+
+	// Invalidating again is a no-op
+	if count, err := client.InvalidateTag(ctx, "class:42"); err != nil || count != 0 {
+		t.Errorf("repeated InvalidateTag = %d, %v", count, err)
+	}
+}
+
+func TestSetWithTagsKeepsIndexAsLongAsLongestMember(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	if err := client.SetWithTags(ctx, "short", "a", time.Minute, "class:42"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetWithTags(ctx, "long", "b", time.Hour, "class:42"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetWithTags(ctx, "shorter", "c", time.Second, "class:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl := server.TTL(client.Key(tagKeyPrefix + "class:42")); ttl != time.Hour {
+		t.Errorf("tag index TTL = %s, want %s", ttl, time.Hour)
+	}
+}
+
+func TestSetManyWritesEveryEntryWithTTL(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	entries := map[string]any{
+		"student:1": "Ana",
+		"student:2": "Marko",
+		"student:3": "Elena",
+	}
+	if err := client.SetMany(ctx, entries, 10*time.Minute); err != nil {
+		t.Fatalf("SetMany: %v", err)
+	}
+
+	for key, want := range entries {
+		var got string
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatalf("Get(%s): %v", key, err)
+		}
+		if got != want {
+			t.Errorf("Get(%s) = %q, want %q", key, got, want)
+		}
+		if ttl := server.TTL(client.Key(key)); ttl != 10*time.Minute {
+			t.Errorf("TTL(%s) = %s, want 10m", key, ttl)
+		}
+	}
+
+	// A non-positive TTL falls back to the client default
+	if err := client.SetMany(ctx, map[string]any{"student:4": "Ivan"}, 0); err != nil {
+		t.Fatalf("SetMany with default TTL: %v", err)
+	}
+	if ttl := server.TTL(client.Key("student:4")); ttl != time.Minute {
+		t.Errorf("default TTL = %s, want 1m", ttl)
+	}
+}
+
+func TestSetManyEncodesBeforeWriting(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	err := client.SetMany(context.Background(), map[string]any{"ok": "Ana", "bad": make(chan int)}, time.Minute)
+	if err == nil {
+		t.Fatal("SetMany accepted a value that cannot be encoded")
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("SetMany wrote %v despite the encoding error", keys)
+	}
+}
+
+func benchmarkEntries(n int) map[string]any {
+	entries := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		entries[fmt.Sprintf("student:%d", i)] = fmt.Sprintf("student %d", i)
+	}
+	return entries
+}
+
+func BenchmarkSetMany(b *testing.B) {
+	ctx := context.Background()
+	server := miniredis.RunT(b)
+	client, err := NewClient(server.Addr(), "", 0, time.Minute)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+	entries := benchmarkEntries(500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.SetMany(ctx, entries, time.Minute); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetSequential(b *testing.B) {
+	ctx := context.Background()
+	server := miniredis.RunT(b)
+	client, err := NewClient(server.Addr(), "", 0, time.Minute)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+	entries := benchmarkEntries(500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for key, value := range entries {
+			if err := client.SetWithTTL(ctx, key, value, time.Minute); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestClaimOnceGrantsExactlyOneClaim(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	replicas := []*Client{newTestClient(t, server), newTestClient(t, server)}
+
+	var wg sync.WaitGroup
+	var claims atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := replicas[i%len(replicas)].ClaimOnce(ctx, "event-1", time.Minute)
+			if err != nil {
+				t.Errorf("ClaimOnce: %v", err)
+			}
+			if claimed {
+				claims.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := claims.Load(); got != 1 {
+		t.Fatalf("%d claims granted, want 1", got)
+	}
+
+	if claimed, _ := replicas[0].ClaimOnce(ctx, "event-2", time.Minute); !claimed {
+		t.Error("a different ID was not claimable")
+	}
+
+	server.FastForward(time.Minute + time.Second)
+	if claimed, _ := replicas[1].ClaimOnce(ctx, "event-1", time.Minute); !claimed {
+		t.Error("claim not released after its TTL")
+	}
+}
+
+func TestAcquireLockContention(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	replicas := []*Client{newTestClient(t, server), newTestClient(t, server)}
+
+	var wg sync.WaitGroup
+	var holders atomic.Int32
+	tokens := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, acquired, err := replicas[i%len(replicas)].AcquireLock(ctx, "report:class-7", time.Minute)
+			switch {
+			case acquired:
+				holders.Add(1)
+				tokens <- token
+			case !errors.Is(err, ErrLockNotAcquired):
+				t.Errorf("AcquireLock = %v, want ErrLockNotAcquired", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := holders.Load(); got != 1 {
+		t.Fatalf("%d holders, want 1", got)
+	}
+	token := <-tokens
+	if token == "" {
+		t.Fatal("holder got an empty token")
+	}
+
+	if _, acquired, err := replicas[1].AcquireLock(ctx, "report:class-8", time.Minute); !acquired || err != nil {
+		t.Errorf("AcquireLock on another key = %v, %v, want acquired", acquired, err)
+	}
+
+	if err := replicas[0].ReleaseLock(ctx, "report:class-7", token); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+	next, acquired, err := replicas[1].AcquireLock(ctx, "report:class-7", time.Minute)
+	if !acquired || err != nil {
+		t.Fatalf("AcquireLock after release = %v, %v, want acquired", acquired, err)
+	}
+	if next == token {
+		t.Error("the next holder got the same token")
+	}
+}
+
+func TestReleaseLockOnlyByOwner(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	token, _, err := client.AcquireLock(ctx, "report:class-7", 10*time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	if err := client.ReleaseLock(ctx, "report:class-7", "not-the-token"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("ReleaseLock with a wrong token = %v, want ErrLockNotHeld", err)
+	}
+	if _, acquired, _ := client.AcquireLock(ctx, "report:class-7", 10*time.Second); acquired {
+		t.Fatal("a wrong token released the lock")
+	}
+
+	// Once the lock expires and someone else takes it, the old holder must not release it
+	server.FastForward(11 * time.Second)
+	newToken, acquired, err := client.AcquireLock(ctx, "report:class-7", 10*time.Second)
+	if !acquired || err != nil {
+		t.Fatalf("AcquireLock after expiry = %v, %v, want acquired", acquired, err)
+	}
+	if err := client.ReleaseLock(ctx, "report:class-7", token); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("ReleaseLock by the expired holder = %v, want ErrLockNotHeld", err)
+	}
+	if got, _ := server.Get("lock:report:class-7"); got != newToken {
+		t.Errorf("lock value = %q, want the new holder's token", got)
+	}
+	if ttl := server.TTL("lock:report:class-7"); ttl != 10*time.Second {
+		t.Errorf("lock TTL = %s, want 10s", ttl)
+	}
+}
+
+func TestAllowNSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+	const limit, window = 3, time.Minute
+
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	server.SetTime(start)
+	allow := func() (bool, int) {
+		t.Helper()
+		allowed, remaining, err := client.AllowN(ctx, "user-1", limit, window)
+		if err != nil {
+			t.Fatalf("AllowN: %v", err)
+		}
+		return allowed, remaining
+	}
+
+	for i := range limit {
+		if i == 1 {
+			server.SetTime(start.Add(20 * time.Second))
+		}
+		if allowed, remaining := allow(); !allowed || remaining != limit-i-1 {
+			t.Fatalf("request %d = %v, %d remaining, want allowed with %d remaining", i+1, allowed, remaining, limit-i-1)
+		}
+	}
+	// limit+1 within the window is denied, and the denial isn't counted
+	for range 2 {
+		if allowed, remaining := allow(); allowed || remaining != 0 {
+			t.Fatalf("request over the limit = %v, %d remaining, want denied", allowed, remaining)
+		}
+	}
+	if allowed, _, _ := client.AllowN(ctx, "user-2", limit, window); !allowed {
+		t.Error("another user was limited")
+	}
+
+	// Only the first request has left the window
+	server.SetTime(start.Add(window + time.Second))
+	if allowed, remaining := allow(); !allowed || remaining != 0 {
+		t.Fatalf("request after the oldest left = %v, %d remaining, want allowed with 0 remaining", allowed, remaining)
+	}
+	if allowed, _ := allow(); allowed {
+		t.Error("window admitted more than the limit")
+	}
+
+	// Once the whole window has passed the limit is restored
+	server.SetTime(start.Add(3 * window))
+	if allowed, remaining := allow(); !allowed || remaining != limit-1 {
+		t.Errorf("request after the window = %v, %d remaining, want allowed with %d remaining", allowed, remaining, limit-1)
+	}
+	if ttl := server.TTL("ratelimit:user-1"); ttl <= 0 || ttl > window {
+		t.Errorf("request log TTL = %s, want at most the window", ttl)
+	}
+}
+
+func TestAllowNConcurrent(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	replicas := []*Client{newTestClient(t, server), newTestClient(t, server)}
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, err := replicas[i%len(replicas)].AllowN(ctx, "user-1", 10, time.Minute)
+			if err != nil {
+				t.Errorf("AllowN: %v", err)
+			}
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := allowed.Load(); got != 10 {
+		t.Errorf("%d requests allowed, want 10", got)
+	}
+}
+
+func TestAllowNNonPositiveLimit(t *testing.T) {
+	client := newTestClient(t, miniredis.RunT(t))
+	if allowed, remaining, err := client.AllowN(context.Background(), "user-1", 0, time.Minute); allowed || remaining != 0 || err != nil {
+		t.Errorf("AllowN with no limit = %v, %d, %v, want denied", allowed, remaining, err)
+	}
+}
+
+// getCounter counts the GET commands sent, i.e. the cache lookups made
+type getCounter struct {
+	gets atomic.Int32
+}
+
+func (h *getCounter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *getCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "get" {
+			h.gets.Add(1)
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *getCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+type report struct {
+	Class  string
+	Grades []int
+}
+
+func TestGetOrSetStampedeProtection(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithStampedeProtection())
+	hook := &getCounter{}
+	client.client.AddHook(hook)
+
+	const callers = 20
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (any, error) {
+		loads.Add(1)
+		<-release
+		return report{Class: "VI-2", Grades: []int{5, 4, 3}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]report, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.GetOrSet(ctx, "report:class-7", &results[i], load); err != nil {
+				t.Errorf("GetOrSet: %v", err)
+			}
+		}()
+	}
+
+	// Hold the load until every caller has missed the cache and joined it
+	deadline := time.Now().Add(time.Second)
+	for hook.gets.Load() < callers {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d callers looked up the cache", hook.gets.Load(), callers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("fn ran %d times for %d concurrent callers, want once", got, callers)
+	}
+	for i, result := range results {
+		if result.Class != "VI-2" || !slices.Equal(result.Grades, []int{5, 4, 3}) {
+			t.Fatalf("results[%d] = %+v", i, result)
+		}
+	}
+	// Every caller decoded its own copy
+	results[0].Grades[0] = 1
+	if results[1].Grades[0] != 5 {
+		t.Error("callers share the computed value")
+	}
+	if !server.Exists("report:class-7") {
+		t.Error("computed value was not cached")
+	}
+}
+
+func TestGetOrSetStampedeProtectionSharesErrors(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithStampedeProtection())
+	hook := &getCounter{}
+	client.client.AddHook(hook)
+
+	const callers = 10
+	loadErr := errors.New("database unavailable")
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (any, error) {
+		loads.Add(1)
+		<-release
+		return nil, loadErr
+	}
+
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var r report
+			if err := client.GetOrSet(ctx, "report:class-7", &r, load); !errors.Is(err, loadErr) {
+				t.Errorf("GetOrSet = %v, want the load error", err)
+			}
+		}()
+	}
+	for hook.gets.Load() < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Errorf("fn ran %d times, want once", got)
+	}
+	if server.Exists("report:class-7") {
+		t.Error("a failed load was cached")
+	}
+}
+
+func TestGetOrSetHitSkipsLoad(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, miniredis.RunT(t))
+	if err := client.Set(ctx, "report:class-7", report{Class: "VI-2", Grades: []int{5}}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var r report
+	err := client.GetOrSet(ctx, "report:class-7", &r, func() (any, error) {
+		t.Error("loaded a cached key")
+		return nil, nil
+	})
+	if err != nil || r.Class != "VI-2" || !slices.Equal(r.Grades, []int{5}) {
+		t.Errorf("GetOrSet = %+v, %v, want the cached report", r, err)
+	}
+}
+
+func TestGetOrSetTyped(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+
+	loads := 0
+	load := func() (report, error) {
+		loads++
+		return report{Class: "VI-2", Grades: []int{5, 4}}, nil
+	}
+
+	// Miss: the computed value is returned as is and cached with the given TTL
+	got, err := GetOrSetTyped(ctx, client, "report:class-7", 10*time.Minute, load)
+	if err != nil {
+		t.Fatalf("GetOrSetTyped on a miss: %v", err)
+	}
+	if loads != 1 || got.Class != "VI-2" || !slices.Equal(got.Grades, []int{5, 4}) {
+		t.Fatalf("miss = %+v after %d loads", got, loads)
+	}
+	if ttl := server.TTL("report:class-7"); ttl != 10*time.Minute {
+		t.Errorf("cached TTL = %s, want 10m", ttl)
+	}
+
+	// Hit: decoded from Redis without calling fn
+	got, err = GetOrSetTyped(ctx, client, "report:class-7", 10*time.Minute, load)
+	if err != nil {
+		t.Fatalf("GetOrSetTyped on a hit: %v", err)
+	}
+	if loads != 1 || got.Class != "VI-2" || !slices.Equal(got.Grades, []int{5, 4}) {
+		t.Errorf("hit = %+v after %d loads, want the cached report without loading", got, loads)
+	}
+
+	// A value cached through the untyped API is readable as T
+	if err := client.Set(ctx, "report:class-8", report{Class: "VII-1"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := GetOrSetTyped(ctx, client, "report:class-8", time.Minute, load); err != nil || got.Class != "VII-1" || loads != 1 {
+		t.Errorf("GetOrSetTyped = %+v, %v, want VII-1 from the cache", got, err)
+	}
+}
+
+func TestGetOrSetTypedDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+	loadErr := errors.New("database unavailable")
+
+	got, err := GetOrSetTyped(ctx, client, "report:class-7", time.Minute, func() (*report, error) {
+		return nil, loadErr
+	})
+	if !errors.Is(err, loadErr) || got != nil {
+		t.Errorf("GetOrSetTyped = %+v, %v, want the load error", got, err)
+	}
+	if server.Exists("report:class-7") {
+		t.Error("a failed load was cached")
+	}
+}
+
+func newBenchmarkClient(b *testing.B) *Client {
+	b.Helper()
+	client, err := NewClient(miniredis.RunT(b).Addr(), "", 0, time.Minute)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { client.Close() })
+	return client
+}
+
+var benchmarkReport = report{Class: "VI-2", Grades: []int{5, 4, 3, 5, 5, 4, 2, 5}}
+
+func BenchmarkGetOrSetMiss(b *testing.B) {
+	ctx := context.Background()
+	client := newBenchmarkClient(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var r report
+		if err := client.GetOrSet(ctx, fmt.Sprintf("report:%d", i), &r, func() (any, error) { return benchmarkReport, nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOrSetTypedMiss(b *testing.B) {
+	ctx := context.Background()
+	client := newBenchmarkClient(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetOrSetTyped(ctx, client, fmt.Sprintf("report:%d", i), time.Minute, func() (report, error) { return benchmarkReport, nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// pipelineCounter counts the pipelines sent, i.e. the round trips of batch commands
+type pipelineCounter struct {
+	pipelines, commands atomic.Int32
+}
+
+func (h *pipelineCounter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *pipelineCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.commands.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h *pipelineCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.pipelines.Add(1)
+		return next(ctx, cmds)
+	}
+}
+
+func TestMSetAndMGet(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithKeyPrefix("print:"))
+	hook := &pipelineCounter{}
+	client.client.AddHook(hook)
+
+	classes := map[string]any{}
+	for i := 1; i <= 5; i++ {
+		classes[fmt.Sprintf("class:%d", i)] = report{Class: fmt.Sprintf("VI-%d", i), Grades: []int{i}}
+	}
+	if err := client.MSet(ctx, classes, time.Minute); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+	if got := hook.pipelines.Load(); got != 1 {
+		t.Errorf("MSet took %d round trips, want 1", got)
+	}
+	if ttl := server.TTL("print:class:3"); ttl != time.Minute {
+		t.Errorf("TTL of class:3 = %s, want 1m", ttl)
+	}
+	// MSet encodes values the way Set does
+	var single report
+	if err := client.Get(ctx, "class:2", &single); err != nil || single.Class != "VI-2" {
+		t.Errorf("Get(class:2) = %+v, %v", single, err)
+	}
+	if err := client.SetTombstone(ctx, "class:7", time.Minute); err != nil {
+		t.Fatalf("SetTombstone: %v", err)
+	}
+
+	keys := []string{"class:1", "class:2", "class:6", "class:3", "class:4", "class:5", "class:7"}
+	results := make([]report, len(keys))
+	targets := make([]any, len(keys))
+	for i := range results {
+		targets[i] = &results[i]
+	}
+	hook.pipelines.Store(0)
+	hook.commands.Store(0)
+
+	hits, err := client.MGet(ctx, keys, targets)
+	if err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if got := hook.pipelines.Load(); got != 1 || hook.commands.Load() != 0 {
+		t.Errorf("MGet took %d pipelines and %d single commands, want one round trip", got, hook.commands.Load())
+	}
+
+	wantHits := []bool{true, true, false, true, true, true, false}
+	if !slices.Equal(hits, wantHits) {
+		t.Fatalf("hits = %v, want %v", hits, wantHits)
+	}
+	for i, key := range keys {
+		if !hits[i] {
+			if results[i].Class != "" {
+				t.Errorf("%s target = %+v, want it untouched on a miss", key, results[i])
+			}
+			continue
+		}
+		want := classes[key].(report)
+		if results[i].Class != want.Class || !slices.Equal(results[i].Grades, want.Grades) {
+			t.Errorf("%s = %+v, want %+v", key, results[i], want)
+		}
+	}
+}
+
+func TestMGetArguments(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, miniredis.RunT(t))
+
+	if hits, err := client.MGet(ctx, nil, nil); err != nil || len(hits) != 0 {
+		t.Errorf("MGet of no keys = %v, %v, want no hits", hits, err)
+	}
+	var r report
+	if _, err := client.MGet(ctx, []string{"class:1", "class:2"}, []any{&r}); err == nil {
+		t.Error("MGet with fewer targets than keys succeeded")
+	}
+}
+
+func TestPingAndHealth(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s)
+
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("Ping against a live server: %v", err)
+	}
+	if err := client.Health(ctx); err != nil {
+		t.Errorf("Health against a live server: %v", err)
+	}
+
+	s.Close()
+	if err := client.Ping(ctx); err == nil {
+		t.Error("Ping after the server closed succeeded")
+	}
+	if err := client.Health(ctx); err == nil {
+		t.Error("Health after the server closed succeeded")
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	gateway := newTestClient(t, server, WithKeyPrefix("gateway:"))
+	admin := newTestClient(t, server, WithKeyPrefix("admin:"))
+	unprefixed := newTestClient(t, server)
+
+	for name, client := range map[string]*Client{"gateway": gateway, "admin": admin, "unprefixed": unprefixed} {
+		if err := client.Set(ctx, "user:1", name); err != nil {
+			t.Fatalf("%s Set: %v", name, err)
+		}
+	}
+	// The same API key lands in a separate raw key per service
+	for _, raw := range []string{"gateway:user:1", "admin:user:1", "user:1"} {
+		if !server.Exists(raw) {
+			t.Errorf("raw key %s missing; keys are %q", raw, server.Keys())
+		}
+	}
+	var owner string
+	if err := gateway.Get(ctx, "user:1", &owner); err != nil || owner != "gateway" {
+		t.Errorf("gateway Get = %q, %v, want its own value", owner, err)
+	}
+	if err := unprefixed.Get(ctx, "user:1", &owner); err != nil || owner != "unprefixed" {
+		t.Errorf("unprefixed Get = %q, %v, want its own value", owner, err)
+	}
+
+	if exists, err := gateway.Exists(ctx, "user:1"); err != nil || !exists {
+		t.Errorf("Exists = %v, %v, want true", exists, err)
+	}
+	if err := gateway.Expire(ctx, "user:1", time.Minute); err != nil {
+		t.Fatalf("Expire: %v", err)
+	}
+	if ttl := server.TTL("gateway:user:1"); ttl != time.Minute {
+		t.Errorf("TTL of gateway:user:1 = %s, want 1m", ttl)
+	}
+	if n, err := gateway.Increment(ctx, "logins"); err != nil || n != 1 {
+		t.Errorf("Increment = %d, %v, want 1", n, err)
+	}
+	if got, _ := server.Get("gateway:logins"); got != "1" {
+		t.Errorf("raw gateway:logins = %q, want 1", got)
+	}
+
+	if err := gateway.Delete(ctx, "user:1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if server.Exists("gateway:user:1") || !server.Exists("admin:user:1") || !server.Exists("user:1") {
+		t.Errorf("Delete reached outside its prefix; keys are %q", server.Keys())
+	}
+}
+
+func TestDeletePatternStaysWithinPrefix(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	// Glob characters in the prefix are matched literally
+	client := newTestClient(t, server, WithKeyPrefix("print[1]:"))
+
+	for _, raw := range []string{"print[1]:student:1", "print[1]:student:2", "print[1]:class:1", "print1:student:3", "admin:student:4"} {
+		server.Set(raw, "x")
+	}
+	if err := client.DeletePattern(ctx, "student:*"); err != nil {
+		t.Fatalf("DeletePattern: %v", err)
+	}
+	if got, want := server.Keys(), []string{"admin:student:4", "print1:student:3", "print[1]:class:1"}; !slices.Equal(got, want) {
+		t.Errorf("keys after DeletePattern = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Codec serializes cached values. JSON values are stored as-is; other codecs prefix
+// the payload with a two byte format marker so Get can decode any value regardless of
+// how it was written. Codecs other than the built-in ones must be registered with
+// RegisterCodec before they are used.
+type Codec interface {
+	// ID identifies the codec in the format marker; 0, 't' and 'z' are reserved
+	ID() byte
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, target any) error
+}
+
+var (
+	// JSONCodec is the default, human readable format
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec is a compact binary format suited to large values such as grade matrices
+	GobCodec Codec = gobCodec{}
+)
+
+// formatMarker starts every non-JSON value; no JSON document begins with a NUL byte
+const formatMarker = 0x00
+
+// gzipID marks a gzipped value, which decodes to another encoded value
+const gzipID = 'z'
+
+// tombstoneID marks a value recorded as known to be missing from the source of truth
+const tombstoneID = 't'
+
+// ErrTombstone is returned by Get for keys cached as not found with SetTombstone
+var ErrTombstone = errors.New("cached as not found")
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[byte]Codec{
+		GobCodec.ID(): GobCodec,
+	}
+)
+
+// RegisterCodec makes codec available to writes and lets Get decode values written
+// with it, e.g. at startup before a client using WithCodec(msgpackCodec) is created.
+// Its ID must not be reserved or taken by another codec.
+func RegisterCodec(codec Codec) error {
+	id := codec.ID()
+	switch id {
+	case 0, tombstoneID, gzipID:
+		return fmt.Errorf("codec ID %q is reserved", id)
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if registered, ok := codecs[id]; ok && reflect.TypeOf(registered) != reflect.TypeOf(codec) {
+		return fmt.Errorf("codec ID %q is already registered", id)
+	}
+	codecs[id] = codec
+	return nil
+}
+
+// lookupCodec returns the registered codec with the given ID
+func lookupCodec(id byte) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[id]
+	return codec, ok
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ID() byte                                { return 0 }
+func (jsonCodec) Marshal(value any) ([]byte, error)       { return json.Marshal(value) }
+func (jsonCodec) Unmarshal(data []byte, target any) error { return json.Unmarshal(data, target) }
+
+type gobCodec struct{}
+
+func (gobCodec) ID() byte { return 'g' }
+
+func (gobCodec) Marshal(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, target any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(target)
+}
+
+// encode serializes value with codec, adding the format marker for non-JSON codecs.
+// Unregistered codecs are refused, since Get could not decode what they write.
+func encode(codec Codec, value any) ([]byte, error) {
+	if id := codec.ID(); id != 0 {
+		if registered, ok := lookupCodec(id); !ok || reflect.TypeOf(registered) != reflect.TypeOf(codec) {
+			return nil, fmt.Errorf("codec %q is not registered", id)
+		}
+	}
+
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	if codec.ID() == 0 {
+		return data, nil
+	}
+	return append([]byte{formatMarker, codec.ID()}, data...), nil
+}
+
+// encode serializes value with codec and compresses it when it exceeds the client's
+// threshold
+func (c *Client) encode(codec Codec, value any) ([]byte, error) {
+	data, err := encode(codec, value)
+	if err != nil || c.compressAbove == 0 || len(data) <= c.compressAbove {
+		return data, err
+	}
+	return compress(data)
+}
+
+// compress gzips an encoded value behind the gzip format marker
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{formatMarker, gzipID})
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress value: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress value: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decode detects the value's format from its marker and unmarshals it into target
+func decode(data []byte, target any) error {
+	codec := JSONCodec
+	if len(data) >= 2 && data[0] == formatMarker {
+		if data[1] == tombstoneID {
+			return ErrTombstone
+		}
+		if data[1] == gzipID {
+			zr, err := gzip.NewReader(bytes.NewReader(data[2:]))
+			if err != nil {
+				return fmt.Errorf("failed to decompress Redis value: %w", err)
+			}
+			inner, err := io.ReadAll(zr)
+			if err != nil {
+				return fmt.Errorf("failed to decompress Redis value: %w", err)
+			}
+			return decode(inner, target)
+		}
+		var ok bool
+		if codec, ok = lookupCodec(data[1]); !ok {
+			return fmt.Errorf("unknown cache value format %q", data[1])
+		}
+		data = data[2:]
+	}
+
+	if err := codec.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to unmarshal Redis value: %w", err)
+	}
+	return nil
+}
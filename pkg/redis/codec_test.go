@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// base64Codec stands in for a third-party format such as msgpack: its payload is not
+// JSON, so decoding it as anything but itself fails
+type base64Codec struct{}
+
+func (base64Codec) ID() byte { return 'b' }
+
+func (base64Codec) Marshal(value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	return []byte(base64.StdEncoding.EncodeToString(data)), err
+}
+
+func (base64Codec) Unmarshal(data []byte, target any) error {
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+// idCodec is a codec with an arbitrary ID
+type idCodec struct {
+	jsonCodec
+	id byte
+}
+
+func (c idCodec) ID() byte { return c.id }
+
+func newTestClient(t *testing.T, server *miniredis.Miniredis, opts ...Option) *Client {
+	t.Helper()
+	client, err := NewClient(server.Addr(), "", 0, time.Minute, opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRegisterCodecRejectsReservedAndTakenIDs(t *testing.T) {
+	for _, id := range []byte{0, tombstoneID, gzipID} {
+		if err := RegisterCodec(idCodec{id: id}); err == nil {
+			t.Errorf("RegisterCodec accepted reserved ID %q", id)
+		}
+	}
+	if err := RegisterCodec(idCodec{id: GobCodec.ID()}); err == nil {
+		t.Error("RegisterCodec accepted the gob codec's ID for another codec")
+	}
+	if err := RegisterCodec(GobCodec); err != nil {
+		t.Errorf("re-registering a codec: %v", err)
+	}
+}
+
+func TestUnregisteredCodecIsRefused(t *testing.T) {
+	client := newTestClient(t, miniredis.RunT(t))
+	err := client.SetWithCodec(context.Background(), "k", "v", time.Minute, idCodec{id: 'u'})
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("SetWithCodec with an unregistered codec = %v", err)
+	}
+}
+
+func TestMixedFormatRoundTrip(t *testing.T) {
+	if err := RegisterCodec(base64Codec{}); err != nil {
+		t.Fatalf("RegisterCodec: %v", err)
+	}
+
+	type grades struct {
+		Student string
+		Grades  map[string]int
+	}
+	value := grades{Student: "Ana", Grades: map[string]int{"math": 5, "art": 4}}
+	large := grades{Student: strings.Repeat("Ana ", 100), Grades: value.Grades}
+
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	plain := newTestClient(t, server, WithCodec(base64Codec{}))
+	compressed := newTestClient(t, server, WithCompression(64))
+
+	writes := []struct {
+		key    string
+		client *Client
+		codec  Codec
+		value  grades
+	}{
+		{"json", plain, JSONCodec, value},
+		{"gob", plain, GobCodec, value},
+		{"default", plain, plain.codec, value},
+		{"gzip-gob", compressed, GobCodec, large},
+		{"gzip-custom", compressed, base64Codec{}, large},
+	}
+	for _, w := range writes {
+		if err := w.client.SetWithCodec(ctx, w.key, w.value, time.Minute, w.codec); err != nil {
+			t.Fatalf("SetWithCodec(%s): %v", w.key, err)
+		}
+	}
+	// Any client decodes any format
+	for _, w := range writes {
+		var got grades
+		if err := compressed.Get(ctx, w.key, &got); err != nil {
+			t.Errorf("Get(%s): %v", w.key, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, w.value) {
+			t.Errorf("Get(%s) = %+v, want %+v", w.key, got, w.value)
+		}
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	legacy := newTestClient(t, server)
+	client := newTestClient(t, server, WithCompression(1024))
+
+	small := report{Class: "VI-2", Grades: []int{5, 4}}
+	large := report{Class: strings.Repeat("VI-2 ", 2000), Grades: make([]int, 2000)}
+	if err := client.Set(ctx, "report:small", small); err != nil {
+		t.Fatalf("Set(small): %v", err)
+	}
+	if err := client.Set(ctx, "report:large", large); err != nil {
+		t.Fatalf("Set(large): %v", err)
+	}
+	// Written before compression was enabled
+	if err := legacy.Set(ctx, "report:legacy", large); err != nil {
+		t.Fatalf("Set(legacy): %v", err)
+	}
+
+	isGzipped := func(key string) bool {
+		raw, err := server.Get(key)
+		if err != nil {
+			t.Fatalf("raw %s: %v", key, err)
+		}
+		return len(raw) > 2 && raw[0] == formatMarker && raw[1] == gzipID
+	}
+	if isGzipped("report:small") {
+		t.Error("value under the threshold was compressed")
+	}
+	if !isGzipped("report:large") {
+		t.Error("value over the threshold was stored uncompressed")
+	}
+	if isGzipped("report:legacy") {
+		t.Error("client without compression compressed a value")
+	}
+	compressedRaw, _ := server.Get("report:large")
+	legacyRaw, _ := server.Get("report:legacy")
+	if len(compressedRaw) >= len(legacyRaw)/10 {
+		t.Errorf("compressed value is %d bytes, uncompressed %d", len(compressedRaw), len(legacyRaw))
+	}
+
+	for _, key := range []string{"report:small", "report:large", "report:legacy"} {
+		want := large
+		if key == "report:small" {
+			want = small
+		}
+		for name, reader := range map[string]*Client{"compressing": client, "legacy": legacy} {
+			var got report
+			if err := reader.Get(ctx, key, &got); err != nil {
+				t.Errorf("%s Get(%s): %v", name, key, err)
+				continue
+			}
+			if got.Class != want.Class || !slices.Equal(got.Grades, want.Grades) {
+				t.Errorf("%s Get(%s) did not round-trip", name, key)
+			}
+		}
+	}
+}
+
+func TestCorruptCompressedValue(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithCompression(1))
+	server.Set("report:broken", string([]byte{formatMarker, gzipID, 0x1f, 0x8b, 0x00}))
+
+	var got report
+	if err := client.Get(context.Background(), "report:broken", &got); err == nil || !strings.Contains(err.Error(), "decompress") {
+		t.Errorf("Get of a corrupt value = %v, want a decompress error", err)
+	}
+}
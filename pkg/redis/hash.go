@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HSet stores value, JSON-encoded, in one field of the hash at key. Fields are always
+// JSON so HGetAll can hand them back raw; the hash has no TTL unless one is set with
+// Expire.
+func (c *Client) HSet(ctx context.Context, key, field string, value any) error {
+	data, err := encode(JSONCodec, value)
+	if err != nil {
+		return err
+	}
+
+	err = c.write(ctx, func() error {
+		return c.client.HSet(ctx, c.Key(key), field, data).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set hash field in Redis: %w", err)
+	}
+	return nil
+}
+
+// HGet unmarshals one field of the hash at key into target, returning ErrCacheMiss
+// when the field or the hash is absent
+func (c *Client) HGet(ctx context.Context, key, field string, target any) error {
+	var val []byte
+	err := c.withRetry(ctx, func() error {
+		var err error
+		val, err = c.client.HGet(ctx, c.Key(key), field).Bytes()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrCacheMiss
+		}
+		return fmt.Errorf("failed to get hash field from Redis: %w", err)
+	}
+
+	return decode(val, target)
+}
+
+// HGetAll fills target with every field of the hash at key, leaving each value as raw
+// JSON for the caller to unmarshal. A missing hash leaves target empty.
+func (c *Client) HGetAll(ctx context.Context, key string, target map[string]json.RawMessage) error {
+	var fields map[string]string
+	err := c.withRetry(ctx, func() error {
+		var err error
+		fields, err = c.client.HGetAll(ctx, c.Key(key)).Result()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get hash from Redis: %w", err)
+	}
+
+	for field, value := range fields {
+		target[field] = json.RawMessage(value)
+	}
+	return nil
+}
+
+// HDel removes fields from the hash at key; fields that don't exist are ignored
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	err := c.write(ctx, func() error {
+		return c.client.HDel(ctx, c.Key(key), fields...).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete hash fields from Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type sessionDevice struct {
+	Name     string `json:"name"`
+	LastSeen int64  `json:"last_seen"`
+}
+
+func TestHashFields(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s, WithKeyPrefix("gateway:"))
+
+	if err := client.HSet(ctx, "session:user-1", "tenant", "school_a"); err != nil {
+		t.Fatalf("HSet tenant: %v", err)
+	}
+	if err := client.HSet(ctx, "session:user-1", "device", sessionDevice{Name: "laptop", LastSeen: 1760000000}); err != nil {
+		t.Fatalf("HSet device: %v", err)
+	}
+	if err := client.HSet(ctx, "session:user-1", "refreshes", 3); err != nil {
+		t.Fatalf("HSet refreshes: %v", err)
+	}
+	// Fields live in one hash under the prefixed key
+	if got, _ := s.HKeys("gateway:session:user-1"); len(got) != 3 {
+		t.Errorf("hash fields = %q, want 3", got)
+	}
+
+	var device sessionDevice
+	if err := client.HGet(ctx, "session:user-1", "device", &device); err != nil {
+		t.Fatalf("HGet: %v", err)
+	}
+	if device != (sessionDevice{Name: "laptop", LastSeen: 1760000000}) {
+		t.Errorf("device = %+v", device)
+	}
+
+	all := map[string]json.RawMessage{}
+	if err := client.HGetAll(ctx, "session:user-1", all); err != nil {
+		t.Fatalf("HGetAll: %v", err)
+	}
+	var tenant string
+	var refreshes int
+	if err := json.Unmarshal(all["tenant"], &tenant); err != nil || tenant != "school_a" {
+		t.Errorf("tenant = %q (%v), want school_a", tenant, err)
+	}
+	if err := json.Unmarshal(all["refreshes"], &refreshes); err != nil || refreshes != 3 {
+		t.Errorf("refreshes = %d (%v), want 3", refreshes, err)
+	}
+	if err := json.Unmarshal(all["device"], &device); err != nil || device.Name != "laptop" {
+		t.Errorf("device = %+v (%v), want laptop", device, err)
+	}
+
+	if err := client.HDel(ctx, "session:user-1", "device", "missing"); err != nil {
+		t.Fatalf("HDel: %v", err)
+	}
+	if err := client.HGet(ctx, "session:user-1", "device", &device); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("HGet deleted field = %v, want ErrCacheMiss", err)
+	}
+	if err := client.HGet(ctx, "session:user-1", "tenant", &tenant); err != nil {
+		t.Errorf("HGet remaining field: %v", err)
+	}
+	if err := client.HDel(ctx, "session:user-1"); err != nil {
+		t.Errorf("HDel without fields: %v", err)
+	}
+}
+
+func TestHashMissing(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, miniredis.RunT(t))
+
+	var value string
+	if err := client.HGet(ctx, "session:nobody", "tenant", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("HGet on a missing hash = %v, want ErrCacheMiss", err)
+	}
+	all := map[string]json.RawMessage{}
+	if err := client.HGetAll(ctx, "session:nobody", all); err != nil || len(all) != 0 {
+		t.Errorf("HGetAll on a missing hash = %v, %v, want nothing", all, err)
+	}
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Key events published by Redis keyspace notifications
+const (
+	KeyEventExpired = "expired"
+	KeyEventEvicted = "evicted"
+)
+
+// ErrKeyEventsDisabled is returned by SubscribeKeyEvents when the server's
+// notify-keyspace-events setting does not publish the requested events
+var ErrKeyEventsDisabled = errors.New("redis keyspace notifications are disabled")
+
+// keyEventFlags are the notify-keyspace-events classes each event needs besides "E"
+var keyEventFlags = map[string]string{
+	KeyEventExpired: "x",
+	KeyEventEvicted: "e",
+}
+
+// SubscribeKeyEvents calls handle with the event and key of every keyspace
+// notification for events in the client's database, until ctx is done. Keys are
+// passed without the client's prefix; keys outside it are skipped. The server must
+// publish the events (e.g. notify-keyspace-events "Exe"); if it reports that it does
+// not, ErrKeyEventsDisabled is returned right away. Servers that refuse CONFIG GET,
+// as many managed offerings do, are subscribed to regardless.
+func (c *Client) SubscribeKeyEvents(ctx context.Context, handle func(event, key string), events ...string) error {
+	if err := c.checkKeyEvents(ctx, events); err != nil {
+		return err
+	}
+
+	db := c.client.Options().DB
+	channels := make([]string, 0, len(events))
+	for _, event := range events {
+		channels = append(channels, fmt.Sprintf("__keyevent@%d__:%s", db, event))
+	}
+
+	pubsub := c.client.Subscribe(ctx, channels...)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to key events: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("key event subscription closed")
+			}
+			// Keys outside the client's prefix belong to another namespace
+			key, ok := strings.CutPrefix(msg.Payload, c.prefix)
+			if !ok {
+				continue
+			}
+			_, event, _ := strings.Cut(msg.Channel, "__:")
+			handle(event, key)
+		}
+	}
+}
+
+// checkKeyEvents verifies the server publishes every requested event. A server that
+// refuses CONFIG GET is given the benefit of the doubt.
+func (c *Client) checkKeyEvents(ctx context.Context, events []string) error {
+	config, err := c.client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return nil
+	}
+
+	flags := config["notify-keyspace-events"]
+	if !strings.Contains(flags, "E") {
+		return fmt.Errorf("%w: notify-keyspace-events %q lacks keyevent notifications (E)", ErrKeyEventsDisabled, flags)
+	}
+	for _, event := range events {
+		flag, ok := keyEventFlags[event]
+		if ok && !strings.Contains(flags, flag) && !strings.Contains(flags, "A") {
+			return fmt.Errorf("%w: notify-keyspace-events %q does not publish %s events (%s)", ErrKeyEventsDisabled, flags, event, flag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// withKeyspaceConfig makes the server answer CONFIG GET notify-keyspace-events with
+// flags; miniredis has no CONFIG command of its own
+func withKeyspaceConfig(t *testing.T, s *miniredis.Miniredis, flags string) {
+	t.Helper()
+	err := s.Server().Register("CONFIG", func(c *server.Peer, cmd string, args []string) {
+		c.WriteMapLen(1)
+		c.WriteBulk("notify-keyspace-events")
+		c.WriteBulk(flags)
+	})
+	if err != nil {
+		t.Fatalf("Register CONFIG: %v", err)
+	}
+}
+
+type keyEvent struct{ event, key string }
+
+func TestSubscribeKeyEvents(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s, WithKeyPrefix("gateway:"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan keyEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.SubscribeKeyEvents(ctx, func(event, key string) {
+			received <- keyEvent{event, key}
+		}, KeyEventExpired, KeyEventEvicted)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for s.PubSubNumSub("__keyevent@0__:evicted")["__keyevent@0__:evicted"] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("never subscribed to key events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s.Publish("__keyevent@0__:expired", "gateway:student:1")
+	// Keys of another service sharing the server are skipped
+	s.Publish("__keyevent@0__:evicted", "admin:student:2")
+	s.Publish("__keyevent@0__:evicted", "gateway:student:3")
+
+	for _, want := range []keyEvent{{KeyEventExpired, "student:1"}, {KeyEventEvicted, "student:3"}} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("event = %+v, want %+v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event for %s", want.key)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("SubscribeKeyEvents = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SubscribeKeyEvents did not stop with its context")
+	}
+	if len(received) != 0 {
+		t.Errorf("unexpected event %+v", <-received)
+	}
+}
+
+func TestSubscribeKeyEventsChecksServerConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        string
+		wantDisabled bool
+	}{
+		{name: "notifications off", flags: "", wantDisabled: true},
+		{name: "keyspace only", flags: "Kx", wantDisabled: true},
+		{name: "no evicted events", flags: "Ex", wantDisabled: true},
+		{name: "expired and evicted", flags: "Exe"},
+		{name: "all events", flags: "EA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := miniredis.RunT(t)
+			withKeyspaceConfig(t, s, tt.flags)
+			client := newTestClient(t, s)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := client.SubscribeKeyEvents(ctx, func(string, string) {}, KeyEventExpired, KeyEventEvicted)
+			if got := errors.Is(err, ErrKeyEventsDisabled); got != tt.wantDisabled {
+				t.Errorf("SubscribeKeyEvents = %v, want disabled %v", err, tt.wantDisabled)
+			}
+		})
+	}
+}
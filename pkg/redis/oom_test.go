@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// oomReply is what Redis answers writes with at maxmemory under noeviction
+const oomReply = "OOM command not allowed when used memory > 'maxmemory'."
+
+func TestWritesReportCacheFullOnOOM(t *testing.T) {
+	ctx := context.Background()
+	writes := map[string]func(*Client) error{
+		"Set": func(c *Client) error { return c.Set(ctx, "student:1", "Ana") },
+		"SetTombstone": func(c *Client) error {
+			return c.SetTombstone(ctx, "student:1", time.Minute)
+		},
+		"SetMany": func(c *Client) error {
+			return c.SetMany(ctx, map[string]any{"student:1": "Ana", "student:2": "Marko"}, time.Minute)
+		},
+		"SetWithTags": func(c *Client) error {
+			return c.SetWithTags(ctx, "student:1", "Ana", time.Minute, "class:7")
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			s := miniredis.RunT(t)
+			client := newTestClient(t, s, WithRetry(3, time.Millisecond))
+			hook := &failingHook{}
+			client.client.AddHook(hook)
+			s.SetError(oomReply)
+
+			err := write(client)
+			if !errors.Is(err, ErrCacheFull) {
+				t.Fatalf("%s = %v, want ErrCacheFull", name, err)
+			}
+			if errors.Is(err, ErrCacheReadOnly) {
+				t.Errorf("%s = %v, want no read-only mode without a cooldown", name, err)
+			}
+			// A full server stays full, so the write is not retried
+			if hook.calls > 1 {
+				t.Errorf("%s sent %d times, want once", name, hook.calls)
+			}
+		})
+	}
+}
+
+func TestOtherWriteErrorsAreNotCacheFull(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s, WithReadOnlyOnOOM(time.Minute))
+	s.SetError("ERR Protocol error: invalid bulk length")
+
+	if err := client.Set(context.Background(), "student:1", "Ana"); err == nil || errors.Is(err, ErrCacheFull) {
+		t.Errorf("Set = %v, want an error other than ErrCacheFull", err)
+	}
+	if client.ReadOnly() {
+		t.Error("client went read-only on an error other than OOM")
+	}
+}
+
+func TestReadOnlyOnOOM(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s, WithReadOnlyOnOOM(100*time.Millisecond))
+	if err := client.Set(ctx, "student:1", "Ana"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	s.SetError(oomReply)
+	if err := client.Set(ctx, "student:2", "Marko"); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Set on a full Redis = %v, want ErrCacheFull", err)
+	}
+	if !client.ReadOnly() {
+		t.Fatal("client did not go read-only after an OOM reply")
+	}
+
+	// Memory frees up, but writes stay paused for the cooldown while reads go on
+	s.SetError("")
+	if err := client.Set(ctx, "student:2", "Marko"); !errors.Is(err, ErrCacheReadOnly) || !errors.Is(err, ErrCacheFull) {
+		t.Errorf("Set while read-only = %v, want ErrCacheReadOnly wrapping ErrCacheFull", err)
+	}
+	if s.Exists("student:2") {
+		t.Error("write reached Redis while the client was read-only")
+	}
+	var name string
+	if err := client.Get(ctx, "student:1", &name); err != nil || name != "Ana" {
+		t.Errorf("Get while read-only = %q, %v, want Ana", name, err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if client.ReadOnly() {
+		t.Fatal("client still read-only after the cooldown")
+	}
+	if err := client.Set(ctx, "student:2", "Marko"); err != nil {
+		t.Errorf("Set after the cooldown: %v", err)
+	}
+}
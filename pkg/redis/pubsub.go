@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// subscriptionRetryDelay is how long a subscription waits before receiving again after
+// the connection dropped
+const subscriptionRetryDelay = time.Second
+
+// Publish sends message, JSON-encoded like cached values, to every subscriber of
+// channel, e.g. so all instances drop a local cache after shared data changed
+func (c *Client) Publish(ctx context.Context, channel string, message any) error {
+	data, err := encode(JSONCodec, message)
+	if err != nil {
+		return err
+	}
+
+	err = c.withRetry(ctx, func() error {
+		return c.client.Publish(ctx, channel, data).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe calls handler with the payload of every message published to channel,
+// from a goroutine, until ctx is done or the returned cancel func is called. cancel
+// waits for an in-flight handler to return. The subscription is confirmed before
+// Subscribe returns; if the connection drops later it is re-established and
+// re-subscribed, and messages published in between are lost.
+func (c *Client) Subscribe(ctx context.Context, channel string, handler func([]byte)) (func(), error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	// Receiving blocks regardless of ctx, so closing the subscription is what stops it
+	context.AfterFunc(ctx, func() { pubsub.Close() })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for {
+			msg, err := pubsub.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// The next receive reconnects and subscribes again
+				log.Warn().Err(err).Str("channel", channel).Msg("Redis subscription interrupted, reconnecting")
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(subscriptionRetryDelay):
+				}
+				continue
+			}
+			handler([]byte(msg.Payload))
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
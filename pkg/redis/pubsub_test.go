@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type invalidation struct {
+	Keys []string `json:"keys"`
+}
+
+// waitForSubscribers waits until channel has n subscribers on the server
+func waitForSubscribers(t *testing.T, s *miniredis.Miniredis, channel string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.PubSubNumSub(channel)[channel] != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d subscribers, want %d", channel, s.PubSubNumSub(channel)[channel], n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// receive returns the next payload, failing the test if none arrives in time
+func receive(t *testing.T, payloads <-chan []byte) invalidation {
+	t.Helper()
+	select {
+	case payload := <-payloads:
+		var msg invalidation
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			t.Fatalf("payload %q is not JSON: %v", payload, err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return invalidation{}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	subscriber := newTestClient(t, s)
+	publisher := newTestClient(t, s)
+
+	payloads := make(chan []byte, 10)
+	cancel, err := subscriber.Subscribe(ctx, "cache:invalidate", func(payload []byte) { payloads <- payload })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	// The subscription is confirmed on return, so nothing published now is lost
+	if err := publisher.Publish(ctx, "cache:invalidate", invalidation{Keys: []string{"subjects", "schools:7"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := receive(t, payloads); len(got.Keys) != 2 || got.Keys[0] != "subjects" || got.Keys[1] != "schools:7" {
+		t.Errorf("received %+v", got)
+	}
+	if err := publisher.Publish(ctx, "cache:other", invalidation{Keys: []string{"x"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	cancel()
+	waitForSubscribers(t, s, "cache:invalidate", 0)
+	if err := publisher.Publish(ctx, "cache:invalidate", invalidation{Keys: []string{"subjects"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case payload := <-payloads:
+		t.Errorf("handler called with %q after cancel or for another channel", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeStopsWithContext(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	cancel, err := client.Subscribe(ctx, "cache:invalidate", func([]byte) {})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	cancelCtx()
+	waitForSubscribers(t, s, "cache:invalidate", 0)
+	// Cancelling afterwards is harmless
+	cancel()
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s)
+
+	payloads := make(chan []byte, 10)
+	cancel, err := client.Subscribe(ctx, "cache:invalidate", func(payload []byte) { payloads <- payload })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer cancel()
+
+	// Drop every connection; the subscription must come back on its own
+	s.Close()
+	if err := s.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	waitForSubscribers(t, s, "cache:invalidate", 1)
+
+	if err := client.Publish(ctx, "cache:invalidate", invalidation{Keys: []string{"subjects"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := receive(t, payloads); len(got.Keys) != 1 || got.Keys[0] != "subjects" {
+		t.Errorf("received %+v after reconnecting", got)
+	}
+}
+
+func TestSubscribeFailsWhenRedisIsDown(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := newTestClient(t, s)
+	s.Close()
+
+	if _, err := client.Subscribe(context.Background(), "cache:invalidate", func([]byte) {}); err == nil {
+		t.Error("Subscribe with Redis down succeeded")
+	}
+}
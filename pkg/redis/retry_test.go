@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// failingHook fails the next failures commands with err before they reach Redis
+type failingHook struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (h *failingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.calls++
+		if h.failures > 0 {
+			h.failures--
+			cmd.SetErr(h.err)
+			return h.err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRetryRecoversFromTransientErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"loading", errors.New("LOADING Redis is loading the dataset in memory")},
+		{"moved", errors.New("MOVED 3999 127.0.0.1:6381")},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			client := newTestClient(t, server, WithRetry(3, time.Millisecond))
+			if err := client.Set(ctx, "student:42", "Ana"); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+
+			hook := &failingHook{failures: 2, err: tt.err}
+			client.client.AddHook(hook)
+
+			var name string
+			if err := client.Get(ctx, "student:42", &name); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if name != "Ana" || hook.calls != 3 {
+				t.Errorf("Get = %q after %d calls, want \"Ana\" after 3", name, hook.calls)
+			}
+		})
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithRetry(2, time.Millisecond))
+	hook := &failingHook{failures: 10, err: errors.New("LOADING Redis is loading the dataset in memory")}
+	client.client.AddHook(hook)
+
+	var name string
+	if err := client.Get(context.Background(), "student:42", &name); err == nil {
+		t.Fatal("Get succeeded while Redis was loading")
+	}
+	if hook.calls != 3 {
+		t.Errorf("Get made %d calls, want 3", hook.calls)
+	}
+}
+
+func TestRetrySkipsCacheMiss(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithRetry(3, time.Millisecond))
+	hook := &failingHook{}
+	client.client.AddHook(hook)
+
+	var name string
+	if err := client.Get(context.Background(), "student:42", &name); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get = %v, want ErrCacheMiss", err)
+	}
+	if hook.calls != 1 {
+		t.Errorf("cache miss made %d calls, want 1", hook.calls)
+	}
+}
+
+func TestRetryDefaultsToNone(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server)
+	hook := &failingHook{failures: 1, err: errors.New("LOADING Redis is loading the dataset in memory")}
+	client.client.AddHook(hook)
+
+	var name string
+	if err := client.Get(context.Background(), "student:42", &name); err == nil {
+		t.Fatal("Get succeeded while Redis was loading")
+	}
+	if hook.calls != 1 {
+		t.Errorf("Get made %d calls, want 1 without WithRetry", hook.calls)
+	}
+}
+
+func TestRetryCoversWrites(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithRetry(3, time.Millisecond))
+	hook := &failingHook{err: &net.OpError{Op: "write", Net: "tcp", Err: errors.New("broken pipe")}}
+	client.client.AddHook(hook)
+
+	hook.failures, hook.calls = 2, 0
+	if err := client.Set(ctx, "student:42", "Ana"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !server.Exists("student:42") || hook.calls != 3 {
+		t.Errorf("Set stored the key %v after %d calls, want stored after 3", server.Exists("student:42"), hook.calls)
+	}
+
+	hook.failures, hook.calls = 2, 0
+	if err := client.Delete(ctx, "student:42"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if server.Exists("student:42") || hook.calls != 3 {
+		t.Errorf("Delete left the key %v after %d calls, want deleted after 3", server.Exists("student:42"), hook.calls)
+	}
+}
+
+func TestRetrySkipsEncodingErrors(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newTestClient(t, server, WithRetry(3, time.Millisecond))
+	hook := &failingHook{}
+	client.client.AddHook(hook)
+
+	if err := client.Set(context.Background(), "student:42", make(chan int)); err == nil {
+		t.Fatal("Set of an unencodable value succeeded")
+	}
+	if hook.calls != 0 {
+		t.Errorf("unencodable value made %d calls, want none", hook.calls)
+	}
+
+	server.Set("student:42", "not json")
+	var name string
+	if err := client.Get(context.Background(), "student:42", &name); err == nil {
+		t.Fatal("Get of a corrupt value succeeded")
+	}
+	if hook.calls != 1 {
+		t.Errorf("corrupt value made %d calls, want 1", hook.calls)
+	}
+}
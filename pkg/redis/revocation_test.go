@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestTokenBlocklist(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	blocklist := NewTokenBlocklist(newTestClient(t, s))
+
+	if revoked, err := blocklist.IsRevoked(ctx, "token-1"); err != nil || revoked {
+		t.Fatalf("IsRevoked before Revoke = %v, %v, want false", revoked, err)
+	}
+	if err := blocklist.Revoke(ctx, "token-1", time.Minute); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if !s.Exists("revoked:token-1") {
+		t.Errorf("keys = %v, want revoked:token-1", s.Keys())
+	}
+
+	if revoked, err := blocklist.IsRevoked(ctx, "token-1"); err != nil || !revoked {
+		t.Errorf("IsRevoked(token-1) = %v, %v, want true", revoked, err)
+	}
+	if revoked, err := blocklist.IsRevoked(ctx, "token-2"); err != nil || revoked {
+		t.Errorf("IsRevoked(token-2) = %v, %v, want false", revoked, err)
+	}
+
+	// The entry outlives the token and no longer
+	s.FastForward(time.Minute)
+	if revoked, err := blocklist.IsRevoked(ctx, "token-1"); err != nil || revoked {
+		t.Errorf("IsRevoked after the TTL = %v, %v, want false", revoked, err)
+	}
+}
+
+func TestTokenBlocklistServerDown(t *testing.T) {
+	s := miniredis.RunT(t)
+	blocklist := NewTokenBlocklist(newTestClient(t, s))
+	s.Close()
+
+	if _, err := blocklist.IsRevoked(context.Background(), "token-1"); err == nil {
+		t.Error("IsRevoked with Redis down succeeded, want an error for the fail-open/closed policy")
+	}
+}
@@ -0,0 +1,129 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Header carries the request ID from the gateway to internal services and back to clients
+const Header = "X-Request-ID"
+
+// Formats accepted by New
+const (
+	// FormatUUID is a random UUIDv4, e.g. 0b1e3c2a-5d7f-4e8a-9c6b-2f1d0e9a8b7c
+	FormatUUID = "uuid"
+	// FormatULID is a ULID, sortable by creation time, e.g. 01JA2Z3K4M5N6P7Q8R9S0T1V2W
+	FormatULID = "ulid"
+	// FormatShort is a 12 character Crockford base32 ID meant to be read out or typed
+	// into a support ticket, e.g. 0M4K7RQX0B2D
+	FormatShort = "short"
+)
+
+// crockford is the Crockford base32 alphabet, which leaves out I, L, O and U so IDs
+// can't be misread
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// shortEpoch anchors the timestamp of short IDs
+var shortEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// shortCounter numbers the short IDs of this process. It starts at a random value so
+// instances are unlikely to produce the same sequence in the same second.
+var shortCounter atomic.Uint32
+
+func init() {
+	shortCounter.Store(binary.BigEndian.Uint32(randomBytes(4)))
+}
+
+// Generator returns a new request ID on every call; it is safe for concurrent use
+type Generator func() string
+
+// New returns the generator for a format. An empty format means FormatUUID.
+func New(format string) (Generator, error) {
+	switch format {
+	case "", FormatUUID:
+		return newUUID, nil
+	case FormatULID:
+		return newULID, nil
+	case FormatShort:
+		return newShort, nil
+	default:
+		return nil, fmt.Errorf("unknown request ID format %q (expected %s, %s or %s)", format, FormatUUID, FormatULID, FormatShort)
+	}
+}
+
+// MustNew is New for formats already validated, e.g. by config loading; it panics on an
+// unknown format
+func MustNew(format string) Generator {
+	generate, err := New(format)
+	if err != nil {
+		panic(err)
+	}
+	return generate
+}
+
+func newUUID() string {
+	b := randomBytes(16)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// newULID encodes a 48-bit millisecond timestamp followed by 80 random bits
+func newULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	b[0], b[1], b[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	b[3], b[4], b[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	copy(b[6:], randomBytes(10))
+
+	// 128 bits in 26 characters; the first carries only the top 3 bits
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// newShort encodes 60 bits: 34 bits of seconds since shortEpoch, enough for centuries,
+// and a 26-bit counter. Within a process IDs are unique unless more than 67 million
+// are made in one second; across instances the random counter start keeps collisions
+// unlikely.
+func newShort() string {
+	secs := uint64(time.Since(shortEpoch)/time.Second) & (1<<34 - 1)
+	n := secs<<26 | uint64(shortCounter.Add(1))&(1<<26-1)
+
+	out := make([]byte, 12)
+	for i := 11; i >= 0; i-- {
+		out[i] = crockford[n&31]
+		n >>= 5
+	}
+	return string(out)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return b
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying the request ID
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
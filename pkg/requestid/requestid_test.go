@@ -0,0 +1,126 @@
+package requestid
+
+import (
+	"cmp"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   *regexp.Regexp
+	}{
+		{format: "", want: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{format: FormatUUID, want: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{format: FormatULID, want: regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+		{format: FormatShort, want: regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{12}$`)},
+	}
+	for _, tt := range tests {
+		t.Run(cmp.Or(tt.format, "default"), func(t *testing.T) {
+			generate, err := New(tt.format)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			seen := map[string]bool{}
+			for range 100 {
+				id := generate()
+				if !tt.want.MatchString(id) {
+					t.Fatalf("ID %q does not match %s", id, tt.want)
+				}
+				if seen[id] {
+					t.Fatalf("duplicate ID %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	for _, format := range []string{"UUID", "snowflake", " short"} {
+		if _, err := New(format); err == nil || !strings.Contains(err.Error(), "unknown request ID format") {
+			t.Errorf("New(%q) error = %v, want unknown format", format, err)
+		}
+	}
+}
+
+// decodeTime reads the millisecond timestamp leading a ULID
+func decodeTime(t *testing.T, id string) time.Time {
+	t.Helper()
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	return time.UnixMilli(ms)
+}
+
+func TestULIDTimestamp(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	first := newULID()
+	time.Sleep(2 * time.Millisecond)
+	second := newULID()
+	after := time.Now()
+
+	for _, id := range []string{first, second} {
+		if at := decodeTime(t, id); at.Before(before) || at.After(after) {
+			t.Errorf("ULID %s encodes %s, want between %s and %s", id, at, before, after)
+		}
+	}
+	// IDs made in later milliseconds sort after earlier ones
+	if second <= first {
+		t.Errorf("ULID %s sorts before the earlier %s", second, first)
+	}
+}
+
+func TestShortIDsAreUniqueUnderLoad(t *testing.T) {
+	const workers, perWorker = 32, 4000
+	ids := make([][]string, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[w] = make([]string, perWorker)
+			for i := range perWorker {
+				ids[w][i] = newShort()
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, workers*perWorker)
+	for _, batch := range ids {
+		for _, id := range batch {
+			if seen[id] {
+				t.Fatalf("short ID %s generated twice", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestMustNewPanicsOnUnknownFormat(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNew did not panic")
+		}
+	}()
+	MustNew("snowflake")
+}
+
+func TestContext(t *testing.T) {
+	if id, ok := FromContext(context.Background()); ok || id != "" {
+		t.Errorf("FromContext of an empty context = %q, %v", id, ok)
+	}
+	if _, ok := FromContext(WithContext(context.Background(), "")); ok {
+		t.Error("FromContext reported an empty ID")
+	}
+	if id, ok := FromContext(WithContext(context.Background(), "0M4K7RQX0B2D")); !ok || id != "0M4K7RQX0B2D" {
+		t.Errorf("FromContext = %q, %v, want 0M4K7RQX0B2D", id, ok)
+	}
+}
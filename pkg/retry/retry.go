@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy configures how an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values <= 1 disable retrying.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles on every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts (0 means uncapped)
+	MaxDelay time.Duration
+	// Jitter spreads each wait randomly between half and all of the delay, so clients
+	// failing together don't retry in lockstep
+	Jitter bool
+}
+
+// NoRetry runs an operation exactly once
+var NoRetry = Policy{MaxAttempts: 1}
+
+// Delay returns the wait before the given retry (1 for the first retry)
+func (p Policy) Delay(retry int) time.Duration {
+	if retry < 1 || p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// wait returns the delay before the given retry with jitter applied
+func (p Policy) wait(retry int) time.Duration {
+	delay := p.Delay(retry)
+	if !p.Jitter || delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// Do runs fn until it succeeds, returns an error that retryable rejects, the
+// attempts are exhausted or ctx is done. The last error from fn is returned.
+func Do(ctx context.Context, policy Policy, retryable func(error) bool, fn func() error) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if err == nil || attempt == attempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.wait(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
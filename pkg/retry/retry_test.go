@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDelay(t *testing.T) {
+	policy := Policy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{0, 0},
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{30, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.retry); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestWaitJitterStaysWithinDelay(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, Jitter: true}
+	for i := 0; i < 100; i++ {
+		if got := policy.wait(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("wait(1) = %s, want between 50ms and 100ms", got)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errLogical := errors.New("logical")
+	retryable := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name         string
+		policy       Policy
+		failures     []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{"succeeds first time", Policy{MaxAttempts: 3}, nil, nil, 1},
+		{"recovers from transient errors", Policy{MaxAttempts: 3}, []error{errTransient, errTransient}, nil, 3},
+		{"gives up after max attempts", Policy{MaxAttempts: 3}, []error{errTransient, errTransient, errTransient, errTransient}, errTransient, 3},
+		{"does not retry logical errors", Policy{MaxAttempts: 3}, []error{errLogical}, errLogical, 1},
+		{"no retry policy", NoRetry, []error{errTransient}, errTransient, 1},
+		{"zero attempts still runs once", Policy{}, []error{errTransient}, errTransient, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), tt.policy, retryable, func() error {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Do() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDoStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Hour}, func(error) bool { return true }, func() error {
+		attempts++
+		cancel()
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || attempts != 1 {
+		t.Errorf("Do() = %v after %d attempts, want the last error after 1", err, attempts)
+	}
+}
@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Header carries the tenant identifier between the gateway and internal services
+const Header = "X-Tenant-ID"
+
+type contextKey struct{}
+
+// validID restricts tenant identifiers to valid unquoted Postgres schema names
+var validID = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// Validate checks that id can safely be used as a schema name
+func Validate(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid tenant identifier %q", id)
+	}
+	return nil
+}
+
+// WithContext returns a copy of ctx carrying the tenant identifier
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant identifier stored in ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}